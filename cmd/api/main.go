@@ -19,6 +19,28 @@ func main() {
 		}
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		var dest string
+		if len(os.Args) > 2 {
+			dest = os.Args[2]
+		}
+		if err := engine.Backup(readEnvOrFile, os.Stdout, Version, dest); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "usage: %s restore <backup archive>\n", os.Args[0])
+			os.Exit(1)
+		}
+		if err := engine.Restore(readEnvOrFile, os.Stdout, Version, os.Args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if err := engine.Run(readEnvOrFile, os.Stdout, Version); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
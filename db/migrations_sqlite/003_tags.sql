@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS tags (
+    id   INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE IF NOT EXISTS artist_tags (
+    artist_id INTEGER NOT NULL REFERENCES artists(id) ON DELETE CASCADE,
+    tag_id    INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    source    TEXT NOT NULL,
+    PRIMARY KEY (artist_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_artist_tags_tag_id ON artist_tags(tag_id);
+
+CREATE TABLE IF NOT EXISTS release_tags (
+    release_id INTEGER NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    tag_id     INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    source     TEXT NOT NULL,
+    PRIMARY KEY (release_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_release_tags_tag_id ON release_tags(tag_id);
+
+CREATE TABLE IF NOT EXISTS track_tags (
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    tag_id   INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    source   TEXT NOT NULL,
+    PRIMARY KEY (track_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_track_tags_tag_id ON track_tags(tag_id);
+
+-- a track carries its own tags as well as the tags of its release and artists
+CREATE VIEW IF NOT EXISTS track_effective_tags AS
+SELECT track_id, tag_id FROM track_tags
+UNION
+SELECT t.id AS track_id, rt.tag_id FROM tracks t JOIN release_tags rt ON rt.release_id = t.release_id
+UNION
+SELECT at2.track_id, ag.tag_id FROM artist_tracks at2 JOIN artist_tags ag ON ag.artist_id = at2.artist_id;
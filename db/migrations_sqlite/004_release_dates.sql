@@ -0,0 +1,5 @@
+-- +goose Up
+-- first release date as reported by MusicBrainz, formatted YYYY, YYYY-MM or YYYY-MM-DD.
+-- NULL means the date has not been looked up yet, and an empty string means MusicBrainz
+-- has no date for the release.
+ALTER TABLE releases ADD COLUMN release_date TEXT;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- release group types as reported by MusicBrainz or set by the user. secondary_types is a
+-- comma separated list, e.g. 'Compilation,Live'.
+ALTER TABLE releases ADD COLUMN primary_type TEXT;
+ALTER TABLE releases ADD COLUMN secondary_types TEXT;
+ALTER TABLE releases ADD COLUMN type_source TEXT;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- number of tracks on the MusicBrainz tracklist of a release. NULL means the tracklist has
+-- not been looked up yet.
+ALTER TABLE releases ADD COLUMN track_count INTEGER;
+
+CREATE TABLE IF NOT EXISTS release_tracks (
+    release_id     INTEGER NOT NULL REFERENCES releases(id) ON DELETE CASCADE,
+    disc_number    INTEGER NOT NULL,
+    position       INTEGER NOT NULL,
+    number         TEXT NOT NULL DEFAULT '',
+    title          TEXT NOT NULL,
+    recording_mbid TEXT,
+    duration       INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (release_id, disc_number, position)
+);
+
+-- links every tracklist entry to the listened track it corresponds to, if any. Tracks are
+-- matched by recording MBID first, then by any of their aliases against the entry's title.
+CREATE VIEW IF NOT EXISTS release_tracks_linked AS
+SELECT rt.*, COALESCE(
+    (SELECT t.id FROM tracks t
+     WHERE t.release_id = rt.release_id AND t.musicbrainz_id = rt.recording_mbid),
+    (SELECT t.id FROM tracks t
+     JOIN track_aliases ta ON ta.track_id = t.id
+     WHERE t.release_id = rt.release_id AND ta.alias = rt.title COLLATE NOCASE
+     ORDER BY ta.is_primary DESC, t.id LIMIT 1)
+) AS track_id
+FROM release_tracks rt;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- artist details as reported by MusicBrainz or set by the user. country is an ISO 3166-1
+-- alpha-2 code. metadata_source is NULL until the details have been looked up.
+ALTER TABLE artists ADD COLUMN country TEXT;
+ALTER TABLE artists ADD COLUMN area TEXT;
+ALTER TABLE artists ADD COLUMN artist_type TEXT;
+ALTER TABLE artists ADD COLUMN gender TEXT;
+ALTER TABLE artists ADD COLUMN sort_name TEXT;
+ALTER TABLE artists ADD COLUMN metadata_source TEXT;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- when the MusicBrainz relationships of an artist were last fetched. NULL means never.
+ALTER TABLE artists ADD COLUMN relations_fetched_at INTEGER;
+
+-- MusicBrainz relationships between artists. The related artist is stored by MBID, as it
+-- does not need to be in the library.
+CREATE TABLE IF NOT EXISTS artist_relations (
+    artist_id     INTEGER NOT NULL REFERENCES artists(id) ON DELETE CASCADE,
+    related_mbid  TEXT NOT NULL,
+    related_name  TEXT NOT NULL,
+    relation_type TEXT NOT NULL,
+    direction     TEXT NOT NULL,
+    PRIMARY KEY (artist_id, related_mbid, relation_type, direction)
+);
+CREATE INDEX IF NOT EXISTS idx_artist_relations_related_mbid ON artist_relations(related_mbid);
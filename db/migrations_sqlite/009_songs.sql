@@ -0,0 +1,44 @@
+-- +goose Up
+-- a song groups the tracks of the same recording across releases, e.g. the album version and
+-- the compilation version of a track. Every track belongs to exactly one song.
+CREATE TABLE IF NOT EXISTS songs (
+    id INTEGER PRIMARY KEY
+);
+
+ALTER TABLE tracks ADD COLUMN song_id INTEGER REFERENCES songs(id);
+
+-- existing tracks each start out as their own song
+INSERT INTO songs (id) SELECT id FROM tracks;
+UPDATE tracks SET song_id = id;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_song_id ON tracks(song_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_create_track_song
+AFTER INSERT ON tracks
+WHEN NEW.song_id IS NULL
+BEGIN
+    INSERT INTO songs (id) VALUES (NULL);
+    UPDATE tracks SET song_id = last_insert_rowid() WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_delete_orphan_songs_on_delete
+AFTER DELETE ON tracks
+BEGIN
+    DELETE FROM songs
+    WHERE id = OLD.song_id
+      AND NOT EXISTS (SELECT 1 FROM tracks WHERE song_id = OLD.song_id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_delete_orphan_songs_on_update
+AFTER UPDATE OF song_id ON tracks
+BEGIN
+    DELETE FROM songs
+    WHERE id = OLD.song_id
+      AND NOT EXISTS (SELECT 1 FROM tracks WHERE song_id = OLD.song_id);
+END;
+-- +goose StatementEnd
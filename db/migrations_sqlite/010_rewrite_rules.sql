@@ -0,0 +1,25 @@
+-- +goose Up
+-- Rules that rewrite the metadata of submitted listens before they are matched to the
+-- catalog. Rules run in ascending position order.
+CREATE TABLE IF NOT EXISTS rewrite_rules (
+    id         INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL DEFAULT '',
+    enabled    INTEGER NOT NULL DEFAULT 1,
+    position   INTEGER NOT NULL DEFAULT 0,
+    action     TEXT NOT NULL,
+    field      TEXT NOT NULL DEFAULT '',
+    pattern    TEXT NOT NULL DEFAULT '',
+    value      TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
+
+-- A rule only applies to a listen when all of its conditions match.
+CREATE TABLE IF NOT EXISTS rewrite_rule_conditions (
+    rule_id          INTEGER NOT NULL REFERENCES rewrite_rules(id) ON DELETE CASCADE,
+    position         INTEGER NOT NULL,
+    field            TEXT NOT NULL,
+    match_type       TEXT NOT NULL,
+    value            TEXT NOT NULL,
+    case_insensitive INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (rule_id, position)
+);
@@ -0,0 +1,4 @@
+-- +goose Up
+-- the metadata a listen was submitted with, as JSON, before rewrite rules and matching to the
+-- catalog. NULL for listens submitted before it was recorded.
+ALTER TABLE listens ADD COLUMN submission TEXT;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Submissions discarded by the ingest filters, kept so filtering can be reviewed.
+CREATE TABLE IF NOT EXISTS filtered_listens (
+    id          INTEGER PRIMARY KEY,
+    filtered_at INTEGER NOT NULL,
+    listened_at INTEGER NOT NULL,
+    user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client      TEXT NOT NULL DEFAULT '',
+    artist      TEXT NOT NULL,
+    title       TEXT NOT NULL,
+    album       TEXT NOT NULL DEFAULT '',
+    reason      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_filtered_listens_filtered_at ON filtered_listens(filtered_at);
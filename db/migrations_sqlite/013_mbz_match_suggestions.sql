@@ -0,0 +1,46 @@
+-- +goose Up
+-- when MusicBrainz was last searched for a match for an item without an MBID. NULL means never.
+ALTER TABLE artists ADD COLUMN mbz_searched_at INTEGER;
+ALTER TABLE releases ADD COLUMN mbz_searched_at INTEGER;
+ALTER TABLE tracks ADD COLUMN mbz_searched_at INTEGER;
+
+-- MBIDs proposed for artists, releases and tracks that have none, found by searching MusicBrainz.
+-- status is one of pending, accepted, rejected or applied (accepted automatically).
+CREATE TABLE IF NOT EXISTS mbz_match_suggestions (
+    id             INTEGER PRIMARY KEY,
+    entity_type    TEXT NOT NULL,
+    entity_id      INTEGER NOT NULL,
+    musicbrainz_id TEXT NOT NULL,
+    name           TEXT NOT NULL,
+    artist         TEXT NOT NULL DEFAULT '',
+    confidence     REAL NOT NULL,
+    status         TEXT NOT NULL DEFAULT 'pending',
+    created_at     INTEGER NOT NULL,
+    reviewed_at    INTEGER,
+    UNIQUE (entity_type, entity_id, musicbrainz_id)
+);
+CREATE INDEX IF NOT EXISTS idx_mbz_match_suggestions_status ON mbz_match_suggestions(status, confidence);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_delete_artist_match_suggestions
+AFTER DELETE ON artists
+BEGIN
+    DELETE FROM mbz_match_suggestions WHERE entity_type = 'artist' AND entity_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_delete_album_match_suggestions
+AFTER DELETE ON releases
+BEGIN
+    DELETE FROM mbz_match_suggestions WHERE entity_type = 'album' AND entity_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_delete_track_match_suggestions
+AFTER DELETE ON tracks
+BEGIN
+    DELETE FROM mbz_match_suggestions WHERE entity_type = 'track' AND entity_id = OLD.id;
+END;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- likely duplicate artists, albums and tracks found by the duplicate analyzer, to be merged
+-- from from_id into to_id. reasons is a comma separated list of the signals that matched.
+-- status is pending or dismissed; accepted suggestions go away with the merged item.
+CREATE TABLE IF NOT EXISTS merge_suggestions (
+    id          INTEGER PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    from_id     INTEGER NOT NULL,
+    to_id       INTEGER NOT NULL,
+    score       REAL NOT NULL,
+    reasons     TEXT NOT NULL,
+    status      TEXT NOT NULL DEFAULT 'pending',
+    created_at  INTEGER NOT NULL,
+    UNIQUE (entity_type, from_id, to_id)
+);
+CREATE INDEX IF NOT EXISTS idx_merge_suggestions_status ON merge_suggestions(status, score);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_delete_artist_merge_suggestions
+AFTER DELETE ON artists
+BEGIN
+    DELETE FROM merge_suggestions
+    WHERE entity_type = 'artist' AND (from_id = OLD.id OR to_id = OLD.id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_delete_album_merge_suggestions
+AFTER DELETE ON releases
+BEGIN
+    DELETE FROM merge_suggestions
+    WHERE entity_type = 'album' AND (from_id = OLD.id OR to_id = OLD.id);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS trg_delete_track_merge_suggestions
+AFTER DELETE ON tracks
+BEGIN
+    DELETE FROM merge_suggestions
+    WHERE entity_type = 'track' AND (from_id = OLD.id OR to_id = OLD.id);
+END;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- merges of artists, albums and tracks that can still be undone. changes holds the rows the
+-- merge removed, added and updated, as JSON, so that they can be put back. The counts are of
+-- what moved onto to_id. undone_at is set once the merge has been undone.
+CREATE TABLE IF NOT EXISTS merges (
+    id            INTEGER PRIMARY KEY,
+    entity_type   TEXT NOT NULL,
+    from_id       INTEGER NOT NULL,
+    from_name     TEXT NOT NULL,
+    to_id         INTEGER NOT NULL,
+    to_name       TEXT NOT NULL,
+    changes       TEXT NOT NULL,
+    listen_count  INTEGER NOT NULL,
+    track_count   INTEGER NOT NULL,
+    release_count INTEGER NOT NULL,
+    alias_count   INTEGER NOT NULL,
+    created_at    INTEGER NOT NULL,
+    undone_at     INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_merges_created_at ON merges(created_at);
@@ -0,0 +1,20 @@
+-- +goose Up
+-- changes made through the API. action is the method and route of the request, and params its
+-- query parameters as JSON. before and after hold the metadata of the artist, album or track
+-- that was changed, as JSON, and are null when it didn't exist. username and api_key_label are
+-- kept as they were at the time of the change.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id            INTEGER PRIMARY KEY,
+    user_id       INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    username      TEXT NOT NULL DEFAULT '',
+    api_key_label TEXT,
+    action        TEXT NOT NULL,
+    entity_type   TEXT NOT NULL,
+    entity_id     INTEGER,
+    params        TEXT,
+    before        TEXT,
+    after         TEXT,
+    created_at    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
@@ -0,0 +1,14 @@
+-- +goose Up
+-- deleted artists, albums and tracks. changes holds the rows the delete removed, including the
+-- listens, as JSON in the same form as merges.changes, so that they can be put back.
+-- listen_count is the number of listens that were deleted along with the item.
+CREATE TABLE IF NOT EXISTS trash (
+    id           INTEGER PRIMARY KEY,
+    entity_type  TEXT NOT NULL,
+    entity_id    INTEGER NOT NULL,
+    name         TEXT NOT NULL,
+    changes      TEXT NOT NULL,
+    listen_count INTEGER NOT NULL,
+    deleted_at   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_trash_deleted_at ON trash(deleted_at);
@@ -0,0 +1,11 @@
+-- +goose Up
+-- the ordered artist credit of a track or release. position is the place of the artist in the
+-- credit, credited_name the name the artist is credited as when it differs from the artist's
+-- name, and join_phrase the text between this artist and the next, like ' feat. ' or ' x '.
+-- position is NULL for artists whose credit is not known.
+ALTER TABLE artist_tracks ADD COLUMN position INTEGER;
+ALTER TABLE artist_tracks ADD COLUMN credited_name TEXT;
+ALTER TABLE artist_tracks ADD COLUMN join_phrase TEXT;
+ALTER TABLE artist_releases ADD COLUMN position INTEGER;
+ALTER TABLE artist_releases ADD COLUMN credited_name TEXT;
+ALTER TABLE artist_releases ADD COLUMN join_phrase TEXT;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- the audio files found by the library scanner, along with the tags read from them. mtime and
+-- size are those of the file when it was last read, so that rescans can skip unchanged files.
+-- track_id is the track the file was matched to, which is NULL until a match is found.
+CREATE TABLE IF NOT EXISTS library_files (
+    id                 INTEGER PRIMARY KEY,
+    path               TEXT NOT NULL UNIQUE,
+    mtime              INTEGER NOT NULL,
+    size               INTEGER NOT NULL,
+    title              TEXT NOT NULL,
+    artist             TEXT NOT NULL,
+    album              TEXT NOT NULL,
+    album_artist       TEXT NOT NULL,
+    track_number       INTEGER NOT NULL,
+    disc_number        INTEGER NOT NULL,
+    duration           INTEGER NOT NULL,
+    isrc               TEXT NOT NULL,
+    recording_mbid     TEXT,
+    release_mbid       TEXT,
+    release_group_mbid TEXT,
+    artist_mbids       TEXT NOT NULL,
+    has_picture        INTEGER NOT NULL,
+    track_id           INTEGER REFERENCES tracks(id) ON DELETE SET NULL,
+    scanned_at         INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_library_files_track_id ON library_files(track_id);
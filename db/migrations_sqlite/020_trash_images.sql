@@ -0,0 +1,12 @@
+-- +goose Up
+-- the images of the items in the trash, which are kept for as long as the items can be restored.
+CREATE TABLE IF NOT EXISTS trash_images (
+    trash_id INTEGER NOT NULL REFERENCES trash(id) ON DELETE CASCADE,
+    image    TEXT NOT NULL,
+    PRIMARY KEY (trash_id, image)
+);
+CREATE INDEX IF NOT EXISTS idx_trash_images_image ON trash_images(image);
+
+INSERT OR IGNORE INTO trash_images (trash_id, image)
+SELECT t.id, j.value FROM trash t, json_tree(t.changes) j
+WHERE j.key = 'image' AND j.type = 'text';
@@ -0,0 +1,86 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtistMetadata(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	for endpoint, body := range map[string]string{
+		"/apis/web/v1/artist/1": `{"country":"jp","area":"Fukuoka","type":"person","gender":"female","sort_name":"Sayuri"}`,
+		"/apis/web/v1/artist/2": `{"country":"JP","type":"Person"}`,
+		"/apis/web/v1/artist/3": `{"country":"US","type":"group"}`,
+	} {
+		resp, err := makeAuthRequest(t, session, "PATCH", endpoint, strings.NewReader(body))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode, endpoint)
+	}
+
+	for _, body := range []string{`{"country":"Japan"}`, `{"type":"band"}`, `{"gender":"unknown"}`} {
+		resp, err := makeAuthRequest(t, session, "PATCH", "/apis/web/v1/artist/1", strings.NewReader(body))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	// fields that are left out are kept
+	resp, err := makeAuthRequest(t, session, "PATCH", "/apis/web/v1/artist/3", strings.NewReader(`{"country":"GB"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/artist/1")
+	require.NoError(t, err)
+	var artist models.Artist
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&artist))
+	assert.Equal(t, "JP", artist.Country)
+	assert.Equal(t, "Fukuoka", artist.Area)
+	assert.Equal(t, "Person", artist.Type)
+	assert.Equal(t, "Female", artist.Gender)
+	assert.Equal(t, "Sayuri", artist.SortName)
+
+	getGroups := func(endpoint string) []db.RankedItem[models.ArtistGroup] {
+		resp, err := http.DefaultClient.Get(host() + endpoint + "?period=all_time")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var groups []db.RankedItem[models.ArtistGroup]
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&groups))
+		return groups
+	}
+
+	countries := getGroups("/apis/web/v1/stats/artist-countries")
+	require.Len(t, countries, 2)
+	assert.Equal(t, "JP", countries[0].Item.Name)
+	assert.EqualValues(t, 2, countries[0].Item.ListenCount)
+	assert.EqualValues(t, 2, countries[0].Item.ArtistCount)
+	assert.EqualValues(t, 1, countries[0].Rank)
+	assert.Equal(t, "GB", countries[1].Item.Name)
+	assert.EqualValues(t, 2, countries[1].Rank)
+
+	types := getGroups("/apis/web/v1/stats/artist-types")
+	require.Len(t, types, 2)
+	assert.Equal(t, "Person", types[0].Item.Name)
+	assert.Equal(t, "Group", types[1].Item.Name)
+
+	// details set by the user are not overwritten by musicbrainz
+	require.NoError(t, store.UpdateArtist(context.Background(), db.UpdateArtistOpts{
+		ID:             1,
+		MetadataUpdate: true,
+		Country:        "US",
+		MetadataSource: db.InformationSourceMusicBrainz,
+	}))
+	a, err := store.GetArtist(context.Background(), db.GetArtistOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "JP", a.Country)
+
+	truncateTestData(t)
+}
@@ -0,0 +1,76 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtistRelations(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	ctx := context.Background()
+	// musicbrainz is disabled in tests, so the artists need their ids set by hand
+	require.NoError(t, store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: 1, MusicBrainzID: uuid.MustParse("efc787f0-046f-4a60-beff-77b398c8cdf4")}))
+	require.NoError(t, store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: 3, MusicBrainzID: uuid.MustParse("1262ab85-308b-46e7-b0b5-91fef8e46b62")}))
+	// pretend さユり is a member of ネクライトーキー, which also has a member outside the library
+	require.NoError(t, store.SaveArtistRelations(ctx, 1, []models.ArtistRelation{
+		{MbzID: uuid.MustParse("1262ab85-308b-46e7-b0b5-91fef8e46b62"), Name: "ネクライトーキー", Type: "member of band", Direction: "forward"},
+	}))
+	require.NoError(t, store.SaveArtistRelations(ctx, 3, []models.ArtistRelation{
+		{MbzID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Name: "朝日", Type: "member of band", Direction: "backward"},
+	}))
+
+	getRelated := func(id string) []models.ArtistRelation {
+		resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/artist/" + id + "/related")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var related []models.ArtistRelation
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&related))
+		return related
+	}
+
+	related := getRelated("1")
+	require.Len(t, related, 1)
+	require.NotNil(t, related[0].ID)
+	assert.EqualValues(t, 3, *related[0].ID)
+	assert.Equal(t, "forward", related[0].Direction)
+	assert.EqualValues(t, 1, related[0].ListenCount)
+
+	related = getRelated("3")
+	require.Len(t, related, 2)
+	byName := map[string]models.ArtistRelation{}
+	for _, r := range related {
+		byName[r.Name] = r
+	}
+	assert.Nil(t, byName["朝日"].ID, "artists outside the library are not linked")
+	require.NotNil(t, byName["さユり"].ID)
+	assert.Equal(t, "backward", byName["さユり"].Direction, "relations from the other side are reversed")
+
+	getArtist := func(query string) models.Artist {
+		resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/artist/3" + query)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var artist models.Artist
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&artist))
+		return artist
+	}
+
+	artist := getArtist("")
+	assert.EqualValues(t, 1, artist.ListenCount)
+	assert.Empty(t, artist.RolledUp)
+
+	artist = getArtist("?roll_up=true")
+	assert.EqualValues(t, 2, artist.ListenCount)
+	assert.Len(t, artist.RolledUp, 2)
+
+	truncateTestData(t)
+}
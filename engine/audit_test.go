@@ -0,0 +1,132 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	t.Cleanup(func() {
+		require.NoError(t, store.Exec("DELETE FROM audit_log"))
+	})
+	require.NoError(t, store.Exec("DELETE FROM audit_log"))
+
+	getEntries := func(t *testing.T, endpoint string) []*models.AuditEntry {
+		resp, err := makeAuthRequest(t, session, "GET", endpoint, nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page db.PaginatedResponse[*models.AuditEntry]
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		return page.Items
+	}
+
+	resp, err := makeAuthRequest(t, session, "PATCH", "/apis/web/v1/artist/1", strings.NewReader(`{"country":"JP"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "PATCH", "/apis/web/v1/artist/9999", strings.NewReader(`{"country":"JP"}`))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, resp.StatusCode, http.StatusBadRequest)
+
+	req, err := http.NewRequest("POST", host()+"/apis/web/v1/artist/1/aliases", strings.NewReader(`{"alias":"Sayuri"}`))
+	require.NoError(t, err)
+	req.Header.Add("Authorization", "Token "+apikey)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Less(t, resp.StatusCode, http.StatusBadRequest)
+
+	resp, err = makeAuthRequest(t, session, "DELETE", "/apis/web/v1/track/3", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	entries := getEntries(t, "/apis/web/v1/audit?entity_type=artist")
+	require.Len(t, entries, 2, "failed requests are not recorded")
+	alias, update := entries[0], entries[1]
+
+	assert.Equal(t, "PATCH /artist/{id}", update.Action)
+	require.NotNil(t, update.EntityID)
+	assert.EqualValues(t, 1, *update.EntityID)
+	assert.Equal(t, "test", update.Username)
+	assert.Nil(t, update.ApiKeyLabel)
+	var before, after struct {
+		Artists struct {
+			Country *string `json:"country"`
+		} `json:"artists"`
+		Aliases []struct {
+			Alias string `json:"alias"`
+		} `json:"artist_aliases"`
+	}
+	require.NoError(t, json.Unmarshal(update.Before, &before))
+	require.NoError(t, json.Unmarshal(update.After, &after))
+	assert.Nil(t, before.Artists.Country)
+	require.NotNil(t, after.Artists.Country)
+	assert.Equal(t, "JP", *after.Artists.Country)
+
+	assert.Equal(t, "POST /artist/{id}/aliases", alias.Action)
+	require.NotNil(t, alias.ApiKeyLabel, "the api key the change was made with is recorded")
+	keys, err := store.GetApiKeysByUserID(t.Context(), 1)
+	require.NoError(t, err)
+	for _, k := range keys {
+		if k.Key == apikey {
+			assert.Equal(t, k.Label, *alias.ApiKeyLabel)
+		}
+	}
+	require.NoError(t, json.Unmarshal(alias.Before, &before))
+	require.NoError(t, json.Unmarshal(alias.After, &after))
+	assert.Len(t, after.Aliases, len(before.Aliases)+1)
+
+	entries = getEntries(t, "/apis/web/v1/track/3/history")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "DELETE /track/{id}", entries[0].Action)
+	assert.NotEmpty(t, entries[0].Before)
+	assert.Equal(t, "null", string(entries[0].After), "the track was deleted")
+
+	assert.Len(t, getEntries(t, "/apis/web/v1/artist/1/history"), 2)
+	assert.Len(t, getEntries(t, "/apis/web/v1/audit?action=POST"), 1)
+	assert.Len(t, getEntries(t, "/apis/web/v1/audit"), 3)
+
+	truncateTestData(t)
+}
+
+func TestAuditLog_RequestBody(t *testing.T) {
+	truncateTestData(t)
+	t.Cleanup(func() {
+		require.NoError(t, store.Exec("DELETE FROM audit_log"))
+	})
+	require.NoError(t, store.Exec("DELETE FROM audit_log"))
+
+	resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/rules/preview",
+		strings.NewReader(`{"artist":"Artist","title":"Title","rules":[]}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "PATCH", "/apis/web/v1/user",
+		strings.NewReader(`{"username":"test","token":"hunter2","nested":{"api_key":"hunter2","kept":true}}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/audit", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var page db.PaginatedResponse[*models.AuditEntry]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	require.Len(t, page.Items, 1, "previewing rules is not recorded")
+
+	entry := page.Items[0]
+	assert.Equal(t, "PATCH /user", entry.Action)
+	var params map[string]any
+	require.NoError(t, json.Unmarshal(entry.Params, &params))
+	assert.Equal(t, map[string]any{
+		"username": "test",
+		"nested":   map[string]any{"kept": true},
+	}, params, "the body is recorded without secrets")
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gabehf/koito/internal/backup"
+	"github.com/gabehf/koito/internal/cfg"
+)
+
+// Backup writes a full backup archive of the configured instance to dest. If dest is empty,
+// the archive is written to a timestamped file in the working directory.
+func Backup(getenv func(string) string, w io.Writer, version string, dest string) error {
+	l, ctx, err := initLogger(getenv, version, w)
+	if err != nil {
+		return fmt.Errorf("Backup: %w", err)
+	}
+
+	if dest == "" {
+		dest = fmt.Sprintf("koito_backup_%d.tar.gz", time.Now().Unix())
+	}
+
+	store := connectDB(l)
+	defer store.Close(ctx)
+
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("Backup: %w", err)
+	}
+	defer f.Close()
+
+	if err := backup.CreateArchive(ctx, store, f); err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("Backup: %w", err)
+	}
+
+	l.Info().Msgf("Backup written to %s", dest)
+	return nil
+}
+
+// Restore rebuilds the configured config directory from a backup archive. The config
+// directory must not exist yet or be empty.
+func Restore(getenv func(string) string, w io.Writer, version string, src string) error {
+	l, ctx, err := initLogger(getenv, version, w)
+	if err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+	defer f.Close()
+
+	if err := backup.Restore(ctx, f, cfg.ConfigDir()); err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+
+	l.Info().Msgf("Backup %s restored into %s", src, cfg.ConfigDir())
+	return nil
+}
@@ -0,0 +1,121 @@
+package engine_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/engine/handlers"
+	"github.com/gabehf/koito/imagecache"
+	"github.com/gabehf/koito/internal/backup"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	imgid := uuid.New()
+	imgPath := imagecache.BuildImagePath(imgid, imagecache.ImageSizeSource)
+	require.NoError(t, os.MkdirAll(filepath.Dir(imgPath), 0744))
+	require.NoError(t, os.WriteFile(imgPath, []byte("not really an image"), 0644))
+	defer imagecache.DeleteImage(imgid)
+
+	resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/backup", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/gzip", resp.Header.Get("Content-Type"))
+
+	restoreDir := filepath.Join(t.TempDir(), "restored")
+	require.NoError(t, backup.Restore(context.Background(), resp.Body, restoreDir))
+
+	rel, err := filepath.Rel(cfg.ConfigDir(), imgPath)
+	require.NoError(t, err)
+	img, err := os.ReadFile(filepath.Join(restoreDir, rel))
+	require.NoError(t, err)
+	assert.Equal(t, "not really an image", string(img))
+
+	restored, err := sql.Open("sqlite", filepath.Join(restoreDir, backup.DatabaseName))
+	require.NoError(t, err)
+	defer restored.Close()
+	var listens, users int
+	require.NoError(t, restored.QueryRow(`SELECT COUNT(*) FROM listens`).Scan(&listens))
+	require.NoError(t, restored.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&users))
+	assert.Equal(t, 3, listens)
+	assert.Equal(t, 1, users)
+
+	// restoring over an existing config dir is refused
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/backup", nil)
+	require.NoError(t, err)
+	assert.Error(t, backup.Restore(context.Background(), resp.Body, restoreDir))
+
+	truncateTestData(t)
+}
+
+func TestScheduledBackup(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	dir := filepath.Join(cfg.ConfigDir(), backup.BackupDir)
+	require.NoError(t, os.MkdirAll(dir, 0744))
+	defer os.RemoveAll(dir)
+
+	// two old backups from the same day; only the newer one should survive retention
+	y, m, d := time.Now().UTC().AddDate(-1, -1, 0).Date()
+	old := time.Date(y, m, d, 12, 0, 0, 0, time.UTC)
+	older := "koito_" + old.Add(-2*time.Hour).Format("20060102-150405") + ".db"
+	newer := "koito_" + old.Format("20060102-150405") + ".db"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, older), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, newer), nil, 0644))
+
+	require.NoError(t, backup.RunScheduledBackup(context.Background(), store))
+
+	assert.NoFileExists(t, filepath.Join(dir, older))
+	assert.FileExists(t, filepath.Join(dir, newer))
+
+	resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/backups", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status backup.Status
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	require.Len(t, status.Backups, 2)
+	assert.NotZero(t, status.Backups[0].Size)
+	require.NotNil(t, status.LastSuccess)
+	assert.Empty(t, status.LastError)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/health")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var health handlers.HealthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	assert.Equal(t, "ready", health.Status)
+	assert.Equal(t, status.LastSuccess.Unix(), health.Backup.LastSuccess.Unix())
+	assert.Equal(t, "ok", health.Backup.Status)
+
+	restored, err := sql.Open("sqlite", filepath.Join(dir, status.Backups[0].Name))
+	require.NoError(t, err)
+	defer restored.Close()
+	var listens int
+	require.NoError(t, restored.QueryRow(`SELECT COUNT(*) FROM listens`).Scan(&listens))
+	assert.Equal(t, 3, listens)
+
+	truncateTestData(t)
+}
+
+func TestIntegrityCheck_SpecialPath(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "what?#100%.db")
+	require.NoError(t, store.VacuumInto(ctx, path))
+	assert.NoError(t, store.IntegrityCheck(ctx, path))
+
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0644))
+	assert.Error(t, store.IntegrityCheck(ctx, path))
+}
@@ -16,12 +16,14 @@ import (
 	"time"
 
 	"github.com/gabehf/koito/engine/middleware"
+	"github.com/gabehf/koito/internal/backup"
 	"github.com/gabehf/koito/internal/catalog"
 	"github.com/gabehf/koito/internal/cfg"
 	"github.com/gabehf/koito/internal/db"
 	"github.com/gabehf/koito/internal/db/sqlite"
 	"github.com/gabehf/koito/internal/images"
 	"github.com/gabehf/koito/internal/importer"
+	"github.com/gabehf/koito/internal/library"
 	"github.com/gabehf/koito/internal/logger"
 	mbz "github.com/gabehf/koito/internal/mbz"
 	"github.com/gabehf/koito/internal/migrate"
@@ -71,6 +73,16 @@ func connectDB(l *zerolog.Logger) db.DB {
 	return s
 }
 
+// imageProviders converts the image provider names from the config, which are validated when the
+// image sources are initialized.
+func imageProviders(names []string) []images.Provider {
+	var ret []images.Provider
+	for _, name := range names {
+		ret = append(ret, images.Provider(name))
+	}
+	return ret
+}
+
 func Run(
 	getenv func(string) string,
 	w io.Writer,
@@ -201,13 +213,20 @@ func Run(
 	}
 
 	l.Debug().Msg("Engine: Initializing image sources")
-	images.Initialize(images.ImageSourceOpts{
-		UserAgent:      cfg.UserAgent(),
-		EnableCAA:      !cfg.CoverArtArchiveDisabled(),
-		EnableDeezer:   !cfg.DeezerDisabled(),
-		EnableSubsonic: cfg.SubsonicEnabled(),
-		EnableLastFM:   cfg.LastFMApiKey() != "",
+	err = images.Initialize(images.ImageSourceOpts{
+		UserAgent:       cfg.UserAgent(),
+		EnableCAA:       !cfg.CoverArtArchiveDisabled(),
+		EnableDeezer:    !cfg.DeezerDisabled(),
+		EnableSubsonic:  cfg.SubsonicEnabled(),
+		EnableLastFM:    cfg.LastFMApiKey() != "",
+		LibraryDir:      cfg.LibraryDir(),
+		ArtistProviders: imageProviders(cfg.ArtistImageProviders()),
+		AlbumProviders:  imageProviders(cfg.AlbumImageProviders()),
 	})
+	if err != nil {
+		l.Fatal().Err(err).Msgf("Engine: Invalid %s or %s", cfg.ARTIST_IMAGE_PROVIDERS_ENV, cfg.ALBUM_IMAGE_PROVIDERS_ENV)
+		return err
+	}
 	l.Info().Msg("Engine: Image sources initialized")
 
 	if len(cfg.AllowedOrigins()) == 0 || cfg.AllowedOrigins()[0] == "" {
@@ -256,16 +275,47 @@ func Run(
 		}()
 	}
 
+	l.Info().Msg("Engine: Pruning old merges")
+	// merges and trash go first, as the images of merged and deleted items are kept while they
+	// can be restored
+	catalog.PruneMerges(ctx, store)
+	l.Info().Msg("Engine: Purging old items from the trash")
+	catalog.PurgeTrash(ctx, store)
+	go catalog.RunTrashPurge(ctx, store)
 	l.Info().Msg("Engine: Pruning orphaned images")
 	go catalog.PruneOrphanedImages(logger.NewContext(l), store)
 	l.Info().Msg("Engine: Checking image cache migration status")
 	go catalog.MigrateImageCache(logger.NewContext(l), store)
 	l.Info().Msg("Engine: Running duration backfill task")
 	go catalog.BackfillTrackDurationsFromMusicBrainz(ctx, store, mbzC)
+	l.Info().Msg("Engine: Running release date backfill task")
+	go catalog.BackfillReleaseDatesFromMusicBrainz(ctx, store, mbzC)
+	l.Info().Msg("Engine: Running release type backfill task")
+	go catalog.BackfillReleaseTypesFromMusicBrainz(ctx, store, mbzC)
+	l.Info().Msg("Engine: Running tracklist backfill task")
+	go catalog.BackfillTracklistsFromMusicBrainz(ctx, store, mbzC)
+	l.Info().Msg("Engine: Running artist metadata backfill task")
+	go catalog.BackfillArtistMetadataFromMusicBrainz(ctx, store, mbzC)
+	l.Info().Msg("Engine: Running artist relationship backfill task")
+	go catalog.BackfillArtistRelationsFromMusicBrainz(ctx, store, mbzC)
+	if !cfg.MusicBrainzDisabled() {
+		l.Info().Msg("Engine: Searching MusicBrainz for items without MusicBrainz IDs")
+		go catalog.MatchMissingMbzIDs(ctx, store, mbzC)
+	}
+	l.Info().Msg("Engine: Looking for duplicate artists, albums and tracks")
+	go catalog.FindDuplicates(ctx, store)
 	l.Info().Msg("Engine: Attempting to fetch missing artist images")
 	go catalog.FetchMissingArtistImages(ctx, store)
 	l.Info().Msg("Engine: Attempting to fetch missing album images")
 	go catalog.FetchMissingAlbumImages(ctx, store)
+	if cfg.AutoBackupEnabled() {
+		l.Info().Msgf("Engine: Scheduling automatic backups every %s", cfg.BackupInterval())
+		go backup.RunScheduler(ctx, store)
+	}
+	if cfg.LibraryDir() != "" {
+		l.Info().Msgf("Engine: Scanning library directory %s every %s", cfg.LibraryDir(), cfg.LibraryScanInterval())
+		go library.RunScanner(ctx, store)
+	}
 
 	l.Info().Msg("Engine: Initialization finished")
 	quit := make(chan os.Signal, 1)
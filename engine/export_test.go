@@ -0,0 +1,107 @@
+package engine_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gabehf/koito/engine/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportListenBrainz(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/export?format=listenbrainz", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	var listens []handlers.LbzSubmitListenPayload
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var p handlers.LbzSubmitListenPayload
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &p))
+		listens = append(listens, p)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, listens, 3)
+
+	// listens are exported oldest first
+	first := listens[0]
+	assert.NotZero(t, first.ListenedAt)
+	assert.Equal(t, "さユり", first.TrackMeta.ArtistName)
+	assert.Equal(t, "花の塔", first.TrackMeta.TrackName)
+	assert.Equal(t, "酸欠少女", first.TrackMeta.ReleaseName)
+	assert.Equal(t, "21524d55-b1f8-45d1-b172-976cba447199", first.TrackMeta.MBIDMapping.RecordingMBID)
+	assert.Equal(t, "eb790e90-0065-4852-b47d-bbeede4aa9fc", first.TrackMeta.AdditionalInfo.ReleaseMBID)
+	assert.Equal(t, []string{"さユり"}, first.TrackMeta.AdditionalInfo.ArtistNames)
+	assert.EqualValues(t, 275000, first.TrackMeta.AdditionalInfo.DurationMs)
+	assert.Equal(t, "navidrome", first.TrackMeta.AdditionalInfo.SubmissionClient)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/export?format=unknown", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	truncateTestData(t)
+}
+
+func TestExportFiltered(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	// csv filtered to a single artist
+	resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/export?format=csv&artist_id=1", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get("Content-Type"))
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "listened_at", records[0][0])
+	assert.Equal(t, "花の塔", records[1][4])
+	assert.Equal(t, "さユり", records[1][9])
+
+	// ndjson resuming from the first listen's cursor
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/export?format=ndjson", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var cursors []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line struct {
+			Cursor string `json:"cursor"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		cursors = append(cursors, line.Cursor)
+	}
+	require.Len(t, cursors, 3)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/export?format=ndjson&gzip=true&since="+cursors[0], nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/gzip", resp.Header.Get("Content-Type"))
+	gz, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	var resumed []string
+	scanner = bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var line struct {
+			Cursor string `json:"cursor"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		resumed = append(resumed, line.Cursor)
+	}
+	assert.Equal(t, cursors[1:], resumed)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/export?format=ndjson&since=abc", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	truncateTestData(t)
+}
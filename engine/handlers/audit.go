@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetAuditLogHandler returns the audit log, newest first. Entries can be filtered with the
+// entity_type, entity_id, user_id and action parameters, where action matches the start of the
+// action, and with the usual timeframe parameters.
+func GetAuditLogHandler(store db.AuditStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetAuditLogHandler: Received request to retrieve audit log")
+
+		q := r.URL.Query()
+		itemOpts := OptsFromRequest(r)
+		opts := db.GetAuditEntriesOpts{
+			EntityType: q.Get("entity_type"),
+			Action:     q.Get("action"),
+			Limit:      itemOpts.Limit,
+			Page:       itemOpts.Page,
+		}
+		for key, dst := range map[string]*int32{"entity_id": &opts.EntityID, "user_id": &opts.UserID} {
+			if v := q.Get(key); v != "" {
+				id, err := strconv.Atoi(v)
+				if err != nil || id < 1 {
+					utils.WriteError(w, "invalid "+key, http.StatusBadRequest)
+					return
+				}
+				*dst = int32(id)
+			}
+		}
+		opts.From, opts.To = db.TimeframeToTimeRange(itemOpts.Timeframe)
+
+		writeAuditEntries(w, r, "GetAuditLogHandler", store, opts)
+	}
+}
+
+// GetArtistHistoryHandler returns the audit log entries of an artist, newest first.
+func GetArtistHistoryHandler(store db.AuditStore) http.HandlerFunc {
+	return entityHistoryHandler(store, "artist", "GetArtistHistoryHandler")
+}
+
+// GetAlbumHistoryHandler returns the audit log entries of an album, newest first.
+func GetAlbumHistoryHandler(store db.AuditStore) http.HandlerFunc {
+	return entityHistoryHandler(store, "album", "GetAlbumHistoryHandler")
+}
+
+// GetTrackHistoryHandler returns the audit log entries of a track, newest first.
+func GetTrackHistoryHandler(store db.AuditStore) http.HandlerFunc {
+	return entityHistoryHandler(store, "track", "GetTrackHistoryHandler")
+}
+
+func entityHistoryHandler(store db.AuditStore, entityType, handler string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := logger.FromContext(r.Context())
+
+		l.Debug().Msgf("%s: Received request to retrieve %s history", handler, entityType)
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			utils.WriteError(w, "invalid "+entityType+" id", http.StatusBadRequest)
+			return
+		}
+		itemOpts := OptsFromRequest(r)
+		writeAuditEntries(w, r, handler, store, db.GetAuditEntriesOpts{
+			EntityType: entityType,
+			EntityID:   id,
+			Limit:      itemOpts.Limit,
+			Page:       itemOpts.Page,
+		})
+	}
+}
+
+func writeAuditEntries(w http.ResponseWriter, r *http.Request, handler string, store db.AuditStore, opts db.GetAuditEntriesOpts) {
+	l := logger.FromContext(r.Context())
+	entries, err := store.GetAuditEntries(r.Context(), opts)
+	if err != nil {
+		l.Err(err).Msgf("%s: Failed to retrieve audit log", handler)
+		utils.WriteError(w, "failed to retrieve audit log", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, entries)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gabehf/koito/internal/backup"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// BackupHandler streams a full backup archive of the database and image cache.
+func BackupHandler(store db.BackupStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+		l.Debug().Msg("BackupHandler: Received request for backup archive")
+
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="koito_backup_%d.tar.gz"`, time.Now().Unix()))
+
+		cw := &countingWriter{w: w}
+		if err := backup.CreateArchive(ctx, store, cw); err != nil {
+			l.Err(err).Msg("BackupHandler: Failed to create backup archive")
+			if cw.n > 0 {
+				// part of the archive was already sent, so the connection is aborted for the
+				// client to see a failed download rather than a corrupt archive
+				panic(http.ErrAbortHandler)
+			}
+			w.Header().Del("Content-Disposition")
+			utils.WriteError(w, "failed to create backup archive", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// GetBackupStatusHandler returns the state of scheduled backups and the backups on disk.
+func GetBackupStatusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteJSON(w, http.StatusOK, backup.GetStatus())
+	}
+}
@@ -59,6 +59,8 @@ func DeleteListenHandler(store db.ListenStore) http.HandlerFunc {
 	}
 }
 
+// PurgeAllDataHandler deletes every listen, artist, album and track for good, bypassing the
+// trash. As this can't be undone, the request must be made with confirm=true.
 func PurgeAllDataHandler(store db.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -66,6 +68,12 @@ func PurgeAllDataHandler(store db.DB) http.HandlerFunc {
 
 		l.Debug().Msg("PurgeAllDataHandler: Received request to purge all data")
 
+		if confirm, _ := utils.ParseBool(r.URL.Query().Get("confirm")); !confirm {
+			l.Debug().Msg("PurgeAllDataHandler: Purge was not confirmed")
+			utils.WriteError(w, "purging all data can't be undone, confirm with confirm=true", http.StatusBadRequest)
+			return
+		}
+
 		if err := store.PurgeAllData(ctx); err != nil {
 			l.Err(err).Msg("PurgeAllDataHandler: Failed to purge all data")
 			utils.WriteError(w, "failed to purge data", http.StatusInternalServerError)
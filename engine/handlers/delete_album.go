@@ -8,6 +8,7 @@ import (
 	"github.com/gabehf/koito/internal/utils"
 )
 
+// DeleteAlbumHandler moves an album to the trash, from where it can be restored for a while.
 func DeleteAlbumHandler(store db.AlbumStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -8,6 +8,7 @@ import (
 	"github.com/gabehf/koito/internal/utils"
 )
 
+// DeleteArtistHandler moves an artist to the trash, from where it can be restored for a while.
 func DeleteArtistHandler(store db.ArtistStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
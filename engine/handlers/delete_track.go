@@ -8,6 +8,7 @@ import (
 	"github.com/gabehf/koito/internal/utils"
 )
 
+// DeleteTrackHandler moves a track to the trash, from where it can be restored for a while.
 func DeleteTrackHandler(store db.TrackStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
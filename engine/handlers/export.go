@@ -1,19 +1,40 @@
 package handlers
 
 import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/gabehf/koito/engine/middleware"
 	"github.com/gabehf/koito/internal/db"
 	"github.com/gabehf/koito/internal/export"
 	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
 	"github.com/gabehf/koito/internal/utils"
 )
 
+type exportFormat struct {
+	contentType string
+	filename    string
+	write       func(context.Context, *models.User, db.ExportStore, io.Writer, export.ExportOpts) error
+	// whether the export is line delimited, so that a line with an error can end it
+	lines bool
+}
+
+var exportFormats = map[string]exportFormat{
+	"koito":        {"application/json", "koito_export.json", export.ExportData, false},
+	"listenbrainz": {"application/x-ndjson", "koito_export.jsonl", export.ExportListenBrainz, true},
+	"ndjson":       {"application/x-ndjson", "koito_export.ndjson", export.ExportNDJSON, true},
+	"csv":          {"text/csv", "koito_export.csv", export.ExportCSV, false},
+}
+
+// ExportHandler streams the user's listens in the requested format. The timeframe and the
+// artist_id, album_id and track_id parameters narrow down the export, and since takes a cursor
+// from a previous export to only include newer listens.
 func ExportHandler(store db.ExportStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Content-Disposition", `attachment; filename="koito_export.json"`)
 		ctx := r.Context()
 		l := logger.FromContext(ctx)
 		l.Debug().Msg("ExportHandler: Recieved request for export file")
@@ -23,11 +44,86 @@ func ExportHandler(store db.ExportStore) http.HandlerFunc {
 			utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
 			return
 		}
-		err := export.ExportData(ctx, u, store, w)
+
+		formatName := r.URL.Query().Get("format")
+		if formatName == "" {
+			formatName = "koito"
+		}
+		format, ok := exportFormats[formatName]
+		if !ok {
+			l.Debug().Msgf("ExportHandler: Unknown export format '%s'", formatName)
+			utils.WriteError(w, "unknown export format", http.StatusBadRequest)
+			return
+		}
+
+		itemOpts := OptsFromRequest(r)
+		opts := export.ExportOpts{
+			Timeframe: itemOpts.Timeframe,
+			ArtistID:  int32(itemOpts.ArtistID),
+			AlbumID:   int32(itemOpts.AlbumID),
+			TrackID:   int32(itemOpts.TrackID),
+		}
+		if since := r.URL.Query().Get("since"); since != "" {
+			cursor, err := export.ParseCursor(since)
+			if err != nil {
+				l.Debug().AnErr("error", err).Msg("ExportHandler: Invalid since cursor")
+				utils.WriteError(w, "invalid since cursor", http.StatusBadRequest)
+				return
+			}
+			opts.Since = cursor
+		}
+
+		// counts what reaches the client, so a failed export can tell whether its error can still
+		// be sent as the response
+		cw := &countingWriter{w: w}
+		var out io.Writer = cw
+		var gz *gzip.Writer
+		if compress, _ := utils.ParseBool(r.URL.Query().Get("gzip")); compress {
+			gz = gzip.NewWriter(cw)
+			out = gz
+			format.contentType = "application/gzip"
+			format.filename += ".gz"
+		}
+
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+format.filename+`"`)
+
+		err := format.write(ctx, u, store, out, opts)
 		if err != nil {
 			l.Err(err).Msg("ExportHandler: Failed to create export file")
-			utils.WriteError(w, "failed to create export file", http.StatusInternalServerError)
-			return
+			if cw.n == 0 {
+				w.Header().Del("Content-Disposition")
+				utils.WriteError(w, "failed to create export file", http.StatusInternalServerError)
+				return
+			}
+			if !format.lines {
+				// part of the export was already sent and the format has no room for an error,
+				// so the connection is aborted for the client to see a failed download rather
+				// than a file that looks complete
+				if gz != nil {
+					gz.Close()
+				}
+				panic(http.ErrAbortHandler)
+			}
+			// the error ends the file as its last line instead. It goes through the gzip writer
+			// when compressing, so the file can still be decompressed.
+			fmt.Fprintf(out, "\n"+`{"error":"%s"}`+"\n", "failed to create export file")
+		}
+		if gz != nil {
+			if err := gz.Close(); err != nil {
+				l.Err(err).Msg("ExportHandler: Failed to finish gzip stream")
+			}
 		}
 	}
 }
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
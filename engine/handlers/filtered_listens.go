@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetFilteredListensHandler returns the submissions most recently discarded by the ingest
+// filters, along with how many were discarded in total and for each reason.
+func GetFilteredListensHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetFilteredListensHandler: Received request to retrieve filtered listens")
+
+		log, err := store.GetFilteredListens(ctx, OptsFromRequest(r).Limit)
+		if err != nil {
+			l.Err(err).Msg("GetFilteredListensHandler: Failed to retrieve filtered listens")
+			utils.WriteError(w, "failed to retrieve filtered listens", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, log)
+	}
+}
+
+// PurgeFilteredListensHandler deletes the stored listens that match the ingest filters. Listens
+// are selected with the usual timeframe parameters, which are required. Unless dry_run=false is
+// given, only the listens that would be purged are returned.
+func PurgeFilteredListensHandler(store submitListenHandlerStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("PurgeFilteredListensHandler: Received request to purge filtered listens")
+
+		itemOpts := OptsFromRequest(r)
+		if _, t2 := db.TimeframeToTimeRange(itemOpts.Timeframe); t2.IsZero() {
+			l.Debug().Msg("PurgeFilteredListensHandler: Missing timeframe")
+			utils.WriteError(w, "a timeframe is required, use period=all_time to check every listen", http.StatusBadRequest)
+			return
+		}
+		dryRun := true
+		if v, ok := utils.ParseBool(r.URL.Query().Get("dry_run")); ok {
+			dryRun = v
+		}
+
+		res, err := catalog.PurgeFilteredListens(ctx, store, catalog.PurgeFilteredListensOpts{
+			Timeframe: itemOpts.Timeframe,
+			DryRun:    dryRun,
+		})
+		if err != nil {
+			l.Err(err).Msg("PurgeFilteredListensHandler: Failed to purge filtered listens")
+			utils.WriteError(w, "failed to purge filtered listens", http.StatusInternalServerError)
+			return
+		}
+		if !dryRun {
+			l.Info().Msgf("PurgeFilteredListensHandler: Purged %d listens", res.Purged)
+		}
+		utils.WriteJSON(w, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetAlbumTracklistHandler returns the full MusicBrainz tracklist of an album, with the
+// listened track linked to each position. The list is empty when no tracklist is known.
+func GetAlbumTracklistHandler(store db.AlbumStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetAlbumTracklistHandler: Received request to retrieve album tracklist")
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("GetAlbumTracklistHandler: Invalid album id")
+			utils.WriteError(w, "invalid album id", http.StatusBadRequest)
+			return
+		}
+
+		tracklist, err := store.GetAlbumTracklist(ctx, id)
+		if err != nil {
+			l.Err(err).Msgf("GetAlbumTracklistHandler: Failed to retrieve tracklist for album with ID %d", id)
+			utils.WriteError(w, "failed to retrieve tracklist", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetAlbumTracklistHandler: Successfully retrieved tracklist for album with ID %d", id)
+		utils.WriteJSON(w, http.StatusOK, tracklist)
+	}
+}
+
+// GetAlbumCompletionHandler returns how many tracks of an album have been heard, the tracks
+// that have not been heard yet, and how many times the album was played in full, in order.
+func GetAlbumCompletionHandler(store db.AlbumStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetAlbumCompletionHandler: Received request to retrieve album completion")
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("GetAlbumCompletionHandler: Invalid album id")
+			utils.WriteError(w, "invalid album id", http.StatusBadRequest)
+			return
+		}
+
+		completion, err := store.GetAlbumCompletion(ctx, id)
+		if err != nil {
+			l.Err(err).Msgf("GetAlbumCompletionHandler: Failed to retrieve completion for album with ID %d", id)
+			utils.WriteError(w, "failed to retrieve album completion", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetAlbumCompletionHandler: Successfully retrieved completion for album with ID %d", id)
+		utils.WriteJSON(w, http.StatusOK, completion)
+	}
+}
@@ -25,7 +25,10 @@ func GetArtistHandler(store db.ArtistStore) func(w http.ResponseWriter, r *http.
 
 		l.Debug().Msgf("GetArtistHandler: Retrieving artist with ID %d", id)
 
-		artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: id})
+		// roll_up=true includes the listens of related groups, members and their projects
+		rollUp, _ := utils.ParseBool(r.URL.Query().Get("roll_up"))
+
+		artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: id, RollUp: rollUp})
 		if err != nil {
 			l.Err(err).Msgf("GetArtistHandler: Failed to retrieve artist with ID %d", id)
 			utils.WriteError(w, "artist with specified id could not be found", http.StatusNotFound)
@@ -94,3 +97,31 @@ func GetArtistInterestHandler(store db.ListenStore) http.HandlerFunc {
 		utils.WriteJSON(w, http.StatusOK, interest)
 	}
 }
+
+// GetRelatedArtistsHandler returns the MusicBrainz relationships of an artist to other
+// artists, such as the bands they are a member of or the members of a band.
+func GetRelatedArtistsHandler(store db.ArtistStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetRelatedArtistsHandler: Received request to retrieve related artists")
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("GetRelatedArtistsHandler: Invalid artist id")
+			utils.WriteError(w, "invalid artist id", http.StatusBadRequest)
+			return
+		}
+
+		related, err := store.GetRelatedArtists(ctx, id)
+		if err != nil {
+			l.Err(err).Msgf("GetRelatedArtistsHandler: Failed to retrieve related artists for artist with ID %d", id)
+			utils.WriteError(w, "failed to retrieve related artists", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetRelatedArtistsHandler: Successfully retrieved related artists for artist with ID %d", id)
+		utils.WriteJSON(w, http.StatusOK, related)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetListensByArtistCountryHandler returns listen counts grouped by the country of the
+// listened artists, for artists with a known country.
+func GetListensByArtistCountryHandler(store db.ArtistStore) http.HandlerFunc {
+	return getListensByArtistGroup("GetListensByArtistCountryHandler", store.GetListensByArtistCountry)
+}
+
+// GetListensByArtistTypeHandler returns listen counts grouped by the type (person, group, ...)
+// of the listened artists, for artists with a known type.
+func GetListensByArtistTypeHandler(store db.ArtistStore) http.HandlerFunc {
+	return getListensByArtistGroup("GetListensByArtistTypeHandler", store.GetListensByArtistType)
+}
+
+func getListensByArtistGroup(
+	name string,
+	get func(ctx context.Context, timeframe db.Timeframe) ([]db.RankedItem[*models.ArtistGroup], error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msgf("%s: Received request to retrieve listens by artist group", name)
+
+		groups, err := get(ctx, TimeframeFromRequest(r))
+		if err != nil {
+			l.Err(err).Msgf("%s: Failed to retrieve listens by artist group", name)
+			utils.WriteError(w, "failed to get listens by artist group", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("%s: Successfully retrieved listens by artist group", name)
+		utils.WriteJSON(w, http.StatusOK, groups)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetTopReleaseYearsHandler returns listen counts grouped by the year the listened albums
+// were first released, for albums with a known release date.
+func GetTopReleaseYearsHandler(store db.AlbumStore) http.HandlerFunc {
+	return getTopReleaseYears(store, false)
+}
+
+// GetTopDecadesHandler returns listen counts grouped by the decade the listened albums
+// were first released, for albums with a known release date.
+func GetTopDecadesHandler(store db.AlbumStore) http.HandlerFunc {
+	return getTopReleaseYears(store, true)
+}
+
+func getTopReleaseYears(store db.AlbumStore, decades bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msgf("GetTopReleaseYearsHandler: Received request to retrieve top release years (decades=%t)", decades)
+
+		years, err := store.GetTopReleaseYears(ctx, db.GetReleaseYearsOpts{
+			Timeframe: TimeframeFromRequest(r),
+			Decades:   decades,
+		})
+		if err != nil {
+			l.Err(err).Msg("GetTopReleaseYearsHandler: Failed to retrieve top release years")
+			utils.WriteError(w, "failed to get release years", http.StatusBadRequest)
+			return
+		}
+
+		l.Debug().Msg("GetTopReleaseYearsHandler: Successfully retrieved top release years")
+		utils.WriteJSON(w, http.StatusOK, years)
+	}
+}
+
+// GetReleaseAgeHandler returns how many listens in the timeframe went to new music versus
+// older music. A release counts as new when it came out less than new_within years (default 1)
+// before the year of the listen.
+func GetReleaseAgeHandler(store db.AlbumStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetReleaseAgeHandler: Received request to retrieve release age statistics")
+
+		newWithin := 1
+		if v := r.URL.Query().Get("new_within"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				l.Debug().Msgf("GetReleaseAgeHandler: Invalid new_within parameter '%s'", v)
+				utils.WriteError(w, "new_within must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			newWithin = n
+		}
+
+		stats, err := store.GetReleaseAgeStats(ctx, db.GetReleaseAgeOpts{
+			Timeframe:      TimeframeFromRequest(r),
+			NewWithinYears: newWithin,
+		})
+		if err != nil {
+			l.Err(err).Msg("GetReleaseAgeHandler: Failed to retrieve release age statistics")
+			utils.WriteError(w, "failed to get release age statistics", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msg("GetReleaseAgeHandler: Successfully retrieved release age statistics")
+		utils.WriteJSON(w, http.StatusOK, stats)
+	}
+}
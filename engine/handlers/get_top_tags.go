@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+func GetTopTagsHandler(store db.TagStore) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetTopTagsHandler: Received request to retrieve top tags")
+
+		opts := OptsFromRequest(r)
+		l.Debug().Msgf("GetTopTagsHandler: Retrieving top tags with options: %+v", opts)
+
+		tags, err := store.GetTopTagsPaginated(ctx, opts)
+		if err != nil {
+			l.Err(err).Msg("GetTopTagsHandler: Failed to retrieve top tags")
+			utils.WriteError(w, "failed to get tags", http.StatusBadRequest)
+			return
+		}
+
+		l.Debug().Msg("GetTopTagsHandler: Successfully retrieved top tags")
+		utils.WriteJSON(w, http.StatusOK, tags)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gabehf/koito/internal/cfg"
 	"github.com/gabehf/koito/internal/db"
 	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
 )
 
 const defaultLimitSize = 100
@@ -45,6 +46,10 @@ func OptsFromRequest(r *http.Request) db.GetItemsOpts {
 	albumId, _ := strconv.Atoi(albumIdStr)
 	trackIdStr := r.URL.Query().Get("track_id")
 	trackId, _ := strconv.Atoi(trackIdStr)
+	tag := strings.TrimSpace(r.URL.Query().Get("tag"))
+	releaseTypes := parseList(r.URL.Query().Get("type"))
+	excludeReleaseTypes := parseList(r.URL.Query().Get("exclude_type"))
+	bySong, _ := utils.ParseBool(r.URL.Query().Get("by_song"))
 
 	tf := TimeframeFromRequest(r)
 
@@ -62,8 +67,8 @@ func OptsFromRequest(r *http.Request) db.GetItemsOpts {
 		period = db.PeriodAllTime
 	}
 
-	l.Debug().Msgf("OptsFromRequest: Parsed options: limit=%d, page=%d, week=%d, month=%d, year=%d, from=%d, to=%d, artist_id=%d, album_id=%d, track_id=%d, period=%s",
-		limit, page, tf.Week, tf.Month, tf.Year, tf.FromUnix, tf.ToUnix, artistId, albumId, trackId, period)
+	l.Debug().Msgf("OptsFromRequest: Parsed options: limit=%d, page=%d, week=%d, month=%d, year=%d, from=%d, to=%d, artist_id=%d, album_id=%d, track_id=%d, tag=%s, type=%v, exclude_type=%v, by_song=%t, period=%s",
+		limit, page, tf.Week, tf.Month, tf.Year, tf.FromUnix, tf.ToUnix, artistId, albumId, trackId, tag, releaseTypes, excludeReleaseTypes, bySong, period)
 
 	return db.GetItemsOpts{
 		Limit:     limit,
@@ -72,7 +77,24 @@ func OptsFromRequest(r *http.Request) db.GetItemsOpts {
 		ArtistID:  artistId,
 		AlbumID:   albumId,
 		TrackID:   trackId,
+		Tag:       tag,
+
+		ReleaseTypes:        releaseTypes,
+		ExcludeReleaseTypes: excludeReleaseTypes,
+
+		BySong: bySong,
+	}
+}
+
+// parseList splits a comma separated query parameter, dropping empty entries.
+func parseList(s string) []string {
+	var ret []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			ret = append(ret, v)
+		}
 	}
+	return ret
 }
 
 func TimeframeFromRequest(r *http.Request) db.Timeframe {
@@ -1,10 +1,50 @@
 package handlers
 
-import "net/http"
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
 
-func HealthHandler() http.HandlerFunc {
+	"github.com/gabehf/koito/internal/backup"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+type HealthResponse struct {
+	Status string             `json:"status"`
+	Backup HealthBackupStatus `json:"backup"`
+}
+
+// HealthBackupStatus is the part of the backup status that is safe to show without logging in.
+// The error of a failed backup is only shown on the backup status endpoint.
+type HealthBackupStatus struct {
+	Enabled bool `json:"enabled"`
+	// "ok" or "failed", depending on how the last backup went, or empty before the first
+	Status      string     `json:"status,omitempty"`
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+func HealthHandler(ready *atomic.Bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ready"}`))
+		if !ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		status := backup.GetStatus()
+		backupStatus := HealthBackupStatus{
+			Enabled:     status.Enabled,
+			LastRun:     status.LastRun,
+			LastSuccess: status.LastSuccess,
+		}
+		if status.LastRun != nil {
+			backupStatus.Status = "ok"
+			if status.LastError != "" {
+				backupStatus.Status = "failed"
+			}
+		}
+		utils.WriteJSON(w, http.StatusOK, HealthResponse{
+			Status: "ready",
+			Backup: backupStatus,
+		})
 	}
 }
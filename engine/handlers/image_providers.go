@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gabehf/koito/imagecache"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/images"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+	"github.com/google/uuid"
+)
+
+// GetImageProvidersHandler returns the order and health of the image providers.
+func GetImageProvidersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteJSON(w, http.StatusOK, images.Health())
+	}
+}
+
+// RefetchArtistImageHandler replaces the image of an artist with the one found by the provider
+// in the provider parameter.
+func RefetchArtistImageHandler(store db.ArtistStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		artistID, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("RefetchArtistImageHandler: Invalid artist id")
+			utils.WriteError(w, "invalid artist id", http.StatusBadRequest)
+			return
+		}
+
+		artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: artistID})
+		if errors.Is(err, db.ErrNotFound) {
+			utils.WriteError(w, "artist with specified id could not be found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Err(err).Msg("RefetchArtistImageHandler: Failed to get artist")
+			utils.WriteError(w, "failed to get artist", http.StatusInternalServerError)
+			return
+		}
+
+		aliases := []string{artist.Name}
+		if rows, err := store.GetAllArtistAliases(ctx, artistID); err != nil {
+			l.Err(err).Msg("RefetchArtistImageHandler: Failed to get artist aliases")
+		} else if len(rows) > 0 {
+			aliases = utils.FlattenAliases(rows)
+		}
+
+		refetchImage(w, r, "artist", artist.Image.Small, func(p images.Provider) (string, error) {
+			return images.GetArtistImageFrom(ctx, p, images.ArtistImageOpts{
+				Aliases: aliases,
+				MBID:    artist.MbzID,
+			})
+		}, func(id uuid.UUID, src string) error {
+			return store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: artistID, Image: id, ImageSrc: src})
+		})
+	}
+}
+
+// RefetchAlbumImageHandler replaces the image of an album with the one found by the provider in
+// the provider parameter.
+func RefetchAlbumImageHandler(store db.AlbumStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		albumID, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("RefetchAlbumImageHandler: Invalid album id")
+			utils.WriteError(w, "invalid album id", http.StatusBadRequest)
+			return
+		}
+
+		album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: albumID})
+		if errors.Is(err, db.ErrNotFound) {
+			utils.WriteError(w, "album with specified id could not be found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			l.Err(err).Msg("RefetchAlbumImageHandler: Failed to get album")
+			utils.WriteError(w, "failed to get album", http.StatusInternalServerError)
+			return
+		}
+
+		refetchImage(w, r, "album", album.Image.Small, func(p images.Provider) (string, error) {
+			return images.GetAlbumImageFrom(ctx, p, images.AlbumImageOpts{
+				Artists:      utils.FlattenSimpleArtistNames(album.Artists),
+				Album:        album.Title,
+				ReleaseMbzID: album.MbzID,
+			})
+		}, func(id uuid.UUID, src string) error {
+			return store.UpdateAlbum(ctx, db.UpdateAlbumOpts{ID: albumID, Image: id, ImageSrc: src})
+		})
+	}
+}
+
+// refetchImage finds an image of an artist or album with find from the provider in the provider
+// parameter, caches it and saves it with update in place of the image linked to by old.
+func refetchImage(w http.ResponseWriter, r *http.Request, kind string, old string,
+	find func(images.Provider) (string, error), update func(uuid.UUID, string) error) {
+	l := logger.FromContext(r.Context())
+
+	provider := images.Provider(r.FormValue("provider"))
+	img, err := find(provider)
+	if errors.Is(err, images.ErrUnknownProvider) || errors.Is(err, images.ErrProviderDisabled) {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil || img == "" {
+		l.Debug().AnErr("error", err).Msgf("refetchImage: No %s image found from %s", kind, provider)
+		utils.WriteError(w, "no image could be found from "+string(provider), http.StatusNotFound)
+		return
+	}
+
+	id := uuid.New()
+	if err := imagecache.DownloadImage(id, img); err != nil {
+		l.Err(err).Msg("refetchImage: Failed to cache image")
+		utils.WriteError(w, "failed to cache image", http.StatusInternalServerError)
+		return
+	}
+	saveImage(w, l, kind, old, id, func() error { return update(id, img) })
+}
@@ -67,8 +67,23 @@ type LbzAdditionalInfo struct {
 	RecordingMBID           string   `json:"recording_mbid,omitempty"`
 	DurationMs              int32    `json:"duration_ms,omitempty"`
 	Duration                int32    `json:"duration,omitempty"`
-	Tags                    []string `json:"tags,omitempty"`
-	AlbumArtist             string   `json:"albumartist,omitempty"`
+	// how much of the track was played, sent by some clients
+	PlayedDurationMs int32    `json:"played_duration_ms,omitempty"`
+	PlayedDuration   int32    `json:"played_duration,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	AlbumArtist      string   `json:"albumartist,omitempty"`
+
+	// the object as submitted, including fields not listed above
+	Raw json.RawMessage `json:"-"`
+}
+
+func (a *LbzAdditionalInfo) UnmarshalJSON(b []byte) error {
+	type additionalInfo LbzAdditionalInfo
+	if err := json.Unmarshal(b, (*additionalInfo)(a)); err != nil {
+		return err
+	}
+	a.Raw = append(json.RawMessage(nil), b...)
+	return nil
 }
 
 const (
@@ -82,6 +97,7 @@ type submitListenHandlerStore interface {
 	db.AlbumStore
 	db.TrackStore
 	db.ListenStore
+	db.RewriteRuleStore
 }
 
 func LbzSubmitListenHandler(store submitListenHandlerStore, mbzc mbz.MusicBrainzCaller) func(w http.ResponseWriter, r *http.Request) {
@@ -190,6 +206,12 @@ func LbzSubmitListenHandler(store submitListenHandlerStore, mbzc mbz.MusicBrainz
 			} else if payload.TrackMeta.AdditionalInfo.DurationMs != 0 {
 				duration = payload.TrackMeta.AdditionalInfo.DurationMs / 1000
 			}
+			var playedDuration int32
+			if payload.TrackMeta.AdditionalInfo.PlayedDuration != 0 {
+				playedDuration = payload.TrackMeta.AdditionalInfo.PlayedDuration
+			} else if payload.TrackMeta.AdditionalInfo.PlayedDurationMs != 0 {
+				playedDuration = payload.TrackMeta.AdditionalInfo.PlayedDurationMs / 1000
+			}
 
 			var listenedAt = time.Now()
 			if payload.ListenedAt != 0 {
@@ -216,15 +238,19 @@ func LbzSubmitListenHandler(store submitListenHandlerStore, mbzc mbz.MusicBrainz
 				TrackTitle:         payload.TrackMeta.TrackName,
 				RecordingMbzID:     recordingMbzID,
 				ReleaseTitle:       payload.TrackMeta.ReleaseName,
+				AlbumArtist:        payload.TrackMeta.AdditionalInfo.AlbumArtist,
 				ReleaseMbzID:       releaseMbzID,
 				ReleaseGroupMbzID:  rgMbzID,
+				Tags:               payload.TrackMeta.AdditionalInfo.Tags,
 				ArtistMbidMappings: artistMbidMap,
 				Duration:           duration,
+				PlayedDuration:     playedDuration,
 				Time:               listenedAt,
 				UserID:             u.ID,
 				Client:             client,
 				IsNowPlaying:       req.ListenType == ListenTypePlayingNow,
 				SkipSaveListen:     req.ListenType == ListenTypePlayingNow,
+				AdditionalInfo:     payload.TrackMeta.AdditionalInfo.Raw,
 			}
 
 			_, err, shared := sfGroup.Do(buildCaolescingKey(payload), func() (interface{}, error) {
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/library"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetLibraryStatusHandler returns the state of the running or last library scan.
+func GetLibraryStatusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteJSON(w, http.StatusOK, library.GetStatus())
+	}
+}
+
+// ScanLibraryHandler starts a scan of the library directory in the background. Its progress
+// can be followed with GetLibraryStatusHandler.
+func ScanLibraryHandler(store library.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("ScanLibraryHandler: Received request to scan the library")
+
+		// the scan outlives the request
+		err := library.StartScan(context.WithoutCancel(ctx), store)
+		switch {
+		case errors.Is(err, library.ErrNotConfigured):
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, library.ErrScanRunning):
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			l.Err(err).Msg("ScanLibraryHandler: Failed to start library scan")
+			utils.WriteError(w, "failed to start library scan", http.StatusInternalServerError)
+			return
+		}
+		l.Info().Msg("ScanLibraryHandler: Started library scan")
+		utils.WriteJSON(w, http.StatusAccepted, library.GetStatus())
+	}
+}
+
+// GetUnplayedLibraryFilesHandler returns the files in the library that have never been
+// listened to, ordered by artist, album and track number.
+func GetUnplayedLibraryFilesHandler(store db.LibraryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetUnplayedLibraryFilesHandler: Received request to retrieve unplayed library files")
+
+		itemOpts := OptsFromRequest(r)
+		files, err := store.GetUnplayedLibraryFiles(ctx, db.GetLibraryFilesOpts{
+			Limit: itemOpts.Limit,
+			Page:  itemOpts.Page,
+		})
+		if err != nil {
+			l.Err(err).Msg("GetUnplayedLibraryFilesHandler: Failed to retrieve unplayed library files")
+			utils.WriteError(w, "failed to retrieve unplayed library files", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, files)
+	}
+}
+
+// GetTracksNotInLibraryHandler returns the tracks listened to in the timeframe that have no
+// file in the library, most listened first.
+func GetTracksNotInLibraryHandler(store db.LibraryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetTracksNotInLibraryHandler: Received request to retrieve tracks not in the library")
+
+		tracks, err := store.GetTracksNotInLibrary(ctx, OptsFromRequest(r))
+		if err != nil {
+			l.Err(err).Msg("GetTracksNotInLibraryHandler: Failed to retrieve tracks not in the library")
+			utils.WriteError(w, "failed to retrieve tracks not in the library", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, tracks)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetMbzSuggestionsHandler returns the MusicBrainz matches found for items without an MBID. Only
+// suggestions awaiting review are returned, unless another status is given with status, or
+// status=all.
+func GetMbzSuggestionsHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetMbzSuggestionsHandler: Received request to retrieve MusicBrainz match suggestions")
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "":
+			status = models.MatchSuggestionPending
+		case "all":
+			status = ""
+		case models.MatchSuggestionPending, models.MatchSuggestionAccepted,
+			models.MatchSuggestionRejected, models.MatchSuggestionApplied:
+		default:
+			utils.WriteError(w, "status must be one of pending, accepted, rejected, applied or all", http.StatusBadRequest)
+			return
+		}
+
+		suggestions, err := store.GetMatchSuggestions(ctx, status)
+		if err != nil {
+			l.Err(err).Msg("GetMbzSuggestionsHandler: Failed to retrieve suggestions")
+			utils.WriteError(w, "failed to retrieve suggestions", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, suggestions)
+	}
+}
+
+// AcceptMbzSuggestionHandler applies the MBID of a pending suggestion to the item it was made
+// for. The other pending suggestions for the item are rejected.
+func AcceptMbzSuggestionHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("AcceptMbzSuggestionHandler: Received request to accept MusicBrainz match suggestion")
+
+		suggestion, ok := pendingMbzSuggestion(w, r, store)
+		if !ok {
+			return
+		}
+		err := catalog.ApplyMatchSuggestion(ctx, store, suggestion, models.MatchSuggestionAccepted)
+		if errors.Is(err, catalog.ErrMbzIDInUse) {
+			utils.WriteError(w, err.Error(), http.StatusConflict)
+			return
+		} else if errors.Is(err, db.ErrNotFound) {
+			utils.WriteError(w, "suggestion not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Err(err).Msg("AcceptMbzSuggestionHandler: Failed to apply suggestion")
+			utils.WriteError(w, "failed to apply suggestion", http.StatusInternalServerError)
+			return
+		}
+		l.Info().Msgf("AcceptMbzSuggestionHandler: Set MusicBrainz ID of %s %d to %s", suggestion.EntityType, suggestion.EntityID, suggestion.MbzID)
+		writeMbzSuggestion(w, r, store, suggestion.ID)
+	}
+}
+
+// RejectMbzSuggestionHandler rejects a pending suggestion.
+func RejectMbzSuggestionHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("RejectMbzSuggestionHandler: Received request to reject MusicBrainz match suggestion")
+
+		suggestion, ok := pendingMbzSuggestion(w, r, store)
+		if !ok {
+			return
+		}
+		if err := store.ResolveMatchSuggestion(ctx, suggestion.ID, models.MatchSuggestionRejected); err != nil {
+			l.Err(err).Msg("RejectMbzSuggestionHandler: Failed to reject suggestion")
+			utils.WriteError(w, "failed to reject suggestion", http.StatusInternalServerError)
+			return
+		}
+		writeMbzSuggestion(w, r, store, suggestion.ID)
+	}
+}
+
+// pendingMbzSuggestion retrieves the suggestion from the id URL parameter, and writes an error
+// response when it does not exist or was already reviewed.
+func pendingMbzSuggestion(w http.ResponseWriter, r *http.Request, store db.DB) (*models.MatchSuggestion, bool) {
+	l := logger.FromContext(r.Context())
+
+	id, err := utils.ParseIDParam(r, "id")
+	if err != nil {
+		utils.WriteError(w, "invalid suggestion id", http.StatusBadRequest)
+		return nil, false
+	}
+	suggestion, err := store.GetMatchSuggestion(r.Context(), id)
+	if errors.Is(err, db.ErrNotFound) {
+		utils.WriteError(w, "suggestion not found", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		l.Err(err).Msg("Failed to retrieve suggestion")
+		utils.WriteError(w, "failed to retrieve suggestion", http.StatusInternalServerError)
+		return nil, false
+	}
+	if suggestion.Status != models.MatchSuggestionPending {
+		utils.WriteError(w, "suggestion was already "+suggestion.Status, http.StatusConflict)
+		return nil, false
+	}
+	return suggestion, true
+}
+
+func writeMbzSuggestion(w http.ResponseWriter, r *http.Request, store db.DB, id int32) {
+	suggestion, err := store.GetMatchSuggestion(r.Context(), id)
+	if err != nil {
+		logger.FromContext(r.Context()).Err(err).Msg("Failed to retrieve suggestion")
+		utils.WriteError(w, "failed to retrieve suggestion", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, suggestion)
+}
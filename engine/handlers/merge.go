@@ -1,14 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/gabehf/koito/internal/catalog"
 	"github.com/gabehf/koito/internal/db"
 	"github.com/gabehf/koito/internal/logger"
 	"github.com/gabehf/koito/internal/utils"
 )
 
-func MergeArtistsHandler(store db.ArtistStore) http.HandlerFunc {
+func MergeArtistsHandler(store db.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		l := logger.FromContext(r.Context())
 
@@ -35,10 +37,19 @@ func MergeArtistsHandler(store db.ArtistStore) http.HandlerFunc {
 			return
 		}
 
+		if dryRun, _ := utils.ParseBool(r.URL.Query().Get("dry_run")); dryRun {
+			writeMergePreview(w, r, store, catalog.MatchEntityArtist, body.MergeFromID, toId)
+			return
+		}
+
 		l.Debug().Msgf("MergeArtistsHandler: Merging artists from ID %d to ID %d", body.MergeFromID, toId)
 
 		err = store.MergeArtists(r.Context(), body.MergeFromID, toId, body.ReplaceImage)
 		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				utils.WriteError(w, "artist not found", http.StatusNotFound)
+				return
+			}
 			l.Err(err).Msg("MergeArtistsHandler: Failed to merge artists")
 			utils.WriteError(w, "Failed to merge artists: "+err.Error(), http.StatusInternalServerError)
 			return
@@ -49,7 +60,7 @@ func MergeArtistsHandler(store db.ArtistStore) http.HandlerFunc {
 	}
 }
 
-func MergeAlbumsHandler(store db.AlbumStore) http.HandlerFunc {
+func MergeAlbumsHandler(store db.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		l := logger.FromContext(r.Context())
 
@@ -76,10 +87,19 @@ func MergeAlbumsHandler(store db.AlbumStore) http.HandlerFunc {
 			return
 		}
 
+		if dryRun, _ := utils.ParseBool(r.URL.Query().Get("dry_run")); dryRun {
+			writeMergePreview(w, r, store, catalog.MatchEntityAlbum, body.MergeFromID, toId)
+			return
+		}
+
 		l.Debug().Msgf("MergeAlbumsHandler: Merging albums from ID %d to ID %d", body.MergeFromID, toId)
 
 		err = store.MergeAlbums(r.Context(), body.MergeFromID, toId, body.ReplaceImage)
 		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				utils.WriteError(w, "album not found", http.StatusNotFound)
+				return
+			}
 			l.Err(err).Msg("MergeAlbumsHandler: Failed to merge albums")
 			utils.WriteError(w, "Failed to merge albums: "+err.Error(), http.StatusInternalServerError)
 			return
@@ -90,7 +110,7 @@ func MergeAlbumsHandler(store db.AlbumStore) http.HandlerFunc {
 	}
 }
 
-func MergeTracksHandler(store db.TrackStore) http.HandlerFunc {
+func MergeTracksHandler(store db.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		l := logger.FromContext(r.Context())
 
@@ -116,10 +136,19 @@ func MergeTracksHandler(store db.TrackStore) http.HandlerFunc {
 			return
 		}
 
+		if dryRun, _ := utils.ParseBool(r.URL.Query().Get("dry_run")); dryRun {
+			writeMergePreview(w, r, store, catalog.MatchEntityTrack, body.MergeFromID, toId)
+			return
+		}
+
 		l.Debug().Msgf("MergeTracksHandler: Merging tracks from ID %d to ID %d", body.MergeFromID, toId)
 
 		err = store.MergeTracks(r.Context(), body.MergeFromID, toId)
 		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				utils.WriteError(w, "track not found", http.StatusNotFound)
+				return
+			}
 			l.Err(err).Msg("MergeTracksHandler: Failed to merge tracks")
 			utils.WriteError(w, "Failed to merge tracks: "+err.Error(), http.StatusInternalServerError)
 			return
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetMergeSuggestionsHandler returns the pending merge suggestions for likely duplicates,
+// optionally only those for one type of item with type=artist, album or track.
+func GetMergeSuggestionsHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetMergeSuggestionsHandler: Received request to retrieve merge suggestions")
+
+		entityType := r.URL.Query().Get("type")
+		switch entityType {
+		case "", catalog.MatchEntityArtist, catalog.MatchEntityAlbum, catalog.MatchEntityTrack:
+		default:
+			utils.WriteError(w, "type must be one of artist, album or track", http.StatusBadRequest)
+			return
+		}
+
+		suggestions, err := store.GetMergeSuggestions(ctx, entityType)
+		if err != nil {
+			l.Err(err).Msg("GetMergeSuggestionsHandler: Failed to retrieve merge suggestions")
+			utils.WriteError(w, "failed to retrieve merge suggestions", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, suggestions)
+	}
+}
+
+// RefreshMergeSuggestionsHandler runs the duplicate analyzer, replacing the pending merge
+// suggestions.
+func RefreshMergeSuggestionsHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("RefreshMergeSuggestionsHandler: Received request to look for duplicates")
+
+		n, err := catalog.FindDuplicates(ctx, store)
+		if err != nil {
+			l.Err(err).Msg("RefreshMergeSuggestionsHandler: Failed to look for duplicates")
+			utils.WriteError(w, "failed to look for duplicates", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, map[string]int{"suggestions": n})
+	}
+}
+
+// AcceptMergeSuggestionsHandler merges the items of each of the given suggestions, and reports
+// the outcome of each.
+func AcceptMergeSuggestionsHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("AcceptMergeSuggestionsHandler: Received request to accept merge suggestions")
+
+		body, err := utils.DecodeBody[struct {
+			IDs []int32 `json:"ids"`
+		}](r)
+		if err != nil || len(body.IDs) == 0 {
+			l.Debug().AnErr("error", err).Msg("AcceptMergeSuggestionsHandler: required body key 'ids' invalid or missing")
+			utils.WriteError(w, "ids is invalid or missing", http.StatusBadRequest)
+			return
+		}
+
+		results := catalog.AcceptMergeSuggestions(ctx, store, body.IDs)
+		utils.WriteJSON(w, http.StatusOK, results)
+	}
+}
+
+// DismissMergeSuggestionHandler dismisses a merge suggestion, so the pair is not suggested again.
+func DismissMergeSuggestionHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("DismissMergeSuggestionHandler: Received request to dismiss merge suggestion")
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			utils.WriteError(w, "invalid suggestion id", http.StatusBadRequest)
+			return
+		}
+		err = store.DismissMergeSuggestion(ctx, id)
+		if errors.Is(err, db.ErrNotFound) {
+			utils.WriteError(w, "suggestion not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Err(err).Msg("DismissMergeSuggestionHandler: Failed to dismiss merge suggestion")
+			utils.WriteError(w, "failed to dismiss merge suggestion", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
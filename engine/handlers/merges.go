@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// writeMergePreview responds with what merging fromId into toId would move, for merge requests
+// made with dry_run=true.
+func writeMergePreview(w http.ResponseWriter, r *http.Request, store db.MergeStore, entityType string, fromId, toId int32) {
+	l := logger.FromContext(r.Context())
+
+	preview, err := store.PreviewMerge(r.Context(), entityType, fromId, toId)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			utils.WriteError(w, entityType+" not found", http.StatusNotFound)
+			return
+		}
+		l.Err(err).Msg("writeMergePreview: Failed to preview merge")
+		utils.WriteError(w, "failed to preview merge", http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, preview)
+}
+
+// GetMergesHandler returns the merges that can still be undone, newest first. Merges that were
+// already undone are included, with their undone_at set.
+func GetMergesHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetMergesHandler: Received request to retrieve merges")
+
+		merges, err := store.GetMerges(ctx, time.Now().Add(-cfg.MergeUndoRetention()))
+		if err != nil {
+			l.Err(err).Msg("GetMergesHandler: Failed to retrieve merges")
+			utils.WriteError(w, "failed to retrieve merges", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, merges)
+	}
+}
+
+// UndoMergeHandler undoes a merge, bringing back the merged item with everything that was
+// moved off of it.
+func UndoMergeHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("UndoMergeHandler: Received request to undo merge")
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			utils.WriteError(w, "invalid merge id", http.StatusBadRequest)
+			return
+		}
+
+		err = catalog.UndoMerge(ctx, store, id)
+		switch {
+		case err == nil:
+		case errors.Is(err, db.ErrNotFound):
+			utils.WriteError(w, "merge not found", http.StatusNotFound)
+			return
+		case errors.Is(err, catalog.ErrMergeExpired):
+			utils.WriteError(w, "merge is too old to be undone", http.StatusGone)
+			return
+		case errors.Is(err, catalog.ErrMergeUndone), errors.Is(err, db.ErrConflict):
+			l.Debug().AnErr("error", err).Msgf("UndoMergeHandler: Merge %d can't be undone", id)
+			utils.WriteError(w, "merge can't be undone: "+err.Error(), http.StatusConflict)
+			return
+		default:
+			l.Err(err).Msgf("UndoMergeHandler: Failed to undo merge %d", id)
+			utils.WriteError(w, "failed to undo merge", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("UndoMergeHandler: Undid merge %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
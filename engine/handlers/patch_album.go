@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gabehf/koito/internal/db"
 	"github.com/gabehf/koito/internal/logger"
@@ -94,8 +95,10 @@ func UpdateAlbumHandler(store db.AlbumStore) http.HandlerFunc {
 		}
 
 		body, err := utils.DecodeBody[struct {
-			MBID             *string `json:"mbid"`
-			IsVariousArtists *bool   `json:"is_various_artists"`
+			MBID             *string   `json:"mbid"`
+			IsVariousArtists *bool     `json:"is_various_artists"`
+			PrimaryType      *string   `json:"primary_type"`
+			SecondaryTypes   *[]string `json:"secondary_types"`
 		}](r)
 		if err != nil {
 			l.Debug().Msg("UpdateAlbumHandler: Invalid request body")
@@ -103,7 +106,7 @@ func UpdateAlbumHandler(store db.AlbumStore) http.HandlerFunc {
 			return
 		}
 
-		if body.MBID == nil && body.IsVariousArtists == nil {
+		if body.MBID == nil && body.IsVariousArtists == nil && body.PrimaryType == nil && body.SecondaryTypes == nil {
 			l.Debug().Msg("UpdateAlbumHandler: Request body contains no updatable fields")
 			utils.WriteError(w, "no updatable fields provided", http.StatusBadRequest)
 			return
@@ -126,6 +129,41 @@ func UpdateAlbumHandler(store db.AlbumStore) http.HandlerFunc {
 			updateOpts.VariousArtistsValue = *body.IsVariousArtists
 		}
 
+		if body.PrimaryType != nil || body.SecondaryTypes != nil {
+			// fields that are left out keep their current value
+			album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: albumID})
+			if err != nil {
+				l.Error().Err(err).Msg("UpdateAlbumHandler: Failed to get album")
+				utils.WriteError(w, "album not found", http.StatusNotFound)
+				return
+			}
+			updateOpts.TypeUpdate = true
+			updateOpts.TypeSource = db.InformationSourceUserProvided
+			updateOpts.PrimaryType = album.PrimaryType
+			updateOpts.SecondaryTypes = album.SecondaryTypes
+			if body.PrimaryType != nil {
+				t, ok := canonicalType(*body.PrimaryType, releasePrimaryTypes)
+				if !ok {
+					l.Debug().Msgf("UpdateAlbumHandler: Unknown primary type '%s'", *body.PrimaryType)
+					utils.WriteError(w, "unknown primary type", http.StatusBadRequest)
+					return
+				}
+				updateOpts.PrimaryType = t
+			}
+			if body.SecondaryTypes != nil {
+				updateOpts.SecondaryTypes = nil
+				for _, st := range *body.SecondaryTypes {
+					t, ok := canonicalType(st, releaseSecondaryTypes)
+					if !ok || t == "" {
+						l.Debug().Msgf("UpdateAlbumHandler: Unknown secondary type '%s'", st)
+						utils.WriteError(w, "unknown secondary type", http.StatusBadRequest)
+						return
+					}
+					updateOpts.SecondaryTypes = append(updateOpts.SecondaryTypes, t)
+				}
+			}
+		}
+
 		if err = store.UpdateAlbum(ctx, updateOpts); err != nil {
 			l.Error().Err(err).Msg("UpdateAlbumHandler: Failed to update album")
 			utils.WriteError(w, "failed to update album", http.StatusInternalServerError)
@@ -135,3 +173,25 @@ func UpdateAlbumHandler(store db.AlbumStore) http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// release group types as defined by MusicBrainz
+var (
+	releasePrimaryTypes   = []string{"Album", "Single", "EP", "Broadcast", "Other"}
+	releaseSecondaryTypes = []string{"Compilation", "Soundtrack", "Spokenword", "Interview", "Audiobook",
+		"Audio drama", "Live", "Remix", "DJ-mix", "Mixtape/Street", "Demo", "Field recording"}
+)
+
+// canonicalType returns the known type matching t case insensitively. An empty t is
+// allowed and clears the type.
+func canonicalType(t string, known []string) (string, bool) {
+	t = strings.TrimSpace(t)
+	if t == "" {
+		return "", true
+	}
+	for _, k := range known {
+		if strings.EqualFold(k, t) {
+			return k, true
+		}
+	}
+	return "", false
+}
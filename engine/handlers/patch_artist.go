@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/gabehf/koito/internal/db"
 	"github.com/gabehf/koito/internal/logger"
@@ -54,7 +56,12 @@ func UpdateArtistHandler(store db.ArtistStore) http.HandlerFunc {
 		}
 
 		body, err := utils.DecodeBody[struct {
-			MBID *string `json:"mbid"`
+			MBID     *string `json:"mbid"`
+			Country  *string `json:"country"`
+			Area     *string `json:"area"`
+			Type     *string `json:"type"`
+			Gender   *string `json:"gender"`
+			SortName *string `json:"sort_name"`
 		}](r)
 		if err != nil {
 			l.Debug().Msg("UpdateArtistHandler: Invalid request body")
@@ -62,7 +69,8 @@ func UpdateArtistHandler(store db.ArtistStore) http.HandlerFunc {
 			return
 		}
 
-		if body.MBID == nil {
+		if body.MBID == nil && body.Country == nil && body.Area == nil && body.Type == nil &&
+			body.Gender == nil && body.SortName == nil {
 			l.Debug().Msg("UpdateArtistHandler: Request body contains no updatable fields")
 			utils.WriteError(w, "no updatable fields provided", http.StatusBadRequest)
 			return
@@ -80,6 +88,56 @@ func UpdateArtistHandler(store db.ArtistStore) http.HandlerFunc {
 			updateOpts.MusicBrainzID = mbid
 		}
 
+		if body.Country != nil || body.Area != nil || body.Type != nil || body.Gender != nil || body.SortName != nil {
+			// fields that are left out keep their current value
+			artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: artistID})
+			if err != nil {
+				l.Error().Err(err).Msg("UpdateArtistHandler: Failed to get artist")
+				utils.WriteError(w, "artist not found", http.StatusNotFound)
+				return
+			}
+			updateOpts.MetadataUpdate = true
+			updateOpts.MetadataSource = db.InformationSourceUserProvided
+			updateOpts.Country = artist.Country
+			updateOpts.Area = artist.Area
+			updateOpts.Type = artist.Type
+			updateOpts.Gender = artist.Gender
+			updateOpts.SortName = artist.SortName
+			if body.Country != nil {
+				country := strings.ToUpper(strings.TrimSpace(*body.Country))
+				if country != "" && !countryCodeRegex.MatchString(country) {
+					l.Debug().Msgf("UpdateArtistHandler: Invalid country code '%s'", *body.Country)
+					utils.WriteError(w, "country must be an ISO 3166-1 alpha-2 code", http.StatusBadRequest)
+					return
+				}
+				updateOpts.Country = country
+			}
+			if body.Area != nil {
+				updateOpts.Area = strings.TrimSpace(*body.Area)
+			}
+			if body.Type != nil {
+				t, ok := canonicalType(*body.Type, artistTypes)
+				if !ok {
+					l.Debug().Msgf("UpdateArtistHandler: Unknown artist type '%s'", *body.Type)
+					utils.WriteError(w, "unknown artist type", http.StatusBadRequest)
+					return
+				}
+				updateOpts.Type = t
+			}
+			if body.Gender != nil {
+				g, ok := canonicalType(*body.Gender, artistGenders)
+				if !ok {
+					l.Debug().Msgf("UpdateArtistHandler: Unknown gender '%s'", *body.Gender)
+					utils.WriteError(w, "unknown gender", http.StatusBadRequest)
+					return
+				}
+				updateOpts.Gender = g
+			}
+			if body.SortName != nil {
+				updateOpts.SortName = strings.TrimSpace(*body.SortName)
+			}
+		}
+
 		if err = store.UpdateArtist(ctx, updateOpts); err != nil {
 			l.Error().Err(err).Msg("UpdateArtistHandler: Failed to update artist")
 			utils.WriteError(w, "failed to update artist", http.StatusInternalServerError)
@@ -89,3 +147,10 @@ func UpdateArtistHandler(store db.ArtistStore) http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// artist types and genders as defined by MusicBrainz
+var (
+	artistTypes      = []string{"Person", "Group", "Orchestra", "Choir", "Character", "Other"}
+	artistGenders    = []string{"Male", "Female", "Non-binary", "Other", "Not applicable"}
+	countryCodeRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+)
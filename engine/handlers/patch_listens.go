@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// UpdateListenHandler changes the track, timestamp or client of the listen identified by the
+// track_id and unix query parameters, as for DeleteListenHandler.
+func UpdateListenHandler(store db.ListenStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("UpdateListenHandler: Received request to update listen record")
+
+		trackID, err := strconv.Atoi(r.URL.Query().Get("track_id"))
+		if err != nil || trackID < 1 {
+			l.Debug().Msg("UpdateListenHandler: Missing or invalid track ID in request")
+			utils.WriteError(w, "valid track_id must be provided", http.StatusBadRequest)
+			return
+		}
+		unix, err := strconv.ParseInt(r.URL.Query().Get("unix"), 10, 64)
+		if err != nil {
+			l.Debug().Msg("UpdateListenHandler: Missing or invalid timestamp in request")
+			utils.WriteError(w, "valid unix timestamp must be provided", http.StatusBadRequest)
+			return
+		}
+
+		body, err := utils.DecodeBody[struct {
+			TrackID *int32  `json:"track_id"`
+			Unix    *int64  `json:"unix"`
+			Client  *string `json:"client"`
+		}](r)
+		if err != nil || (body.TrackID == nil && body.Unix == nil && body.Client == nil) {
+			utils.WriteError(w, "request body must contain track_id, unix or client", http.StatusBadRequest)
+			return
+		}
+
+		opts := db.UpdateListenOpts{
+			TrackID:    int32(trackID),
+			ListenedAt: time.Unix(unix, 0),
+			Client:     body.Client,
+		}
+		if body.TrackID != nil {
+			if *body.TrackID < 1 {
+				utils.WriteError(w, "invalid track_id", http.StatusBadRequest)
+				return
+			}
+			opts.NewTrackID = *body.TrackID
+		}
+		if body.Unix != nil {
+			if *body.Unix <= 0 || time.Unix(*body.Unix, 0).After(time.Now()) {
+				utils.WriteError(w, "invalid unix timestamp", http.StatusBadRequest)
+				return
+			}
+			opts.NewTime = time.Unix(*body.Unix, 0)
+		}
+
+		l.Debug().Msgf("UpdateListenHandler: Updating listen record for track ID %d at timestamp %d", trackID, unix)
+
+		err = store.UpdateListen(ctx, opts)
+		if errors.Is(err, db.ErrNotFound) {
+			l.Debug().AnErr("error", err).Msg("UpdateListenHandler: Listen or track not found")
+			utils.WriteError(w, "listen or track not found", http.StatusNotFound)
+			return
+		} else if errors.Is(err, db.ErrConflict) {
+			l.Debug().AnErr("error", err).Msg("UpdateListenHandler: Listen already exists")
+			utils.WriteError(w, "the track already has a listen at that time", http.StatusConflict)
+			return
+		} else if err != nil {
+			l.Err(err).Msg("UpdateListenHandler: Failed to update listen record")
+			utils.WriteError(w, "failed to update listen", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("UpdateListenHandler: Successfully updated listen record for track ID %d at timestamp %d", trackID, unix)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// MoveListensHandler moves every listen matching track_id, from, to and client to to_track_id.
+// Listens the target track already has a listen at the same time as are skipped.
+func MoveListensHandler(store db.ListenStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("MoveListensHandler: Received request to move listens")
+
+		body, err := utils.DecodeBody[struct {
+			TrackID   int32  `json:"track_id"`
+			From      int64  `json:"from"`
+			To        int64  `json:"to"`
+			Client    string `json:"client"`
+			ToTrackID int32  `json:"to_track_id"`
+		}](r)
+		if err != nil || body.ToTrackID < 1 {
+			utils.WriteError(w, "valid to_track_id must be provided", http.StatusBadRequest)
+			return
+		}
+		if body.TrackID == 0 && body.From == 0 && body.To == 0 && body.Client == "" {
+			utils.WriteError(w, "listens must be selected with track_id, from, to or client", http.StatusBadRequest)
+			return
+		}
+
+		opts := db.MoveListensOpts{
+			TrackID:   body.TrackID,
+			Client:    body.Client,
+			ToTrackID: body.ToTrackID,
+		}
+		if body.From != 0 {
+			opts.From = time.Unix(body.From, 0)
+		}
+		if body.To != 0 {
+			opts.To = time.Unix(body.To, 0)
+		}
+
+		result, err := store.MoveListens(ctx, opts)
+		if errors.Is(err, db.ErrNotFound) {
+			l.Debug().AnErr("error", err).Msg("MoveListensHandler: Target track not found")
+			utils.WriteError(w, "track not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Err(err).Msg("MoveListensHandler: Failed to move listens")
+			utils.WriteError(w, "failed to move listens", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("MoveListensHandler: Moved %d listens to track %d, skipped %d", result.Moved, body.ToTrackID, result.Skipped)
+		utils.WriteJSON(w, http.StatusOK, result)
+	}
+}
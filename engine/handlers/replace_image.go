@@ -48,25 +48,13 @@ func ReplaceArtistImageHandler(store db.ArtistStore) http.HandlerFunc {
 			return
 		}
 
-		if err = store.UpdateArtist(ctx, db.UpdateArtistOpts{
-			ID:       artistID,
-			Image:    id,
-			ImageSrc: imgsrc,
-		}); err != nil {
-			l.Err(err).Msg("ReplaceArtistImageHandler: Artist image could not be updated")
-			utils.WriteError(w, "artist image could not be updated", http.StatusInternalServerError)
-			return
-		}
-
-		if artist.Image.Small != "" {
-			if err = imagecache.DeleteImage(*parseOldImage(artist.Image.Small)); err != nil {
-				l.Err(err).Msg("ReplaceArtistImageHandler: Failed to delete old image file")
-				utils.WriteError(w, "could not delete old image file", http.StatusInternalServerError)
-				return
-			}
-		}
-
-		utils.WriteJSON(w, http.StatusOK, ReplaceImageResponse{Success: true, Image: id.String()})
+		saveImage(w, l, "artist", artist.Image.Small, id, func() error {
+			return store.UpdateArtist(ctx, db.UpdateArtistOpts{
+				ID:       artistID,
+				Image:    id,
+				ImageSrc: imgsrc,
+			})
+		})
 	}
 }
 
@@ -96,25 +84,13 @@ func ReplaceAlbumImageHandler(store db.AlbumStore) http.HandlerFunc {
 			return
 		}
 
-		if err = store.UpdateAlbum(ctx, db.UpdateAlbumOpts{
-			ID:       albumID,
-			Image:    id,
-			ImageSrc: imgsrc,
-		}); err != nil {
-			l.Err(err).Msg("ReplaceAlbumImageHandler: Album image could not be updated")
-			utils.WriteError(w, "album image could not be updated", http.StatusInternalServerError)
-			return
-		}
-
-		if album.Image.Small != "" {
-			if err = imagecache.DeleteImage(*parseOldImage(album.Image.Small)); err != nil {
-				l.Err(err).Msg("ReplaceAlbumImageHandler: Failed to delete old image file")
-				utils.WriteError(w, "could not delete old image file", http.StatusInternalServerError)
-				return
-			}
-		}
-
-		utils.WriteJSON(w, http.StatusOK, ReplaceImageResponse{Success: true, Image: id.String()})
+		saveImage(w, l, "album", album.Image.Small, id, func() error {
+			return store.UpdateAlbum(ctx, db.UpdateAlbumOpts{
+				ID:       albumID,
+				Image:    id,
+				ImageSrc: imgsrc,
+			})
+		})
 	}
 }
 
@@ -168,6 +144,25 @@ func resolveImage(r *http.Request, l *zerolog.Logger) (uuid.UUID, string, error)
 	return id, catalog.ImageSourceUserUpload, nil
 }
 
+// saveImage points an artist or album at the newly cached image with update, then deletes the
+// file of its old image, linked to by old, and writes the response.
+func saveImage(w http.ResponseWriter, l *zerolog.Logger, kind string, old string, id uuid.UUID, update func() error) {
+	if err := update(); err != nil {
+		l.Err(err).Msgf("saveImage: The %s image could not be updated", kind)
+		utils.WriteError(w, kind+" image could not be updated", http.StatusInternalServerError)
+		return
+	}
+
+	// the new image is already saved, so a leftover old file is not worth failing the request for
+	if oldID := parseOldImage(old); oldID != nil {
+		if err := imagecache.DeleteImage(*oldID); err != nil {
+			l.Err(err).Msg("saveImage: Failed to delete old image file")
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, ReplaceImageResponse{Success: true, Image: id.String()})
+}
+
 // parses the image id from /image/{uuid}/size.webp style links
 func parseOldImage(link string) *uuid.UUID {
 	ss := strings.Split(link, "/")
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// ReprocessListensHandler runs the selected listens through rewrite rules and catalog matching
+// again. Listens are selected with the usual timeframe parameters, which are required, and
+// optionally narrowed down with client, artist_id and album_id. Unless dry_run=false is given,
+// only the changes that would be made are returned. Otherwise the job is started in the
+// background and its progress can be followed with GetReprocessStatusHandler.
+func ReprocessListensHandler(store submitListenHandlerStore, mbzc mbz.MusicBrainzCaller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("ReprocessListensHandler: Received request to reprocess listens")
+
+		itemOpts := OptsFromRequest(r)
+		if _, t2 := db.TimeframeToTimeRange(itemOpts.Timeframe); t2.IsZero() {
+			l.Debug().Msg("ReprocessListensHandler: Missing timeframe")
+			utils.WriteError(w, "a timeframe is required, use period=all_time to reprocess every listen", http.StatusBadRequest)
+			return
+		}
+		dryRun := true
+		if v, ok := utils.ParseBool(r.URL.Query().Get("dry_run")); ok {
+			dryRun = v
+		}
+		opts := catalog.ReprocessOpts{
+			Timeframe: itemOpts.Timeframe,
+			Client:    r.URL.Query().Get("client"),
+			ArtistID:  int32(itemOpts.ArtistID),
+			AlbumID:   int32(itemOpts.AlbumID),
+			MbzCaller: mbzc,
+			DryRun:    dryRun,
+		}
+
+		if dryRun {
+			res, err := catalog.ReprocessListens(ctx, store, opts)
+			if err != nil {
+				l.Err(err).Msg("ReprocessListensHandler: Failed to reprocess listens")
+				utils.WriteError(w, "failed to reprocess listens", http.StatusInternalServerError)
+				return
+			}
+			utils.WriteJSON(w, http.StatusOK, res)
+			return
+		}
+
+		// the job outlives the request
+		if err := catalog.StartReprocess(context.WithoutCancel(ctx), store, opts); err != nil {
+			if errors.Is(err, catalog.ErrReprocessRunning) {
+				utils.WriteError(w, err.Error(), http.StatusConflict)
+				return
+			}
+			l.Err(err).Msg("ReprocessListensHandler: Failed to start reprocessing")
+			utils.WriteError(w, "failed to start reprocessing", http.StatusInternalServerError)
+			return
+		}
+		l.Info().Msg("ReprocessListensHandler: Started reprocessing listens")
+		utils.WriteJSON(w, http.StatusAccepted, catalog.GetReprocessStatus())
+	}
+}
+
+// GetReprocessStatusHandler returns the state of the running or last reprocessing job.
+func GetReprocessStatusHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteJSON(w, http.StatusOK, catalog.GetReprocessStatus())
+	}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+type rewriteRuleBody struct {
+	Name       *string                    `json:"name"`
+	Enabled    *bool                      `json:"enabled"`
+	Position   *int32                     `json:"position"`
+	Conditions *[]models.RewriteCondition `json:"conditions"`
+	Action     *string                    `json:"action"`
+	Field      *string                    `json:"field"`
+	Pattern    *string                    `json:"pattern"`
+	Value      *string                    `json:"value"`
+}
+
+// apply sets the fields present in the body on the rule.
+func (b rewriteRuleBody) apply(rule *models.RewriteRule) {
+	if b.Name != nil {
+		rule.Name = *b.Name
+	}
+	if b.Enabled != nil {
+		rule.Enabled = *b.Enabled
+	}
+	if b.Position != nil {
+		rule.Position = *b.Position
+	}
+	if b.Conditions != nil {
+		rule.Conditions = *b.Conditions
+	}
+	if b.Action != nil {
+		rule.Action = *b.Action
+	}
+	if b.Field != nil {
+		rule.Field = *b.Field
+	}
+	if b.Pattern != nil {
+		rule.Pattern = *b.Pattern
+	}
+	if b.Value != nil {
+		rule.Value = *b.Value
+	}
+}
+
+func GetRewriteRulesHandler(store db.RewriteRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		rules, err := store.GetRewriteRules(ctx)
+		if err != nil {
+			l.Error().Err(err).Msg("GetRewriteRulesHandler: Failed to retrieve rewrite rules")
+			utils.WriteError(w, "failed to retrieve rewrite rules", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("GetRewriteRulesHandler: Retrieved %d rewrite rules", len(rules))
+		utils.WriteJSON(w, http.StatusOK, rules)
+	}
+}
+
+func CreateRewriteRuleHandler(store db.RewriteRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		body, err := utils.DecodeBody[rewriteRuleBody](r)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("CreateRewriteRuleHandler: Invalid request body")
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		rule := models.RewriteRule{Enabled: true}
+		body.apply(&rule)
+		if err := catalog.ValidateRewriteRule(rule); err != nil {
+			l.Debug().AnErr("error", err).Msg("CreateRewriteRuleHandler: Invalid rewrite rule")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		saved, err := store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+			Name:       rule.Name,
+			Enabled:    rule.Enabled,
+			Position:   rule.Position,
+			Conditions: rule.Conditions,
+			Action:     rule.Action,
+			Field:      rule.Field,
+			Pattern:    rule.Pattern,
+			Value:      rule.Value,
+		})
+		if err != nil {
+			l.Error().Err(err).Msg("CreateRewriteRuleHandler: Failed to save rewrite rule")
+			utils.WriteError(w, "failed to save rewrite rule", http.StatusInternalServerError)
+			return
+		}
+
+		catalog.InvalidateRewriteRules()
+		l.Debug().Msgf("CreateRewriteRuleHandler: Successfully created rewrite rule ID %d", saved.ID)
+		utils.WriteJSON(w, http.StatusCreated, saved)
+	}
+}
+
+// UpdateRewriteRuleHandler changes the fields of a rule present in the request body. When
+// conditions are given, they replace all of the rule's conditions.
+func UpdateRewriteRuleHandler(store db.RewriteRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateRewriteRuleHandler: Invalid rule id")
+			utils.WriteError(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+
+		body, err := utils.DecodeBody[rewriteRuleBody](r)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateRewriteRuleHandler: Invalid request body")
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		rule, err := store.GetRewriteRule(ctx, id)
+		if errors.Is(err, db.ErrNotFound) {
+			utils.WriteError(w, "rewrite rule not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Error().Err(err).Msg("UpdateRewriteRuleHandler: Failed to retrieve rewrite rule")
+			utils.WriteError(w, "failed to retrieve rewrite rule", http.StatusInternalServerError)
+			return
+		}
+		body.apply(rule)
+		if err := catalog.ValidateRewriteRule(*rule); err != nil {
+			l.Debug().AnErr("error", err).Msg("UpdateRewriteRuleHandler: Invalid rewrite rule")
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = store.UpdateRewriteRule(ctx, db.UpdateRewriteRuleOpts{
+			ID:         rule.ID,
+			Name:       rule.Name,
+			Enabled:    rule.Enabled,
+			Position:   rule.Position,
+			Conditions: rule.Conditions,
+			Action:     rule.Action,
+			Field:      rule.Field,
+			Pattern:    rule.Pattern,
+			Value:      rule.Value,
+		})
+		if err != nil {
+			l.Error().Err(err).Msg("UpdateRewriteRuleHandler: Failed to update rewrite rule")
+			utils.WriteError(w, "failed to update rewrite rule", http.StatusInternalServerError)
+			return
+		}
+
+		catalog.InvalidateRewriteRules()
+		l.Debug().Msgf("UpdateRewriteRuleHandler: Successfully updated rewrite rule ID %d", id)
+		utils.WriteJSON(w, http.StatusOK, rule)
+	}
+}
+
+func DeleteRewriteRuleHandler(store db.RewriteRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("DeleteRewriteRuleHandler: Invalid rule id")
+			utils.WriteError(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+
+		if err := store.DeleteRewriteRule(ctx, id); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				utils.WriteError(w, "rewrite rule not found", http.StatusNotFound)
+				return
+			}
+			l.Error().Err(err).Msg("DeleteRewriteRuleHandler: Failed to delete rewrite rule")
+			utils.WriteError(w, "failed to delete rewrite rule", http.StatusInternalServerError)
+			return
+		}
+
+		catalog.InvalidateRewriteRules()
+		l.Debug().Msgf("DeleteRewriteRuleHandler: Successfully deleted rewrite rule ID %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PreviewRewriteRulesHandler shows how the metadata of a listen would be rewritten. The rules
+// in the request body are used when given, so a rule can be tried out before it is saved;
+// otherwise the saved rules are used.
+func PreviewRewriteRulesHandler(store db.RewriteRuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		body, err := utils.DecodeBody[struct {
+			catalog.ListenMetadata
+			Rules []rewriteRuleBody `json:"rules"`
+		}](r)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("PreviewRewriteRulesHandler: Invalid request body")
+			utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var rules []*models.RewriteRule
+		if body.Rules == nil {
+			rules, err = store.GetRewriteRules(ctx)
+			if err != nil {
+				l.Error().Err(err).Msg("PreviewRewriteRulesHandler: Failed to retrieve rewrite rules")
+				utils.WriteError(w, "failed to retrieve rewrite rules", http.StatusInternalServerError)
+				return
+			}
+		}
+		for _, b := range body.Rules {
+			rule := &models.RewriteRule{Enabled: true}
+			b.apply(rule)
+			if err := catalog.ValidateRewriteRule(*rule); err != nil {
+				l.Debug().AnErr("error", err).Msg("PreviewRewriteRulesHandler: Invalid rewrite rule")
+				utils.WriteError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			rules = append(rules, rule)
+		}
+
+		utils.WriteJSON(w, http.StatusOK, catalog.ApplyRewriteRules(rules, body.ListenMetadata))
+	}
+}
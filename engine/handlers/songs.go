@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetSongHandler returns a song with all of its tracks across releases and their combined
+// listen counts.
+func GetSongHandler(store db.TrackStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetSongHandler: Received request to retrieve song")
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("GetSongHandler: Invalid song id")
+			utils.WriteError(w, "invalid song id", http.StatusBadRequest)
+			return
+		}
+
+		song, err := store.GetSong(ctx, id)
+		if err != nil {
+			l.Err(err).Msgf("GetSongHandler: Failed to retrieve song with ID %d", id)
+			utils.WriteError(w, "song with specified id could not be found", http.StatusNotFound)
+			return
+		}
+
+		l.Debug().Msgf("GetSongHandler: Successfully retrieved song with ID %d", id)
+		utils.WriteJSON(w, http.StatusOK, song)
+	}
+}
+
+// AddTracksToSongHandler confirms that the given tracks are the same song, moving them into the
+// song. The tracks keep their own releases and listens.
+func AddTracksToSongHandler(store db.TrackStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		songID, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("AddTracksToSongHandler: Invalid song id")
+			utils.WriteError(w, "invalid song id", http.StatusBadRequest)
+			return
+		}
+
+		body, err := utils.DecodeBody[struct {
+			TrackIDs []int32 `json:"track_ids"`
+		}](r)
+		if err != nil || len(body.TrackIDs) == 0 {
+			l.Debug().Msg("AddTracksToSongHandler: Invalid or missing track_ids in request body")
+			utils.WriteError(w, "track_ids must be provided", http.StatusBadRequest)
+			return
+		}
+
+		if err = store.AddTracksToSong(ctx, songID, body.TrackIDs); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				l.Debug().Err(err).Msg("AddTracksToSongHandler: Song or track not found")
+				utils.WriteError(w, "song or track not found", http.StatusNotFound)
+				return
+			}
+			l.Error().Err(err).Msg("AddTracksToSongHandler: Failed to add tracks to song")
+			utils.WriteError(w, "failed to add tracks to song", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RemoveTrackFromSongHandler moves a track out of a song it was wrongly grouped into.
+func RemoveTrackFromSongHandler(store db.TrackStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		songID, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("RemoveTrackFromSongHandler: Invalid song id")
+			utils.WriteError(w, "invalid song id", http.StatusBadRequest)
+			return
+		}
+		trackID, err := utils.ParseIDParam(r, "track_id")
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("RemoveTrackFromSongHandler: Invalid track id")
+			utils.WriteError(w, "invalid track id", http.StatusBadRequest)
+			return
+		}
+
+		track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: trackID})
+		if err != nil || track.SongID != songID {
+			l.Debug().Msgf("RemoveTrackFromSongHandler: Track %d is not part of song %d", trackID, songID)
+			utils.WriteError(w, "track is not part of the song", http.StatusNotFound)
+			return
+		}
+
+		if err = store.RemoveTrackFromSong(ctx, trackID); err != nil {
+			l.Error().Err(err).Msg("RemoveTrackFromSongHandler: Failed to remove track from song")
+			utils.WriteError(w, "failed to remove track from song", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
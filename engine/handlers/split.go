@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+	"github.com/google/uuid"
+)
+
+// parseSplitMbzID parses the optional MusicBrainz ID of a split part.
+func parseSplitMbzID(mbid string) (uuid.UUID, bool) {
+	if mbid == "" {
+		return uuid.Nil, true
+	}
+	u, err := uuid.Parse(mbid)
+	return u, err == nil
+}
+
+// writeSplitError responds to a failed split. Items that don't exist are not found, and splits
+// that would leave the original item empty or reuse a MusicBrainz ID are conflicts.
+func writeSplitError(w http.ResponseWriter, r *http.Request, handler string, err error) {
+	l := logger.FromContext(r.Context())
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		l.Debug().AnErr("error", err).Msgf("%s: Item to split not found", handler)
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, db.ErrConflict):
+		l.Debug().AnErr("error", err).Msgf("%s: Split conflicts with existing data", handler)
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	default:
+		l.Err(err).Msgf("%s: Failed to split", handler)
+		utils.WriteError(w, "failed to split: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SplitArtistHandler splits new artists off of an artist. Each part names the tracks and albums
+// of the artist that go to the new artist, and whether they stay credited to the original
+// artist too, as for an "Artist A & Artist B" that should have been two artists.
+func SplitArtistHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("SplitArtistHandler: Received request to split artist")
+
+		artistID, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			utils.WriteError(w, "invalid artist id", http.StatusBadRequest)
+			return
+		}
+		body, err := utils.DecodeBody[struct {
+			Parts []struct {
+				Name     string   `json:"name"`
+				Aliases  []string `json:"aliases"`
+				MBID     string   `json:"mbid"`
+				TrackIDs []int32  `json:"track_ids"`
+				AlbumIDs []int32  `json:"album_ids"`
+				Shared   bool     `json:"shared"`
+			} `json:"parts"`
+		}](r)
+		if err != nil || len(body.Parts) == 0 {
+			utils.WriteError(w, "request body must contain at least one part", http.StatusBadRequest)
+			return
+		}
+
+		opts := db.SplitArtistOpts{ID: artistID}
+		for _, p := range body.Parts {
+			mbid, ok := parseSplitMbzID(p.MBID)
+			if !ok {
+				utils.WriteError(w, "provided musicbrainz id is invalid", http.StatusBadRequest)
+				return
+			}
+			if strings.TrimSpace(p.Name) == "" || len(p.TrackIDs)+len(p.AlbumIDs) == 0 {
+				utils.WriteError(w, "each part must have a name and at least one track or album", http.StatusBadRequest)
+				return
+			}
+			opts.Parts = append(opts.Parts, db.SplitArtistPart{
+				Name:          p.Name,
+				Aliases:       p.Aliases,
+				MusicBrainzID: mbid,
+				TrackIDs:      p.TrackIDs,
+				AlbumIDs:      p.AlbumIDs,
+				Shared:        p.Shared,
+			})
+		}
+
+		artists, err := store.SplitArtist(ctx, opts)
+		if err != nil {
+			writeSplitError(w, r, "SplitArtistHandler", err)
+			return
+		}
+		l.Debug().Msgf("SplitArtistHandler: Split %d artists off of artist %d", len(artists), artistID)
+		utils.WriteJSON(w, http.StatusCreated, artists)
+	}
+}
+
+// SplitAlbumHandler splits new albums off of an album, each taking the given tracks.
+func SplitAlbumHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("SplitAlbumHandler: Received request to split album")
+
+		albumID, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			utils.WriteError(w, "invalid album id", http.StatusBadRequest)
+			return
+		}
+		body, err := utils.DecodeBody[struct {
+			Parts []struct {
+				Title    string   `json:"title"`
+				Aliases  []string `json:"aliases"`
+				MBID     string   `json:"mbid"`
+				TrackIDs []int32  `json:"track_ids"`
+			} `json:"parts"`
+		}](r)
+		if err != nil || len(body.Parts) == 0 {
+			utils.WriteError(w, "request body must contain at least one part", http.StatusBadRequest)
+			return
+		}
+
+		opts := db.SplitAlbumOpts{ID: albumID}
+		for _, p := range body.Parts {
+			mbid, ok := parseSplitMbzID(p.MBID)
+			if !ok {
+				utils.WriteError(w, "provided musicbrainz id is invalid", http.StatusBadRequest)
+				return
+			}
+			if strings.TrimSpace(p.Title) == "" || len(p.TrackIDs) == 0 {
+				utils.WriteError(w, "each part must have a title and at least one track", http.StatusBadRequest)
+				return
+			}
+			opts.Parts = append(opts.Parts, db.SplitAlbumPart{
+				Title:         p.Title,
+				Aliases:       p.Aliases,
+				MusicBrainzID: mbid,
+				TrackIDs:      p.TrackIDs,
+			})
+		}
+
+		albums, err := store.SplitAlbum(ctx, opts)
+		if err != nil {
+			writeSplitError(w, r, "SplitAlbumHandler", err)
+			return
+		}
+		l.Debug().Msgf("SplitAlbumHandler: Split %d albums off of album %d", len(albums), albumID)
+		utils.WriteJSON(w, http.StatusCreated, albums)
+	}
+}
+
+// SplitTrackHandler splits new tracks off of a track, as when two different songs with the same
+// title were matched into one track. Each part takes the listens at the given unix times in
+// listened_at, or else the listens within from and to made with client.
+func SplitTrackHandler(store db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("SplitTrackHandler: Received request to split track")
+
+		trackID, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			utils.WriteError(w, "invalid track id", http.StatusBadRequest)
+			return
+		}
+		body, err := utils.DecodeBody[struct {
+			Parts []struct {
+				Title      string   `json:"title"`
+				Aliases    []string `json:"aliases"`
+				MBID       string   `json:"mbid"`
+				ListenedAt []int64  `json:"listened_at"`
+				From       int64    `json:"from"`
+				To         int64    `json:"to"`
+				Client     string   `json:"client"`
+			} `json:"parts"`
+		}](r)
+		if err != nil || len(body.Parts) == 0 {
+			utils.WriteError(w, "request body must contain at least one part", http.StatusBadRequest)
+			return
+		}
+
+		opts := db.SplitTrackOpts{ID: trackID}
+		for _, p := range body.Parts {
+			mbid, ok := parseSplitMbzID(p.MBID)
+			if !ok {
+				utils.WriteError(w, "provided musicbrainz id is invalid", http.StatusBadRequest)
+				return
+			}
+			if strings.TrimSpace(p.Title) == "" {
+				utils.WriteError(w, "each part must have a title", http.StatusBadRequest)
+				return
+			}
+			if len(p.ListenedAt) == 0 && p.From == 0 && p.To == 0 && p.Client == "" {
+				utils.WriteError(w, "each part must select listens with listened_at, from, to or client", http.StatusBadRequest)
+				return
+			}
+			part := db.SplitTrackPart{
+				Title:         p.Title,
+				Aliases:       p.Aliases,
+				MusicBrainzID: mbid,
+				Client:        p.Client,
+			}
+			for _, t := range p.ListenedAt {
+				part.ListenedAt = append(part.ListenedAt, time.Unix(t, 0))
+			}
+			if p.From != 0 {
+				part.From = time.Unix(p.From, 0)
+			}
+			if p.To != 0 {
+				part.To = time.Unix(p.To, 0)
+			}
+			opts.Parts = append(opts.Parts, part)
+		}
+
+		tracks, err := store.SplitTrack(ctx, opts)
+		if err != nil {
+			writeSplitError(w, r, "SplitTrackHandler", err)
+			return
+		}
+		l.Debug().Msgf("SplitTrackHandler: Split %d tracks off of track %d", len(tracks), trackID)
+		utils.WriteJSON(w, http.StatusCreated, tracks)
+	}
+}
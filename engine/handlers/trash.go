@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/utils"
+)
+
+// GetTrashHandler returns the deleted artists, albums and tracks, most recently deleted first.
+func GetTrashHandler(store db.TrashStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("GetTrashHandler: Received request to retrieve trash")
+
+		items, err := store.GetTrash(ctx)
+		if err != nil {
+			l.Err(err).Msg("GetTrashHandler: Failed to retrieve trash")
+			utils.WriteError(w, "failed to retrieve trash", http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, items)
+	}
+}
+
+// RestoreTrashItemHandler restores a deleted item along with its listens.
+func RestoreTrashItemHandler(store db.TrashStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("RestoreTrashItemHandler: Received request to restore deleted item")
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			utils.WriteError(w, "invalid trash id", http.StatusBadRequest)
+			return
+		}
+
+		err = catalog.RestoreTrashItem(ctx, store, id)
+		switch {
+		case err == nil:
+		case errors.Is(err, db.ErrNotFound):
+			utils.WriteError(w, "deleted item not found", http.StatusNotFound)
+			return
+		case errors.Is(err, catalog.ErrTrashExpired):
+			utils.WriteError(w, "item was deleted too long ago to be restored", http.StatusGone)
+			return
+		case errors.Is(err, db.ErrConflict):
+			l.Debug().AnErr("error", err).Msgf("RestoreTrashItemHandler: Item %d can't be restored", id)
+			utils.WriteError(w, "item can't be restored: "+err.Error(), http.StatusConflict)
+			return
+		default:
+			l.Err(err).Msgf("RestoreTrashItemHandler: Failed to restore item %d", id)
+			utils.WriteError(w, "failed to restore item", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("RestoreTrashItemHandler: Restored item %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeleteTrashItemHandler removes a deleted item from the trash for good.
+func DeleteTrashItemHandler(store db.TrashStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		l := logger.FromContext(ctx)
+
+		l.Debug().Msg("DeleteTrashItemHandler: Received request to purge deleted item")
+
+		id, err := utils.ParseIDParam(r, "id")
+		if err != nil {
+			utils.WriteError(w, "invalid trash id", http.StatusBadRequest)
+			return
+		}
+
+		err = store.DeleteTrashItem(ctx, id)
+		if errors.Is(err, db.ErrNotFound) {
+			utils.WriteError(w, "deleted item not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			l.Err(err).Msgf("DeleteTrashItemHandler: Failed to purge item %d", id)
+			utils.WriteError(w, "failed to purge item", http.StatusInternalServerError)
+			return
+		}
+
+		l.Debug().Msgf("DeleteTrashItemHandler: Purged item %d", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
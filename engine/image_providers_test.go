@@ -0,0 +1,45 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gabehf/koito/internal/images"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageProviders(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/images/providers", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var health []images.ProviderHealth
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	require.Len(t, health, len(images.AlbumProviders()))
+	for _, h := range health {
+		if h.Provider == images.ProviderDeezer {
+			assert.False(t, h.Enabled)
+			assert.Equal(t, 3, h.ArtistPriority)
+			assert.Equal(t, 5, h.AlbumPriority)
+		}
+		if h.Provider == images.ProviderCAA {
+			assert.False(t, h.Enabled)
+			assert.Zero(t, h.ArtistPriority)
+		}
+	}
+
+	status := func(t *testing.T, endpoint string) int {
+		resp, err := makeAuthRequest(t, session, "POST", endpoint, nil)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+	assert.Equal(t, http.StatusBadRequest, status(t, "/apis/web/v1/artist/1/image/refetch?provider=nope"))
+	assert.Equal(t, http.StatusBadRequest, status(t, "/apis/web/v1/artist/1/image/refetch?provider=deezer"))
+	assert.Equal(t, http.StatusBadRequest, status(t, "/apis/web/v1/artist/1/image/refetch?provider=caa"))
+	assert.Equal(t, http.StatusBadRequest, status(t, "/apis/web/v1/album/1/image/refetch?provider=caa"))
+	assert.Equal(t, http.StatusNotFound, status(t, "/apis/web/v1/album/99999/image/refetch?provider=caa"))
+}
@@ -0,0 +1,133 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestFilter(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	t.Cleanup(func() {
+		cfg.SetIngestFilter(cfg.IngestFilterConfig{})
+		require.NoError(t, store.Exec("DELETE FROM filtered_listens"))
+	})
+
+	cfg.SetIngestFilter(cfg.IngestFilterConfig{DenyClients: []string{"podcast addict"}})
+	all := db.GetSubmittedListensOpts{Timeframe: db.Timeframe{Period: db.PeriodAllTime}}
+
+	req, err := http.NewRequest("POST", host()+"/apis/listenbrainz/1/submit-listens", strings.NewReader(fmt.Sprintf(`{
+		"listen_type": "single",
+		"payload": [{
+			"listened_at": %d,
+			"track_metadata": {
+				"artist_name": "Radiolab",
+				"track_name": "Colors",
+				"release_name": "Radiolab",
+				"additional_info": {"media_player": "Podcast Addict"}
+			}
+		}]
+	}`, time.Now().Add(-30*time.Minute).Unix())))
+	require.NoError(t, err)
+	req.Header.Add("Authorization", fmt.Sprintf("Token %s", apikey))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "filtered submissions are still accepted")
+
+	listens, err := store.GetSubmittedListens(t.Context(), all)
+	require.NoError(t, err)
+	assert.Len(t, listens, 3)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/filtered-listens", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var log db.FilteredListenLog
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&log))
+	assert.EqualValues(t, 1, log.Total)
+	assert.EqualValues(t, 1, log.ByReason[catalog.FilterReasonClientDenied])
+	require.Len(t, log.Items, 1)
+	assert.Equal(t, "Colors", log.Items[0].Title)
+	assert.Equal(t, "Podcast Addict", log.Items[0].Client)
+
+	// purge the listens from a client that is now denied
+	cfg.SetIngestFilter(cfg.IngestFilterConfig{DenyClients: []string{"navidrome"}})
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/filtered-listens/purge", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a timeframe is required")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/filtered-listens/purge?period=all_time", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var res catalog.PurgeFilteredListensResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.True(t, res.DryRun)
+	assert.Equal(t, 3, res.Purged)
+	listens, err = store.GetSubmittedListens(t.Context(), all)
+	require.NoError(t, err)
+	assert.Len(t, listens, 3)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/filtered-listens/purge?period=all_time&dry_run=false", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.False(t, res.DryRun)
+	assert.Equal(t, 3, res.Purged)
+	assert.Equal(t, 3, res.ByReason[catalog.FilterReasonClientDenied])
+	listens, err = store.GetSubmittedListens(t.Context(), all)
+	require.NoError(t, err)
+	assert.Empty(t, listens)
+}
+
+func TestIngestFilter_PlayedDuration(t *testing.T) {
+	truncateTestData(t)
+	t.Cleanup(func() {
+		cfg.SetIngestFilter(cfg.IngestFilterConfig{})
+		require.NoError(t, store.Exec("DELETE FROM filtered_listens"))
+	})
+	cfg.SetIngestFilter(cfg.IngestFilterConfig{MinPlayedDuration: 30})
+
+	submit := func(t *testing.T, title, additionalInfo string) {
+		req, err := http.NewRequest("POST", host()+"/apis/listenbrainz/1/submit-listens", strings.NewReader(fmt.Sprintf(`{
+			"listen_type": "single",
+			"payload": [{
+				"listened_at": %d,
+				"track_metadata": {
+					"artist_name": "Radiolab",
+					"track_name": "%s",
+					"release_name": "Radiolab",
+					"additional_info": %s
+				}
+			}]
+		}`, time.Now().Add(-30*time.Minute).Unix(), title, additionalInfo)))
+		require.NoError(t, err)
+		req.Header.Add("Authorization", fmt.Sprintf("Token %s", apikey))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	submit(t, "Skipped", `{"duration_ms": 1800000, "played_duration_ms": 5000}`)
+	submit(t, "Skipped Too", `{"played_duration": 10}`)
+	submit(t, "Played", `{"duration_ms": 1800000, "played_duration_ms": 600000}`)
+
+	listens, err := store.GetSubmittedListens(t.Context(), db.GetSubmittedListensOpts{Timeframe: db.Timeframe{Period: db.PeriodAllTime}})
+	require.NoError(t, err)
+	require.Len(t, listens, 1)
+	require.NotNil(t, listens[0].Submission)
+	assert.Equal(t, "Played", listens[0].Submission.Title)
+	assert.EqualValues(t, 600, listens[0].Submission.PlayedDuration)
+
+	log, err := store.GetFilteredListens(t.Context(), 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, log.ByReason[catalog.FilterReasonPlayedTooShort])
+}
@@ -0,0 +1,97 @@
+package engine_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/library"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTaggedFLAC writes a FLAC file with no audio and the given Vorbis comments.
+func writeTaggedFLAC(t *testing.T, path string, comments ...string) {
+	t.Helper()
+	comment := binary.LittleEndian.AppendUint32(nil, 0)
+	comment = binary.LittleEndian.AppendUint32(comment, uint32(len(comments)))
+	for _, c := range comments {
+		comment = binary.LittleEndian.AppendUint32(comment, uint32(len(c)))
+		comment = append(comment, c...)
+	}
+	data := []byte("fLaC")
+	data = append(data, 0, 0, 0, 34)
+	data = append(data, make([]byte, 34)...)
+	data = append(data, 0x84, byte(len(comment)>>16), byte(len(comment)>>8), byte(len(comment)))
+	data = append(data, comment...)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func TestLibrary(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	require.NoError(t, store.Exec("DELETE FROM library_files"))
+
+	status := func(t *testing.T, method, endpoint string) int {
+		resp, err := makeAuthRequest(t, session, method, endpoint, nil)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	cfg.SetLibraryDir("")
+	assert.Equal(t, http.StatusBadRequest, status(t, "POST", "/apis/web/v1/library/scan"))
+
+	dir := t.TempDir()
+	defer cfg.SetLibraryDir("")
+	cfg.SetLibraryDir(dir)
+	writeTaggedFLAC(t, filepath.Join(dir, "さユり", "酸欠少女", "01.flac"),
+		"TITLE=花の塔", "ARTIST=さユり", "ALBUM=酸欠少女",
+		"MUSICBRAINZ_TRACKID=21524d55-b1f8-45d1-b172-976cba447199")
+	writeTaggedFLAC(t, filepath.Join(dir, "さユり", "酸欠少女", "02.flac"),
+		"TITLE=Never Played", "ARTIST=さユり", "ALBUM=酸欠少女")
+
+	require.Equal(t, http.StatusAccepted, status(t, "POST", "/apis/web/v1/library/scan"))
+	var st library.Status
+	for range 50 {
+		resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/library", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&st))
+		if !st.Running {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.False(t, st.Running)
+	assert.True(t, st.Enabled)
+	assert.Empty(t, st.Error)
+	require.NotNil(t, st.Result)
+	assert.Equal(t, 2, st.Result.Added)
+	assert.Equal(t, 1, st.Result.Matched)
+
+	resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/library/unplayed", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var unplayed db.PaginatedResponse[*models.LibraryFile]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&unplayed))
+	require.Len(t, unplayed.Items, 1)
+	assert.Equal(t, "Never Played", unplayed.Items[0].Title)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/library/missing?period=all_time", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var missing db.PaginatedResponse[db.RankedItem[*models.Track]]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&missing))
+	assert.EqualValues(t, 2, missing.TotalCount)
+	for _, item := range missing.Items {
+		assert.NotEqual(t, "花の塔", item.Item.Title)
+	}
+}
@@ -0,0 +1,95 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditListens(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	ctx := t.Context()
+
+	older, oldest := time.Now().Add(-48*time.Hour).Unix(), time.Now().Add(-72*time.Hour).Unix()
+	for _, ts := range []int64{older, oldest} {
+		require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 1, Time: time.Unix(ts, 0), UserID: 1, Client: "spotify"}))
+	}
+
+	resp, err := makeAuthRequest(t, session, "PATCH", fmt.Sprintf("/apis/web/v1/listens?track_id=1&unix=%d", older),
+		strings.NewReader(fmt.Sprintf(`{"unix":%d,"client":"web"}`, older-60)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "PATCH", fmt.Sprintf("/apis/web/v1/listens?track_id=1&unix=%d", oldest),
+		strings.NewReader(fmt.Sprintf(`{"unix":%d}`, older-60)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "the track already has a listen at that time")
+
+	resp, err = makeAuthRequest(t, session, "PATCH", fmt.Sprintf("/apis/web/v1/listens?track_id=1&unix=%d", older),
+		strings.NewReader(`{"client":"web"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "the listen was moved")
+
+	resp, err = makeAuthRequest(t, session, "PATCH", fmt.Sprintf("/apis/web/v1/listens?track_id=1&unix=%d", oldest),
+		strings.NewReader(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "PATCH", fmt.Sprintf("/apis/web/v1/listens?track_id=1&unix=%d", oldest),
+		strings.NewReader(`{"track_id":3}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, track.ListenCount)
+	track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 3})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, track.ListenCount)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/listens/move", strings.NewReader(`{"to_track_id":2}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a filter is required")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/listens/move", strings.NewReader(`{"track_id":1,"to_track_id":9999}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/listens/move",
+		strings.NewReader(fmt.Sprintf(`{"track_id":3,"to":%d,"to_track_id":1}`, time.Now().Add(-24*time.Hour).Unix())))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var result db.MoveListensResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.EqualValues(t, 1, result.Moved)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/listens/move", strings.NewReader(`{"track_id":3,"to_track_id":2}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.EqualValues(t, 1, result.Moved)
+	assert.EqualValues(t, 0, result.Skipped)
+
+	// the track, album and artist left without listens are removed
+	_, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 3})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+	_, err = store.GetAlbum(ctx, db.GetAlbumOpts{ID: 3})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+	_, err = store.GetArtist(ctx, db.GetArtistOpts{ID: 3})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+	track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 2})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, track.ListenCount)
+	track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, track.ListenCount)
+
+	truncateTestData(t)
+}
@@ -0,0 +1,83 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMbzSuggestions(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	t.Cleanup(func() { require.NoError(t, store.Exec("DELETE FROM mbz_match_suggestions")) })
+	ctx := t.Context()
+
+	otherMbzID := uuid.MustParse("00000000-0000-0000-0000-0000000000c3")
+	require.NoError(t, store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: 2, MusicBrainzID: otherMbzID}))
+
+	newMbzID := uuid.MustParse("00000000-0000-0000-0000-0000000000c1")
+	rejectMe, err := store.SaveMatchSuggestion(ctx, db.SaveMatchSuggestionOpts{
+		EntityType: catalog.MatchEntityArtist, EntityID: 1, MbzID: uuid.MustParse("00000000-0000-0000-0000-0000000000c2"),
+		Name: "Sayuri", Confidence: 0.7,
+	})
+	require.NoError(t, err)
+	acceptMe, err := store.SaveMatchSuggestion(ctx, db.SaveMatchSuggestionOpts{
+		EntityType: catalog.MatchEntityArtist, EntityID: 1, MbzID: newMbzID, Name: "さユり", Confidence: 0.9,
+	})
+	require.NoError(t, err)
+	inUse, err := store.SaveMatchSuggestion(ctx, db.SaveMatchSuggestionOpts{
+		EntityType: catalog.MatchEntityArtist, EntityID: 3, MbzID: otherMbzID, Name: "キタニタツヤ", Confidence: 0.6,
+	})
+	require.NoError(t, err)
+
+	resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/suggestions/mbz", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var suggestions []*models.MatchSuggestion
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&suggestions))
+	require.Len(t, suggestions, 3)
+	assert.Equal(t, acceptMe.ID, suggestions[0].ID)
+	assert.Equal(t, "さユり", suggestions[0].EntityName)
+
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/suggestions/mbz/%d/reject", rejectMe.ID), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var suggestion models.MatchSuggestion
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&suggestion))
+	assert.Equal(t, models.MatchSuggestionRejected, suggestion.Status)
+	assert.NotNil(t, suggestion.ReviewedAt)
+
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/suggestions/mbz/%d/accept", rejectMe.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "reviewed suggestions can't be accepted")
+
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/suggestions/mbz/%d/accept", acceptMe.ID), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: 1})
+	require.NoError(t, err)
+	require.NotNil(t, artist.MbzID)
+	assert.Equal(t, newMbzID, *artist.MbzID)
+
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/suggestions/mbz/%d/accept", inUse.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "an MBID can't be used by two artists")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/suggestions/mbz/99999/reject", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/suggestions/mbz?status=all", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&suggestions))
+	assert.Len(t, suggestions, 3)
+}
@@ -0,0 +1,64 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeSuggestions(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	ctx := t.Context()
+
+	duplicate, err := store.SaveArtist(ctx, db.SaveArtistOpts{Name: "Sayuri", Aliases: []string{"Sayuri", "さユり"}})
+	require.NoError(t, err)
+
+	resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/suggestions/merges/refresh", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/suggestions/merges?type=artist", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var suggestions []*models.MergeSuggestion
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&suggestions))
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, duplicate.ID, suggestions[0].FromID)
+	assert.Equal(t, "さユり", suggestions[0].ToName)
+	assert.Equal(t, []string{catalog.DuplicateReasonAlias}, suggestions[0].Reasons)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/suggestions/merges?type=genre", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/suggestions/merges/accept", strings.NewReader(`{"ids": []}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/suggestions/merges/accept",
+		strings.NewReader(fmt.Sprintf(`{"ids": [%d, 99999]}`, suggestions[0].ID)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var results []catalog.MergeResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Merged)
+	assert.False(t, results[1].Merged)
+	assert.NotEmpty(t, results[1].Error)
+
+	_, err = store.GetArtist(ctx, db.GetArtistOpts{ID: duplicate.ID})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/suggestions/merges/99999/dismiss", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
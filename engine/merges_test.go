@@ -0,0 +1,97 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePreviewAndUndo(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/artist/2/merge?dry_run=true", strings.NewReader(`{"merge_from_id":1}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var preview models.Merge
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&preview))
+	assert.Zero(t, preview.ID)
+	assert.Equal(t, models.MergeCounts{Listens: 1, Tracks: 1, Releases: 1, Aliases: 1}, preview.Moved)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/artist/2/merge?dry_run=true", strings.NewReader(`{"merge_from_id":999}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/artist/1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "a dry run doesn't merge anything")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/artist/2/merge", strings.NewReader(`{"merge_from_id":1}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/merges", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var merges []*models.Merge
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&merges))
+	require.NotEmpty(t, merges)
+	merge := merges[0]
+	assert.Equal(t, "artist", merge.EntityType)
+	assert.EqualValues(t, 1, merge.FromID)
+	assert.EqualValues(t, 2, merge.ToID)
+	assert.Nil(t, merge.UndoneAt)
+
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/merges/%d/undo", merge.ID), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/artist/1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var artist models.Artist
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&artist))
+	assert.EqualValues(t, 1, artist.ListenCount)
+
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/merges/%d/undo", merge.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/merges/99999/undo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	truncateTestData(t)
+}
+
+func TestPurgeAllData_Merges(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	require.NoError(t, store.Exec("DELETE FROM merges"))
+
+	resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/artist/2/merge", strings.NewReader(`{"merge_from_id":1}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	merges, err := store.GetMerges(t.Context(), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, merges, 1)
+	id := merges[0].ID
+
+	resp, err = makeAuthRequest(t, session, "DELETE", "/apis/web/v1/data?confirm=true", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	merges, err = store.GetMerges(t.Context(), time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, merges)
+
+	resp, err = makeAuthRequest(t, session, "POST", fmt.Sprintf("/apis/web/v1/merges/%d/undo", id), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// auditedEntities are the entity types the audit log records the metadata of before and after a
+// change.
+var auditedEntities = []string{"artist", "album", "track"}
+
+// unauditedActions are the requests that don't change data despite their method, or that
+// happen too often to be worth recording, like each listen submitted from the web.
+var unauditedActions = []string{"POST /rules/preview", "POST /listens"}
+
+// maxAuditedBody is the size of the largest request body that is recorded in the audit log.
+const maxAuditedBody = 64 << 10
+
+// Audit records the requests that change data in the audit log, along with the user and API key
+// that made them. The entity of a request is the first part of its route, and its ID the id
+// route parameter. For artists, albums and tracks, the metadata of the item before and after the
+// request is recorded too, and for other requests the request body, without any secrets. Requests
+// that fail are not recorded. Must be used after Authenticate.
+func Audit(store db.AuditStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			l := logger.FromContext(ctx)
+
+			route := strings.TrimPrefix(chi.RouteContext(ctx).RoutePattern(), "/apis/web/v1")
+			opts := db.SaveAuditEntryOpts{
+				Action:     r.Method + " " + route,
+				EntityType: strings.Split(strings.TrimPrefix(route, "/"), "/")[0],
+			}
+			if slices.Contains(unauditedActions, opts.Action) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if id, err := strconv.Atoi(chi.URLParam(r, "id")); err == nil {
+				id32 := int32(id)
+				opts.EntityID = &id32
+			}
+			snapshot := opts.EntityID != nil && slices.Contains(auditedEntities, opts.EntityType)
+
+			var body any
+			if snapshot {
+				before, err := store.GetAuditSnapshot(ctx, opts.EntityType, *opts.EntityID)
+				if err != nil {
+					l.Err(err).Msg("Audit: Failed to read item before change")
+				}
+				opts.Before = before
+			} else if r.Body != nil {
+				// the body is read ahead for the handler, and put back in front of what is left
+				buf, err := io.ReadAll(io.LimitReader(r.Body, maxAuditedBody))
+				if err != nil {
+					l.Err(err).Msg("Audit: Failed to read request body")
+				}
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+				if json.Unmarshal(buf, &body) == nil {
+					body = withoutSecrets(body)
+				}
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+			if ww.Status() >= http.StatusBadRequest {
+				return
+			}
+
+			if snapshot {
+				after, err := store.GetAuditSnapshot(ctx, opts.EntityType, *opts.EntityID)
+				if err != nil {
+					l.Err(err).Msg("Audit: Failed to read item after change")
+				}
+				opts.After = after
+			}
+			if user := GetUserFromContext(ctx); user != nil {
+				opts.UserID = &user.ID
+				opts.Username = user.Username
+			}
+			if key := GetApiKeyFromContext(ctx); key != nil {
+				opts.ApiKeyLabel = &key.Label
+			}
+			params := make(map[string]any)
+			for key, values := range r.URL.Query() {
+				if isSecret(key) || len(values) == 0 {
+					continue
+				}
+				params[key] = values[0]
+			}
+			if fields, ok := body.(map[string]any); ok {
+				maps.Copy(params, fields)
+			} else if body != nil {
+				params["body"] = body
+			}
+			if len(params) > 0 {
+				opts.Params, _ = json.Marshal(params)
+			}
+
+			if err := store.SaveAuditEntry(ctx, opts); err != nil {
+				l.Err(err).Msgf("Audit: Failed to record '%s'", opts.Action)
+			}
+		})
+	}
+}
+
+// isSecret reports whether a parameter or body field holds a secret that must not be recorded.
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range []string{"password", "token", "secret", "apikey", "api_key"} {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return name == "key"
+}
+
+// withoutSecrets removes the secret fields from a decoded JSON value, at any depth.
+func withoutSecrets(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if isSecret(k) {
+				delete(v, k)
+			} else {
+				v[k] = withoutSecrets(field)
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = withoutSecrets(v[i])
+		}
+	}
+	return v
+}
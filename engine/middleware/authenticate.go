@@ -20,7 +20,7 @@ type MiddlwareContextKey string
 
 const (
 	UserContextKey   MiddlwareContextKey = "user"
-	apikeyContextKey MiddlwareContextKey = "apikeyID"
+	apikeyContextKey MiddlwareContextKey = "apikey"
 )
 
 type AuthMode int
@@ -39,6 +39,7 @@ func Authenticate(store db.UserStore, mode AuthMode) func(http.Handler) http.Han
 			l := logger.FromContext(ctx)
 
 			var user *models.User
+			var key *models.ApiKey
 			var err error
 
 			switch mode {
@@ -46,19 +47,19 @@ func Authenticate(store db.UserStore, mode AuthMode) func(http.Handler) http.Han
 				user, err = validateSession(ctx, store, r)
 
 			case AuthModeAPIKey:
-				user, err = validateAPIKey(ctx, store, r)
+				user, key, err = validateAPIKey(ctx, store, r)
 
 			case AuthModeSessionOrAPIKey:
 				user, err = validateSession(ctx, store, r)
 				if err != nil || user == nil {
-					user, err = validateAPIKey(ctx, store, r)
+					user, key, err = validateAPIKey(ctx, store, r)
 				}
 
 			case AuthModeLoginGate:
 				if cfg.LoginGate() {
 					user, err = validateSession(ctx, store, r)
 					if err != nil || user == nil {
-						user, err = validateAPIKey(ctx, store, r)
+						user, key, err = validateAPIKey(ctx, store, r)
 					}
 				} else {
 					next.ServeHTTP(w, r)
@@ -78,6 +79,9 @@ func Authenticate(store db.UserStore, mode AuthMode) func(http.Handler) http.Han
 			}
 
 			ctx = context.WithValue(ctx, UserContextKey, user)
+			if key != nil {
+				ctx = context.WithValue(ctx, apikeyContextKey, key)
+			}
 			r = r.WithContext(ctx)
 
 			next.ServeHTTP(w, r)
@@ -127,14 +131,14 @@ func validateSession(ctx context.Context, store db.UserStore, r *http.Request) (
 	return u, nil
 }
 
-func validateAPIKey(ctx context.Context, store db.UserStore, r *http.Request) (*models.User, error) {
+func validateAPIKey(ctx context.Context, store db.UserStore, r *http.Request) (*models.User, *models.ApiKey, error) {
 	l := logger.FromContext(ctx)
 
 	l.Debug().Msg("ValidateApiKey: Checking if user is already authenticated")
 
 	authH := r.Header.Get("Authorization")
 	if authH == "" {
-		return nil, nil // no header present, not an error
+		return nil, nil, nil // no header present, not an error
 	}
 
 	var token string
@@ -142,23 +146,20 @@ func validateAPIKey(ctx context.Context, store db.UserStore, r *http.Request) (*
 		token = strings.TrimSpace(authH[6:]) // strip "Token "
 	} else {
 		l.Error().Msg("ValidateApiKey: Authorization header must be formatted 'Token {token}'")
-		return nil, errors.New("authorization header is invalid")
+		return nil, nil, errors.New("authorization header is invalid")
 	}
 
-	u, err := store.GetUserByApiKey(ctx, token)
+	// the key is returned along with the user for its label, which the audit log records
+	u, key, err := store.GetUserByApiKey(ctx, token)
 	if err != nil {
 		l.Err(err).Msg("ValidateApiKey: Failed to get user from database using api key")
-		return nil, errors.New("internal server error")
+		return nil, nil, errors.New("internal server error")
 	}
 	if u == nil {
 		l.Debug().Msg("ValidateApiKey: API key does not exist")
-		return nil, errors.New("authorization token is invalid")
+		return nil, nil, errors.New("authorization token is invalid")
 	}
-
-	ctx = context.WithValue(r.Context(), UserContextKey, u)
-	r = r.WithContext(ctx)
-
-	return u, nil
+	return u, key, nil
 }
 
 func GetUserFromContext(ctx context.Context) *models.User {
@@ -168,3 +169,13 @@ func GetUserFromContext(ctx context.Context) *models.User {
 	}
 	return user
 }
+
+// GetApiKeyFromContext returns the API key the request was authenticated with, or nil when it
+// was authenticated with a session.
+func GetApiKeyFromContext(ctx context.Context) *models.ApiKey {
+	key, ok := ctx.Value(apikeyContextKey).(*models.ApiKey)
+	if !ok {
+		return nil
+	}
+	return key
+}
@@ -86,6 +86,12 @@ func Logger(baseLogger *zerolog.Logger) func(next http.Handler) http.Handler {
 			defer func() {
 				t2 := time.Now()
 				if rec := recover(); rec != nil {
+					if rec == http.ErrAbortHandler {
+						// the handler gave up on a response it already started, so the
+						// connection must be aborted rather than written to
+						l.Warn().Str("type", "access").Msgf("Aborted %s %s", r.Method, r.URL.Path)
+						panic(rec)
+					}
 					l.Error().
 						Str("type", "error").
 						Timestamp().
@@ -0,0 +1,65 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseYears(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	// musicbrainz is disabled during tests, so set the dates by hand
+	require.NoError(t, store.Exec(`UPDATE releases SET release_date = '1995-03-01' WHERE id = 1`))
+	require.NoError(t, store.Exec(`UPDATE releases SET release_date = '1998' WHERE id = 2`))
+
+	resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/top/release-years?period=all_time")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var years []db.RankedItem[models.ReleaseYear]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&years))
+	require.Len(t, years, 2)
+	assert.Equal(t, 1998, years[0].Item.Year)
+	assert.Equal(t, 1995, years[1].Item.Year)
+	assert.EqualValues(t, 1, years[0].Item.ListenCount)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/top/decades?period=all_time")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var decades []db.RankedItem[models.ReleaseYear]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decades))
+	require.Len(t, decades, 1)
+	assert.Equal(t, 1990, decades[0].Item.Year)
+	assert.EqualValues(t, 2, decades[0].Item.ListenCount)
+	assert.EqualValues(t, 2, decades[0].Item.AlbumCount)
+
+	// one listen is to a release from this year
+	require.NoError(t, store.Exec(`UPDATE releases SET release_date = strftime('%Y-%m-%d', 'now') WHERE id = 2`))
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/stats/release-age?period=all_time")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var age db.ReleaseAgeStats
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&age))
+	assert.EqualValues(t, 1, age.NewListens)
+	assert.EqualValues(t, 1, age.OldListens)
+	assert.EqualValues(t, 1, age.UnknownListens)
+	assert.InDelta(t, 0.5, age.NewRatio, 0.001)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/stats/release-age?new_within=0")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/album/1")
+	require.NoError(t, err)
+	var album models.Album
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&album))
+	assert.Equal(t, "1995-03-01", album.ReleaseDate)
+
+	truncateTestData(t)
+}
@@ -0,0 +1,75 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseTypes(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	for endpoint, body := range map[string]string{
+		"/apis/web/v1/album/1": `{"primary_type":"single"}`,
+		"/apis/web/v1/album/2": `{"primary_type":"Album","secondary_types":["compilation"]}`,
+		"/apis/web/v1/album/3": `{"primary_type":"album"}`,
+	} {
+		resp, err := makeAuthRequest(t, session, "PATCH", endpoint, strings.NewReader(body))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode, endpoint)
+	}
+
+	resp, err := makeAuthRequest(t, session, "PATCH", "/apis/web/v1/album/1", strings.NewReader(`{"primary_type":"mixtape"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/album/2")
+	require.NoError(t, err)
+	var album models.Album
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&album))
+	assert.Equal(t, "Album", album.PrimaryType)
+	assert.Equal(t, []string{"Compilation"}, album.SecondaryTypes)
+
+	getAlbums := func(query string) []string {
+		resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/top/albums?period=all_time&" + query)
+		require.NoError(t, err)
+		var albums db.PaginatedResponse[db.RankedItem[models.Album]]
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&albums))
+		var titles []string
+		for _, a := range albums.Items {
+			titles = append(titles, a.Item.Title)
+		}
+		return titles
+	}
+
+	assert.Len(t, getAlbums("type=album"), 2)
+	assert.Len(t, getAlbums("type=album,single"), 3)
+	assert.Equal(t, []string{"酸欠少女"}, getAlbums("type=SINGLE"))
+	assert.Len(t, getAlbums("type=album&exclude_type=compilation"), 1)
+	assert.Len(t, getAlbums("exclude_type=compilation"), 2)
+	assert.Len(t, getAlbums("type=single&artist_id=1"), 1)
+	assert.Empty(t, getAlbums("type=%25"), "wildcards in types are matched literally")
+	assert.Empty(t, getAlbums("type=compilatio_"))
+	assert.Len(t, getAlbums("exclude_type=%25"), 3)
+
+	// types set by the user are not overwritten by musicbrainz
+	require.NoError(t, store.UpdateAlbum(context.Background(), db.UpdateAlbumOpts{
+		ID:          1,
+		TypeUpdate:  true,
+		PrimaryType: "Album",
+		TypeSource:  db.InformationSourceMusicBrainz,
+	}))
+	a, err := store.GetAlbum(context.Background(), db.GetAlbumOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Single", a.PrimaryType)
+
+	truncateTestData(t)
+}
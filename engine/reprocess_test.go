@@ -0,0 +1,98 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReprocessListens(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	t.Cleanup(func() {
+		require.NoError(t, store.Exec("DELETE FROM rewrite_rules"))
+		catalog.InvalidateRewriteRules()
+	})
+
+	// a listen that was wrongly tagged by its player, without any MusicBrainz IDs
+	req, err := http.NewRequest("POST", host()+"/apis/listenbrainz/1/submit-listens", strings.NewReader(fmt.Sprintf(`{
+		"listen_type": "single",
+		"payload": [{
+			"listened_at": %d,
+			"track_metadata": {
+				"artist_name": "さユり",
+				"track_name": "花の塔 - Remastered",
+				"release_name": "酸欠少女",
+				"additional_info": {"media_player": "tester", "custom_field": "x"}
+			}
+		}]
+	}`, time.Now().Add(-30*time.Minute).Unix())))
+	require.NoError(t, err)
+	req.Header.Add("Authorization", fmt.Sprintf("Token %s", apikey))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ctx := context.Background()
+	listens, err := store.GetSubmittedListens(ctx, db.GetSubmittedListensOpts{
+		Timeframe: db.Timeframe{Period: db.PeriodAllTime},
+		Client:    "tester",
+	})
+	require.NoError(t, err)
+	require.Len(t, listens, 1)
+	require.NotNil(t, listens[0].Submission, "listens keep the metadata they were submitted with")
+	assert.Equal(t, "花の塔 - Remastered", listens[0].Submission.Title)
+	assert.JSONEq(t, `{"media_player": "tester", "custom_field": "x"}`, string(listens[0].Submission.AdditionalInfo))
+	assert.NotEqualValues(t, 1, listens[0].TrackID)
+
+	_, err = store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		Enabled: true, Action: "strip", Field: "title", Pattern: `\s*-\s*Remastered$`,
+	})
+	require.NoError(t, err)
+	catalog.InvalidateRewriteRules()
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/reprocess", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a timeframe is required")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/reprocess?period=all_time", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var res catalog.ReprocessResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.True(t, res.DryRun)
+	assert.Equal(t, 4, res.Listens)
+	require.Len(t, res.Changes, 1)
+	assert.Equal(t, "花の塔 - Remastered", res.Changes[0].From.Title)
+	assert.Equal(t, "花の塔", res.Changes[0].To.Title)
+	require.NotNil(t, res.Changes[0].To.ID)
+	assert.EqualValues(t, 1, *res.Changes[0].To.ID)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/reprocess?period=all_time&dry_run=false", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var status catalog.ReprocessStatus
+	require.Eventually(t, func() bool {
+		resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/reprocess", nil)
+		require.NoError(t, err)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		return !status.Running
+	}, 5*time.Second, 50*time.Millisecond)
+	require.NotNil(t, status.Result)
+	assert.Len(t, status.Result.Changes, 1)
+	assert.Equal(t, 4, status.Processed)
+
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, track.ListenCount)
+}
@@ -0,0 +1,78 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteRules(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	t.Cleanup(func() {
+		require.NoError(t, store.Exec("DELETE FROM rewrite_rules"))
+		catalog.InvalidateRewriteRules()
+	})
+
+	resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/rules", strings.NewReader(`{
+		"name": "strip remasters",
+		"action": "strip",
+		"field": "title",
+		"pattern": "\\s*-\\s*Remastered( \\d{4})?$"
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var rule models.RewriteRule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rule))
+	assert.True(t, rule.Enabled, "rules are enabled by default")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/rules", strings.NewReader(`{"action":"strip","field":"title","pattern":"("}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	preview := func(body string) catalog.RewriteResult {
+		resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/rules/preview", strings.NewReader(body))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var res catalog.RewriteResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+		return res
+	}
+	res := preview(`{"artist":"さユり","title":"花の塔 - Remastered 2011","album":"酸欠少女"}`)
+	assert.Equal(t, "花の塔", res.Title)
+	assert.Equal(t, []int32{rule.ID}, res.Applied)
+
+	// unsaved rules can be previewed
+	res = preview(`{"artist":"さユり","title":"花の塔","client":"spam","rules":[
+		{"action":"drop","conditions":[{"field":"client","match":"exact","value":"SPAM","case_insensitive":true}]}
+	]}`)
+	assert.True(t, res.Dropped)
+
+	resp, err = makeAuthRequest(t, session, "PATCH", "/apis/web/v1/rules/"+fmt.Sprint(rule.ID), strings.NewReader(`{"enabled":false}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	res = preview(`{"artist":"さユり","title":"花の塔 - Remastered 2011"}`)
+	assert.Equal(t, "花の塔 - Remastered 2011", res.Title)
+
+	resp, err = makeAuthRequest(t, session, "GET", "/apis/web/v1/rules", nil)
+	require.NoError(t, err)
+	var rules []models.RewriteRule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rules))
+	require.Len(t, rules, 1)
+	assert.False(t, rules[0].Enabled)
+	assert.Equal(t, "strip remasters", rules[0].Name)
+
+	resp, err = makeAuthRequest(t, session, "DELETE", "/apis/web/v1/rules/"+fmt.Sprint(rule.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, err = makeAuthRequest(t, session, "DELETE", "/apis/web/v1/rules/"+fmt.Sprint(rule.ID), nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
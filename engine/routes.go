@@ -45,26 +45,37 @@ func bindRoutes(
 			r.Get("/artist/{id}", handlers.GetArtistHandler(db))                  // done
 			r.Get("/artist/{id}/aliases", handlers.GetArtistAliasesHandler(db))   // done
 			r.Get("/artist/{id}/interest", handlers.GetArtistInterestHandler(db)) // done
+			r.Get("/artist/{id}/related", handlers.GetRelatedArtistsHandler(db))
 
 			r.Get("/album/{id}", handlers.GetAlbumHandler(db))                   // done
 			r.Get("/album/{id}/artists", handlers.GetArtistsForAlbumHandler(db)) // done
 			r.Get("/album/{id}/aliases", handlers.GetAlbumAliasesHandler(db))    // done
 			r.Get("/album/{id}/interest", handlers.GetAlbumInterestHandler(db))  // done
+			r.Get("/album/{id}/tracklist", handlers.GetAlbumTracklistHandler(db))
+			r.Get("/album/{id}/completion", handlers.GetAlbumCompletionHandler(db))
 
 			r.Get("/track/{id}", handlers.GetTrackHandler(db))                   // done
 			r.Get("/track/{id}/artists", handlers.GetArtistsForTrackHandler(db)) // done
 			r.Get("/track/{id}/aliases", handlers.GetTrackAliasesHandler(db))    // done
 			r.Get("/track/{id}/interest", handlers.GetTrackInterestHandler(db))  // done
 
+			r.Get("/song/{id}", handlers.GetSongHandler(db))
+
 			r.Get("/top/tracks", handlers.GetTopTracksHandler(db))
 			r.Get("/top/albums", handlers.GetTopAlbumsHandler(db))
 			r.Get("/top/artists", handlers.GetTopArtistsHandler(db))
+			r.Get("/top/tags", handlers.GetTopTagsHandler(db))
+			r.Get("/top/release-years", handlers.GetTopReleaseYearsHandler(db))
+			r.Get("/top/decades", handlers.GetTopDecadesHandler(db))
 
 			r.Get("/listens", handlers.GetListensHandler(db))
 			r.Get("/listen-activity", handlers.GetListenActivityHandler(db))
 			r.Get("/first-activity", handlers.FirstActivityHandler(db))
 			r.Get("/now-playing", handlers.NowPlayingHandler(db))
 			r.Get("/stats", handlers.StatsHandler(db))
+			r.Get("/stats/release-age", handlers.GetReleaseAgeHandler(db))
+			r.Get("/stats/artist-countries", handlers.GetListensByArtistCountryHandler(db))
+			r.Get("/stats/artist-types", handlers.GetListensByArtistTypeHandler(db))
 			r.Get("/search", handlers.SearchHandler(db))
 			r.Get("/summary", handlers.SummaryHandler(db))
 		})
@@ -81,46 +92,81 @@ func bindRoutes(
 			r.Post("/login", handlers.LoginHandler(db))
 		}
 
-		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-			if !ready.Load() {
-				http.Error(w, "not ready", http.StatusServiceUnavailable)
-				return
-			}
-			w.WriteHeader(http.StatusOK)
-		})
+		r.Get("/health", handlers.HealthHandler(ready))
 
 		r.Group(func(r chi.Router) {
 			r.Use(middleware.Authenticate(db, middleware.AuthModeSessionOrAPIKey))
+			r.Use(middleware.Audit(db))
 
+			r.Get("/artist/{id}/history", handlers.GetArtistHistoryHandler(db))
 			r.Delete("/artist/{id}", handlers.DeleteArtistHandler(db))
 			r.Delete("/artist/{id}/aliases", handlers.DeleteArtistAliasHandler(db))
 			r.Post("/artist/{id}/merge", handlers.MergeArtistsHandler(db))
+			r.Post("/artist/{id}/split", handlers.SplitArtistHandler(db))
 			r.Post("/artist/{id}/aliases", handlers.CreateArtistAliasHandler(db))
 			r.Patch("/artist/{id}", handlers.UpdateArtistHandler(db))
 			r.Patch("/artist/{id}/image", handlers.ReplaceArtistImageHandler(db))
+			r.Post("/artist/{id}/image/refetch", handlers.RefetchArtistImageHandler(db))
 			r.Patch("/artist/{id}/aliases/primary", handlers.SetPrimaryArtistAliasHandler(db))
 
+			r.Get("/album/{id}/history", handlers.GetAlbumHistoryHandler(db))
 			r.Delete("/album/{id}", handlers.DeleteAlbumHandler(db))
 			r.Delete("/album/{id}/aliases", handlers.DeleteAlbumAliasHandler(db))
 			r.Post("/album/{id}/merge", handlers.MergeAlbumsHandler(db))
+			r.Post("/album/{id}/split", handlers.SplitAlbumHandler(db))
 			r.Post("/album/{id}/aliases", handlers.CreateAlbumAliasHandler(db))
 			r.Patch("/album/{id}", handlers.UpdateAlbumHandler(db))
 			r.Patch("/album/{id}/image", handlers.ReplaceAlbumImageHandler(db))
+			r.Post("/album/{id}/image/refetch", handlers.RefetchAlbumImageHandler(db))
 			r.Patch("/album/{id}/aliases/primary", handlers.SetPrimaryAlbumAliasHandler(db))
 			r.Patch("/album/{id}/artists/{artist_id}", handlers.SetPrimaryAlbumArtistHandler(db))
 
+			r.Get("/track/{id}/history", handlers.GetTrackHistoryHandler(db))
 			r.Delete("/track/{id}", handlers.DeleteTrackHandler(db))
 			r.Delete("/track/{id}/aliases", handlers.DeleteTrackAliasHandler(db))
 			r.Delete("/track/{id}/artists/{artist_id}", handlers.DeleteTrackArtistHandler(db))
 			r.Post("/track/{id}/merge", handlers.MergeTracksHandler(db))
+			r.Post("/track/{id}/split", handlers.SplitTrackHandler(db))
 			r.Post("/track/{id}/aliases", handlers.CreateTrackAliasHandler(db))
 			r.Post("/track/{id}/artists", handlers.AddTrackArtistsHandler(db))
 			r.Patch("/track/{id}", handlers.UpdateTrackHandler(db))
 			r.Patch("/track/{id}/aliases/primary", handlers.SetPrimaryTrackAliasHandler(db))
 			r.Patch("/track/{id}/artists/{artist_id}", handlers.SetPrimaryTrackArtistHandler(db))
 
+			r.Post("/song/{id}/tracks", handlers.AddTracksToSongHandler(db))
+			r.Delete("/song/{id}/tracks/{track_id}", handlers.RemoveTrackFromSongHandler(db))
+
 			r.Post("/listens", handlers.SubmitListenWithIDHandler(db))
 			r.Delete("/listens", handlers.DeleteListenHandler(db))
+			r.Patch("/listens", handlers.UpdateListenHandler(db))
+			r.Post("/listens/move", handlers.MoveListensHandler(db))
+			r.Post("/reprocess", handlers.ReprocessListensHandler(db, mbz))
+			r.Get("/reprocess", handlers.GetReprocessStatusHandler())
+			r.Get("/filtered-listens", handlers.GetFilteredListensHandler(db))
+			r.Post("/filtered-listens/purge", handlers.PurgeFilteredListensHandler(db))
+
+			r.Get("/rules", handlers.GetRewriteRulesHandler(db))
+			r.Post("/rules", handlers.CreateRewriteRuleHandler(db))
+			r.Post("/rules/preview", handlers.PreviewRewriteRulesHandler(db))
+			r.Patch("/rules/{id}", handlers.UpdateRewriteRuleHandler(db))
+			r.Delete("/rules/{id}", handlers.DeleteRewriteRuleHandler(db))
+
+			r.Get("/suggestions/mbz", handlers.GetMbzSuggestionsHandler(db))
+			r.Post("/suggestions/mbz/{id}/accept", handlers.AcceptMbzSuggestionHandler(db))
+			r.Post("/suggestions/mbz/{id}/reject", handlers.RejectMbzSuggestionHandler(db))
+			r.Get("/suggestions/merges", handlers.GetMergeSuggestionsHandler(db))
+			r.Post("/suggestions/merges/refresh", handlers.RefreshMergeSuggestionsHandler(db))
+			r.Post("/suggestions/merges/accept", handlers.AcceptMergeSuggestionsHandler(db))
+			r.Post("/suggestions/merges/{id}/dismiss", handlers.DismissMergeSuggestionHandler(db))
+
+			r.Get("/merges", handlers.GetMergesHandler(db))
+			r.Post("/merges/{id}/undo", handlers.UndoMergeHandler(db))
+
+			r.Get("/trash", handlers.GetTrashHandler(db))
+			r.Post("/trash/{id}/restore", handlers.RestoreTrashItemHandler(db))
+			r.Delete("/trash/{id}", handlers.DeleteTrashItemHandler(db))
+
+			r.Get("/audit", handlers.GetAuditLogHandler(db))
 
 			r.Get("/user/apikeys", handlers.GetApiKeysHandler(db))
 			r.Post("/user/apikeys", handlers.GenerateApiKeyHandler(db))
@@ -131,6 +177,15 @@ func bindRoutes(
 			r.Patch("/user", handlers.UpdateUserHandler(db))
 
 			r.Get("/export", handlers.ExportHandler(db))
+			r.Get("/backup", handlers.BackupHandler(db))
+			r.Get("/backups", handlers.GetBackupStatusHandler())
+
+			r.Get("/images/providers", handlers.GetImageProvidersHandler())
+
+			r.Get("/library", handlers.GetLibraryStatusHandler())
+			r.Post("/library/scan", handlers.ScanLibraryHandler(db))
+			r.Get("/library/unplayed", handlers.GetUnplayedLibraryFilesHandler(db))
+			r.Get("/library/missing", handlers.GetTracksNotInLibraryHandler(db))
 			r.Delete("/data", handlers.PurgeAllDataHandler(db))
 		})
 	})
@@ -0,0 +1,85 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSongs(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	ctx := context.Background()
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	songPath := fmt.Sprintf("/apis/web/v1/song/%d", track.SongID)
+
+	topSongs := func(period string) db.PaginatedResponse[db.RankedItem[models.Track]] {
+		resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/top/tracks?by_song=true&period=" + period)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var tracks db.PaginatedResponse[db.RankedItem[models.Track]]
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&tracks))
+		return tracks
+	}
+	assert.Len(t, topSongs("all_time").Items, 3, "every track starts out as its own song")
+
+	resp, err := makeAuthRequest(t, session, "POST", songPath+"/tracks", strings.NewReader(`{"track_ids":[2]}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Get(host() + songPath)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var song models.Song
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&song))
+	assert.Len(t, song.Tracks, 2)
+	assert.EqualValues(t, 2, song.ListenCount)
+
+	songs := topSongs("all_time")
+	require.Len(t, songs.Items, 2)
+	assert.EqualValues(t, 2, songs.Items[0].Item.ListenCount)
+	assert.Equal(t, track.SongID, songs.Items[0].Item.SongID)
+
+	// the song is shown as its most listened track in the timeframe
+	require.NoError(t, store.Exec(`INSERT INTO listens (track_id, listened_at, user_id) VALUES (2, ?, 1), (2, ?, 1)`,
+		time.Now().AddDate(-1, 0, 0).Unix(), time.Now().AddDate(-1, 0, -1).Unix()))
+	songs = topSongs("all_time")
+	require.NotEmpty(t, songs.Items)
+	assert.EqualValues(t, 4, songs.Items[0].Item.ListenCount)
+	assert.EqualValues(t, 2, songs.Items[0].Item.ID)
+	songs = topSongs("week")
+	require.NotEmpty(t, songs.Items)
+	assert.EqualValues(t, 2, songs.Items[0].Item.ListenCount)
+	assert.EqualValues(t, 1, songs.Items[0].Item.ID)
+
+	// only the listens and tracks of the album count when filtering by album
+	songs = topSongs(fmt.Sprintf("all_time&album_id=%d", track.AlbumID))
+	require.Len(t, songs.Items, 1)
+	assert.EqualValues(t, 1, songs.Items[0].Item.ListenCount)
+	assert.EqualValues(t, 1, songs.Items[0].Item.ID)
+
+	// a track can only be removed from the song it belongs to
+	resp, err = makeAuthRequest(t, session, "DELETE", songPath+"/tracks/3", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "DELETE", songPath+"/tracks/2", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Len(t, topSongs("all_time").Items, 3)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/song/999999/tracks", strings.NewReader(`{"track_ids":[2]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,100 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	ctx := t.Context()
+
+	for _, ts := range []time.Time{time.Now().Add(-48 * time.Hour), time.Now().Add(-72 * time.Hour)} {
+		require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: 1, Time: ts, UserID: 1, Client: "spotify"}))
+	}
+
+	resp, err := makeAuthRequest(t, session, "POST", "/apis/web/v1/track/1/split",
+		strings.NewReader(`{"parts":[{"title":"花の塔 (Live)","client":"spotify"}]}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var tracks []*models.Track
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tracks))
+	require.Len(t, tracks, 1)
+	live := tracks[0]
+	assert.Equal(t, "花の塔 (Live)", live.Title)
+	assert.EqualValues(t, 2, live.ListenCount)
+	assert.EqualValues(t, 1, live.AlbumID)
+	require.Len(t, live.Artists, 1)
+	assert.EqualValues(t, 1, live.Artists[0].ID)
+	original, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, original.ListenCount)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/track/1/split",
+		strings.NewReader(`{"parts":[{"title":"花の塔","client":"spotify"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "no listens are left to select")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/track/1/split",
+		strings.NewReader(`{"parts":[{"title":"花の塔"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/album/1/split",
+		strings.NewReader(fmt.Sprintf(`{"parts":[{"title":"酸欠少女 (Live)","track_ids":[%d]}]}`, live.ID)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var albums []*models.Album
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&albums))
+	require.Len(t, albums, 1)
+	liveAlbum := albums[0]
+	assert.Equal(t, "酸欠少女 (Live)", liveAlbum.Title)
+	assert.EqualValues(t, 2, liveAlbum.ListenCount)
+	require.Len(t, liveAlbum.Artists, 1)
+	assert.EqualValues(t, 1, liveAlbum.Artists[0].ID)
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/album/1/split",
+		strings.NewReader(`{"parts":[{"title":"酸欠少女","track_ids":[1]}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "the original album would be empty")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/album/1/split",
+		strings.NewReader(`{"parts":[{"title":"酸欠少女","track_ids":[2]}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "the track is on another album")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/artist/1/split",
+		strings.NewReader(fmt.Sprintf(`{"parts":[{"name":"Sayuri Live","album_ids":[%d],"shared":true}]}`, liveAlbum.ID)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var artists []*models.Artist
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&artists))
+	require.Len(t, artists, 1)
+	assert.Equal(t, "Sayuri Live", artists[0].Name)
+	assert.EqualValues(t, 2, artists[0].ListenCount)
+	sayuri, err := store.GetArtist(ctx, db.GetArtistOpts{ID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, sayuri.ListenCount, "shared tracks stay credited to the original artist")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/artist/1/split",
+		strings.NewReader(fmt.Sprintf(`{"parts":[{"name":"Sayuri Live","album_ids":[1,%d]}]}`, liveAlbum.ID)))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "the original artist would be left without tracks")
+
+	resp, err = makeAuthRequest(t, session, "POST", "/apis/web/v1/artist/9999/split",
+		strings.NewReader(`{"parts":[{"name":"Nobody","track_ids":[1]}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	truncateTestData(t)
+}
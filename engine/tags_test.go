@@ -0,0 +1,91 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	body := fmt.Sprintf(`{
+		"listen_type": "single",
+		"payload": [
+			{
+				"listened_at": %d,
+				"track_metadata": {
+					"additional_info": {
+						"artist_names": ["さユり"],
+						"duration_ms": 240000,
+						"submission_client": "navidrome",
+						"tags": ["J-Pop", " Rock "]
+					},
+					"artist_name": "さユり",
+					"release_name": "ミカヅキ",
+					"track_name": "ミカヅキ"
+				}
+			}
+		]
+	}`, time.Now().Add(-3*time.Hour).Unix())
+	req, err := http.NewRequest("POST", host()+"/apis/listenbrainz/1/submit-listens", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Add("Authorization", fmt.Sprintf("Token %s", apikey))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	respBytes, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, `{"status": "ok"}`, string(respBytes))
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/top/tags?period=all_time")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var tags db.PaginatedResponse[db.RankedItem[models.Tag]]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tags))
+	require.Len(t, tags.Items, 2)
+	names := []string{tags.Items[0].Item.Name, tags.Items[1].Item.Name}
+	assert.ElementsMatch(t, []string{"j-pop", "rock"}, names)
+	assert.EqualValues(t, 1, tags.Items[0].Item.ListenCount)
+	assert.EqualValues(t, 240, tags.Items[0].Item.TimeListened)
+
+	// tag filters are case insensitive
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/top/tracks?period=all_time&tag=ROCK")
+	require.NoError(t, err)
+	var tracks db.PaginatedResponse[db.RankedItem[models.Track]]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tracks))
+	require.Len(t, tracks.Items, 1)
+	assert.Equal(t, "ミカヅキ", tracks.Items[0].Item.Title)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/top/artists?period=all_time&tag=j-pop")
+	require.NoError(t, err)
+	var artists db.PaginatedResponse[db.RankedItem[models.Artist]]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&artists))
+	require.Len(t, artists.Items, 1)
+	assert.Equal(t, "さユり", artists.Items[0].Item.Name)
+	assert.EqualValues(t, 1, artists.Items[0].Item.ListenCount)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/top/albums?period=all_time&tag=jazz")
+	require.NoError(t, err)
+	var albums db.PaginatedResponse[db.RankedItem[models.Album]]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&albums))
+	assert.Empty(t, albums.Items)
+
+	// the tags are returned with the track
+	resp, err = http.DefaultClient.Get(fmt.Sprintf("%s/apis/web/v1/track/%d", host(), tracks.Items[0].Item.ID))
+	require.NoError(t, err)
+	var track models.Track
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&track))
+	assert.Equal(t, []string{"j-pop", "rock"}, track.Tags)
+
+	truncateTestData(t)
+}
@@ -0,0 +1,58 @@
+package engine_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlbumTracklist(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+
+	resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/album/1/tracklist")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var tracklist []models.TracklistEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tracklist))
+	assert.Empty(t, tracklist)
+
+	recording := uuid.MustParse("21524d55-b1f8-45d1-b172-976cba447199")
+	require.NoError(t, store.SaveAlbumTracklist(context.Background(), 1, []models.TracklistEntry{
+		{DiscNumber: 1, Position: 1, Number: "1", Title: "花の塔", RecordingMbzID: &recording, Duration: 276},
+		{DiscNumber: 1, Position: 2, Number: "2", Title: "月と花束", Duration: 260},
+	}))
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/album/1/tracklist")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tracklist))
+	require.Len(t, tracklist, 2)
+	require.NotNil(t, tracklist[0].TrackID)
+	assert.EqualValues(t, 1, tracklist[0].ListenCount)
+	assert.Nil(t, tracklist[1].TrackID)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/album/1/completion")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var completion db.AlbumCompletion
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&completion))
+	assert.EqualValues(t, 2, completion.TotalTracks)
+	assert.EqualValues(t, 1, completion.HeardTracks)
+	assert.Equal(t, 0.5, completion.Completion)
+	require.Len(t, completion.Unheard, 1)
+	assert.Equal(t, "月と花束", completion.Unheard[0].Title)
+	assert.EqualValues(t, 0, completion.FullAlbumPlays)
+
+	resp, err = http.DefaultClient.Get(host() + "/apis/web/v1/album/abc/completion")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	truncateTestData(t)
+}
@@ -0,0 +1,107 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	require.NoError(t, store.Exec("DELETE FROM trash"))
+
+	getTrash := func(t *testing.T) []*models.TrashItem {
+		resp, err := makeAuthRequest(t, session, "GET", "/apis/web/v1/trash", nil)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var items []*models.TrashItem
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&items))
+		return items
+	}
+	status := func(t *testing.T, method, endpoint string) int {
+		resp, err := makeAuthRequest(t, session, method, endpoint, nil)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusNoContent, status(t, "DELETE", "/apis/web/v1/artist/1"))
+	assert.Equal(t, http.StatusNotFound, status(t, "GET", "/apis/web/v1/album/1"), "the album of the artist is deleted too")
+	items := getTrash(t)
+	require.Len(t, items, 1)
+	assert.Equal(t, "artist", items[0].EntityType)
+	assert.EqualValues(t, 1, items[0].EntityID)
+	assert.Equal(t, "さユり", items[0].Name)
+	assert.EqualValues(t, 1, items[0].ListenCount)
+
+	require.Equal(t, http.StatusNoContent, status(t, "POST", fmt.Sprintf("/apis/web/v1/trash/%d/restore", items[0].ID)))
+	resp, err := http.DefaultClient.Get(host() + "/apis/web/v1/album/1")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var album models.Album
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&album))
+	assert.EqualValues(t, 1, album.ListenCount, "the listens are restored")
+	require.Len(t, album.Artists, 1)
+	assert.EqualValues(t, 1, album.Artists[0].ID)
+	assert.Empty(t, getTrash(t))
+
+	require.Equal(t, http.StatusNoContent, status(t, "DELETE", "/apis/web/v1/track/2"))
+	items = getTrash(t)
+	require.Len(t, items, 1)
+	require.Equal(t, http.StatusNoContent, status(t, "DELETE", fmt.Sprintf("/apis/web/v1/trash/%d", items[0].ID)))
+	assert.Equal(t, http.StatusNotFound, status(t, "POST", fmt.Sprintf("/apis/web/v1/trash/%d/restore", items[0].ID)))
+
+	retention := cfg.TrashRetention()
+	defer cfg.SetTrashRetention(retention)
+	require.Equal(t, http.StatusNoContent, status(t, "DELETE", "/apis/web/v1/track/3"))
+	items = getTrash(t)
+	require.Len(t, items, 1)
+	cfg.SetTrashRetention(0)
+	time.Sleep(time.Second)
+	assert.Equal(t, http.StatusGone, status(t, "POST", fmt.Sprintf("/apis/web/v1/trash/%d/restore", items[0].ID)))
+	require.NoError(t, catalog.PurgeTrash(t.Context(), store))
+	assert.Empty(t, getTrash(t))
+
+	assert.Equal(t, http.StatusBadRequest, status(t, "DELETE", "/apis/web/v1/data"), "purging all data needs confirmation")
+	assert.Equal(t, http.StatusOK, status(t, "GET", "/apis/web/v1/artist/1"))
+	assert.Equal(t, http.StatusNoContent, status(t, "DELETE", "/apis/web/v1/data?confirm=true"))
+	assert.Equal(t, http.StatusNotFound, status(t, "GET", "/apis/web/v1/artist/1"))
+
+	truncateTestData(t)
+}
+
+func TestTrash_Images(t *testing.T) {
+	truncateTestData(t)
+	t.Run("Submit Listens", doSubmitListens)
+	require.NoError(t, store.Exec("DELETE FROM trash"))
+	t.Cleanup(func() { truncateTestData(t) })
+
+	image := uuid.New()
+	require.NoError(t, store.Exec("UPDATE artists SET image = ? WHERE id = 1", image.String()))
+
+	resp, err := makeAuthRequest(t, session, "DELETE", "/apis/web/v1/artist/1", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	ok, err := store.ImageHasAssociation(t.Context(), image)
+	require.NoError(t, err)
+	assert.True(t, ok, "the image is kept while the artist can be restored")
+
+	trash, err := store.GetTrash(t.Context())
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	resp, err = makeAuthRequest(t, session, "DELETE", fmt.Sprintf("/apis/web/v1/trash/%d", trash[0].ID), nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	ok, err = store.ImageHasAssociation(t.Context(), image)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -57,7 +57,18 @@ func (s ImageSize) String() string {
 }
 
 // DownloadImage downloads an image from the given URL, then saves it to the cache at source quality.
+// Local image sources from the library directory are read from disk instead.
 func DownloadImage(imgid uuid.UUID, url string) error {
+	if images.IsLocalImage(url) {
+		data, err := images.ReadLocalImage(url)
+		if err != nil {
+			return fmt.Errorf("DownloadImage: %w", err)
+		}
+		if err := compressAndSaveImage(imgid, ImageSizeSource, bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("DownloadImage: %w", err)
+		}
+		return nil
+	}
 	err := images.ValidateImageURL(url)
 	if err != nil {
 		return fmt.Errorf("DownloadImage: %w", err)
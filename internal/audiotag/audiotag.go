@@ -0,0 +1,192 @@
+// Package audiotag reads the metadata of audio files: ID3v2 tags of MP3 files, Vorbis comments
+// of FLAC, Ogg Vorbis and Opus files, and the metadata atoms of MP4 files.
+package audiotag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnsupported is returned for files that are not in one of the supported formats.
+var ErrUnsupported = errors.New("unsupported file format")
+
+// Extensions are the file extensions of the supported formats.
+var Extensions = []string{".mp3", ".flac", ".ogg", ".oga", ".opus", ".m4a", ".m4b", ".mp4"}
+
+// HasSupportedExtension reports whether the file has the extension of a supported format.
+func HasSupportedExtension(path string) bool {
+	return slices.Contains(Extensions, strings.ToLower(filepath.Ext(path)))
+}
+
+type Tags struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	TrackNumber int
+	DiscNumber  int
+	Duration    int32 // in seconds
+	ISRC        string
+
+	RecordingMbzID    uuid.UUID
+	ReleaseMbzID      uuid.UUID
+	ReleaseGroupMbzID uuid.UUID
+	ArtistMbzIDs      []uuid.UUID
+
+	// whether the file has embedded artwork
+	HasPicture bool
+	// the embedded artwork, only read by ReadPicture
+	Picture *Picture
+}
+
+type Picture struct {
+	MIME string
+	// the ID3v2 picture type, where 3 is the front cover
+	Type byte
+	Data []byte
+}
+
+const pictureTypeFrontCover = 3
+
+// the largest block of metadata that is read, which only matters for files with large pictures
+const maxBlockSize = 16 << 20
+
+// ReadFile reads the tags of an audio file, without the embedded artwork.
+func ReadFile(path string) (*Tags, error) {
+	return read(path, false)
+}
+
+// ReadPicture reads the embedded artwork of an audio file, preferring the front cover. Returns
+// nil when the file has no artwork.
+func ReadPicture(path string) (*Picture, error) {
+	tags, err := read(path, true)
+	if err != nil {
+		return nil, err
+	}
+	return tags.Picture, nil
+}
+
+func read(path string, pictures bool) (*Tags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("audiotag: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("audiotag: %w", err)
+	}
+
+	var magic [12]byte
+	if _, err := io.ReadFull(f, magic[:]); err != nil {
+		return nil, fmt.Errorf("audiotag: %s: %w", path, ErrUnsupported)
+	}
+	t := &tags{Tags: &Tags{}, pictures: pictures}
+	switch {
+	case bytes.HasPrefix(magic[:], []byte("fLaC")):
+		err = readFLAC(f, 4, t)
+	case bytes.HasPrefix(magic[:], []byte("OggS")):
+		err = readOgg(f, info.Size(), t)
+	case string(magic[4:8]) == "ftyp":
+		err = readMP4(f, info.Size(), t)
+	case bytes.HasPrefix(magic[:], []byte("ID3")):
+		err = readID3(f, info.Size(), t)
+	case magic[0] == 0xff && magic[1]&0xe0 == 0xe0:
+		// an MP3 file without tags
+		t.Duration = mpegDuration(f, 0, info.Size())
+	default:
+		err = ErrUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("audiotag: %s: %w", path, err)
+	}
+	return t.Tags, nil
+}
+
+// tags collects the tags while a file is read.
+type tags struct {
+	*Tags
+	pictures bool
+}
+
+func (t *tags) setPicture(p Picture) {
+	t.HasPicture = true
+	if !t.pictures || len(p.Data) == 0 {
+		return
+	}
+	if t.Picture == nil || (t.Picture.Type != pictureTypeFrontCover && p.Type == pictureTypeFrontCover) {
+		t.Picture = &p
+	}
+}
+
+// set stores a tag by its common name, as used by Vorbis comments and the ID3v2 and MP4 custom
+// tags written by MusicBrainz Picard.
+func (t *tags) set(key, value string) {
+	value = strings.TrimSpace(strings.TrimRight(value, "\x00"))
+	if value == "" {
+		return
+	}
+	switch strings.ToUpper(key) {
+	case "TITLE":
+		setOnce(&t.Title, value)
+	case "ARTIST":
+		setOnce(&t.Artist, value)
+	case "ALBUM":
+		setOnce(&t.Album, value)
+	case "ALBUMARTIST", "ALBUM ARTIST":
+		setOnce(&t.AlbumArtist, value)
+	case "TRACKNUMBER":
+		setNumber(&t.TrackNumber, value)
+	case "DISCNUMBER":
+		setNumber(&t.DiscNumber, value)
+	case "ISRC":
+		setOnce(&t.ISRC, value)
+	case "MUSICBRAINZ_TRACKID", "MUSICBRAINZ TRACK ID":
+		setUUID(&t.RecordingMbzID, value)
+	case "MUSICBRAINZ_ALBUMID", "MUSICBRAINZ ALBUM ID":
+		setUUID(&t.ReleaseMbzID, value)
+	case "MUSICBRAINZ_RELEASEGROUPID", "MUSICBRAINZ RELEASE GROUP ID":
+		setUUID(&t.ReleaseGroupMbzID, value)
+	case "MUSICBRAINZ_ARTISTID", "MUSICBRAINZ ARTIST ID":
+		for _, s := range strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == ';' || r == 0 }) {
+			if id, err := uuid.Parse(strings.TrimSpace(s)); err == nil && !slices.Contains(t.ArtistMbzIDs, id) {
+				t.ArtistMbzIDs = append(t.ArtistMbzIDs, id)
+			}
+		}
+	}
+}
+
+func setOnce(dst *string, value string) {
+	if *dst == "" {
+		*dst = value
+	}
+}
+
+// setNumber parses numbers like "3" and "3/12".
+func setNumber(dst *int, value string) {
+	if *dst != 0 {
+		return
+	}
+	value, _, _ = strings.Cut(value, "/")
+	if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n > 0 {
+		*dst = n
+	}
+}
+
+func setUUID(dst *uuid.UUID, value string) {
+	if *dst != uuid.Nil {
+		return
+	}
+	if id, err := uuid.Parse(value); err == nil {
+		*dst = id
+	}
+}
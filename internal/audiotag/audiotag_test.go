@@ -0,0 +1,384 @@
+package audiotag_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gabehf/koito/internal/audiotag"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	recordingID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	releaseID   = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	artistID1   = uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	artistID2   = uuid.MustParse("00000000-0000-0000-0000-000000000004")
+	cover       = []byte("\xff\xd8\xff\xe0 not really a jpeg")
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func be32(n int) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(n))
+}
+
+func le32(n int) []byte {
+	return binary.LittleEndian.AppendUint32(nil, uint32(n))
+}
+
+func syncsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+func id3Frame(version int, id string, data []byte) []byte {
+	b := []byte(id)
+	if version == 4 {
+		b = append(b, syncsafe(len(data))...)
+	} else {
+		b = append(b, be32(len(data))...)
+	}
+	b = append(b, 0, 0)
+	return append(b, data...)
+}
+
+func id3Tag(version int, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	// padding
+	body = append(body, make([]byte, 32)...)
+	b := []byte{'I', 'D', '3', byte(version), 0, 0}
+	b = append(b, syncsafe(len(body))...)
+	return append(b, body...)
+}
+
+// mpegFrames returns an MPEG-1 layer III frame at 128 kbps and 44.1 kHz with a Xing header,
+// followed by filler.
+func mpegFrames(frames int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	copy(frame[36:], "Xing")
+	copy(frame[40:], be32(1))
+	copy(frame[44:], be32(frames))
+	return append(frame, make([]byte, 1024)...)
+}
+
+func TestReadFile_ID3v23(t *testing.T) {
+	tag := id3Tag(3,
+		id3Frame(3, "TIT2", []byte("\x00Song")),
+		// UTF-16 with a byte order mark
+		id3Frame(3, "TPE1", []byte("\x01\xff\xfeA\x00r\x00t\x00i\x00s\x00t\x00\x00\x00")),
+		id3Frame(3, "TALB", []byte("\x00Album")),
+		id3Frame(3, "TPE2", []byte("\x00Album Artist")),
+		id3Frame(3, "TRCK", []byte("\x003/12")),
+		id3Frame(3, "TPOS", []byte("\x002")),
+		id3Frame(3, "TSRC", []byte("\x00USABC1234567")),
+		id3Frame(3, "UFID", append([]byte("http://musicbrainz.org\x00"), recordingID.String()...)),
+		id3Frame(3, "TXXX", []byte("\x03MusicBrainz Album Id\x00"+releaseID.String())),
+		id3Frame(3, "TXXX", []byte("\x03MusicBrainz Artist Id\x00"+artistID1.String()+"\x00"+artistID2.String())),
+		id3Frame(3, "APIC", append([]byte("\x00image/jpeg\x00\x03\x00"), cover...)),
+	)
+	// 180 seconds of audio
+	path := writeFile(t, "song.mp3", append(tag, mpegFrames(6891)...))
+
+	tags, err := audiotag.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Song", tags.Title)
+	assert.Equal(t, "Artist", tags.Artist)
+	assert.Equal(t, "Album", tags.Album)
+	assert.Equal(t, "Album Artist", tags.AlbumArtist)
+	assert.Equal(t, 3, tags.TrackNumber)
+	assert.Equal(t, 2, tags.DiscNumber)
+	assert.Equal(t, "USABC1234567", tags.ISRC)
+	assert.Equal(t, recordingID, tags.RecordingMbzID)
+	assert.Equal(t, releaseID, tags.ReleaseMbzID)
+	assert.Equal(t, []uuid.UUID{artistID1, artistID2}, tags.ArtistMbzIDs)
+	assert.EqualValues(t, 180, tags.Duration)
+	assert.True(t, tags.HasPicture)
+	assert.Nil(t, tags.Picture, "pictures are only read by ReadPicture")
+
+	pic, err := audiotag.ReadPicture(path)
+	require.NoError(t, err)
+	require.NotNil(t, pic)
+	assert.Equal(t, "image/jpeg", pic.MIME)
+	assert.Equal(t, cover, pic.Data)
+}
+
+func TestReadFile_ID3v24(t *testing.T) {
+	tag := id3Tag(4,
+		id3Frame(4, "TIT2", []byte("\x03Sóng")),
+		id3Frame(4, "TPE1", []byte("\x03Artist")),
+		id3Frame(4, "TLEN", []byte("\x00245500")),
+		// a back cover, which is replaced by the front cover
+		id3Frame(4, "APIC", append([]byte("\x00image/png\x00\x04\x00"), "back"...)),
+		id3Frame(4, "APIC", append([]byte("\x00image/jpeg\x00\x03\x00"), cover...)),
+	)
+	path := writeFile(t, "song.mp3", append(tag, mpegFrames(1)...))
+
+	tags, err := audiotag.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Sóng", tags.Title)
+	assert.Equal(t, "Artist", tags.Artist)
+	assert.EqualValues(t, 245, tags.Duration, "the length frame takes precedence")
+
+	pic, err := audiotag.ReadPicture(path)
+	require.NoError(t, err)
+	require.NotNil(t, pic)
+	assert.Equal(t, cover, pic.Data)
+}
+
+func TestReadFile_UntaggedMP3(t *testing.T) {
+	// a constant bitrate stream with no Xing header: 16000 bytes per second at 128 kbps
+	data := make([]byte, 16000*10)
+	copy(data, []byte{0xff, 0xfb, 0x90, 0x00})
+	path := writeFile(t, "song.mp3", data)
+
+	tags, err := audiotag.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, tags.Title)
+	assert.EqualValues(t, 10, tags.Duration)
+	assert.False(t, tags.HasPicture)
+}
+
+func TestReadFile_ID3TooLarge(t *testing.T) {
+	// the header claims a tag of 256 MB in a file of a few bytes
+	header := []byte{'I', 'D', '3', 4, 0, 0, 0x7f, 0x7f, 0x7f, 0x7f}
+	path := writeFile(t, "song.mp3", append(header, mpegFrames(1)...))
+
+	_, err := audiotag.ReadFile(path)
+	assert.ErrorContains(t, err, "too large", "the tag is not read into memory")
+}
+
+func vorbisComment(comments ...string) []byte {
+	b := append(le32(len("vendor")), "vendor"...)
+	b = append(b, le32(len(comments))...)
+	for _, c := range comments {
+		b = append(b, le32(len(c))...)
+		b = append(b, c...)
+	}
+	return b
+}
+
+func flacPicture(typ int, mime string, data []byte) []byte {
+	b := append(be32(typ), be32(len(mime))...)
+	b = append(b, mime...)
+	b = append(b, be32(0)...)
+	b = append(b, make([]byte, 16)...)
+	b = append(b, be32(len(data))...)
+	return append(b, data...)
+}
+
+func flacBlock(typ byte, last bool, data []byte) []byte {
+	if last {
+		typ |= 0x80
+	}
+	return append([]byte{typ, byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}, data...)
+}
+
+func TestReadFile_FLAC(t *testing.T) {
+	sampleRate, samples := 44100, 44100*200+100
+	info := make([]byte, 34)
+	info[10] = byte(sampleRate >> 12)
+	info[11] = byte(sampleRate >> 4)
+	info[12] = byte(sampleRate&0x0f)<<4 | 1<<1
+	info[13] = 0x0f<<4 | byte(samples>>32&0x0f)
+	copy(info[14:], be32(samples))
+
+	data := []byte("fLaC")
+	data = append(data, flacBlock(0, false, info)...)
+	data = append(data, flacBlock(4, false, vorbisComment(
+		"TITLE=Song",
+		"ARTIST=Artist",
+		"ALBUM=Album",
+		"ALBUMARTIST=Album Artist",
+		"TRACKNUMBER=7",
+		"DISCNUMBER=1/2",
+		"ISRC=USABC1234567",
+		"MUSICBRAINZ_TRACKID="+recordingID.String(),
+		"MUSICBRAINZ_ALBUMID="+releaseID.String(),
+		"MUSICBRAINZ_ARTISTID="+artistID1.String(),
+		"musicbrainz_artistid="+artistID2.String(),
+	))...)
+	data = append(data, flacBlock(6, true, flacPicture(3, "image/jpeg", cover))...)
+	path := writeFile(t, "song.flac", data)
+
+	tags, err := audiotag.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Song", tags.Title)
+	assert.Equal(t, "Artist", tags.Artist)
+	assert.Equal(t, "Album", tags.Album)
+	assert.Equal(t, "Album Artist", tags.AlbumArtist)
+	assert.Equal(t, 7, tags.TrackNumber)
+	assert.Equal(t, 1, tags.DiscNumber)
+	assert.Equal(t, "USABC1234567", tags.ISRC)
+	assert.Equal(t, recordingID, tags.RecordingMbzID)
+	assert.Equal(t, releaseID, tags.ReleaseMbzID)
+	assert.Equal(t, []uuid.UUID{artistID1, artistID2}, tags.ArtistMbzIDs)
+	assert.EqualValues(t, 200, tags.Duration)
+	assert.True(t, tags.HasPicture)
+
+	pic, err := audiotag.ReadPicture(path)
+	require.NoError(t, err)
+	require.NotNil(t, pic)
+	assert.Equal(t, "image/jpeg", pic.MIME)
+	assert.Equal(t, cover, pic.Data)
+}
+
+func oggPage(serial uint32, granule uint64, packets ...[]byte) []byte {
+	var segments, body []byte
+	for _, p := range packets {
+		n := len(p)
+		for ; n >= 255; n -= 255 {
+			segments = append(segments, 255)
+		}
+		segments = append(segments, byte(n))
+		body = append(body, p...)
+	}
+	b := []byte("OggS\x00\x00")
+	b = binary.LittleEndian.AppendUint64(b, granule)
+	b = binary.LittleEndian.AppendUint32(b, serial)
+	b = append(b, make([]byte, 8)...) // sequence number and checksum
+	b = append(b, byte(len(segments)))
+	b = append(b, segments...)
+	return append(b, body...)
+}
+
+func TestReadFile_Opus(t *testing.T) {
+	head := []byte("OpusHead\x01\x02")
+	head = binary.LittleEndian.AppendUint16(head, 312)
+	head = append(head, le32(48000)...)
+	head = append(head, 0, 0, 0)
+
+	picture := base64.StdEncoding.EncodeToString(flacPicture(3, "image/png", cover))
+	comment := append([]byte("OpusTags"), vorbisComment(
+		"TITLE=Song",
+		"ARTIST=Artist",
+		"MUSICBRAINZ_TRACKID="+recordingID.String(),
+		"METADATA_BLOCK_PICTURE="+picture,
+	)...)
+
+	var data []byte
+	data = append(data, oggPage(7, 0, head)...)
+	// a long packet, spanning several segments
+	data = append(data, oggPage(7, 0, comment)...)
+	data = append(data, oggPage(7, 48000*95+312, make([]byte, 100))...)
+	path := writeFile(t, "song.opus", data)
+
+	tags, err := audiotag.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Song", tags.Title)
+	assert.Equal(t, "Artist", tags.Artist)
+	assert.Equal(t, recordingID, tags.RecordingMbzID)
+	assert.EqualValues(t, 95, tags.Duration)
+	assert.True(t, tags.HasPicture)
+
+	pic, err := audiotag.ReadPicture(path)
+	require.NoError(t, err)
+	require.NotNil(t, pic)
+	assert.Equal(t, "image/png", pic.MIME)
+	assert.Equal(t, cover, pic.Data)
+}
+
+func TestReadFile_OggVorbis(t *testing.T) {
+	id := []byte("\x01vorbis")
+	id = append(id, le32(0)...)
+	id = append(id, 2)
+	id = append(id, le32(44100)...)
+	id = append(id, make([]byte, 14)...)
+	comment := append([]byte("\x03vorbis"), vorbisComment("TITLE=Song", "TRACKNUMBER=4")...)
+
+	var data []byte
+	data = append(data, oggPage(1, 0, id)...)
+	data = append(data, oggPage(1, 0, comment, []byte("setup"))...)
+	data = append(data, oggPage(1, 44100*61, make([]byte, 100))...)
+	path := writeFile(t, "song.ogg", data)
+
+	tags, err := audiotag.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Song", tags.Title)
+	assert.Equal(t, 4, tags.TrackNumber)
+	assert.EqualValues(t, 61, tags.Duration)
+	assert.False(t, tags.HasPicture)
+}
+
+func atom(typ string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	return append(append(be32(8+len(body)), typ...), body...)
+}
+
+func mp4Data(dataType int, value []byte) []byte {
+	return atom("data", be32(dataType), be32(0), value)
+}
+
+func TestReadFile_MP4(t *testing.T) {
+	mvhd := make([]byte, 100)
+	copy(mvhd[12:], be32(1000))
+	copy(mvhd[16:], be32(123456))
+
+	data := atom("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	data = append(data, atom("moov",
+		atom("mvhd", mvhd),
+		atom("udta",
+			atom("meta", be32(0),
+				atom("hdlr", make([]byte, 25)),
+				atom("ilst",
+					atom("\xa9nam", mp4Data(1, []byte("Song"))),
+					atom("\xa9ART", mp4Data(1, []byte("Artist"))),
+					atom("\xa9alb", mp4Data(1, []byte("Album"))),
+					atom("aART", mp4Data(1, []byte("Album Artist"))),
+					atom("trkn", mp4Data(0, []byte{0, 0, 0, 5, 0, 10, 0, 0})),
+					atom("disk", mp4Data(0, []byte{0, 0, 0, 2, 0, 2})),
+					atom("----",
+						atom("mean", be32(0), []byte("com.apple.iTunes")),
+						atom("name", be32(0), []byte("MusicBrainz Track Id")),
+						mp4Data(1, []byte(recordingID.String())),
+					),
+					atom("----",
+						atom("mean", be32(0), []byte("com.apple.iTunes")),
+						atom("name", be32(0), []byte("ISRC")),
+						mp4Data(1, []byte("USABC1234567")),
+					),
+					atom("covr", mp4Data(14, cover)),
+				),
+			),
+		),
+	)...)
+	data = append(data, atom("mdat", make([]byte, 64))...)
+	path := writeFile(t, "song.m4a", data)
+
+	tags, err := audiotag.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Song", tags.Title)
+	assert.Equal(t, "Artist", tags.Artist)
+	assert.Equal(t, "Album", tags.Album)
+	assert.Equal(t, "Album Artist", tags.AlbumArtist)
+	assert.Equal(t, 5, tags.TrackNumber)
+	assert.Equal(t, 2, tags.DiscNumber)
+	assert.Equal(t, "USABC1234567", tags.ISRC)
+	assert.Equal(t, recordingID, tags.RecordingMbzID)
+	assert.EqualValues(t, 123, tags.Duration)
+	assert.True(t, tags.HasPicture)
+
+	pic, err := audiotag.ReadPicture(path)
+	require.NoError(t, err)
+	require.NotNil(t, pic)
+	assert.Equal(t, "image/png", pic.MIME)
+	assert.Equal(t, cover, pic.Data)
+}
+
+func TestReadFile_Unsupported(t *testing.T) {
+	path := writeFile(t, "notes.mp3", []byte("these are not the tags you are looking for"))
+	_, err := audiotag.ReadFile(path)
+	assert.ErrorIs(t, err, audiotag.ErrUnsupported)
+
+	assert.True(t, audiotag.HasSupportedExtension("/music/a/b.FLAC"))
+	assert.False(t, audiotag.HasSupportedExtension("/music/a/cover.jpg"))
+}
@@ -0,0 +1,151 @@
+package audiotag
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+	flacPicture       = 6
+)
+
+// readFLAC reads the metadata blocks of a FLAC stream, starting after the "fLaC" marker at
+// offset.
+func readFLAC(r io.ReadSeeker, offset int64, t *tags) error {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+		last := header[0]&0x80 != 0
+		typ := header[0] & 0x7f
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		switch {
+		case typ == flacStreamInfo, typ == flacVorbisComment, typ == flacPicture && t.pictures:
+			data := make([]byte, size)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return err
+			}
+			switch typ {
+			case flacStreamInfo:
+				if len(data) < 18 {
+					return errors.New("invalid FLAC stream info")
+				}
+				sampleRate := int64(data[10])<<12 | int64(data[11])<<4 | int64(data[12])>>4
+				samples := int64(data[13]&0x0f)<<32 | int64(binary.BigEndian.Uint32(data[14:18]))
+				if sampleRate > 0 {
+					t.Duration = int32(samples / sampleRate)
+				}
+			case flacVorbisComment:
+				if err := readVorbisComment(data, t); err != nil {
+					return err
+				}
+			case flacPicture:
+				if p, ok := flacPictureBlock(data); ok {
+					t.setPicture(p)
+				}
+			}
+		default:
+			if typ == flacPicture {
+				t.HasPicture = true
+			}
+			if _, err := r.Seek(size, io.SeekCurrent); err != nil {
+				return err
+			}
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// flacPictureBlock decodes a FLAC picture block, which is also used by the
+// METADATA_BLOCK_PICTURE Vorbis comment.
+func flacPictureBlock(data []byte) (Picture, bool) {
+	var p Picture
+	next := func(n int) []byte {
+		if n < 0 || len(data) < n {
+			data = nil
+			return nil
+		}
+		b := data[:n]
+		data = data[n:]
+		return b
+	}
+	u32 := func() int {
+		b := next(4)
+		if b == nil {
+			return -1
+		}
+		return int(binary.BigEndian.Uint32(b))
+	}
+	typ := u32()
+	p.MIME = string(next(u32()))
+	next(u32()) // description
+	next(16)    // width, height, depth and colors
+	p.Data = next(u32())
+	if typ < 0 || p.Data == nil {
+		return Picture{}, false
+	}
+	p.Type = byte(typ)
+	return p, true
+}
+
+// readVorbisComment reads a Vorbis comment block: a vendor string followed by a list of
+// KEY=value comments, all with little endian lengths.
+func readVorbisComment(data []byte, t *tags) error {
+	errInvalid := errors.New("invalid Vorbis comment")
+	str := func() (string, bool) {
+		if len(data) < 4 {
+			return "", false
+		}
+		n := int(binary.LittleEndian.Uint32(data))
+		if n < 0 || len(data)-4 < n {
+			return "", false
+		}
+		s := string(data[4 : 4+n])
+		data = data[4+n:]
+		return s, true
+	}
+	if _, ok := str(); !ok {
+		return errInvalid
+	}
+	if len(data) < 4 {
+		return errInvalid
+	}
+	count := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	for range count {
+		comment, ok := str()
+		if !ok {
+			return errInvalid
+		}
+		key, value, ok := strings.Cut(comment, "=")
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(key, "METADATA_BLOCK_PICTURE") {
+			t.HasPicture = true
+			if !t.pictures {
+				continue
+			}
+			if b, err := base64.StdEncoding.DecodeString(value); err == nil {
+				if p, ok := flacPictureBlock(b); ok {
+					t.setPicture(p)
+				}
+			}
+			continue
+		}
+		t.set(key, value)
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// the ID3v2 frames that are read, by their v2.3/v2.4 ID. v2.2 IDs are mapped to these.
+var id3TextFrames = map[string]string{
+	"TIT2": "TITLE",
+	"TPE1": "ARTIST",
+	"TALB": "ALBUM",
+	"TPE2": "ALBUMARTIST",
+	"TRCK": "TRACKNUMBER",
+	"TPOS": "DISCNUMBER",
+	"TSRC": "ISRC",
+}
+
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2", "TP1": "TPE1", "TAL": "TALB", "TP2": "TPE2", "TRK": "TRCK", "TPA": "TPOS",
+	"TRC": "TSRC", "TLE": "TLEN", "TXX": "TXXX", "UFI": "UFID", "PIC": "APIC",
+}
+
+// readID3 reads the ID3v2 tag at the start of an MP3 file, and the duration of the audio after
+// it.
+func readID3(r io.ReadSeeker, size int64, t *tags) error {
+	var header [10]byte
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	version := header[3]
+	flags := header[5]
+	tagSize := int64(syncsafe(header[6:10]))
+	if version < 2 || version > 4 {
+		return errors.New("unsupported ID3v2 version")
+	}
+	if tagSize > maxBlockSize || tagSize > size-10 {
+		return errors.New("ID3v2 tag too large")
+	}
+	body := make([]byte, tagSize)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return err
+	}
+	audioStart := 10 + tagSize
+	if flags&0x10 != 0 {
+		audioStart += 10
+	}
+	if version < 4 && flags&0x80 != 0 {
+		body = removeUnsync(body)
+	}
+	if flags&0x40 != 0 && version > 2 && len(body) >= 4 {
+		// skip the extended header
+		n := int(binary.BigEndian.Uint32(body))
+		if version == 3 {
+			n += 4
+		} else {
+			n = int(syncsafe(body[:4]))
+		}
+		if n > len(body) {
+			return errors.New("invalid ID3v2 extended header")
+		}
+		body = body[n:]
+	}
+
+	var length int
+	for len(body) > 0 && body[0] != 0 {
+		var id string
+		var frameSize int
+		var frameFlags uint16
+		if version == 2 {
+			if len(body) < 6 {
+				break
+			}
+			id = id3v22Frames[string(body[:3])]
+			frameSize = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+			body = body[6:]
+		} else {
+			if len(body) < 10 {
+				break
+			}
+			id = string(body[:4])
+			if version == 4 {
+				frameSize = int(syncsafe(body[4:8]))
+			} else {
+				frameSize = int(binary.BigEndian.Uint32(body[4:8]))
+			}
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+			body = body[10:]
+		}
+		if frameSize > len(body) {
+			// a broken frame
+			break
+		}
+		data := body[:frameSize]
+		body = body[frameSize:]
+
+		data, ok := id3FrameData(version, frameFlags, data)
+		if !ok {
+			continue
+		}
+		switch id {
+		case "TXXX":
+			desc, value := id3UserText(data)
+			t.set(desc, value)
+		case "UFID":
+			owner, ident, ok := bytes.Cut(data, []byte{0})
+			if ok && string(owner) == "http://musicbrainz.org" {
+				setUUID(&t.RecordingMbzID, string(ident))
+			}
+		case "TLEN":
+			if ms, err := strconv.Atoi(strings.TrimSpace(id3Text(data))); err == nil {
+				length = ms
+			}
+		case "APIC":
+			if p, ok := id3Picture(version, data); ok {
+				t.setPicture(p)
+			}
+		default:
+			if key, ok := id3TextFrames[id]; ok {
+				t.set(key, id3Text(data))
+			}
+		}
+	}
+
+	if length > 0 {
+		t.Duration = int32(length / 1000)
+	} else {
+		t.Duration = mpegDuration(r, audioStart, size)
+	}
+	return nil
+}
+
+// id3FrameData undoes the frame level encodings of a frame. Returns false for compressed and
+// encrypted frames, which are skipped.
+func id3FrameData(version byte, flags uint16, data []byte) ([]byte, bool) {
+	switch version {
+	case 3:
+		if flags&0x00c0 != 0 {
+			return nil, false
+		}
+		if flags&0x0020 != 0 && len(data) > 0 {
+			data = data[1:]
+		}
+	case 4:
+		if flags&0x000c != 0 {
+			return nil, false
+		}
+		if flags&0x0040 != 0 && len(data) > 0 {
+			data = data[1:]
+		}
+		if flags&0x0001 != 0 && len(data) >= 4 {
+			data = data[4:]
+		}
+		if flags&0x0002 != 0 {
+			data = removeUnsync(data)
+		}
+	}
+	return data, true
+}
+
+// id3Text decodes a text frame. Only the first of multiple values is returned.
+func id3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	value, _ := id3String(data[0], data[1:])
+	return value
+}
+
+// id3UserText decodes a TXXX frame into its description and value.
+func id3UserText(data []byte) (string, string) {
+	if len(data) == 0 {
+		return "", ""
+	}
+	desc, rest := id3String(data[0], data[1:])
+	// values of multi-valued frames are joined with a null byte, which set splits where needed
+	var values []string
+	for len(rest) > 0 {
+		var v string
+		v, rest = id3String(data[0], rest)
+		values = append(values, v)
+	}
+	return desc, strings.Join(values, "\x00")
+}
+
+// id3Picture decodes an APIC frame, or a PIC frame of ID3v2.2.
+func id3Picture(version byte, data []byte) (Picture, bool) {
+	if len(data) < 2 {
+		return Picture{}, false
+	}
+	enc := data[0]
+	data = data[1:]
+	var mime string
+	if version == 2 {
+		if len(data) < 3 {
+			return Picture{}, false
+		}
+		mime = "image/" + strings.ToLower(string(data[:3]))
+		if mime == "image/jpg" {
+			mime = "image/jpeg"
+		}
+		data = data[3:]
+	} else {
+		m, rest, ok := bytes.Cut(data, []byte{0})
+		if !ok {
+			return Picture{}, false
+		}
+		mime = string(m)
+		data = rest
+	}
+	if len(data) < 1 {
+		return Picture{}, false
+	}
+	typ := data[0]
+	_, data = id3String(enc, data[1:])
+	return Picture{MIME: mime, Type: typ, Data: data}, true
+}
+
+// id3String decodes a null terminated string in the given encoding, returning the string and
+// the data after it.
+func id3String(enc byte, data []byte) (string, []byte) {
+	switch enc {
+	case 1, 2:
+		// UTF-16, terminated by two null bytes on an even offset
+		end := len(data)
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				end = i
+				break
+			}
+		}
+		s := decodeUTF16(data[:end], enc == 2)
+		return s, data[min(end+2, len(data)):]
+	default:
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			end = len(data)
+		}
+		s := data[:end]
+		rest := data[min(end+1, len(data)):]
+		if enc == 3 {
+			return string(s), rest
+		}
+		return latin1(s), rest
+	}
+}
+
+func decodeUTF16(b []byte, bigEndian bool) string {
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xff && b[1] == 0xfe:
+			bigEndian = false
+			b = b[2:]
+		case b[0] == 0xfe && b[1] == 0xff:
+			bigEndian = true
+			b = b[2:]
+		}
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		if bigEndian {
+			u[i] = binary.BigEndian.Uint16(b[2*i:])
+		} else {
+			u[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+	}
+	return string(utf16.Decode(u))
+}
+
+func latin1(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
+
+// removeUnsync undoes the unsynchronisation scheme, which inserts a null byte after each 0xff.
+func removeUnsync(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+var (
+	mpegBitrates = map[[2]int][]int{
+		{1, 1}: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{1, 2}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{1, 3}: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		{2, 1}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{2, 2}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{2, 3}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+	mpegSampleRates = map[int][]int{
+		1: {44100, 48000, 32000},
+		2: {22050, 24000, 16000},
+		3: {11025, 12000, 8000},
+	}
+)
+
+// mpegDuration finds the first MPEG audio frame at or after start, and returns the duration of
+// the audio in seconds. The frame count of a Xing, Info or VBRI header is used when there is
+// one, otherwise the file is taken to have a constant bitrate. Returns 0 when no frame is found.
+func mpegDuration(r io.ReadSeeker, start, size int64) int32 {
+	buf := make([]byte, 64*1024)
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0
+	}
+	n, _ := io.ReadFull(r, buf)
+	buf = buf[:n]
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xff || buf[i+1]&0xe0 != 0xe0 {
+			continue
+		}
+		// version: 1 = MPEG-1, 2 = MPEG-2, 3 = MPEG-2.5; layer: 1 = I, 2 = II, 3 = III
+		version := map[byte]int{3: 1, 2: 2, 0: 3}[buf[i+1]>>3&3]
+		layer := 4 - int(buf[i+1]>>1&3)
+		bitrateIdx := int(buf[i+2] >> 4)
+		rateIdx := int(buf[i+2] >> 2 & 3)
+		if version == 0 || layer == 4 || bitrateIdx == 0 || bitrateIdx == 15 || rateIdx == 3 {
+			continue
+		}
+		tableVersion := min(version, 2)
+		bitrate := mpegBitrates[[2]int{tableVersion, layer}][bitrateIdx] * 1000
+		sampleRate := mpegSampleRates[version][rateIdx]
+		mono := buf[i+3]>>6 == 3
+
+		samplesPerFrame := 1152
+		switch {
+		case layer == 1:
+			samplesPerFrame = 384
+		case layer == 3 && version != 1:
+			samplesPerFrame = 576
+		}
+
+		xing := 4 + 32
+		switch {
+		case version == 1 && mono:
+			xing = 4 + 17
+		case version != 1 && mono:
+			xing = 4 + 9
+		case version != 1:
+			xing = 4 + 17
+		}
+		frame := buf[i:]
+		var frames uint32
+		if len(frame) >= xing+12 {
+			tag := string(frame[xing : xing+4])
+			if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(frame[xing+4:])&1 != 0 {
+				frames = binary.BigEndian.Uint32(frame[xing+8:])
+			}
+		}
+		if frames == 0 && len(frame) >= 4+32+18 && string(frame[36:40]) == "VBRI" {
+			frames = binary.BigEndian.Uint32(frame[36+14:])
+		}
+		if frames > 0 {
+			return int32(int64(frames) * int64(samplesPerFrame) / int64(sampleRate))
+		}
+		audio := size - start - int64(i)
+		return int32(audio * 8 / int64(bitrate))
+	}
+	return 0
+}
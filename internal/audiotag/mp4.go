@@ -0,0 +1,199 @@
+package audiotag
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// the metadata items that are read, by their atom type
+var mp4Items = map[string]string{
+	"\xa9nam": "TITLE",
+	"\xa9ART": "ARTIST",
+	"\xa9alb": "ALBUM",
+	"aART":    "ALBUMARTIST",
+}
+
+// the data type of PNG pictures, which are JPEG otherwise
+const mp4DataPNG = 14
+
+// readMP4 reads the duration from the movie header and the tags from the iTunes style metadata
+// of an MP4 file. Only the atoms that are needed are read.
+func readMP4(r io.ReadSeeker, size int64, t *tags) error {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return errors.New("MP4 files must be seekable")
+	}
+	moov, moovSize, err := findAtom(ra, 0, size, "moov")
+	if err != nil {
+		return err
+	}
+	if off, n, err := findAtom(ra, moov, moov+moovSize, "mvhd"); err == nil {
+		data, err := readAtom(ra, off, n)
+		if err != nil {
+			return err
+		}
+		var timescale, duration uint64
+		switch {
+		case len(data) >= 20 && data[0] == 0:
+			timescale = uint64(binary.BigEndian.Uint32(data[12:]))
+			duration = uint64(binary.BigEndian.Uint32(data[16:]))
+		case len(data) >= 32 && data[0] == 1:
+			timescale = uint64(binary.BigEndian.Uint32(data[20:]))
+			duration = binary.BigEndian.Uint64(data[24:])
+		}
+		if timescale > 0 {
+			t.Duration = int32(duration / timescale)
+		}
+	}
+
+	udta, udtaSize, err := findAtom(ra, moov, moov+moovSize, "udta")
+	if err != nil {
+		return nil
+	}
+	meta, metaSize, err := findAtom(ra, udta, udta+udtaSize, "meta")
+	if err != nil {
+		return nil
+	}
+	// meta is a full box, with a version and flags before its children
+	ilst, ilstSize, err := findAtom(ra, meta+4, meta+metaSize, "ilst")
+	if err != nil {
+		return nil
+	}
+	return walkAtoms(ra, ilst, ilst+ilstSize, func(typ string, off, n int64) error {
+		switch {
+		case typ == "covr":
+			t.HasPicture = true
+			if !t.pictures {
+				return nil
+			}
+		case typ != "trkn" && typ != "disk" && typ != "----" && mp4Items[typ] == "":
+			return nil
+		}
+		item, err := readAtom(ra, off, n)
+		if err != nil {
+			return err
+		}
+		var name string
+		var values [][]byte
+		var dataTypes []uint32
+		parseAtoms(item, func(child string, data []byte) {
+			switch child {
+			case "name":
+				if len(data) > 4 {
+					name = string(data[4:])
+				}
+			case "data":
+				if len(data) >= 8 {
+					dataTypes = append(dataTypes, binary.BigEndian.Uint32(data)&0xffffff)
+					values = append(values, data[8:])
+				}
+			}
+		})
+		for i, v := range values {
+			switch typ {
+			case "trkn", "disk":
+				if len(v) >= 4 {
+					n := int(binary.BigEndian.Uint16(v[2:4]))
+					if typ == "trkn" && t.TrackNumber == 0 {
+						t.TrackNumber = n
+					} else if typ == "disk" && t.DiscNumber == 0 {
+						t.DiscNumber = n
+					}
+				}
+			case "covr":
+				mime := "image/jpeg"
+				if dataTypes[i] == mp4DataPNG {
+					mime = "image/png"
+				}
+				t.setPicture(Picture{MIME: mime, Type: pictureTypeFrontCover, Data: v})
+			case "----":
+				t.set(strings.ToUpper(name), string(v))
+			default:
+				t.set(mp4Items[typ], string(v))
+			}
+		}
+		return nil
+	})
+}
+
+// walkAtoms calls fn with the type, offset and size of the payload of each atom between start
+// and end.
+func walkAtoms(r io.ReaderAt, start, end int64, fn func(typ string, off, size int64) error) error {
+	for off := start; off+8 <= end; {
+		var header [16]byte
+		if _, err := r.ReadAt(header[:8], off); err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		typ := string(header[4:8])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			size = end - off
+		case 1:
+			if _, err := r.ReadAt(header[8:16], off+8); err != nil {
+				return err
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize || off+size > end {
+			return errors.New("invalid MP4 atom")
+		}
+		if err := fn(typ, off+headerSize, size-headerSize); err != nil {
+			return err
+		}
+		off += size
+	}
+	return nil
+}
+
+var (
+	errAtomNotFound = errors.New("MP4 atom not found")
+	errStopWalk     = errors.New("stop walking atoms")
+)
+
+// findAtom returns the offset and size of the payload of the first atom of the type between
+// start and end.
+func findAtom(r io.ReaderAt, start, end int64, typ string) (int64, int64, error) {
+	var off, size int64 = -1, 0
+	err := walkAtoms(r, start, end, func(t string, o, n int64) error {
+		if t == typ {
+			off, size = o, n
+			return errStopWalk
+		}
+		return nil
+	})
+	if off >= 0 {
+		return off, size, nil
+	}
+	if err == nil || errors.Is(err, errStopWalk) {
+		err = errAtomNotFound
+	}
+	return 0, 0, err
+}
+
+func readAtom(r io.ReaderAt, off, size int64) ([]byte, error) {
+	if size > maxBlockSize {
+		return nil, errors.New("MP4 atom too large")
+	}
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, off); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// parseAtoms calls fn with the type and payload of each atom in data.
+func parseAtoms(data []byte, fn func(typ string, payload []byte)) {
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data))
+		if size < 8 || size > len(data) {
+			return
+		}
+		fn(string(data[4:8]), data[8:size])
+		data = data[size:]
+	}
+}
@@ -0,0 +1,97 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// readOgg reads the Vorbis comment of an Ogg Vorbis or Opus stream, and the duration from the
+// granule position of its last page.
+func readOgg(r io.ReadSeeker, size int64, t *tags) error {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	var serial uint32
+	first := true
+	var packets [][]byte
+	var packet []byte
+	for len(packets) < 2 {
+		var header [27]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+		if string(header[:4]) != "OggS" {
+			return errors.New("invalid Ogg page")
+		}
+		pageSerial := binary.LittleEndian.Uint32(header[14:18])
+		if first {
+			serial = pageSerial
+			first = false
+		}
+		segments := make([]byte, header[26])
+		if _, err := io.ReadFull(r, segments); err != nil {
+			return err
+		}
+		for _, n := range segments {
+			data := make([]byte, n)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return err
+			}
+			if pageSerial != serial {
+				continue
+			}
+			packet = append(packet, data...)
+			if len(packet) > maxBlockSize {
+				return errors.New("Ogg packet too large")
+			}
+			if n < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+	}
+
+	var sampleRate, preSkip int64
+	var comment []byte
+	id, tagsPacket := packets[0], packets[1]
+	switch {
+	case bytes.HasPrefix(id, []byte("\x01vorbis")) && len(id) >= 16:
+		sampleRate = int64(binary.LittleEndian.Uint32(id[12:16]))
+		comment = bytes.TrimPrefix(tagsPacket, []byte("\x03vorbis"))
+	case bytes.HasPrefix(id, []byte("OpusHead")) && len(id) >= 12:
+		// the granule position of Opus streams is always in 48 kHz samples
+		sampleRate = 48000
+		preSkip = int64(binary.LittleEndian.Uint16(id[10:12]))
+		comment = bytes.TrimPrefix(tagsPacket, []byte("OpusTags"))
+	default:
+		return ErrUnsupported
+	}
+	if err := readVorbisComment(comment, t); err != nil {
+		return err
+	}
+
+	if granule := lastOggGranule(r, size, serial); granule > 0 && sampleRate > 0 {
+		t.Duration = int32(max(0, granule-preSkip) / sampleRate)
+	}
+	return nil
+}
+
+// lastOggGranule finds the granule position of the last page of the stream.
+func lastOggGranule(r io.ReadSeeker, size int64, serial uint32) int64 {
+	n := min(size, 64*1024)
+	if _, err := r.Seek(size-n, io.SeekStart); err != nil {
+		return 0
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0
+	}
+	for i := bytes.LastIndex(buf, []byte("OggS")); i >= 0; i = bytes.LastIndex(buf[:i], []byte("OggS")) {
+		if len(buf)-i >= 27 && binary.LittleEndian.Uint32(buf[i+14:]) == serial {
+			return int64(binary.LittleEndian.Uint64(buf[i+6:]))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,163 @@
+// package backup creates and restores full backups of a Koito config directory
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gabehf/koito/imagecache"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	ManifestName   = "manifest.json"
+	DatabaseName   = "koito.db"
+	manifestFormat = 1
+)
+
+type Manifest struct {
+	Format       int            `json:"format"`
+	KoitoVersion string         `json:"koito_version"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Files        []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// CreateArchive writes a gzipped tar archive to out containing a snapshot of the database taken
+// with VACUUM INTO, the source-size version of every cached image, and a manifest listing the
+// checksum of each file. The manifest is always the last entry in the archive.
+func CreateArchive(ctx context.Context, store db.BackupStore, out io.Writer) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("CreateArchive: Creating backup archive...")
+
+	snapshot, err := os.CreateTemp(cfg.ConfigDir(), ".koito_backup_*.db")
+	if err != nil {
+		return fmt.Errorf("CreateArchive: os.CreateTemp: %w", err)
+	}
+	snapshot.Close()
+	defer os.Remove(snapshot.Name())
+
+	if err := store.VacuumInto(ctx, snapshot.Name()); err != nil {
+		return fmt.Errorf("CreateArchive: %w", err)
+	}
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	manifest := Manifest{
+		Format:       manifestFormat,
+		KoitoVersion: cfg.Version(),
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	f, err := addFile(tw, DatabaseName, snapshot.Name())
+	if err != nil {
+		return fmt.Errorf("CreateArchive: %w", err)
+	}
+	manifest.Files = append(manifest.Files, f)
+
+	var imgErr error
+	err = imagecache.ForEachCachedImageID(func(imageID uuid.UUID) {
+		if imgErr != nil {
+			return
+		}
+		src := imagecache.BuildImagePath(imageID, imagecache.ImageSizeSource)
+		if _, err := os.Stat(src); err != nil {
+			l.Debug().Msgf("CreateArchive: No source image found for image %s, skipping", imageID)
+			return
+		}
+		rel, err := filepath.Rel(cfg.ConfigDir(), src)
+		if err != nil {
+			imgErr = err
+			return
+		}
+		f, err := addFile(tw, filepath.ToSlash(rel), src)
+		if err != nil {
+			imgErr = err
+			return
+		}
+		manifest.Files = append(manifest.Files, f)
+	})
+	if err != nil {
+		return fmt.Errorf("CreateArchive: %w", err)
+	}
+	if imgErr != nil {
+		return fmt.Errorf("CreateArchive: %w", imgErr)
+	}
+
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("CreateArchive: marshal manifest: %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    ManifestName,
+		Mode:    0644,
+		Size:    int64(len(raw)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return fmt.Errorf("CreateArchive: %w", err)
+	}
+	if _, err := tw.Write(raw); err != nil {
+		return fmt.Errorf("CreateArchive: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("CreateArchive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("CreateArchive: %w", err)
+	}
+
+	l.Info().Msgf("CreateArchive: Backup archive created with %d files", len(manifest.Files))
+	return nil
+}
+
+func addFile(tw *tar.Writer, name, src string) (ManifestFile, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("addFile: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("addFile: %w", err)
+	}
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return ManifestFile{}, fmt.Errorf("addFile: %w", err)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tw, h), f); err != nil {
+		return ManifestFile{}, fmt.Errorf("addFile: %w", err)
+	}
+
+	return ManifestFile{
+		Path:   name,
+		Size:   info.Size(),
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
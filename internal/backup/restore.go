@@ -0,0 +1,126 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gabehf/koito/internal/logger"
+)
+
+// Restore extracts a backup archive created by CreateArchive into configDir, which must either
+// not exist or be empty. Every extracted file is checked against the manifest, and if anything
+// fails the partially restored directory is cleared again.
+func Restore(ctx context.Context, in io.Reader, configDir string) (err error) {
+	l := logger.FromContext(ctx)
+	l.Info().Msgf("Restore: Restoring backup into %s", configDir)
+
+	if entries, err := os.ReadDir(configDir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("Restore: config directory %s is not empty", configDir)
+	} else if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Restore: %w", err)
+	}
+	if err := os.MkdirAll(configDir, 0744); err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			cleanDir(configDir)
+		}
+	}()
+
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return fmt.Errorf("Restore: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest *Manifest
+	extracted := make(map[string]ManifestFile)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Restore: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !filepath.IsLocal(hdr.Name) {
+			return fmt.Errorf("Restore: archive contains invalid path %q", hdr.Name)
+		}
+
+		if hdr.Name == ManifestName {
+			manifest = new(Manifest)
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return fmt.Errorf("Restore: decode manifest: %w", err)
+			}
+			continue
+		}
+
+		f, err := extractFile(tr, filepath.Join(configDir, filepath.FromSlash(hdr.Name)))
+		if err != nil {
+			return fmt.Errorf("Restore: %w", err)
+		}
+		f.Path = hdr.Name
+		extracted[hdr.Name] = f
+	}
+
+	if manifest == nil {
+		return errors.New("Restore: archive has no manifest")
+	}
+	if manifest.Format > manifestFormat {
+		return fmt.Errorf("Restore: unsupported backup format %d", manifest.Format)
+	}
+	for _, want := range manifest.Files {
+		got, ok := extracted[want.Path]
+		if !ok {
+			return fmt.Errorf("Restore: file %s listed in manifest is missing from archive", want.Path)
+		}
+		if got != want {
+			return fmt.Errorf("Restore: checksum mismatch for %s", want.Path)
+		}
+	}
+	if _, ok := extracted[DatabaseName]; !ok {
+		return errors.New("Restore: archive does not contain a database")
+	}
+
+	l.Info().Msgf("Restore: Restored %d files from backup created at %s by Koito %s",
+		len(manifest.Files), manifest.CreatedAt.Format("2006-01-02 15:04:05"), manifest.KoitoVersion)
+	return nil
+}
+
+func extractFile(r io.Reader, dest string) (ManifestFile, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0744); err != nil {
+		return ManifestFile{}, err
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+func cleanDir(dir string) {
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		os.RemoveAll(filepath.Join(dir, e.Name()))
+	}
+}
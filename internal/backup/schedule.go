@@ -0,0 +1,232 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+)
+
+const (
+	BackupDir        = "backups"
+	backupPrefix     = "koito_"
+	backupExt        = ".db"
+	backupTimeLayout = "20060102-150405"
+)
+
+type File struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+type Status struct {
+	Enabled     bool       `json:"enabled"`
+	Running     bool       `json:"running"`
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	Backups     []File     `json:"backups"`
+}
+
+var (
+	statusLock sync.RWMutex
+	status     Status
+)
+
+// GetStatus returns the state of scheduled backups along with the backups currently on disk,
+// newest first.
+func GetStatus() Status {
+	statusLock.RLock()
+	ret := status
+	statusLock.RUnlock()
+
+	ret.Backups, _ = ListBackups()
+	if ret.Backups == nil {
+		ret.Backups = []File{}
+	}
+	if ret.LastSuccess == nil && len(ret.Backups) > 0 {
+		ret.LastSuccess = &ret.Backups[0].CreatedAt
+	}
+	return ret
+}
+
+// ListBackups returns the scheduled backups in <config>/backups, newest first.
+func ListBackups() ([]File, error) {
+	entries, err := os.ReadDir(filepath.Join(cfg.ConfigDir(), BackupDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ListBackups: %w", err)
+	}
+
+	var files []File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupExt) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupExt), time.UTC)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, File{Name: name, CreatedAt: t, Size: info.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].CreatedAt.After(files[j].CreatedAt)
+	})
+	return files, nil
+}
+
+// RunScheduler takes a backup every cfg.BackupInterval() until ctx is cancelled. The first
+// backup is taken as soon as the interval has passed since the newest existing backup.
+func RunScheduler(ctx context.Context, store db.BackupStore) {
+	l := logger.FromContext(ctx)
+
+	statusLock.Lock()
+	status.Enabled = true
+	statusLock.Unlock()
+
+	interval := cfg.BackupInterval()
+	var wait time.Duration
+	if backups, _ := ListBackups(); len(backups) > 0 {
+		wait = max(0, interval-time.Since(backups[0].CreatedAt))
+	}
+	l.Info().Msgf("RunScheduler: Next backup in %s", wait.Round(time.Second))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if err := RunScheduledBackup(ctx, store); err != nil {
+				l.Err(err).Msg("RunScheduler: Scheduled backup failed")
+			}
+			timer.Reset(interval)
+		}
+	}
+}
+
+// RunScheduledBackup snapshots the database into <config>/backups, verifies the snapshot with
+// an integrity check, and then prunes old backups according to the configured retention.
+func RunScheduledBackup(ctx context.Context, store db.BackupStore) error {
+	l := logger.FromContext(ctx)
+
+	now := time.Now().UTC()
+	statusLock.Lock()
+	status.Running = true
+	status.LastRun = &now
+	statusLock.Unlock()
+
+	err := takeBackup(ctx, store, now)
+
+	statusLock.Lock()
+	status.Running = false
+	if err != nil {
+		status.LastError = err.Error()
+	} else {
+		status.LastError = ""
+		status.LastSuccess = &now
+	}
+	statusLock.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("RunScheduledBackup: %w", err)
+	}
+
+	backups, err := ListBackups()
+	if err != nil {
+		return fmt.Errorf("RunScheduledBackup: %w", err)
+	}
+	daily, weekly, monthly := cfg.BackupRetention()
+	for _, f := range expiredBackups(backups, daily, weekly, monthly) {
+		l.Debug().Msgf("RunScheduledBackup: Removing expired backup %s", f.Name)
+		if err := os.Remove(filepath.Join(cfg.ConfigDir(), BackupDir, f.Name)); err != nil {
+			l.Err(err).Msgf("RunScheduledBackup: Failed to remove expired backup %s", f.Name)
+		}
+	}
+	return nil
+}
+
+func takeBackup(ctx context.Context, store db.BackupStore, now time.Time) error {
+	l := logger.FromContext(ctx)
+
+	dir := filepath.Join(cfg.ConfigDir(), BackupDir)
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		return err
+	}
+
+	dest := filepath.Join(dir, backupPrefix+now.Format(backupTimeLayout)+backupExt)
+	partial := dest + ".partial"
+	os.Remove(partial)
+
+	if err := store.VacuumInto(ctx, partial); err != nil {
+		os.Remove(partial)
+		return err
+	}
+	if err := store.IntegrityCheck(ctx, partial); err != nil {
+		os.Remove(partial)
+		return err
+	}
+	if err := os.Rename(partial, dest); err != nil {
+		os.Remove(partial)
+		return err
+	}
+
+	l.Info().Msgf("RunScheduledBackup: Backup saved to %s", dest)
+	return nil
+}
+
+// expiredBackups returns the backups that fall outside of the retention policy. The newest
+// backup of each of the last `daily` days, `weekly` ISO weeks and `monthly` months is kept,
+// and the newest backup overall is always kept. backups must be sorted newest first.
+func expiredBackups(backups []File, daily, weekly, monthly int) []File {
+	keep := make(map[string]bool)
+	if len(backups) > 0 {
+		keep[backups[0].Name] = true
+	}
+
+	bucket := func(n int, key func(time.Time) string) {
+		seen := make(map[string]bool)
+		for _, b := range backups {
+			if len(seen) >= n {
+				return
+			}
+			k := key(b.CreatedAt)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			keep[b.Name] = true
+		}
+	}
+	bucket(daily, func(t time.Time) string { return t.Format("2006-01-02") })
+	bucket(weekly, func(t time.Time) string {
+		y, w := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", y, w)
+	})
+	bucket(monthly, func(t time.Time) string { return t.Format("2006-01") })
+
+	var expired []File
+	for _, b := range backups {
+		if !keep[b.Name] {
+			expired = append(expired, b)
+		}
+	}
+	return expired
+}
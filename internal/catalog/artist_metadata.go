@@ -0,0 +1,97 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/google/uuid"
+)
+
+// artistMetadataOpts builds the update that stores the country, area, type, gender and sort
+// name of a MusicBrainz artist. The country falls back to the area's ISO code when MusicBrainz
+// has no country for the artist.
+func artistMetadataOpts(id int32, artist *mbz.MusicBrainzArtist) db.UpdateArtistOpts {
+	country := artist.Country
+	if country == "" && len(artist.Area.Iso3166_1Codes) > 0 {
+		country = artist.Area.Iso3166_1Codes[0]
+	}
+	return db.UpdateArtistOpts{
+		ID:             id,
+		MetadataUpdate: true,
+		Country:        strings.ToUpper(country),
+		Area:           artist.Area.Name,
+		Type:           artist.Type,
+		Gender:         artist.Gender,
+		SortName:       artist.SortName,
+		MetadataSource: db.InformationSourceMusicBrainz,
+	}
+}
+
+// BackfillArtistMetadataFromMusicBrainz looks up the country, area, type, gender and sort name
+// of every artist that has a MusicBrainz ID but whose details have never been looked up.
+func BackfillArtistMetadataFromMusicBrainz(
+	ctx context.Context,
+	store db.ArtistStore,
+	mbzCaller mbz.MusicBrainzCaller,
+) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("BackfillArtistMetadataFromMusicBrainz: Starting backfill of artist metadata from MusicBrainz")
+
+	var from int32 = 0
+
+	for {
+		l.Debug().Int32("ID", from).Msg("Fetching artists to backfill from ID")
+		artists, err := store.GetArtistsWithNoMetadataButHaveMbzID(ctx, from)
+		if err != nil {
+			return fmt.Errorf("BackfillArtistMetadataFromMusicBrainz: failed to fetch artists for metadata backfill: %w", err)
+		}
+
+		if len(artists) == 0 {
+			if from == 0 {
+				l.Info().Msg("BackfillArtistMetadataFromMusicBrainz: No artists need updating. Skipping backfill...")
+			} else {
+				l.Info().Msg("BackfillArtistMetadataFromMusicBrainz: Backfill complete")
+			}
+			return nil
+		}
+
+		for _, artist := range artists {
+			from = artist.ID
+
+			if artist.MbzID == nil || *artist.MbzID == uuid.Nil {
+				continue
+			}
+
+			l.Debug().
+				Str("name", artist.Name).
+				Str("mbz_id", artist.MbzID.String()).
+				Msg("BackfillArtistMetadataFromMusicBrainz: Backfilling artist metadata from MusicBrainz")
+
+			mbzArtist, err := mbzCaller.GetArtist(ctx, *artist.MbzID)
+			if err != nil {
+				l.Err(err).
+					Str("name", artist.Name).
+					Msg("BackfillArtistMetadataFromMusicBrainz: Failed to fetch artist from MusicBrainz")
+				continue
+			}
+
+			opts := artistMetadataOpts(artist.ID, mbzArtist)
+			err = store.UpdateArtist(ctx, opts)
+			if err != nil {
+				l.Err(err).
+					Str("name", artist.Name).
+					Msg("BackfillArtistMetadataFromMusicBrainz: Failed to update artist metadata")
+			} else {
+				l.Info().
+					Str("name", artist.Name).
+					Str("country", opts.Country).
+					Str("type", opts.Type).
+					Msg("BackfillArtistMetadataFromMusicBrainz: Artist metadata backfilled successfully")
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillArtistMetadata(t *testing.T) {
+	store := newTestDB()
+
+	setupTestDataWithMbzIDs(store, t)
+
+	ctx := context.Background()
+	artistMbzID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	artist := *mbzArtistData[artistMbzID]
+	artist.Type = "Group"
+	artist.Area = mbz.MusicBrainzArea{Name: "Japan", Iso3166_1Codes: []string{"JP"}}
+	mbzc := &mbz.MbzMockCaller{
+		Artists: map[uuid.UUID]*mbz.MusicBrainzArtist{artistMbzID: &artist},
+	}
+
+	err := catalog.BackfillArtistMetadataFromMusicBrainz(ctx, store, &mbz.MbzErrorCaller{})
+	assert.NoError(t, err)
+
+	a, err := store.GetArtist(ctx, db.GetArtistOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Empty(t, a.Country)
+
+	err = catalog.BackfillArtistMetadataFromMusicBrainz(ctx, store, mbzc)
+	assert.NoError(t, err)
+
+	a, err = store.GetArtist(ctx, db.GetArtistOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "JP", a.Country, "country should fall back to the area's iso code")
+	assert.Equal(t, "Japan", a.Area)
+	assert.Equal(t, "Group", a.Type)
+	assert.Empty(t, a.Gender)
+	assert.Equal(t, "Atarashii Gakko", a.SortName)
+
+	artists, err := store.GetArtistsWithNoMetadataButHaveMbzID(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, artists)
+}
+
+func TestSubmitListen_ArtistFetchedOnce(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	artistMbzID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	artist := *mbzArtistData[artistMbzID]
+	artist.Type = "Group"
+	counter := &countingMbzCaller{MbzMockCaller: &mbz.MbzMockCaller{
+		Artists: map[uuid.UUID]*mbz.MusicBrainzArtist{artistMbzID: &artist},
+	}}
+
+	err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller:    counter,
+		ArtistNames:  []string{"ATARASHII GAKKO!"},
+		Artist:       "ATARASHII GAKKO!",
+		ArtistMbzIDs: []uuid.UUID{artistMbzID},
+		TrackTitle:   "Tokyo Calling",
+		ReleaseTitle: "AG! Calling",
+		Time:         time.Now(),
+		UserID:       1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, counter.getArtist, "the new artist's aliases and details come from one request")
+
+	a, err := store.GetArtist(ctx, db.GetArtistOpts{MusicBrainzID: artistMbzID})
+	require.NoError(t, err)
+	assert.Equal(t, "Group", a.Type)
+	assert.Contains(t, a.Aliases, "新しい学校のリーダーズ")
+}
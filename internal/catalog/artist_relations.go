@@ -0,0 +1,102 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+// the MusicBrainz artist relationship types that are stored
+var artistRelationTypes = []string{"member of band", "is person", "collaboration", "subgroup"}
+
+// relationsFromMbzArtist returns the relationships of a MusicBrainz artist to other artists.
+func relationsFromMbzArtist(artist *mbz.MusicBrainzArtist) []models.ArtistRelation {
+	relations := make([]models.ArtistRelation, 0)
+	for _, r := range artist.Relations {
+		if r.Artist == nil || !slices.Contains(artistRelationTypes, r.Type) {
+			continue
+		}
+		id, err := uuid.Parse(r.Artist.ID)
+		if err != nil {
+			continue
+		}
+		relations = append(relations, models.ArtistRelation{
+			MbzID:     id,
+			Name:      r.Artist.Name,
+			Type:      r.Type,
+			Direction: r.Direction,
+		})
+	}
+	return relations
+}
+
+// BackfillArtistRelationsFromMusicBrainz fetches the relationships to other artists, such as
+// band memberships, of every artist that has a MusicBrainz ID but whose relationships have
+// never been fetched.
+func BackfillArtistRelationsFromMusicBrainz(
+	ctx context.Context,
+	store db.ArtistStore,
+	mbzCaller mbz.MusicBrainzCaller,
+) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("BackfillArtistRelationsFromMusicBrainz: Starting backfill of artist relationships from MusicBrainz")
+
+	var from int32 = 0
+
+	for {
+		l.Debug().Int32("ID", from).Msg("Fetching artists to backfill from ID")
+		artists, err := store.GetArtistsWithNoRelationsButHaveMbzID(ctx, from)
+		if err != nil {
+			return fmt.Errorf("BackfillArtistRelationsFromMusicBrainz: failed to fetch artists for relationship backfill: %w", err)
+		}
+
+		if len(artists) == 0 {
+			if from == 0 {
+				l.Info().Msg("BackfillArtistRelationsFromMusicBrainz: No artists need updating. Skipping backfill...")
+			} else {
+				l.Info().Msg("BackfillArtistRelationsFromMusicBrainz: Backfill complete")
+			}
+			return nil
+		}
+
+		for _, artist := range artists {
+			from = artist.ID
+
+			if artist.MbzID == nil || *artist.MbzID == uuid.Nil {
+				continue
+			}
+
+			l.Debug().
+				Str("name", artist.Name).
+				Str("mbz_id", artist.MbzID.String()).
+				Msg("BackfillArtistRelationsFromMusicBrainz: Backfilling artist relationships from MusicBrainz")
+
+			mbzArtist, err := mbzCaller.GetArtist(ctx, *artist.MbzID)
+			if err != nil {
+				l.Err(err).
+					Str("name", artist.Name).
+					Msg("BackfillArtistRelationsFromMusicBrainz: Failed to fetch artist from MusicBrainz")
+				continue
+			}
+
+			relations := relationsFromMbzArtist(mbzArtist)
+			err = store.SaveArtistRelations(ctx, artist.ID, relations)
+			if err != nil {
+				l.Err(err).
+					Str("name", artist.Name).
+					Msg("BackfillArtistRelationsFromMusicBrainz: Failed to save artist relationships")
+			} else {
+				l.Info().
+					Str("name", artist.Name).
+					Int("relations", len(relations)).
+					Msg("BackfillArtistRelationsFromMusicBrainz: Artist relationships backfilled successfully")
+			}
+		}
+	}
+}
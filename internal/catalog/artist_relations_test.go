@@ -0,0 +1,55 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillArtistRelations(t *testing.T) {
+	store := newTestDB()
+
+	setupTestDataWithMbzIDs(store, t)
+
+	ctx := context.Background()
+	artistMbzID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	artist := *mbzArtistData[artistMbzID]
+	artist.Relations = []mbz.MusicBrainzRelation{
+		{Type: "member of band", Direction: "backward", TargetType: "artist",
+			Artist: &mbz.MusicBrainzArtist{ID: "00000000-0000-0000-0000-000000000002", Name: "SUZUKA"}},
+		{Type: "subgroup", Direction: "forward", TargetType: "artist",
+			Artist: &mbz.MusicBrainzArtist{ID: "00000000-0000-0000-0000-000000000003", Name: "Subgroup"}},
+		// not an artist relationship type that is stored
+		{Type: "voice actor", Direction: "forward", TargetType: "artist",
+			Artist: &mbz.MusicBrainzArtist{ID: "00000000-0000-0000-0000-000000000004", Name: "Someone"}},
+	}
+	mbzc := &mbz.MbzMockCaller{
+		Artists: map[uuid.UUID]*mbz.MusicBrainzArtist{artistMbzID: &artist},
+	}
+
+	err := catalog.BackfillArtistRelationsFromMusicBrainz(ctx, store, &mbz.MbzErrorCaller{})
+	assert.NoError(t, err)
+	related, err := store.GetRelatedArtists(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, related)
+
+	err = catalog.BackfillArtistRelationsFromMusicBrainz(ctx, store, mbzc)
+	assert.NoError(t, err)
+
+	related, err = store.GetRelatedArtists(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, related, 2)
+	assert.Equal(t, "SUZUKA", related[0].Name)
+	assert.Equal(t, "member of band", related[0].Type)
+	assert.Equal(t, "backward", related[0].Direction)
+	assert.Equal(t, "subgroup", related[1].Type)
+
+	artists, err := store.GetArtistsWithNoRelationsButHaveMbzID(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, artists)
+}
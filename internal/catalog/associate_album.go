@@ -22,6 +22,7 @@ type AssociateAlbumOpts struct {
 	ReleaseGroupMbzID uuid.UUID
 	ReleaseName       string
 	TrackName         string // required
+	AlbumArtist       string
 	Mbzc              mbz.MusicBrainzCaller
 	SkipCacheImage    bool
 }
@@ -94,19 +95,6 @@ func createOrUpdateAlbumWithMbzReleaseID(ctx context.Context, d db.AlbumStore, o
 			return nil, fmt.Errorf("createOrUpdateAlbumWithMbzReleaseID: %w", err)
 		}
 		l.Debug().Msgf("Updated album '%s' with MusicBrainz Release ID", album.Title)
-
-		if opts.ReleaseGroupMbzID != uuid.Nil {
-			aliases, err := opts.Mbzc.GetReleaseTitles(ctx, opts.ReleaseGroupMbzID)
-			if err == nil {
-				l.Debug().Msgf("Associating aliases '%s' with Release '%s'", aliases, album.Title)
-				err = d.SaveAlbumAliases(ctx, album.ID, aliases, "MusicBrainz")
-				if err != nil {
-					l.Err(err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to save aliases")
-				}
-			} else {
-				l.Info().AnErr("err", err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to get release group from MusicBrainz")
-			}
-		}
 	} else if !errors.Is(err, db.ErrNotFound) {
 		l.Err(err).Msg("createOrUpdateAlbumWithMbzReleaseID: error while searching for album by MusicBrainz Release ID")
 		return nil, fmt.Errorf("createOrUpdateAlbumWithMbzReleaseID: %w", err)
@@ -156,20 +144,72 @@ func createOrUpdateAlbumWithMbzReleaseID(ctx context.Context, d db.AlbumStore, o
 			return nil, fmt.Errorf("createOrUpdateAlbumWithMbzReleaseID: %w", err)
 		}
 
-		if opts.ReleaseGroupMbzID != uuid.Nil {
-			aliases, err := opts.Mbzc.GetReleaseTitles(ctx, opts.ReleaseGroupMbzID)
-			if err == nil {
-				l.Debug().Msgf("Associating aliases '%s' with Release '%s'", aliases, album.Title)
-				err = d.SaveAlbumAliases(ctx, album.ID, aliases, "MusicBrainz")
-				if err != nil {
-					l.Err(err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to save aliases")
-				}
-			} else {
-				l.Info().AnErr("err", err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to get release group from MusicBrainz")
-			}
+		l.Info().Msgf("Created album '%s' with MusicBrainz Release ID", album.Title)
+	}
+
+	credits := creditsFromMbz(release.ArtistCredit, opts.Artists)
+	if credits == nil {
+		credits = albumCredits(opts.AlbumArtist, opts.Artists)
+	}
+	if len(credits) > 0 {
+		l.Debug().Msgf("Setting artist credit of '%s' to '%s'", album.Title, CreditString(credits))
+		if err := d.SaveAlbumCredits(ctx, album.ID, credits); err != nil {
+			l.Debug().AnErr("error", err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to save artist credit")
+			credits = nil
 		}
+	}
 
-		l.Info().Msgf("Created album '%s' with MusicBrainz Release ID", album.Title)
+	genres := mbz.GenreNames(release.Genres)
+	var rg *mbz.MusicBrainzReleaseGroup
+	if opts.ReleaseGroupMbzID != uuid.Nil {
+		rg, err = opts.Mbzc.GetReleaseGroup(ctx, opts.ReleaseGroupMbzID)
+		if err == nil {
+			aliases := mbz.ReleaseGroupToTitles(rg)
+			l.Debug().Msgf("Associating aliases '%s' with Release '%s'", aliases, album.Title)
+			err = d.SaveAlbumAliases(ctx, album.ID, aliases, "MusicBrainz")
+			if err != nil {
+				l.Err(err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to save aliases")
+			}
+			genres = append(genres, mbz.GenreNames(rg.Genres)...)
+		} else {
+			l.Info().AnErr("err", err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to get release group from MusicBrainz")
+			rg = nil
+		}
+	}
+	if rg == nil {
+		rg = release.ReleaseGroup
+	}
+	if rg != nil && rg.Type != "" {
+		l.Debug().Msgf("Setting types of '%s' to %s %v", album.Title, rg.Type, rg.SecondaryTypes)
+		err = d.UpdateAlbum(ctx, db.UpdateAlbumOpts{
+			ID:             album.ID,
+			TypeUpdate:     true,
+			PrimaryType:    rg.Type,
+			SecondaryTypes: rg.SecondaryTypes,
+			TypeSource:     db.InformationSourceMusicBrainz,
+		})
+		if err != nil {
+			l.Err(err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to save release types")
+		}
+	}
+	if date := mbz.ReleaseDate(release, rg); date != "" {
+		l.Debug().Msgf("Setting release date of '%s' to %s", album.Title, date)
+		err = d.UpdateAlbum(ctx, db.UpdateAlbumOpts{ID: album.ID, ReleaseDateUpdate: true, ReleaseDate: date})
+		if err != nil {
+			l.Err(err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to save release date")
+		}
+	}
+	if tracklist := tracklistFromRelease(release); len(tracklist) > 0 {
+		l.Debug().Msgf("Saving tracklist of '%s' with %d tracks", album.Title, len(tracklist))
+		if err := d.SaveAlbumTracklist(ctx, album.ID, tracklist); err != nil {
+			l.Err(err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to save tracklist")
+		}
+	}
+	if len(genres) > 0 {
+		l.Debug().Msgf("Associating tags '%s' with Release '%s'", genres, album.Title)
+		if err := d.SaveAlbumTags(ctx, album.ID, genres, "MusicBrainz"); err != nil {
+			l.Err(err).Msg("createOrUpdateAlbumWithMbzReleaseID: failed to save tags")
+		}
 	}
 
 	return &models.Album{
@@ -177,6 +217,7 @@ func createOrUpdateAlbumWithMbzReleaseID(ctx context.Context, d db.AlbumStore, o
 		MbzID:          &opts.ReleaseMbzID,
 		Title:          album.Title,
 		VariousArtists: album.VariousArtists,
+		Credits:        credits,
 	}, nil
 }
 
@@ -190,6 +231,7 @@ func matchAlbumByTitle(ctx context.Context, d db.AlbumStore, opts AssociateAlbum
 		releaseName = opts.TrackName
 	}
 
+	var credits []models.ArtistCredit
 	a, err := d.GetAlbum(ctx, db.GetAlbumOpts{
 		Title:    releaseName,
 		ArtistID: opts.Artists[0].ID,
@@ -240,10 +282,19 @@ func matchAlbumByTitle(ctx context.Context, d db.AlbumStore, opts AssociateAlbum
 			return nil, fmt.Errorf("matchAlbumByTitle: %w", err)
 		}
 		l.Info().Msgf("Created album '%s' with artist and title", a.Title)
+
+		if credits = albumCredits(opts.AlbumArtist, opts.Artists); len(credits) > 0 {
+			l.Debug().Msgf("Setting artist credit of '%s' to '%s'", a.Title, CreditString(credits))
+			if err := d.SaveAlbumCredits(ctx, a.ID, credits); err != nil {
+				l.Debug().AnErr("error", err).Msg("matchAlbumByTitle: failed to save artist credit")
+				credits = nil
+			}
+		}
 	}
 
 	return &models.Album{
-		ID:    a.ID,
-		Title: a.Title,
+		ID:      a.ID,
+		Title:   a.Title,
+		Credits: credits,
 	}, nil
 }
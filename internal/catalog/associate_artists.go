@@ -205,10 +205,15 @@ func matchArtistsByMBID(ctx context.Context, d db.ArtistStore, opts AssociateArt
 func resolveAliasOrCreateArtist(ctx context.Context, mbzID uuid.UUID, names []string, d db.ArtistStore, opts AssociateArtistsOpts) (*models.Artist, error) {
 	l := logger.FromContext(ctx)
 
-	aliases, err := opts.Mbzc.GetArtistPrimaryAliases(ctx, mbzID)
+	// the artist is fetched once, for both its aliases and its details
+	mbzArtist, err := opts.Mbzc.GetArtist(ctx, mbzID)
 	if err != nil {
 		return nil, fmt.Errorf("resolveAliasOrCreateArtist: %w", err)
 	}
+	if mbzArtist == nil {
+		return nil, errors.New("resolveAliasOrCreateArtist: artist could not be found by musicbrainz")
+	}
+	aliases := mbz.PrimaryAliases(ctx, mbzArtist)
 	l.Debug().Msgf("Got aliases %v from MusicBrainz", aliases)
 
 	for _, alias := range aliases {
@@ -224,6 +229,7 @@ func resolveAliasOrCreateArtist(ctx context.Context, mbzID uuid.UUID, names []st
 			if saveAliasErr := d.SaveArtistAliases(ctx, a.ID, aliases, "MusicBrainz"); saveAliasErr != nil {
 				return nil, fmt.Errorf("resolveAliasOrCreateArtist: %w", saveAliasErr)
 			}
+			saveArtistDetails(ctx, d, a.ID, mbzArtist)
 			return a, nil
 		}
 	}
@@ -267,9 +273,31 @@ func resolveAliasOrCreateArtist(ctx context.Context, mbzID uuid.UUID, names []st
 		return nil, fmt.Errorf("resolveAliasOrCreateArtist: %w", err)
 	}
 	l.Info().Msgf("Created artist '%s' with MusicBrainz Artist ID", canonical)
+	saveArtistDetails(ctx, d, u.ID, mbzArtist)
 	return u, nil
 }
 
+// saveArtistDetails stores the genres, country, type, gender, sort name and relationships of
+// the artist from MusicBrainz. Failures are only logged, as missing details should never
+// prevent a listen from being saved.
+func saveArtistDetails(ctx context.Context, d db.ArtistStore, id int32, artist *mbz.MusicBrainzArtist) {
+	l := logger.FromContext(ctx)
+	if err := d.UpdateArtist(ctx, artistMetadataOpts(id, artist)); err != nil {
+		l.Err(err).Msg("saveArtistDetails: failed to save artist metadata")
+	}
+	if err := d.SaveArtistRelations(ctx, id, relationsFromMbzArtist(artist)); err != nil {
+		l.Err(err).Msg("saveArtistDetails: failed to save artist relationships")
+	}
+	if len(artist.Genres) == 0 {
+		return
+	}
+	genres := mbz.GenreNames(artist.Genres)
+	l.Debug().Msgf("Associating tags '%s' with artist '%s'", genres, artist.Name)
+	if err := d.SaveArtistTags(ctx, id, genres, "MusicBrainz"); err != nil {
+		l.Err(err).Msg("saveArtistDetails: failed to save tags")
+	}
+}
+
 func matchArtistsByNames(ctx context.Context, names []string, existing []*models.Artist, d db.ArtistStore, opts AssociateArtistsOpts) ([]*models.Artist, error) {
 	l := logger.FromContext(ctx)
 	var result []*models.Artist
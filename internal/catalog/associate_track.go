@@ -18,7 +18,11 @@ type AssociateTrackOpts struct {
 	TrackMbzID uuid.UUID
 	TrackName  string
 	Duration   int32
+	Tags       []string // submitted by the client, saved with source "Submission"
 	Mbzc       mbz.MusicBrainzCaller
+
+	// the recording of TrackMbzID, shared with the rest of the listen so it's only fetched once
+	recording *mbzRecording
 }
 
 func AssociateTrack(ctx context.Context, d db.TrackStore, opts AssociateTrackOpts) (*models.Track, error) {
@@ -32,14 +36,29 @@ func AssociateTrack(ctx context.Context, d db.TrackStore, opts AssociateTrackOpt
 	if opts.AlbumID == 0 {
 		return nil, errors.New("AssociateTrack: release group id must be specified")
 	}
+	if opts.recording == nil {
+		opts.recording = &mbzRecording{mbzc: opts.Mbzc, id: opts.TrackMbzID}
+	}
+	var track *models.Track
+	var err error
 	// first, try to match track Mbz ID
 	if opts.TrackMbzID != uuid.Nil {
 		l.Debug().Msgf("Associating track '%s' by MusicBrainz recording ID", opts.TrackName)
-		return matchTrackByMbzID(ctx, d, opts)
+		track, err = matchTrackByMbzID(ctx, d, opts)
 	} else {
 		l.Debug().Msgf("Associating track '%s' by title and artist", opts.TrackName)
-		return matchTrackByTrackInfo(ctx, d, opts)
+		track, err = matchTrackByTrackInfo(ctx, d, opts)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Tags) > 0 {
+		l.Debug().Msgf("Associating tags '%s' with track '%s'", opts.Tags, track.Title)
+		if err := d.SaveTrackTags(ctx, track.ID, opts.Tags, "Submission"); err != nil {
+			l.Err(err).Msg("AssociateTrack: failed to save tags")
+		}
+	}
+	return track, nil
 }
 
 // If no match is found, will call matchTrackByTitleAndArtist and associate the Mbz ID with the result
@@ -50,6 +69,9 @@ func matchTrackByMbzID(ctx context.Context, d db.TrackStore, opts AssociateTrack
 	})
 	if err == nil {
 		l.Debug().Msgf("Found track '%s' by MusicBrainz ID", track.Title)
+		if track.AlbumID != opts.AlbumID {
+			return matchTrackOfSongByTrackInfo(ctx, d, opts, track)
+		}
 		return track, nil
 	} else if !errors.Is(err, db.ErrNotFound) {
 		return nil, fmt.Errorf("matchTrackByMbzID: %w", err)
@@ -78,6 +100,43 @@ func matchTrackByMbzID(ctx context.Context, d db.TrackStore, opts AssociateTrack
 	}
 }
 
+// matchTrackOfSongByTrackInfo is used when the recording was already heard on another release.
+// To keep the listen on the release it was heard on, the track on this release is used instead,
+// grouped into the same song as the track of the other release.
+func matchTrackOfSongByTrackInfo(ctx context.Context, d db.TrackStore, opts AssociateTrackOpts, other *models.Track) (*models.Track, error) {
+	l := logger.FromContext(ctx)
+	l.Debug().Msgf("Recording of track '%s' was found on another release, using track of song %d", opts.TrackName, other.SongID)
+	track, err := d.GetTrack(ctx, db.GetTrackOpts{
+		Title:     opts.TrackName,
+		ReleaseID: opts.AlbumID,
+		ArtistIDs: opts.ArtistIDs,
+	})
+	if err == nil {
+		if track.SongID != other.SongID {
+			if err := d.AddTracksToSong(ctx, other.SongID, []int32{track.ID}); err != nil {
+				return nil, fmt.Errorf("matchTrackOfSongByTrackInfo: %w", err)
+			}
+			track.SongID = other.SongID
+		}
+		return track, nil
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("matchTrackOfSongByTrackInfo: %w", err)
+	}
+	// the recording id stays with the track it was first heard on
+	track, err = d.SaveTrack(ctx, db.SaveTrackOpts{
+		AlbumID:   opts.AlbumID,
+		Title:     opts.TrackName,
+		ArtistIDs: opts.ArtistIDs,
+		Duration:  opts.Duration,
+		SongID:    other.SongID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("matchTrackOfSongByTrackInfo: %w", err)
+	}
+	l.Info().Msgf("Created track '%s' as part of song %d", opts.TrackName, other.SongID)
+	return track, nil
+}
+
 func matchTrackByTrackInfo(ctx context.Context, d db.TrackStore, opts AssociateTrackOpts) (*models.Track, error) {
 	l := logger.FromContext(ctx)
 	// try provided track title
@@ -92,8 +151,9 @@ func matchTrackByTrackInfo(ctx context.Context, d db.TrackStore, opts AssociateT
 	} else if !errors.Is(err, db.ErrNotFound) {
 		return nil, fmt.Errorf("matchTrackByTrackInfo: %w", err)
 	} else {
+		var mbzTrack *mbz.MusicBrainzTrack
 		if opts.TrackMbzID != uuid.Nil {
-			mbzTrack, err := opts.Mbzc.GetTrack(ctx, opts.TrackMbzID)
+			mbzTrack, err = opts.recording.get(ctx, opts.TrackMbzID)
 			if err == nil {
 				track, err := d.GetTrack(ctx, db.GetTrackOpts{
 					Title:     mbzTrack.Title,
@@ -124,6 +184,13 @@ func matchTrackByTrackInfo(ctx context.Context, d db.TrackStore, opts AssociateT
 		} else {
 			l.Info().Msgf("Created track '%s' with MusicBrainz Recording ID", opts.TrackName)
 		}
+		if mbzTrack != nil && len(mbzTrack.Genres) > 0 {
+			genres := mbz.GenreNames(mbzTrack.Genres)
+			l.Debug().Msgf("Associating tags '%s' with track '%s'", genres, t.Title)
+			if err := d.SaveTrackTags(ctx, t.ID, genres, "MusicBrainz"); err != nil {
+				l.Err(err).Msg("matchTrackByTrackInfo: failed to save tags")
+			}
+		}
 		return t, nil
 	}
 }
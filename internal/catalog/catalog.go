@@ -5,13 +5,16 @@ package catalog
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gabehf/koito/internal/cfg"
 	"github.com/gabehf/koito/internal/db"
 	"github.com/gabehf/koito/internal/logger"
 	"github.com/gabehf/koito/internal/mbz"
@@ -53,14 +56,20 @@ type SubmitListenOpts struct {
 	TrackTitle         string
 	RecordingMbzID     uuid.UUID
 	Duration           int32 // in seconds
+	PlayedDuration     int32 // in seconds, when the client reports how much of the track was played
 	ReleaseTitle       string
+	AlbumArtist        string // the artist the release is credited to, when the client knows it
 	ReleaseMbzID       uuid.UUID
 	ReleaseGroupMbzID  uuid.UUID
+	Tags               []string
 	Time               time.Time
 
 	UserID       int32
 	Client       string
 	IsNowPlaying bool
+
+	// the additional_info object of the submission, stored with the listen as submitted
+	AdditionalInfo json.RawMessage
 }
 
 const (
@@ -72,11 +81,40 @@ type submitListenStore interface {
 	db.AlbumStore
 	db.TrackStore
 	db.ListenStore
+	db.RewriteRuleStore
 }
 
 func SubmitListen(ctx context.Context, store submitListenStore, opts SubmitListenOpts) error {
 	l := logger.FromContext(ctx)
 
+	submission := submissionFromOpts(opts)
+	if reason := FilterListen(cfg.IngestFilter(), submission); reason != "" {
+		l.Info().Msgf("SubmitListen: Filtered listen '%s' by '%s' from client '%s': %s", opts.TrackTitle, opts.Artist, opts.Client, reason)
+		if opts.SkipSaveListen {
+			return nil
+		}
+		err := store.SaveFilteredListen(ctx, db.SaveFilteredListenOpts{
+			Time:   opts.Time.Truncate(time.Second),
+			UserID: opts.UserID,
+			Client: opts.Client,
+			Artist: opts.Artist,
+			Title:  opts.TrackTitle,
+			Album:  opts.ReleaseTitle,
+			Reason: reason,
+		})
+		if err != nil {
+			return fmt.Errorf("SubmitListen: %w", err)
+		}
+		return nil
+	}
+	rules, err := loadRewriteRules(ctx, store)
+	if err != nil {
+		return fmt.Errorf("SubmitListen: %w", err)
+	}
+	if dropped := rewriteListen(ctx, rules, &opts); dropped {
+		return nil
+	}
+
 	if opts.Artist == "" || opts.TrackTitle == "" {
 		return errors.New("track name and artist are required")
 	}
@@ -84,6 +122,103 @@ func SubmitListen(ctx context.Context, store submitListenStore, opts SubmitListe
 	// bandaid to ensure new activity does not have sub-second precision
 	opts.Time = opts.Time.Truncate(time.Second)
 
+	recording := &mbzRecording{mbzc: opts.MbzCaller, id: opts.RecordingMbzID}
+	artists, rg, track, err := associateListen(ctx, store, opts, recording)
+	if err != nil {
+		return fmt.Errorf("SubmitListen: %w", err)
+	}
+
+	if track.Duration == 0 {
+		if opts.Duration != 0 {
+			l.Debug().Msg("Updating duration using request information")
+			err := store.UpdateTrack(ctx, db.UpdateTrackOpts{
+				ID:       track.ID,
+				Duration: opts.Duration,
+			})
+			if err != nil {
+				l.Err(err).Msgf("Failed to update duration for track %s", track.Title)
+			} else {
+				l.Info().Msgf("Duration updated to %d for track '%s'", opts.Duration, track.Title)
+			}
+		} else if track.MbzID != nil && *track.MbzID != uuid.Nil {
+			l.Debug().Msg("Attempting to update duration using MusicBrainz ID")
+			mbztrack, err := recording.get(ctx, *track.MbzID)
+			if err != nil {
+				l.Err(err).Msg("Failed to make request to MusicBrainz")
+			} else {
+				err = store.UpdateTrack(ctx, db.UpdateTrackOpts{
+					ID:       track.ID,
+					Duration: int32(mbztrack.LengthMs / 1000),
+				})
+				if err != nil {
+					l.Err(err).Msgf("Failed to update duration for track %s", track.Title)
+				} else {
+					l.Info().Msgf("Duration updated to %d for track '%s'", mbztrack.LengthMs/1000, track.Title)
+				}
+				if len(mbztrack.Genres) > 0 {
+					if err := store.SaveTrackTags(ctx, track.ID, mbz.GenreNames(mbztrack.Genres), "MusicBrainz"); err != nil {
+						l.Err(err).Msgf("Failed to save tags for track %s", track.Title)
+					}
+				}
+			}
+		}
+	}
+
+	if opts.IsNowPlaying {
+		if track.Duration == 0 {
+			memkv.Store.Set(strconv.Itoa(int(opts.UserID)), track.ID)
+		} else {
+			memkv.Store.Set(strconv.Itoa(int(opts.UserID)), track.ID, time.Duration(track.Duration)*time.Second)
+		}
+	}
+
+	if opts.SkipSaveListen {
+		return nil
+	}
+
+	artistStr := CreditString(track.Credits)
+	if artistStr == "" {
+		artistStr = buildArtistStr(artists)
+	}
+	l.Info().Msgf("Received listen: '%s' by %s, from release '%s'", track.Title, artistStr, rg.Title)
+
+	return store.SaveListen(ctx, db.SaveListenOpts{
+		TrackID:    track.ID,
+		Time:       opts.Time,
+		UserID:     opts.UserID,
+		Client:     opts.Client,
+		Submission: submission,
+	})
+}
+
+// mbzRecording fetches the MusicBrainz recording submitted with a listen at most once, as
+// matching the track, filling in its duration and crediting its artists can all need it.
+type mbzRecording struct {
+	mbzc    mbz.MusicBrainzCaller
+	id      uuid.UUID
+	fetched bool
+	track   *mbz.MusicBrainzTrack
+	err     error
+}
+
+// get returns the recording with the id. Only the recording of the listen is kept, any other
+// recording is fetched every time.
+func (r *mbzRecording) get(ctx context.Context, id uuid.UUID) (*mbz.MusicBrainzTrack, error) {
+	if id != r.id {
+		return r.mbzc.GetTrack(ctx, id)
+	}
+	if !r.fetched {
+		r.track, r.err = r.mbzc.GetTrack(ctx, id)
+		r.fetched = true
+	}
+	return r.track, r.err
+}
+
+// associateListen matches a listen to its artists, album and track, creating any of them that
+// are not in the catalog yet.
+func associateListen(ctx context.Context, store submitListenStore, opts SubmitListenOpts, recording *mbzRecording) ([]*models.Artist, *models.Album, *models.Track, error) {
+	l := logger.FromContext(ctx)
+
 	artists, err := AssociateArtists(
 		ctx,
 		store,
@@ -98,7 +233,7 @@ func SubmitListen(ctx context.Context, store submitListenStore, opts SubmitListe
 		})
 	if err != nil {
 		l.Err(err).Msg("Failed to associate artists to listen")
-		return fmt.Errorf("SubmitListen: %w", err)
+		return nil, nil, nil, fmt.Errorf("associateListen: %w", err)
 	} else if len(artists) < 1 {
 		l.Debug().Msg("Failed to associate any artists to release")
 	}
@@ -114,13 +249,14 @@ func SubmitListen(ctx context.Context, store submitListenStore, opts SubmitListe
 		ReleaseGroupMbzID: opts.ReleaseGroupMbzID,
 		ReleaseName:       opts.ReleaseTitle,
 		TrackName:         opts.TrackTitle,
+		AlbumArtist:       opts.AlbumArtist,
 		Mbzc:              opts.MbzCaller,
 		Artists:           artists,
 		SkipCacheImage:    opts.SkipCacheImage,
 	})
 	if err != nil {
 		l.Error().Err(err).Msg("Failed to associate release group to listen")
-		return fmt.Errorf("SubmitListen: %w", err)
+		return nil, nil, nil, fmt.Errorf("associateListen: %w", err)
 	}
 	l.Debug().Any("album", rg).Msg("Matched listen to release")
 
@@ -136,65 +272,54 @@ func SubmitListen(ctx context.Context, store submitListenStore, opts SubmitListe
 		TrackMbzID: opts.RecordingMbzID,
 		TrackName:  opts.TrackTitle,
 		Duration:   opts.Duration,
+		Tags:       opts.Tags,
 		Mbzc:       opts.MbzCaller,
+		recording:  recording,
 	})
 	if err != nil {
 		l.Error().Err(err).Msg("Failed to associate track to listen")
-		return fmt.Errorf("SubmitListen: %w", err)
+		return nil, nil, nil, fmt.Errorf("associateListen: %w", err)
 	}
 	l.Debug().Any("track", track).Msg("Matched listen to track")
 
-	if track.Duration == 0 {
-		if opts.Duration != 0 {
-			l.Debug().Msg("Updating duration using request information")
-			err := store.UpdateTrack(ctx, db.UpdateTrackOpts{
-				ID:       track.ID,
-				Duration: opts.Duration,
-			})
-			if err != nil {
-				l.Err(err).Msgf("Failed to update duration for track %s", track.Title)
-			} else {
-				l.Info().Msgf("Duration updated to %d for track '%s'", opts.Duration, track.Title)
-			}
-		} else if track.MbzID != nil && *track.MbzID != uuid.Nil {
-			l.Debug().Msg("Attempting to update duration using MusicBrainz ID")
-			mbztrack, err := opts.MbzCaller.GetTrack(ctx, *track.MbzID)
-			if err != nil {
-				l.Err(err).Msg("Failed to make request to MusicBrainz")
-			} else {
-				err = store.UpdateTrack(ctx, db.UpdateTrackOpts{
-					ID:       track.ID,
-					Duration: int32(mbztrack.LengthMs / 1000),
-				})
-				if err != nil {
-					l.Err(err).Msgf("Failed to update duration for track %s", track.Title)
-				} else {
-					l.Info().Msgf("Duration updated to %d for track '%s'", mbztrack.LengthMs/1000, track.Title)
-				}
-			}
-		}
-	}
+	track.Credits = saveTrackCredits(ctx, store, opts, recording, artists, track)
 
-	if opts.IsNowPlaying {
-		if track.Duration == 0 {
-			memkv.Store.Set(strconv.Itoa(int(opts.UserID)), track.ID)
-		} else {
-			memkv.Store.Set(strconv.Itoa(int(opts.UserID)), track.ID, time.Duration(track.Duration)*time.Second)
-		}
-	}
+	return artists, rg, track, nil
+}
 
-	if opts.SkipSaveListen {
-		return nil
+// rewriteListen applies the rewrite rules to the listen and reports whether the listen was
+// dropped. When the artists are rewritten, the MusicBrainz IDs submitted with the listen are
+// cleared, as they may belong to the artists before the rewrite.
+func rewriteListen(ctx context.Context, rules []*rewriteRule, opts *SubmitListenOpts) bool {
+	l := logger.FromContext(ctx)
+	if len(rules) == 0 {
+		return false
 	}
-
-	l.Info().Msgf("Received listen: '%s' by %s, from release '%s'", track.Title, buildArtistStr(artists), rg.Title)
-
-	return store.SaveListen(ctx, db.SaveListenOpts{
-		TrackID: track.ID,
-		Time:    opts.Time,
-		UserID:  opts.UserID,
-		Client:  opts.Client,
+	res := applyRewriteRules(rules, ListenMetadata{
+		Artist:      opts.Artist,
+		ArtistNames: opts.ArtistNames,
+		Title:       opts.TrackTitle,
+		Album:       opts.ReleaseTitle,
+		Client:      opts.Client,
 	})
+	if len(res.Applied) == 0 {
+		return false
+	}
+	if res.Dropped {
+		l.Info().Msgf("Listen '%s' by %s dropped by rewrite rule %d", opts.TrackTitle, opts.Artist, res.Applied[len(res.Applied)-1])
+		return true
+	}
+	l.Debug().Any("rules", res.Applied).Msgf("Rewrote listen '%s' by %s to '%s' by %s", opts.TrackTitle, opts.Artist, res.Title, res.Artist)
+	if res.Artist != opts.Artist || !slices.Equal(res.ArtistNames, opts.ArtistNames) {
+		opts.ArtistMbzIDs = nil
+		opts.ArtistMbidMappings = nil
+	}
+	opts.Artist = res.Artist
+	opts.ArtistNames = res.ArtistNames
+	opts.TrackTitle = res.Title
+	opts.ReleaseTitle = res.Album
+	opts.Client = res.Client
+	return false
 }
 
 func buildArtistStr(artists []*models.Artist) string {
@@ -0,0 +1,218 @@
+package catalog
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+// ParseArtistCredits parses the artist and title strings of a listen into an ordered artist
+// credit, keeping the text between the artists as join phrases. Artists are split the same way
+// as ParseArtists splits them, so "A feat. B & C" is credited as A, B and C joined by " feat. "
+// and " & ". The credits only have names set.
+func ParseArtistCredits(artist string, title string, addlSeparators []*regexp.Regexp) []models.ArtistCredit {
+	var credits []models.ArtistCredit
+
+	add := func(name, join string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return
+		}
+		for _, c := range credits {
+			if strings.EqualFold(c.Name, name) {
+				return
+			}
+		}
+		if len(credits) > 0 {
+			credits[len(credits)-1].JoinPhrase = join
+		}
+		credits = append(credits, models.ArtistCredit{Name: name, CreditedName: name})
+	}
+	addAll := func(s, join string, seps []*regexp.Regexp) {
+		names, joins := splitCredit(s, seps)
+		for i, name := range names {
+			if i > 0 {
+				join = joins[i-1]
+			}
+			add(name, join)
+		}
+	}
+
+	var feat string
+	for _, re := range bracketFeatPatterns {
+		if matches := re.FindStringSubmatch(artist); matches != nil {
+			artist = strings.Replace(artist, matches[0], "", 1)
+			feat = matches[1]
+			break
+		}
+	}
+	if feat == "" {
+		if matches := inlineFeatPattern.FindStringSubmatch(artist); matches != nil {
+			artist = strings.Replace(artist, matches[0], "", 1)
+			feat = matches[1]
+		}
+	}
+
+	addAll(artist, "", addlSeparators)
+	if feat != "" {
+		addAll(feat, " feat. ", []*regexp.Regexp{featSplitDelimiters})
+	}
+
+	var titleFeat string
+	for _, re := range bracketFeatPatterns {
+		if matches := re.FindStringSubmatch(title); matches != nil {
+			titleFeat = matches[1]
+			break
+		}
+	}
+	if titleFeat == "" {
+		if matches := inlineFeatPattern.FindStringSubmatch(title); matches != nil {
+			titleFeat = matches[1]
+		}
+	}
+	if titleFeat != "" {
+		join := " feat. "
+		if feat != "" {
+			join = ", "
+		}
+		addAll(titleFeat, join, []*regexp.Regexp{featSplitDelimiters})
+	}
+
+	return credits
+}
+
+// splitCredit splits s at the matches of the separators, returning the parts and the
+// separators between them.
+func splitCredit(s string, seps []*regexp.Regexp) ([]string, []string) {
+	var matches [][]int
+	for _, re := range seps {
+		matches = append(matches, re.FindAllStringIndex(s, -1)...)
+	}
+	slices.SortFunc(matches, func(a, b []int) int { return a[0] - b[0] })
+
+	var names, joins []string
+	start := 0
+	for _, m := range matches {
+		// skip empty and overlapping matches
+		if m[0] < start || m[0] == m[1] {
+			continue
+		}
+		names = append(names, s[start:m[0]])
+		joins = append(joins, s[m[0]:m[1]])
+		start = m[1]
+	}
+	names = append(names, s[start:])
+	return names, joins
+}
+
+// matchCredits sets the artist of each credit to the matching artist of the item, by
+// MusicBrainz ID or by name. Returns nil when any credit can't be matched, or when two credits
+// match the same artist.
+func matchCredits(credits []models.ArtistCredit, mbzIDs []uuid.UUID, artists []*models.Artist) []models.ArtistCredit {
+	if len(credits) == 0 {
+		return nil
+	}
+	ret := make([]models.ArtistCredit, 0, len(credits))
+	for i, c := range credits {
+		var match *models.Artist
+		for _, a := range artists {
+			if i < len(mbzIDs) && mbzIDs[i] != uuid.Nil && a.MbzID != nil && *a.MbzID == mbzIDs[i] {
+				match = a
+				break
+			}
+		}
+		if match == nil {
+			for _, a := range artists {
+				if artistExists(c.CreditedName, []*models.Artist{a}) {
+					match = a
+					break
+				}
+			}
+		}
+		if match == nil || slices.ContainsFunc(ret, func(r models.ArtistCredit) bool { return r.ID == match.ID }) {
+			return nil
+		}
+		c.ID = match.ID
+		c.Name = match.Name
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+// creditsFromMbz converts a MusicBrainz artist credit to the credits of an item by the artists.
+// Returns nil when the credit doesn't match the artists.
+func creditsFromMbz(credit []mbz.MusicBrainzArtistCredit, artists []*models.Artist) []models.ArtistCredit {
+	credits := make([]models.ArtistCredit, len(credit))
+	mbzIDs := make([]uuid.UUID, len(credit))
+	for i, c := range credit {
+		name := c.Name
+		if name == "" {
+			name = c.Artist.Name
+		}
+		credits[i] = models.ArtistCredit{Name: name, CreditedName: name, JoinPhrase: c.JoinPhrase}
+		mbzIDs[i], _ = uuid.Parse(c.Artist.ID)
+	}
+	if len(credits) > 0 {
+		credits[len(credits)-1].JoinPhrase = ""
+	}
+	return matchCredits(credits, mbzIDs, artists)
+}
+
+// saveTrackCredits stores the artist credit of a track that has none yet, and returns the
+// credit of the track. The credit of MusicBrainz is used when the track has a recording ID and
+// hasn't been heard before, so that tracks whose credit can't be matched don't cause a lookup on
+// every listen. Otherwise, the credit is parsed from the submitted artist and title. Failures
+// are only logged, as a missing credit should never prevent a listen from being saved.
+func saveTrackCredits(ctx context.Context, store db.TrackStore, opts SubmitListenOpts, recording *mbzRecording, artists []*models.Artist, track *models.Track) []models.ArtistCredit {
+	l := logger.FromContext(ctx)
+	if len(track.Credits) > 0 {
+		return track.Credits
+	}
+
+	var credits []models.ArtistCredit
+	if opts.RecordingMbzID != uuid.Nil && track.ListenCount == 0 {
+		mbzTrack, err := recording.get(ctx, opts.RecordingMbzID)
+		if err != nil {
+			l.Debug().AnErr("error", err).Msg("saveTrackCredits: failed to get recording from MusicBrainz")
+		} else {
+			credits = creditsFromMbz(mbzTrack.ArtistCredit, artists)
+		}
+	}
+	if credits == nil {
+		credits = matchCredits(ParseArtistCredits(opts.Artist, opts.TrackTitle, cfg.ArtistSeparators()), nil, artists)
+	}
+	if len(credits) == 0 {
+		return nil
+	}
+	if err := store.SaveTrackCredits(ctx, track.ID, credits); err != nil {
+		l.Debug().AnErr("error", err).Msgf("saveTrackCredits: failed to save credits of track '%s'", track.Title)
+		return nil
+	}
+	return credits
+}
+
+// albumCredits parses the artist credit of a new album from the album artist submitted with the
+// listen. Returns nil when there is none, or when it doesn't match the artists of the album.
+func albumCredits(albumArtist string, artists []*models.Artist) []models.ArtistCredit {
+	if albumArtist == "" {
+		return nil
+	}
+	return matchCredits(ParseArtistCredits(albumArtist, "", cfg.ArtistSeparators()), nil, artists)
+}
+
+// CreditString renders an artist credit as it is credited, like "A feat. B".
+func CreditString(credits []models.ArtistCredit) string {
+	var b strings.Builder
+	for _, c := range credits {
+		b.WriteString(c.CreditedName + c.JoinPhrase)
+	}
+	return b.String()
+}
@@ -0,0 +1,156 @@
+package catalog_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArtistCredits(t *testing.T) {
+	separators := []*regexp.Regexp{regexp.MustCompile(`\s+·\s+`)}
+	cases := []struct {
+		artist, title string
+		want          string
+		names         []string
+	}{
+		{"A feat. B & C", "Song", "A feat. B & C", []string{"A", "B", "C"}},
+		{"A · B", "Song (feat. C)", "A · B feat. C", []string{"A", "B", "C"}},
+		{"A (feat. B)", "Song [feat. C, A]", "A feat. B, C", []string{"A", "B", "C"}},
+		{"A x B", "Song", "A x B", []string{"A x B"}},
+	}
+	for _, c := range cases {
+		credits := catalog.ParseArtistCredits(c.artist, c.title, separators)
+		names := make([]string, len(credits))
+		for i, credit := range credits {
+			names[i] = credit.CreditedName
+		}
+		assert.Equal(t, c.names, names, c.artist)
+		assert.Equal(t, c.want, catalog.CreditString(credits), c.artist)
+	}
+}
+
+func TestSubmitListen_CreditsFromSubmission(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller:    &mbz.MbzErrorCaller{},
+		Artist:       "Artist A feat. Artist B",
+		TrackTitle:   "Song",
+		ReleaseTitle: "Album",
+		AlbumArtist:  "Artist B feat. Artist A",
+		Time:         time.Now(),
+		UserID:       1,
+	})
+	require.NoError(t, err)
+
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	require.Len(t, track.Credits, 2)
+	assert.Equal(t, "Artist A", track.Credits[0].Name)
+	assert.Equal(t, " feat. ", track.Credits[0].JoinPhrase)
+	assert.Equal(t, "Artist B", track.Credits[1].Name)
+	assert.Equal(t, "Artist A feat. Artist B", catalog.CreditString(track.Credits))
+
+	// the album is credited to the album artist, not the artist of the track
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: track.AlbumID})
+	require.NoError(t, err)
+	assert.Equal(t, "Artist B feat. Artist A", catalog.CreditString(album.Credits))
+
+	// albums without an album artist are not credited from the artist of a track
+	err = catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller:    &mbz.MbzErrorCaller{},
+		Artist:       "Artist A feat. Artist B",
+		TrackTitle:   "Other Song",
+		ReleaseTitle: "Other Album",
+		Time:         time.Now(),
+		UserID:       1,
+	})
+	require.NoError(t, err)
+	album, err = store.GetAlbum(ctx, db.GetAlbumOpts{Title: "Other Album", ArtistID: track.Credits[0].ID})
+	require.NoError(t, err)
+	assert.Empty(t, album.Credits)
+
+	// artists added later are credited last
+	c, err := store.SaveArtist(ctx, db.SaveArtistOpts{Name: "Artist C"})
+	require.NoError(t, err)
+	require.NoError(t, store.AddArtistsToTrack(ctx, db.AddArtistsToTrackOpts{TrackID: 1, ArtistIDs: []int32{c.ID}}))
+	track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Artist A feat. Artist B & Artist C", catalog.CreditString(track.Credits))
+
+	assert.ErrorIs(t, store.SaveTrackCredits(ctx, 1, []models.ArtistCredit{{ID: 999}}), db.ErrNotFound)
+}
+
+func TestSubmitListen_CreditsFromMusicBrainz(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	artistA := uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	artistB := uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	recording := uuid.MustParse("00000000-0000-0000-0000-000000000c01")
+	mbzc := &mbz.MbzMockCaller{
+		Tracks: map[uuid.UUID]*mbz.MusicBrainzTrack{
+			recording: {
+				Title: "Song",
+				ArtistCredit: []mbz.MusicBrainzArtistCredit{
+					{Artist: mbz.MusicBrainzArtist{ID: artistA.String(), Name: "Artist A"}, Name: "A-chan", JoinPhrase: " x "},
+					{Artist: mbz.MusicBrainzArtist{ID: artistB.String(), Name: "Artist B"}, Name: "Artist B"},
+				},
+			},
+		},
+	}
+
+	counter := &countingMbzCaller{MbzMockCaller: mbzc}
+	err := catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller: counter,
+		Artist:    "A-chan x Artist B",
+		ArtistMbidMappings: []catalog.ArtistMbidMap{
+			{Artist: "Artist A", Mbid: artistA},
+			{Artist: "Artist B", Mbid: artistB},
+		},
+		TrackTitle:     "Song",
+		RecordingMbzID: recording,
+		ReleaseTitle:   "Album",
+		Time:           time.Now(),
+		UserID:         1,
+	})
+	require.NoError(t, err)
+	// matching the track, filling in its duration and crediting it share one request
+	assert.Equal(t, 1, counter.getTrack)
+
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: recording})
+	require.NoError(t, err)
+	require.Len(t, track.Credits, 2)
+	assert.Equal(t, "Artist A", track.Credits[0].Name)
+	assert.Equal(t, "A-chan", track.Credits[0].CreditedName)
+	assert.Equal(t, " x ", track.Credits[0].JoinPhrase)
+	assert.Equal(t, "Artist B", track.Credits[1].CreditedName)
+	assert.Equal(t, "", track.Credits[1].JoinPhrase)
+}
+
+// countingMbzCaller counts the recordings and artists fetched from MusicBrainz.
+type countingMbzCaller struct {
+	*mbz.MbzMockCaller
+	getTrack  int
+	getArtist int
+}
+
+func (c *countingMbzCaller) GetArtist(ctx context.Context, id uuid.UUID) (*mbz.MusicBrainzArtist, error) {
+	c.getArtist++
+	return c.MbzMockCaller.GetArtist(ctx, id)
+}
+
+func (c *countingMbzCaller) GetTrack(ctx context.Context, id uuid.UUID) (*mbz.MusicBrainzTrack, error) {
+	c.getTrack++
+	return c.MbzMockCaller.GetTrack(ctx, id)
+}
@@ -0,0 +1,277 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/gosimple/unidecode"
+)
+
+// The signals that make two items look like duplicates.
+const (
+	DuplicateReasonName          = "name"
+	DuplicateReasonAlias         = "alias"
+	DuplicateReasonSharedAlbums  = "shared_albums"
+	DuplicateReasonSharedArtists = "shared_artists"
+	DuplicateReasonSharedTracks  = "shared_tracks"
+	DuplicateReasonSharedMbzIDs  = "shared_mbids"
+	DuplicateReasonSameAlbum     = "same_album"
+)
+
+// pairs scoring lower than this are not suggested
+const minDuplicateScore = 0.5
+
+var (
+	featuringRe       = regexp.MustCompile(`(?i)[(\[]?\s*\b(feat\.?|ft\.?|featuring)\s[^)\]]*[)\]]?`)
+	editionRe         = regexp.MustCompile(`(?i)\s*([(\[][^)\]]*|-\s.*)\b(deluxe|edition|remaster(ed)?|expanded|anniversary|bonus|version)\b[^)\]]*[)\]]?`)
+	leadingArticleRe  = regexp.MustCompile(`(?i)^the\s+`)
+	latinOnlyCharsRe  = regexp.MustCompile(`^[\p{Latin}\p{P}\p{S}\p{N}\p{Zs}]+$`)
+	ampersandReplacer = strings.NewReplacer("&", " and ", "+", " and ")
+)
+
+type mergeStore interface {
+	db.ArtistStore
+	db.AlbumStore
+	db.TrackStore
+	db.MergeSuggestionStore
+}
+
+// duplicateKey normalizes a name for comparison. Case, accents on Latin letters, punctuation and
+// spacing are ignored, along with a leading "The" for artists, edition descriptors such as
+// "(Deluxe)" for albums, and featured artists for tracks.
+func duplicateKey(entityType, name string) string {
+	switch entityType {
+	case MatchEntityArtist:
+		name = leadingArticleRe.ReplaceAllString(strings.TrimSpace(name), "")
+	case MatchEntityAlbum:
+		name = editionRe.ReplaceAllString(name, "")
+	case MatchEntityTrack:
+		name = featuringRe.ReplaceAllString(name, "")
+	}
+	// only Latin script is transliterated, so names in other scripts are never equated with
+	// their romanizations
+	if latinOnlyCharsRe.MatchString(name) {
+		name = unidecode.Unidecode(name)
+	}
+	name = ampersandReplacer.Replace(strings.ToLower(name))
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func duplicateKeys(entityType string, names []string) []string {
+	var keys []string
+	for _, name := range names {
+		if key := duplicateKey(entityType, name); key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// overlap returns the share of the smaller of two sets that is also in the other set.
+func overlap[T comparable](a, b []T) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var shared int
+	for _, v := range a {
+		if slices.Contains(b, v) {
+			shared++
+		}
+	}
+	return float64(shared) / float64(min(len(a), len(b)))
+}
+
+// FindDuplicates looks for likely duplicate artists, albums and tracks, and replaces the pending
+// merge suggestions with what it finds. Items are paired up when their normalized names or
+// aliases are the same, and each pair is scored on the structure the items share:
+//   - artists get a higher score when they have albums with the same title,
+//   - albums must share an artist, and score higher the more tracks and recording MBIDs they share,
+//   - tracks must be on the same album.
+//
+// Artists and tracks with different MBIDs are never suggested, as MusicBrainz tells them apart.
+// It returns the number of suggestions made.
+func FindDuplicates(ctx context.Context, store mergeStore) (int, error) {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("FindDuplicates: Looking for duplicate artists, albums and tracks")
+
+	var total int
+	for _, entityType := range []string{MatchEntityArtist, MatchEntityAlbum, MatchEntityTrack} {
+		candidates, err := store.GetDuplicateCandidates(ctx, entityType)
+		if err != nil {
+			return total, fmt.Errorf("FindDuplicates: %w", err)
+		}
+		suggestions := findDuplicatePairs(entityType, candidates)
+		if err := store.ReplaceMergeSuggestions(ctx, entityType, suggestions); err != nil {
+			return total, fmt.Errorf("FindDuplicates: %w", err)
+		}
+		l.Info().Msgf("FindDuplicates: Found %d likely duplicate %ss", len(suggestions), entityType)
+		total += len(suggestions)
+	}
+	return total, nil
+}
+
+func findDuplicatePairs(entityType string, candidates []*db.DuplicateCandidate) []db.SaveMergeSuggestionOpts {
+	primary := make([]string, len(candidates))
+	buckets := make(map[string][]int)
+	for i, c := range candidates {
+		primary[i] = duplicateKey(entityType, c.Name)
+		for _, key := range duplicateKeys(entityType, append([]string{c.Name}, c.Aliases...)) {
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	var suggestions []db.SaveMergeSuggestionOpts
+	for _, bucket := range buckets {
+		for x := 0; x < len(bucket); x++ {
+			for y := x + 1; y < len(bucket); y++ {
+				pair := [2]int{min(bucket[x], bucket[y]), max(bucket[x], bucket[y])}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+				a, b := candidates[pair[0]], candidates[pair[1]]
+				score, reasons := scoreDuplicate(entityType, a, b, primary[pair[0]] == primary[pair[1]])
+				if score < minDuplicateScore {
+					continue
+				}
+				from, to := a, b
+				if mergeTargetFirst(a, b) {
+					from, to = b, a
+				}
+				suggestions = append(suggestions, db.SaveMergeSuggestionOpts{
+					FromID:  from.ID,
+					ToID:    to.ID,
+					Score:   score,
+					Reasons: reasons,
+				})
+			}
+		}
+	}
+	slices.SortFunc(suggestions, func(a, b db.SaveMergeSuggestionOpts) int {
+		if a.ToID != b.ToID {
+			return int(a.ToID - b.ToID)
+		}
+		return int(a.FromID - b.FromID)
+	})
+	return suggestions
+}
+
+// scoreDuplicate scores how likely two items with a matching name or alias are to be the same.
+// A score of 0 means they are not duplicates.
+func scoreDuplicate(entityType string, a, b *db.DuplicateCandidate, sameName bool) (float64, []string) {
+	var score float64
+	var reasons []string
+	if sameName {
+		score, reasons = 0.5, []string{DuplicateReasonName}
+	} else {
+		score, reasons = 0.4, []string{DuplicateReasonAlias}
+	}
+	differentMbzIDs := a.MbzID != nil && b.MbzID != nil && *a.MbzID != *b.MbzID
+
+	switch entityType {
+	case MatchEntityArtist:
+		if differentMbzIDs {
+			return 0, nil
+		}
+		score += 0.1
+		if overlap(duplicateKeys(MatchEntityAlbum, a.Titles), duplicateKeys(MatchEntityAlbum, b.Titles)) > 0 {
+			score += 0.3
+			reasons = append(reasons, DuplicateReasonSharedAlbums)
+		}
+	case MatchEntityAlbum:
+		if overlap(a.ArtistIDs, b.ArtistIDs) == 0 {
+			return 0, nil
+		}
+		score += 0.1
+		reasons = append(reasons, DuplicateReasonSharedArtists)
+		if o := overlap(duplicateKeys(MatchEntityTrack, a.Titles), duplicateKeys(MatchEntityTrack, b.Titles)); o > 0 {
+			score += 0.2 * o
+			reasons = append(reasons, DuplicateReasonSharedTracks)
+		}
+		if overlap(a.RecordingMbzIDs, b.RecordingMbzIDs) > 0 {
+			score += 0.2
+			reasons = append(reasons, DuplicateReasonSharedMbzIDs)
+		}
+	case MatchEntityTrack:
+		if differentMbzIDs || a.AlbumID != b.AlbumID {
+			return 0, nil
+		}
+		score += 0.3
+		reasons = append(reasons, DuplicateReasonSameAlbum)
+		if overlap(a.ArtistIDs, b.ArtistIDs) > 0 {
+			score += 0.2
+			reasons = append(reasons, DuplicateReasonSharedArtists)
+		}
+	}
+	return min(score, 1), reasons
+}
+
+// mergeTargetFirst reports whether a, rather than b, should be kept when the two are merged. The
+// item with more listens is kept, then the one with an MBID, then the older one.
+func mergeTargetFirst(a, b *db.DuplicateCandidate) bool {
+	if a.ListenCount != b.ListenCount {
+		return a.ListenCount > b.ListenCount
+	}
+	if (a.MbzID != nil) != (b.MbzID != nil) {
+		return a.MbzID != nil
+	}
+	return a.ID < b.ID
+}
+
+// MergeResult reports the outcome of accepting one merge suggestion.
+type MergeResult struct {
+	ID     int32  `json:"id"`
+	Merged bool   `json:"merged"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AcceptMergeSuggestions merges the items of each suggestion, in order. Suggestions that involve
+// an item merged away by an earlier suggestion no longer exist and are reported as not found.
+func AcceptMergeSuggestions(ctx context.Context, store mergeStore, ids []int32) []MergeResult {
+	l := logger.FromContext(ctx)
+	results := make([]MergeResult, 0, len(ids))
+	for _, id := range ids {
+		res := MergeResult{ID: id}
+		if err := acceptMergeSuggestion(ctx, store, id); err != nil {
+			l.Err(err).Msgf("AcceptMergeSuggestions: Failed to accept merge suggestion %d", id)
+			res.Error = err.Error()
+		} else {
+			res.Merged = true
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func acceptMergeSuggestion(ctx context.Context, store mergeStore, id int32) error {
+	s, err := store.GetMergeSuggestion(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.Status != models.MergeSuggestionPending {
+		return fmt.Errorf("suggestion was %s", s.Status)
+	}
+	switch s.EntityType {
+	case MatchEntityArtist:
+		return store.MergeArtists(ctx, s.FromID, s.ToID, false)
+	case MatchEntityAlbum:
+		return store.MergeAlbums(ctx, s.FromID, s.ToID, false)
+	case MatchEntityTrack:
+		return store.MergeTracks(ctx, s.FromID, s.ToID)
+	}
+	return fmt.Errorf("unknown entity type '%s'", s.EntityType)
+}
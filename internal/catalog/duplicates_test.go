@@ -0,0 +1,97 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	listenedAt := time.Unix(1700000000, 0)
+	submit := func(artist, title, album string) {
+		listenedAt = listenedAt.Add(time.Hour)
+		require.NoError(t, catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+			MbzCaller:    &mbz.MbzErrorCaller{},
+			Artist:       artist,
+			TrackTitle:   title,
+			ReleaseTitle: album,
+			Time:         listenedAt,
+			UserID:       1,
+		}))
+	}
+	submit("Beyoncé", "Halo", "I Am... Sasha Fierce")
+	submit("Beyonce", "Halo", "I Am... Sasha Fierce (Deluxe Edition)")
+	submit("Beyonce", "Halo", "I Am... Sasha Fierce (Deluxe Edition)")
+	submit("Beyonce", "Ego (feat. Kanye West)", "I Am... Sasha Fierce (Deluxe Edition)")
+	submit("Beyonce", "Ego feat. Kanye West", "I Am... Sasha Fierce (Deluxe Edition)")
+	submit("The Beatles", "Let It Be", "Let It Be")
+	submit("Beatles", "Yesterday", "Help!")
+	submit("ヨルシカ", "ただ君に晴れ", "負け犬にアンコールはいらない")
+
+	n, err := catalog.FindDuplicates(ctx, store)
+	require.NoError(t, err)
+	suggestions, err := store.GetMergeSuggestions(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, n, len(suggestions))
+
+	byName := func(fromName, toName string) *models.MergeSuggestion {
+		for _, s := range suggestions {
+			if s.FromName == fromName && s.ToName == toName {
+				return s
+			}
+		}
+		return nil
+	}
+	beyonce := byName("Beyoncé", "Beyonce")
+	require.NotNil(t, beyonce, "the artist with fewer listens is merged into the other")
+	assert.Equal(t, catalog.MatchEntityArtist, beyonce.EntityType)
+	assert.Equal(t, []string{catalog.DuplicateReasonName, catalog.DuplicateReasonSharedAlbums}, beyonce.Reasons)
+
+	beatles := byName("Beatles", "The Beatles")
+	require.NotNil(t, beatles)
+	assert.Equal(t, []string{catalog.DuplicateReasonName}, beatles.Reasons)
+	assert.Greater(t, beyonce.Score, beatles.Score)
+
+	ego := byName("Ego feat. Kanye West", "Ego (feat. Kanye West)")
+	require.NotNil(t, ego)
+	assert.Equal(t, catalog.MatchEntityTrack, ego.EntityType)
+	assert.Contains(t, ego.Reasons, catalog.DuplicateReasonSameAlbum)
+
+	// the albums don't share an artist until the artists are merged
+	assert.Nil(t, byName("I Am... Sasha Fierce", "I Am... Sasha Fierce (Deluxe Edition)"))
+	assert.Len(t, suggestions, 3)
+
+	results := catalog.AcceptMergeSuggestions(ctx, store, []int32{beyonce.ID, beyonce.ID})
+	require.Len(t, results, 2)
+	assert.True(t, results[0].Merged)
+	assert.False(t, results[1].Merged, "the suggestion goes away with the merged artist")
+	_, err = store.GetArtist(ctx, db.GetArtistOpts{ID: beyonce.FromID})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	_, err = catalog.FindDuplicates(ctx, store)
+	require.NoError(t, err)
+	suggestions, err = store.GetMergeSuggestions(ctx, catalog.MatchEntityAlbum)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	album := suggestions[0]
+	assert.Equal(t, "I Am... Sasha Fierce", album.FromName)
+	assert.Equal(t, []string{catalog.DuplicateReasonName, catalog.DuplicateReasonSharedArtists, catalog.DuplicateReasonSharedTracks}, album.Reasons)
+
+	// dismissed pairs are not suggested again
+	require.NoError(t, store.DismissMergeSuggestion(ctx, album.ID))
+	_, err = catalog.FindDuplicates(ctx, store)
+	require.NoError(t, err)
+	suggestions, err = store.GetMergeSuggestions(ctx, catalog.MatchEntityAlbum)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
@@ -0,0 +1,138 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// The reasons a submission is discarded by the ingest filters.
+const (
+	FilterReasonClientNotAllowed = "client_not_allowed"
+	FilterReasonClientDenied     = "client_denied"
+	FilterReasonArtistDenied     = "artist_denied"
+	FilterReasonAlbumDenied      = "album_denied"
+	FilterReasonTrackTooShort    = "track_too_short"
+	FilterReasonPlayedTooShort   = "played_too_short"
+)
+
+// FilterListen checks a submission against the ingest filters, and returns the reason the
+// submission should be discarded, or an empty string when it passes. Durations are only checked
+// when the submission includes them.
+func FilterListen(f cfg.IngestFilterConfig, s *models.ListenSubmission) string {
+	matchClient := func(c string) bool { return strings.EqualFold(c, s.Client) }
+	if len(f.AllowClients) > 0 && !slices.ContainsFunc(f.AllowClients, matchClient) {
+		return FilterReasonClientNotAllowed
+	}
+	if slices.ContainsFunc(f.DenyClients, matchClient) {
+		return FilterReasonClientDenied
+	}
+	for _, re := range f.DenyArtists {
+		if re.MatchString(s.Artist) || slices.ContainsFunc(s.ArtistNames, re.MatchString) {
+			return FilterReasonArtistDenied
+		}
+	}
+	for _, re := range f.DenyAlbums {
+		if re.MatchString(s.Album) {
+			return FilterReasonAlbumDenied
+		}
+	}
+	if f.MinTrackDuration > 0 && s.Duration > 0 && s.Duration < f.MinTrackDuration {
+		return FilterReasonTrackTooShort
+	}
+	if f.MinPlayedDuration > 0 && s.PlayedDuration > 0 && s.PlayedDuration < f.MinPlayedDuration {
+		return FilterReasonPlayedTooShort
+	}
+	return ""
+}
+
+type PurgeFilteredListensOpts struct {
+	Timeframe db.Timeframe
+	// When true, nothing is deleted and the result only lists the listens that would be purged
+	DryRun bool
+}
+
+type PurgeFilteredListensResult struct {
+	DryRun   bool                     `json:"dry_run"`
+	Listens  int                      `json:"listens"`
+	Purged   int                      `json:"purged"`
+	ByReason map[string]int           `json:"by_reason"`
+	Items    []*models.FilteredListen `json:"items"`
+	Failed   int                      `json:"failed"`
+}
+
+// PurgeFilteredListens applies the ingest filters to listens that are already stored, and
+// deletes the listens that would have been discarded had they been submitted now. Purged listens
+// are added to the filtered listen log.
+func PurgeFilteredListens(ctx context.Context, store submitListenStore, opts PurgeFilteredListensOpts) (*PurgeFilteredListensResult, error) {
+	l := logger.FromContext(ctx)
+
+	listens, err := store.GetSubmittedListens(ctx, db.GetSubmittedListensOpts{Timeframe: opts.Timeframe})
+	if err != nil {
+		return nil, fmt.Errorf("PurgeFilteredListens: %w", err)
+	}
+	filter := cfg.IngestFilter()
+
+	res := &PurgeFilteredListensResult{
+		DryRun:   opts.DryRun,
+		Listens:  len(listens),
+		ByReason: make(map[string]int),
+		Items:    make([]*models.FilteredListen, 0),
+	}
+	for _, listen := range listens {
+		submission := listen.Submission
+		if submission == nil {
+			submission, err = submissionFromTrack(ctx, store, listen.TrackID)
+			if err != nil {
+				l.Err(err).Msgf("PurgeFilteredListens: Failed to rebuild submission for track %d", listen.TrackID)
+				res.Failed++
+				continue
+			}
+			submission.Client = listen.Client
+		}
+		reason := FilterListen(filter, submission)
+		if reason == "" {
+			continue
+		}
+		item := &models.FilteredListen{
+			FilteredAt: time.Now(),
+			Time:       listen.Time,
+			Client:     listen.Client,
+			Artist:     submission.Artist,
+			Title:      submission.Title,
+			Album:      submission.Album,
+			Reason:     reason,
+		}
+		if !opts.DryRun {
+			if err := store.DeleteListen(ctx, listen.TrackID, listen.Time); err != nil {
+				l.Err(err).Msgf("PurgeFilteredListens: Failed to delete listen of track %d at %s", listen.TrackID, listen.Time)
+				res.Failed++
+				continue
+			}
+			err := store.SaveFilteredListen(ctx, db.SaveFilteredListenOpts{
+				Time:   listen.Time,
+				UserID: listen.UserID,
+				Client: item.Client,
+				Artist: item.Artist,
+				Title:  item.Title,
+				Album:  item.Album,
+				Reason: reason,
+			})
+			if err != nil {
+				l.Err(err).Msg("PurgeFilteredListens: Failed to log purged listen")
+			}
+		}
+		res.Purged++
+		res.ByReason[reason]++
+		res.Items = append(res.Items, item)
+	}
+	l.Info().Msgf("PurgeFilteredListens: %d of %d listens matched the ingest filters (dry run: %t)", res.Purged, res.Listens, opts.DryRun)
+	return res, nil
+}
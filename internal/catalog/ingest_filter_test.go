@@ -0,0 +1,119 @@
+package catalog_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterListen(t *testing.T) {
+	filter := cfg.IngestFilterConfig{
+		DenyClients:       []string{"Pocket Casts"},
+		DenyArtists:       []*regexp.Regexp{regexp.MustCompile(`(?i)podcast`)},
+		DenyAlbums:        []*regexp.Regexp{regexp.MustCompile(`(?i)\baudiobook\b`)},
+		MinTrackDuration:  30,
+		MinPlayedDuration: 20,
+	}
+	song := models.ListenSubmission{Artist: "ヨルシカ", Title: "だから僕は音楽を辞めた", Album: "だから僕は音楽を辞めた", Client: "navidrome"}
+
+	tests := []struct {
+		name   string
+		edit   func(s *models.ListenSubmission)
+		reason string
+	}{
+		{"passes", func(s *models.ListenSubmission) {}, ""},
+		{"client denied ignoring case", func(s *models.ListenSubmission) { s.Client = "pocket casts" }, catalog.FilterReasonClientDenied},
+		{"artist denied", func(s *models.ListenSubmission) { s.Artist = "The Daily Podcast" }, catalog.FilterReasonArtistDenied},
+		{"one of the artists denied", func(s *models.ListenSubmission) { s.ArtistNames = []string{"ヨルシカ", "Podcast Host"} }, catalog.FilterReasonArtistDenied},
+		{"album denied", func(s *models.ListenSubmission) { s.Album = "Dune (Audiobook)" }, catalog.FilterReasonAlbumDenied},
+		{"track too short", func(s *models.ListenSubmission) { s.Duration = 12 }, catalog.FilterReasonTrackTooShort},
+		{"long enough track", func(s *models.ListenSubmission) { s.Duration = 240 }, ""},
+		{"played too short", func(s *models.ListenSubmission) { s.Duration = 240; s.PlayedDuration = 5 }, catalog.FilterReasonPlayedTooShort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := song
+			tt.edit(&s)
+			assert.Equal(t, tt.reason, catalog.FilterListen(filter, &s))
+		})
+	}
+
+	filter.AllowClients = []string{"navidrome"}
+	assert.Empty(t, catalog.FilterListen(filter, &song))
+	other := song
+	other.Client = "jellyfin"
+	assert.Equal(t, catalog.FilterReasonClientNotAllowed, catalog.FilterListen(filter, &other))
+}
+
+func TestSubmitListen_IngestFilter(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+	t.Cleanup(func() { cfg.SetIngestFilter(cfg.IngestFilterConfig{}) })
+
+	opts := catalog.SubmitListenOpts{
+		MbzCaller:    &mbz.MbzErrorCaller{},
+		Artist:       "The Daily",
+		TrackTitle:   "Episode 1",
+		ReleaseTitle: "The Daily",
+		Time:         time.Unix(1700000000, 0),
+		UserID:       1,
+		Client:       "Podcasts",
+	}
+	require.NoError(t, catalog.SubmitListen(ctx, store, opts))
+	all := db.GetSubmittedListensOpts{Timeframe: db.Timeframe{Period: db.PeriodAllTime}}
+	listens, err := store.GetSubmittedListens(ctx, all)
+	require.NoError(t, err)
+	require.Len(t, listens, 1)
+
+	cfg.SetIngestFilter(cfg.IngestFilterConfig{DenyClients: []string{"podcasts"}})
+
+	// new submissions are discarded before anything is saved
+	opts.TrackTitle = "Episode 2"
+	opts.Time = opts.Time.Add(time.Hour)
+	require.NoError(t, catalog.SubmitListen(ctx, store, opts))
+	listens, err = store.GetSubmittedListens(ctx, all)
+	require.NoError(t, err)
+	assert.Len(t, listens, 1)
+	_, err = store.GetTrack(ctx, db.GetTrackOpts{Title: "Episode 2", ReleaseID: 1, ArtistIDs: []int32{1}})
+	assert.ErrorIs(t, err, db.ErrNotFound)
+
+	log, err := store.GetFilteredListens(ctx, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, log.Total)
+	assert.EqualValues(t, 1, log.ByReason[catalog.FilterReasonClientDenied])
+	require.Len(t, log.Items, 1)
+	assert.Equal(t, "Episode 2", log.Items[0].Title)
+	assert.Equal(t, "Podcasts", log.Items[0].Client)
+
+	// stored listens are purged with the same filter
+	purgeOpts := catalog.PurgeFilteredListensOpts{Timeframe: db.Timeframe{Period: db.PeriodAllTime}, DryRun: true}
+	res, err := catalog.PurgeFilteredListens(ctx, store, purgeOpts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Purged)
+	listens, err = store.GetSubmittedListens(ctx, all)
+	require.NoError(t, err)
+	assert.Len(t, listens, 1, "a dry run does not delete anything")
+
+	purgeOpts.DryRun = false
+	res, err = catalog.PurgeFilteredListens(ctx, store, purgeOpts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Purged)
+	require.Len(t, res.Items, 1)
+	assert.Equal(t, "Episode 1", res.Items[0].Title)
+	listens, err = store.GetSubmittedListens(ctx, all)
+	require.NoError(t, err)
+	assert.Empty(t, listens)
+
+	log, err = store.GetFilteredListens(ctx, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, log.Total)
+}
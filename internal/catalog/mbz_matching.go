@@ -0,0 +1,318 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+// The kinds of items MusicBrainz matches are searched for.
+const (
+	MatchEntityArtist = "artist"
+	MatchEntityAlbum  = "album"
+	MatchEntityTrack  = "track"
+)
+
+const (
+	// candidates less confident than this are not suggested at all
+	minMatchConfidence = 0.5
+	// how many candidates are suggested for each item
+	maxMatchSuggestions = 3
+	// a match is only applied automatically when the next best candidate is at least this much
+	// less confident
+	autoApplyMargin = 0.05
+)
+
+var ErrMbzIDInUse = errors.New("the MusicBrainz ID is already used by another item")
+
+type matchStore interface {
+	db.ArtistStore
+	db.AlbumStore
+	db.TrackStore
+	db.MatchSuggestionStore
+}
+
+type matchCandidate struct {
+	MbzID      uuid.UUID
+	Name       string
+	Artist     string
+	Confidence float64
+}
+
+// MatchMissingMbzIDs searches MusicBrainz for every artist, album and track that has no MBID
+// and was never searched for. Candidates are saved as suggestions with a confidence score, and
+// the best candidate is applied straight away when it is confident enough and clearly ahead of
+// the others. Items are only marked as searched when the search succeeds, so nothing is done
+// while MusicBrainz is disabled.
+func MatchMissingMbzIDs(ctx context.Context, store matchStore, mbzCaller mbz.MusicBrainzCaller) error {
+	l := logger.FromContext(ctx)
+	if _, disabled := mbzCaller.(*mbz.MbzErrorCaller); disabled {
+		l.Info().Msg("MatchMissingMbzIDs: MusicBrainz is disabled, skipping search for matches")
+		return nil
+	}
+	l.Info().Msg("MatchMissingMbzIDs: Starting search for MusicBrainz matches")
+
+	kinds := []struct {
+		entityType string
+		get        func(context.Context, int32) ([]*db.UnmatchedItem, error)
+		search     func(context.Context, matchStore, mbz.MusicBrainzCaller, *db.UnmatchedItem) ([]matchCandidate, error)
+	}{
+		{MatchEntityArtist, store.GetUnmatchedArtists, searchArtistMatches},
+		{MatchEntityAlbum, store.GetUnmatchedAlbums, searchAlbumMatches},
+		{MatchEntityTrack, store.GetUnmatchedTracks, searchTrackMatches},
+	}
+	for _, kind := range kinds {
+		var from int32 = 0
+		for {
+			items, err := kind.get(ctx, from)
+			if err != nil {
+				return fmt.Errorf("MatchMissingMbzIDs: failed to fetch %ss to match: %w", kind.entityType, err)
+			}
+			if len(items) == 0 {
+				break
+			}
+			for _, item := range items {
+				from = item.ID
+				if ctx.Err() != nil {
+					return fmt.Errorf("MatchMissingMbzIDs: %w", ctx.Err())
+				}
+				candidates, err := kind.search(ctx, store, mbzCaller, item)
+				if err != nil {
+					l.Err(err).Str("name", item.Name).Msgf("MatchMissingMbzIDs: Failed to search MusicBrainz for %s", kind.entityType)
+					continue
+				}
+				if err := saveMatchCandidates(ctx, store, kind.entityType, item, candidates); err != nil {
+					l.Err(err).Str("name", item.Name).Msgf("MatchMissingMbzIDs: Failed to save matches for %s", kind.entityType)
+					continue
+				}
+				if err := store.MarkMbzSearched(ctx, kind.entityType, item.ID); err != nil {
+					l.Err(err).Str("name", item.Name).Msgf("MatchMissingMbzIDs: Failed to mark %s as searched", kind.entityType)
+				}
+			}
+		}
+	}
+	l.Info().Msg("MatchMissingMbzIDs: Search for MusicBrainz matches complete")
+	return nil
+}
+
+func searchArtistMatches(ctx context.Context, store matchStore, mbzCaller mbz.MusicBrainzCaller, item *db.UnmatchedItem) ([]matchCandidate, error) {
+	results, err := mbzCaller.SearchArtist(ctx, item.Name)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []matchCandidate
+	for _, r := range results {
+		id, err := uuid.Parse(r.ID)
+		if err != nil {
+			continue
+		}
+		if a, err := store.GetArtist(ctx, db.GetArtistOpts{MusicBrainzID: id}); err == nil && a.ID != item.ID {
+			continue
+		}
+		candidates = append(candidates, matchCandidate{
+			MbzID:      id,
+			Name:       r.Name,
+			Confidence: 0.6*float64(r.Score)/100 + 0.4*similarity(item.Name, r.Name),
+		})
+	}
+	return candidates, nil
+}
+
+func searchAlbumMatches(ctx context.Context, store matchStore, mbzCaller mbz.MusicBrainzCaller, item *db.UnmatchedItem) ([]matchCandidate, error) {
+	results, err := mbzCaller.SearchRelease(ctx, item.Name, item.Artist)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []matchCandidate
+	for _, r := range results {
+		id, err := uuid.Parse(r.ID)
+		if err != nil {
+			continue
+		}
+		if a, err := store.GetAlbum(ctx, db.GetAlbumOpts{MusicBrainzID: id}); err == nil && a.ID != item.ID {
+			continue
+		}
+		artist := mbz.ArtistCreditName(r.ArtistCredit)
+		candidates = append(candidates, matchCandidate{
+			MbzID:  id,
+			Name:   r.Title,
+			Artist: artist,
+			Confidence: 0.5*float64(r.Score)/100 +
+				0.3*similarity(item.Name, r.Title) +
+				0.2*similarity(item.Artist, artist),
+		})
+	}
+	return candidates, nil
+}
+
+func searchTrackMatches(ctx context.Context, store matchStore, mbzCaller mbz.MusicBrainzCaller, item *db.UnmatchedItem) ([]matchCandidate, error) {
+	results, err := mbzCaller.SearchRecording(ctx, item.Name, item.Artist, item.Album)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []matchCandidate
+	for _, r := range results {
+		id, err := uuid.Parse(r.ID)
+		if err != nil {
+			continue
+		}
+		if t, err := store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: id}); err == nil && t.ID != item.ID {
+			continue
+		}
+		// how well the releases the recording appears on match the album of the track
+		var album float64
+		for _, release := range r.Releases {
+			if item.AlbumMbzID != nil && release.ID == item.AlbumMbzID.String() {
+				album = 1
+				break
+			}
+			album = max(album, similarity(item.Album, release.Title))
+		}
+		artist := mbz.ArtistCreditName(r.ArtistCredit)
+		candidates = append(candidates, matchCandidate{
+			MbzID:  id,
+			Name:   r.Title,
+			Artist: artist,
+			Confidence: 0.5*float64(r.Score)/100 +
+				0.25*similarity(item.Name, r.Title) +
+				0.15*similarity(item.Artist, artist) +
+				0.1*album,
+		})
+	}
+	return candidates, nil
+}
+
+// saveMatchCandidates saves the most confident candidates for an item as suggestions, and applies
+// the best one when it is confident enough.
+func saveMatchCandidates(ctx context.Context, store matchStore, entityType string, item *db.UnmatchedItem, candidates []matchCandidate) error {
+	l := logger.FromContext(ctx)
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	if len(candidates) > maxMatchSuggestions {
+		candidates = candidates[:maxMatchSuggestions]
+	}
+	var best *models.MatchSuggestion
+	for _, c := range candidates {
+		if c.Confidence < minMatchConfidence {
+			break
+		}
+		suggestion, err := store.SaveMatchSuggestion(ctx, db.SaveMatchSuggestionOpts{
+			EntityType: entityType,
+			EntityID:   item.ID,
+			MbzID:      c.MbzID,
+			Name:       c.Name,
+			Artist:     c.Artist,
+			Confidence: c.Confidence,
+		})
+		if err != nil {
+			return err
+		}
+		if best == nil {
+			best = suggestion
+		}
+	}
+
+	if best == nil || best.Status != models.MatchSuggestionPending {
+		return nil
+	}
+	confident := best.Confidence >= cfg.MbzMatchAutoApplyConfidence()
+	ambiguous := len(candidates) > 1 && candidates[1].Confidence > best.Confidence-autoApplyMargin
+	if !confident || ambiguous {
+		return nil
+	}
+	if err := ApplyMatchSuggestion(ctx, store, best, models.MatchSuggestionApplied); err != nil {
+		return err
+	}
+	l.Info().
+		Str("name", item.Name).
+		Str("mbz_id", best.MbzID.String()).
+		Float64("confidence", best.Confidence).
+		Msgf("MatchMissingMbzIDs: Applied MusicBrainz match for %s", entityType)
+	return nil
+}
+
+// ApplyMatchSuggestion sets the MBID of the item a suggestion was made for, and resolves the
+// suggestion with the given status. Other pending suggestions for the item are rejected.
+// Durations, aliases and images for the new MBID are picked up by the MusicBrainz backfills.
+func ApplyMatchSuggestion(ctx context.Context, store matchStore, s *models.MatchSuggestion, status string) error {
+	var err error
+	switch s.EntityType {
+	case MatchEntityArtist:
+		if a, e := store.GetArtist(ctx, db.GetArtistOpts{MusicBrainzID: s.MbzID}); e == nil && a.ID != s.EntityID {
+			return fmt.Errorf("ApplyMatchSuggestion: %w", ErrMbzIDInUse)
+		}
+		err = store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: s.EntityID, MusicBrainzID: s.MbzID})
+	case MatchEntityAlbum:
+		if a, e := store.GetAlbum(ctx, db.GetAlbumOpts{MusicBrainzID: s.MbzID}); e == nil && a.ID != s.EntityID {
+			return fmt.Errorf("ApplyMatchSuggestion: %w", ErrMbzIDInUse)
+		}
+		err = store.UpdateAlbum(ctx, db.UpdateAlbumOpts{ID: s.EntityID, MusicBrainzID: s.MbzID})
+	case MatchEntityTrack:
+		if t, e := store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: s.MbzID}); e == nil && t.ID != s.EntityID {
+			return fmt.Errorf("ApplyMatchSuggestion: %w", ErrMbzIDInUse)
+		}
+		err = store.UpdateTrack(ctx, db.UpdateTrackOpts{ID: s.EntityID, MusicBrainzID: s.MbzID})
+	default:
+		return fmt.Errorf("ApplyMatchSuggestion: unknown entity type '%s'", s.EntityType)
+	}
+	if err != nil {
+		return fmt.Errorf("ApplyMatchSuggestion: %w", err)
+	}
+	if err := store.ResolveMatchSuggestion(ctx, s.ID, status); err != nil {
+		return fmt.Errorf("ApplyMatchSuggestion: %w", err)
+	}
+	return nil
+}
+
+// similarity compares two names, ignoring case, punctuation and spacing, and returns a value
+// from 0 for nothing in common to 1 for the same name.
+func similarity(a, b string) float64 {
+	ra, rb := normalizeForMatch(a), normalizeForMatch(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	longest := max(len(ra), len(rb))
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func normalizeForMatch(s string) []rune {
+	var ret []rune
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
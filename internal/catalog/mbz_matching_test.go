@@ -0,0 +1,92 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchMissingMbzIDs(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	require.NoError(t, catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+		MbzCaller:    &mbz.MbzErrorCaller{},
+		Artist:       "ATARASHII GAKKO!",
+		TrackTitle:   "Tokyo Calling",
+		ReleaseTitle: "AG! Calling",
+		Time:         time.Unix(1700000000, 0),
+		UserID:       1,
+	}))
+
+	// items are left to be searched once MusicBrainz is enabled
+	require.NoError(t, catalog.MatchMissingMbzIDs(ctx, store, &mbz.MbzErrorCaller{}))
+	unmatched, err := store.GetUnmatchedArtists(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, unmatched, 1)
+
+	artistMbzID := uuid.MustParse("00000000-0000-0000-0000-0000000000a1")
+	albumMbzID := uuid.MustParse("00000000-0000-0000-0000-0000000000b1")
+	deluxeMbzID := uuid.MustParse("00000000-0000-0000-0000-0000000000b2")
+	credit := []mbz.MusicBrainzArtistCredit{{Name: "ATARASHII GAKKO!"}}
+	mbzc := &mbz.MbzMockCaller{
+		ArtistSearches: map[string][]mbz.MusicBrainzArtistSearchResult{
+			"ATARASHII GAKKO!": {
+				{ID: artistMbzID.String(), Name: "ATARASHII GAKKO!", Score: 100},
+				{ID: "00000000-0000-0000-0000-0000000000a2", Name: "Atarashii Chizu", Score: 40},
+			},
+		},
+		ReleaseSearches: map[string][]mbz.MusicBrainzReleaseSearchResult{
+			"AG! Calling": {
+				{ID: albumMbzID.String(), Title: "AG! Calling", ArtistCredit: credit, Score: 80},
+				{ID: deluxeMbzID.String(), Title: "AG! Calling (Deluxe)", ArtistCredit: credit, Score: 78},
+			},
+		},
+	}
+	require.NoError(t, catalog.MatchMissingMbzIDs(ctx, store, mbzc))
+
+	// the artist match is confident enough to be applied
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{Name: "ATARASHII GAKKO!"})
+	require.NoError(t, err)
+	require.NotNil(t, artist.MbzID)
+	assert.Equal(t, artistMbzID, *artist.MbzID)
+	applied, err := store.GetMatchSuggestions(ctx, models.MatchSuggestionApplied)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, catalog.MatchEntityArtist, applied[0].EntityType)
+	assert.InDelta(t, 1.0, applied[0].Confidence, 0.001)
+
+	// the album matches go to the review queue
+	pending, err := store.GetMatchSuggestions(ctx, models.MatchSuggestionPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, albumMbzID, pending[0].MbzID, "the most confident suggestion comes first")
+	assert.Equal(t, "AG! Calling", pending[0].EntityName)
+	assert.Greater(t, pending[0].Confidence, pending[1].Confidence)
+
+	// everything was searched, including the track nothing was found for
+	for _, get := range []func(context.Context, int32) ([]*db.UnmatchedItem, error){
+		store.GetUnmatchedArtists, store.GetUnmatchedAlbums, store.GetUnmatchedTracks,
+	} {
+		unmatched, err := get(ctx, 0)
+		require.NoError(t, err)
+		assert.Empty(t, unmatched)
+	}
+
+	require.NoError(t, catalog.ApplyMatchSuggestion(ctx, store, pending[1], models.MatchSuggestionAccepted))
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: pending[1].EntityID})
+	require.NoError(t, err)
+	require.NotNil(t, album.MbzID)
+	assert.Equal(t, deluxeMbzID, *album.MbzID)
+	rejected, err := store.GetMatchSuggestion(ctx, pending[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MatchSuggestionRejected, rejected.Status, "other suggestions for the album are rejected")
+}
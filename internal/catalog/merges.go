@@ -0,0 +1,47 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+)
+
+var (
+	ErrMergeUndone  = errors.New("merge was already undone")
+	ErrMergeExpired = errors.New("merge is too old to be undone")
+)
+
+// UndoMerge undoes a merge made within the undo retention window.
+func UndoMerge(ctx context.Context, store db.MergeStore, id int32) error {
+	m, err := store.GetMerge(ctx, id)
+	if err != nil {
+		return fmt.Errorf("UndoMerge: %w", err)
+	}
+	if m.UndoneAt != nil {
+		return fmt.Errorf("UndoMerge: %w", ErrMergeUndone)
+	}
+	if m.CreatedAt.Before(time.Now().Add(-cfg.MergeUndoRetention())) {
+		return fmt.Errorf("UndoMerge: %w", ErrMergeExpired)
+	}
+	if err := store.UndoMerge(ctx, id); err != nil {
+		return fmt.Errorf("UndoMerge: %w", err)
+	}
+	return nil
+}
+
+// PruneMerges forgets the merges that are past the undo retention window.
+func PruneMerges(ctx context.Context, store db.MergeStore) error {
+	l := logger.FromContext(ctx)
+	n, err := store.DeleteMergesBefore(ctx, time.Now().Add(-cfg.MergeUndoRetention()))
+	if err != nil {
+		l.Err(err).Msg("PruneMerges: Failed to delete old merges")
+		return fmt.Errorf("PruneMerges: %w", err)
+	}
+	l.Info().Msgf("PruneMerges: Forgot %d merges past the undo retention window", n)
+	return nil
+}
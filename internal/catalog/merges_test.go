@@ -0,0 +1,156 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoMerge(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	listenedAt := time.Unix(1700000000, 0)
+	submit := func(artist, title, album string) {
+		listenedAt = listenedAt.Add(time.Hour)
+		require.NoError(t, catalog.SubmitListen(ctx, store, catalog.SubmitListenOpts{
+			MbzCaller:    &mbz.MbzErrorCaller{},
+			Artist:       artist,
+			TrackTitle:   title,
+			ReleaseTitle: album,
+			Time:         listenedAt,
+			UserID:       1,
+		}))
+	}
+	submit("Beyoncé", "Halo", "I Am... Sasha Fierce")
+	submit("Beyonce", "Halo", "I Am... Sasha Fierce (Deluxe Edition)")
+	submit("Beyonce", "Halo", "I Am... Sasha Fierce (Deluxe Edition)")
+	submit("Beyonce", "Ego", "I Am... Sasha Fierce (Deluxe Edition)")
+
+	artist := func(name string) *models.Artist {
+		a, err := store.GetArtist(ctx, db.GetArtistOpts{Name: name})
+		require.NoError(t, err)
+		return a
+	}
+	album := func(title string, artistID int32) *models.Album {
+		a, err := store.GetAlbum(ctx, db.GetAlbumOpts{Title: title, ArtistID: artistID})
+		require.NoError(t, err)
+		return a
+	}
+	from, to := artist("Beyoncé"), artist("Beyonce")
+	fromAlbum := album("I Am... Sasha Fierce", from.ID)
+
+	t.Run("Preview", func(t *testing.T) {
+		preview, err := store.PreviewMerge(ctx, catalog.MatchEntityArtist, from.ID, to.ID)
+		require.NoError(t, err)
+		assert.Zero(t, preview.ID)
+		assert.Equal(t, "Beyoncé", preview.FromName)
+		assert.Equal(t, models.MergeCounts{Listens: 1, Tracks: 1, Releases: 1, Aliases: 1}, preview.Moved)
+
+		_, err = store.PreviewMerge(ctx, catalog.MatchEntityArtist, from.ID, 9999)
+		assert.ErrorIs(t, err, db.ErrNotFound)
+		_, err = store.GetArtist(ctx, db.GetArtistOpts{ID: from.ID})
+		require.NoError(t, err, "a preview doesn't merge anything")
+	})
+
+	t.Run("Artists", func(t *testing.T) {
+		require.NoError(t, store.MergeArtists(ctx, from.ID, to.ID, false))
+		_, err := store.GetArtist(ctx, db.GetArtistOpts{ID: from.ID})
+		require.ErrorIs(t, err, db.ErrNotFound)
+		aliases, err := store.GetAllArtistAliases(ctx, to.ID)
+		require.NoError(t, err)
+		assert.Len(t, aliases, 2, "the aliases of the merged artist move")
+
+		merges, err := store.GetMerges(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, merges, 1)
+		assert.Equal(t, models.MergeCounts{Listens: 1, Tracks: 1, Releases: 1, Aliases: 1}, merges[0].Moved)
+
+		require.NoError(t, catalog.UndoMerge(ctx, store, merges[0].ID))
+		restored := artist("Beyoncé")
+		assert.Equal(t, from.ID, restored.ID)
+		assert.EqualValues(t, 1, restored.ListenCount)
+		assert.EqualValues(t, 3, artist("Beyonce").ListenCount)
+		aliases, err = store.GetAllArtistAliases(ctx, to.ID)
+		require.NoError(t, err)
+		assert.Len(t, aliases, 1)
+		artists, err := store.GetArtistsForAlbum(ctx, fromAlbum.ID)
+		require.NoError(t, err)
+		require.Len(t, artists, 1)
+		assert.Equal(t, from.ID, artists[0].ID)
+
+		err = catalog.UndoMerge(ctx, store, merges[0].ID)
+		assert.ErrorIs(t, err, catalog.ErrMergeUndone)
+	})
+
+	t.Run("Albums", func(t *testing.T) {
+		toAlbum := album("I Am... Sasha Fierce (Deluxe Edition)", to.ID)
+		require.NoError(t, store.MergeAlbums(ctx, toAlbum.ID, fromAlbum.ID, false))
+		_, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: toAlbum.ID})
+		require.ErrorIs(t, err, db.ErrNotFound)
+		merged := album("I Am... Sasha Fierce", from.ID)
+		assert.EqualValues(t, 4, merged.ListenCount)
+
+		merges, err := store.GetMerges(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Len(t, merges, 2)
+		require.NoError(t, catalog.UndoMerge(ctx, store, merges[0].ID))
+		assert.EqualValues(t, 3, album("I Am... Sasha Fierce (Deluxe Edition)", to.ID).ListenCount)
+		assert.EqualValues(t, 1, album("I Am... Sasha Fierce", from.ID).ListenCount)
+		artists, err := store.GetArtistsForAlbum(ctx, fromAlbum.ID)
+		require.NoError(t, err)
+		assert.Len(t, artists, 1)
+	})
+
+	t.Run("Tracks", func(t *testing.T) {
+		track := func(albumID, artistID int32) *models.Track {
+			tr, err := store.GetTrack(ctx, db.GetTrackOpts{Title: "Halo", ReleaseID: albumID, ArtistIDs: []int32{artistID}})
+			require.NoError(t, err)
+			return tr
+		}
+		toAlbum := album("I Am... Sasha Fierce (Deluxe Edition)", to.ID)
+		fromTrack, toTrack := track(fromAlbum.ID, from.ID), track(toAlbum.ID, to.ID)
+
+		require.NoError(t, store.MergeTracks(ctx, fromTrack.ID, toTrack.ID))
+		assert.EqualValues(t, 3, track(toAlbum.ID, to.ID).ListenCount)
+		// the merged track was the only one of its album and artist
+		_, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: fromAlbum.ID})
+		require.ErrorIs(t, err, db.ErrNotFound)
+		_, err = store.GetArtist(ctx, db.GetArtistOpts{ID: from.ID})
+		require.ErrorIs(t, err, db.ErrNotFound)
+
+		merges, err := store.GetMerges(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.NoError(t, catalog.UndoMerge(ctx, store, merges[0].ID))
+		restored := track(fromAlbum.ID, from.ID)
+		assert.Equal(t, fromTrack.ID, restored.ID)
+		assert.EqualValues(t, 1, restored.ListenCount)
+		assert.EqualValues(t, 2, track(toAlbum.ID, to.ID).ListenCount)
+		assert.Equal(t, from.ID, artist("Beyoncé").ID)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		retention := cfg.MergeUndoRetention()
+		defer cfg.SetMergeUndoRetention(retention)
+
+		require.NoError(t, store.MergeArtists(ctx, from.ID, to.ID, false))
+		merges, err := store.GetMerges(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+
+		cfg.SetMergeUndoRetention(0)
+		time.Sleep(time.Second)
+		err = catalog.UndoMerge(ctx, store, merges[0].ID)
+		assert.ErrorIs(t, err, catalog.ErrMergeExpired)
+		require.NoError(t, catalog.PruneMerges(ctx, store))
+		_, err = store.GetMerge(ctx, merges[0].ID)
+		assert.ErrorIs(t, err, db.ErrNotFound)
+	})
+}
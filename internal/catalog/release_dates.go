@@ -0,0 +1,84 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/google/uuid"
+)
+
+// BackfillReleaseDatesFromMusicBrainz looks up the first release date of every album that has a
+// MusicBrainz release ID but no release date yet. Albums that MusicBrainz has no date for are
+// marked with an empty date so they are not looked up again.
+func BackfillReleaseDatesFromMusicBrainz(
+	ctx context.Context,
+	store db.AlbumStore,
+	mbzCaller mbz.MusicBrainzCaller,
+) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("BackfillReleaseDatesFromMusicBrainz: Starting backfill of release dates from MusicBrainz")
+
+	var from int32 = 0
+
+	for {
+		l.Debug().Int32("ID", from).Msg("Fetching albums to backfill from ID")
+		albums, err := store.GetAlbumsWithNoReleaseDateButHaveMbzID(ctx, from)
+		if err != nil {
+			return fmt.Errorf("BackfillReleaseDatesFromMusicBrainz: failed to fetch albums for release date backfill: %w", err)
+		}
+
+		if len(albums) == 0 {
+			if from == 0 {
+				l.Info().Msg("BackfillReleaseDatesFromMusicBrainz: No albums need updating. Skipping backfill...")
+			} else {
+				l.Info().Msg("BackfillReleaseDatesFromMusicBrainz: Backfill complete")
+			}
+			return nil
+		}
+
+		for _, album := range albums {
+			from = album.ID
+
+			if album.MbzID == nil || *album.MbzID == uuid.Nil {
+				continue
+			}
+
+			l.Debug().
+				Str("title", album.Title).
+				Str("mbz_id", album.MbzID.String()).
+				Msg("BackfillReleaseDatesFromMusicBrainz: Backfilling release date from MusicBrainz")
+
+			release, err := mbzCaller.GetRelease(ctx, *album.MbzID)
+			if err != nil {
+				l.Err(err).
+					Str("title", album.Title).
+					Msg("BackfillReleaseDatesFromMusicBrainz: Failed to fetch release from MusicBrainz")
+				continue
+			}
+
+			date := mbz.ReleaseDate(release, nil)
+			err = store.UpdateAlbum(ctx, db.UpdateAlbumOpts{
+				ID:                album.ID,
+				ReleaseDateUpdate: true,
+				ReleaseDate:       date,
+			})
+			if err != nil {
+				l.Err(err).
+					Str("title", album.Title).
+					Msg("BackfillReleaseDatesFromMusicBrainz: Failed to update release date")
+			} else if date == "" {
+				l.Debug().
+					Str("title", album.Title).
+					Msg("BackfillReleaseDatesFromMusicBrainz: MusicBrainz release has no date")
+			} else {
+				l.Info().
+					Str("title", album.Title).
+					Str("release_date", date).
+					Msg("BackfillReleaseDatesFromMusicBrainz: Release date backfilled successfully")
+			}
+		}
+	}
+}
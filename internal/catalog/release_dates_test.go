@@ -0,0 +1,46 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillReleaseDates(t *testing.T) {
+	store := newTestDB()
+
+	setupTestDataWithMbzIDs(store, t)
+
+	ctx := context.Background()
+	releaseMbzID := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	release := *mbzReleaseData[releaseMbzID]
+	release.Date = "2023-08-01"
+	release.ReleaseGroup = &mbz.MusicBrainzReleaseGroup{FirstReleaseDate: "2023-07-28"}
+	mbzc := &mbz.MbzMockCaller{
+		Releases: map[uuid.UUID]*mbz.MusicBrainzRelease{releaseMbzID: &release},
+	}
+
+	err := catalog.BackfillReleaseDatesFromMusicBrainz(ctx, store, &mbz.MbzErrorCaller{})
+	assert.NoError(t, err)
+
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Empty(t, album.ReleaseDate)
+
+	err = catalog.BackfillReleaseDatesFromMusicBrainz(ctx, store, mbzc)
+	assert.NoError(t, err)
+
+	album, err = store.GetAlbum(ctx, db.GetAlbumOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "2023-07-28", album.ReleaseDate, "release group date should be preferred")
+
+	albums, err := store.GetAlbumsWithNoReleaseDateButHaveMbzID(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, albums)
+}
@@ -0,0 +1,84 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/google/uuid"
+)
+
+// BackfillReleaseTypesFromMusicBrainz looks up the primary and secondary release group types of
+// every album that has a MusicBrainz release ID but whose types have never been looked up.
+func BackfillReleaseTypesFromMusicBrainz(
+	ctx context.Context,
+	store db.AlbumStore,
+	mbzCaller mbz.MusicBrainzCaller,
+) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("BackfillReleaseTypesFromMusicBrainz: Starting backfill of release types from MusicBrainz")
+
+	var from int32 = 0
+
+	for {
+		l.Debug().Int32("ID", from).Msg("Fetching albums to backfill from ID")
+		albums, err := store.GetAlbumsWithNoTypeButHaveMbzID(ctx, from)
+		if err != nil {
+			return fmt.Errorf("BackfillReleaseTypesFromMusicBrainz: failed to fetch albums for release type backfill: %w", err)
+		}
+
+		if len(albums) == 0 {
+			if from == 0 {
+				l.Info().Msg("BackfillReleaseTypesFromMusicBrainz: No albums need updating. Skipping backfill...")
+			} else {
+				l.Info().Msg("BackfillReleaseTypesFromMusicBrainz: Backfill complete")
+			}
+			return nil
+		}
+
+		for _, album := range albums {
+			from = album.ID
+
+			if album.MbzID == nil || *album.MbzID == uuid.Nil {
+				continue
+			}
+
+			l.Debug().
+				Str("title", album.Title).
+				Str("mbz_id", album.MbzID.String()).
+				Msg("BackfillReleaseTypesFromMusicBrainz: Backfilling release types from MusicBrainz")
+
+			release, err := mbzCaller.GetRelease(ctx, *album.MbzID)
+			if err != nil {
+				l.Err(err).
+					Str("title", album.Title).
+					Msg("BackfillReleaseTypesFromMusicBrainz: Failed to fetch release from MusicBrainz")
+				continue
+			}
+
+			opts := db.UpdateAlbumOpts{
+				ID:         album.ID,
+				TypeUpdate: true,
+				TypeSource: db.InformationSourceMusicBrainz,
+			}
+			if release.ReleaseGroup != nil {
+				opts.PrimaryType = release.ReleaseGroup.Type
+				opts.SecondaryTypes = release.ReleaseGroup.SecondaryTypes
+			}
+			err = store.UpdateAlbum(ctx, opts)
+			if err != nil {
+				l.Err(err).
+					Str("title", album.Title).
+					Msg("BackfillReleaseTypesFromMusicBrainz: Failed to update release types")
+			} else {
+				l.Info().
+					Str("title", album.Title).
+					Str("primary_type", opts.PrimaryType).
+					Strs("secondary_types", opts.SecondaryTypes).
+					Msg("BackfillReleaseTypesFromMusicBrainz: Release types backfilled successfully")
+			}
+		}
+	}
+}
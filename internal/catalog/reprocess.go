@@ -0,0 +1,372 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+var ErrReprocessRunning = errors.New("a reprocessing job is already running")
+
+type ReprocessOpts struct {
+	Timeframe db.Timeframe
+	Client    string
+	ArtistID  int32
+	AlbumID   int32
+	MbzCaller mbz.MusicBrainzCaller
+	// When true, nothing is written and the result only describes the changes that would be made
+	DryRun bool
+}
+
+// ReprocessTrack describes the track a listen is, or would be, matched to. ID is nil when the
+// track is not in the catalog yet and would be created.
+type ReprocessTrack struct {
+	ID      *int32   `json:"id"`
+	Title   string   `json:"title"`
+	Artists []string `json:"artists"`
+	Album   string   `json:"album"`
+}
+
+type ReprocessChange struct {
+	Time time.Time      `json:"time"`
+	From ReprocessTrack `json:"from"`
+	To   ReprocessTrack `json:"to"`
+}
+
+type ReprocessResult struct {
+	DryRun  bool              `json:"dry_run"`
+	Listens int               `json:"listens"`
+	Changes []ReprocessChange `json:"changes"`
+	// listens a rewrite rule would now drop; reprocessing never deletes listens
+	Dropped int `json:"dropped"`
+	Failed  int `json:"failed"`
+}
+
+type ReprocessStatus struct {
+	Running    bool             `json:"running"`
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	Processed  int              `json:"processed"`
+	Result     *ReprocessResult `json:"result,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+var (
+	reprocessLock   sync.Mutex
+	reprocessStatus ReprocessStatus
+)
+
+// GetReprocessStatus returns the state of the running or last finished reprocessing job.
+func GetReprocessStatus() ReprocessStatus {
+	reprocessLock.Lock()
+	defer reprocessLock.Unlock()
+	return reprocessStatus
+}
+
+func setReprocessProgress(processed int) {
+	reprocessLock.Lock()
+	defer reprocessLock.Unlock()
+	reprocessStatus.Processed = processed
+}
+
+// StartReprocess runs ReprocessListens in the background. It returns ErrReprocessRunning when a
+// job is already running.
+func StartReprocess(ctx context.Context, store submitListenStore, opts ReprocessOpts) error {
+	reprocessLock.Lock()
+	if reprocessStatus.Running {
+		reprocessLock.Unlock()
+		return ErrReprocessRunning
+	}
+	now := time.Now()
+	reprocessStatus = ReprocessStatus{Running: true, StartedAt: &now}
+	reprocessLock.Unlock()
+
+	go func() {
+		res, err := ReprocessListens(ctx, store, opts)
+		finished := time.Now()
+		reprocessLock.Lock()
+		defer reprocessLock.Unlock()
+		reprocessStatus.Running = false
+		reprocessStatus.FinishedAt = &finished
+		reprocessStatus.Result = res
+		if err != nil {
+			reprocessStatus.Error = err.Error()
+		}
+	}()
+	return nil
+}
+
+// ReprocessListens runs the listens matching the filters through rewrite rules and catalog
+// matching again, using the metadata they were submitted with, and moves each listen to the
+// track it now resolves to. Listens saved before submissions were recorded are reprocessed
+// using the metadata of the track they are matched to.
+//
+// In a dry run nothing is created or moved. The tracks listens would be moved to are looked up
+// in the catalog by MusicBrainz ID, then by name, so the result is a close approximation of
+// what a real run would do.
+func ReprocessListens(ctx context.Context, store submitListenStore, opts ReprocessOpts) (*ReprocessResult, error) {
+	l := logger.FromContext(ctx)
+
+	listens, err := store.GetSubmittedListens(ctx, db.GetSubmittedListensOpts{
+		Timeframe: opts.Timeframe,
+		Client:    opts.Client,
+		ArtistID:  opts.ArtistID,
+		AlbumID:   opts.AlbumID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ReprocessListens: %w", err)
+	}
+	rules, err := loadRewriteRules(ctx, store)
+	if err != nil {
+		return nil, fmt.Errorf("ReprocessListens: %w", err)
+	}
+
+	l.Info().Msgf("ReprocessListens: Reprocessing %d listens (dry run: %t)", len(listens), opts.DryRun)
+	res := &ReprocessResult{DryRun: opts.DryRun, Listens: len(listens), Changes: make([]ReprocessChange, 0)}
+	current := make(map[int32]ReprocessTrack)
+	for i, listen := range listens {
+		if !opts.DryRun {
+			setReprocessProgress(i)
+		}
+		if ctx.Err() != nil {
+			return res, fmt.Errorf("ReprocessListens: %w", ctx.Err())
+		}
+		from, ok := current[listen.TrackID]
+		if !ok {
+			from, err = describeTrack(ctx, store, listen.TrackID)
+			if err != nil {
+				l.Err(err).Msgf("ReprocessListens: Failed to retrieve track %d", listen.TrackID)
+				res.Failed++
+				continue
+			}
+			current[listen.TrackID] = from
+		}
+
+		submission := listen.Submission
+		if submission == nil {
+			submission, err = submissionFromTrack(ctx, store, listen.TrackID)
+			if err != nil {
+				l.Err(err).Msgf("ReprocessListens: Failed to rebuild submission for track %d", listen.TrackID)
+				res.Failed++
+				continue
+			}
+		}
+		submitOpts := optsFromSubmission(submission)
+		submitOpts.MbzCaller = opts.MbzCaller
+		submitOpts.SkipCacheImage = true
+		if dropped := rewriteListen(ctx, rules, &submitOpts); dropped {
+			res.Dropped++
+			continue
+		}
+		if submitOpts.Artist == "" || submitOpts.TrackTitle == "" {
+			res.Failed++
+			continue
+		}
+
+		var to ReprocessTrack
+		if opts.DryRun {
+			to, err = predictListen(ctx, store, submitOpts)
+		} else {
+			var track *models.Track
+			recording := &mbzRecording{mbzc: submitOpts.MbzCaller, id: submitOpts.RecordingMbzID}
+			_, _, track, err = associateListen(ctx, store, submitOpts, recording)
+			if err == nil {
+				to, err = describeTrack(ctx, store, track.ID)
+			}
+		}
+		if err != nil {
+			l.Err(err).Msgf("ReprocessListens: Failed to resolve listen at %s", listen.Time)
+			res.Failed++
+			continue
+		}
+		if to.ID != nil && *to.ID == listen.TrackID {
+			continue
+		}
+		if !opts.DryRun {
+			if err := store.MoveListen(ctx, listen.TrackID, listen.Time, *to.ID); err != nil {
+				l.Err(err).Msgf("ReprocessListens: Failed to move listen at %s", listen.Time)
+				res.Failed++
+				continue
+			}
+		}
+		res.Changes = append(res.Changes, ReprocessChange{Time: listen.Time, From: from, To: to})
+	}
+	if !opts.DryRun {
+		setReprocessProgress(len(listens))
+	}
+	l.Info().Msgf("ReprocessListens: Finished with %d changes, %d dropped and %d failed", len(res.Changes), res.Dropped, res.Failed)
+	return res, nil
+}
+
+func describeTrack(ctx context.Context, store submitListenStore, id int32) (ReprocessTrack, error) {
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: id})
+	if err != nil {
+		return ReprocessTrack{}, err
+	}
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: track.AlbumID})
+	if err != nil {
+		return ReprocessTrack{}, err
+	}
+	ret := ReprocessTrack{ID: &track.ID, Title: track.Title, Album: album.Title, Artists: make([]string, 0)}
+	for _, a := range track.Artists {
+		ret.Artists = append(ret.Artists, a.Name)
+	}
+	return ret, nil
+}
+
+// predictListen looks up the track a listen would be matched to without changing the catalog.
+func predictListen(ctx context.Context, store submitListenStore, opts SubmitListenOpts) (ReprocessTrack, error) {
+	ret := ReprocessTrack{Title: opts.TrackTitle, Album: opts.ReleaseTitle}
+	if ret.Album == "" {
+		ret.Album = opts.TrackTitle
+	}
+	names := opts.ArtistNames
+	if len(names) == 0 {
+		names = ParseArtists(opts.Artist, opts.TrackTitle, cfg.ArtistSeparators())
+	}
+	ret.Artists = names
+
+	var artistIDs []int32
+	for _, name := range names {
+		artist, err := store.GetArtist(ctx, db.GetArtistOpts{Name: name})
+		if errors.Is(err, db.ErrNotFound) {
+			return ret, nil
+		} else if err != nil {
+			return ret, err
+		}
+		artistIDs = append(artistIDs, artist.ID)
+	}
+	if len(artistIDs) == 0 {
+		return ret, nil
+	}
+
+	var album *models.Album
+	var err error
+	if opts.ReleaseMbzID != uuid.Nil {
+		album, err = store.GetAlbum(ctx, db.GetAlbumOpts{MusicBrainzID: opts.ReleaseMbzID})
+	}
+	if album == nil {
+		album, err = store.GetAlbum(ctx, db.GetAlbumOpts{Title: ret.Album, ArtistID: artistIDs[0]})
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		return ret, nil
+	} else if err != nil {
+		return ret, err
+	}
+
+	var track *models.Track
+	if opts.RecordingMbzID != uuid.Nil {
+		track, err = store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: opts.RecordingMbzID})
+		if err == nil && track.AlbumID != album.ID {
+			track = nil
+		}
+	}
+	if track == nil {
+		track, err = store.GetTrack(ctx, db.GetTrackOpts{Title: opts.TrackTitle, ReleaseID: album.ID, ArtistIDs: artistIDs})
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		return ret, nil
+	} else if err != nil {
+		return ret, err
+	}
+	return describeTrack(ctx, store, track.ID)
+}
+
+func submissionFromOpts(opts SubmitListenOpts) *models.ListenSubmission {
+	s := &models.ListenSubmission{
+		Artist:         opts.Artist,
+		ArtistNames:    opts.ArtistNames,
+		ArtistMbzIDs:   opts.ArtistMbzIDs,
+		Title:          opts.TrackTitle,
+		Album:          opts.ReleaseTitle,
+		Duration:       opts.Duration,
+		PlayedDuration: opts.PlayedDuration,
+		Tags:           opts.Tags,
+		Client:         opts.Client,
+		AdditionalInfo: opts.AdditionalInfo,
+	}
+	for _, m := range opts.ArtistMbidMappings {
+		s.ArtistMbzIDMap = append(s.ArtistMbzIDMap, models.ArtistMbzIDMapping{Artist: m.Artist, MbzID: m.Mbid})
+	}
+	if opts.RecordingMbzID != uuid.Nil {
+		s.RecordingMbzID = &opts.RecordingMbzID
+	}
+	if opts.ReleaseMbzID != uuid.Nil {
+		s.ReleaseMbzID = &opts.ReleaseMbzID
+	}
+	if opts.ReleaseGroupMbzID != uuid.Nil {
+		s.ReleaseGroupMbzID = &opts.ReleaseGroupMbzID
+	}
+	return s
+}
+
+func optsFromSubmission(s *models.ListenSubmission) SubmitListenOpts {
+	opts := SubmitListenOpts{
+		Artist:         s.Artist,
+		ArtistNames:    s.ArtistNames,
+		ArtistMbzIDs:   s.ArtistMbzIDs,
+		TrackTitle:     s.Title,
+		ReleaseTitle:   s.Album,
+		Duration:       s.Duration,
+		PlayedDuration: s.PlayedDuration,
+		Tags:           s.Tags,
+		Client:         s.Client,
+		AdditionalInfo: s.AdditionalInfo,
+	}
+	for _, m := range s.ArtistMbzIDMap {
+		opts.ArtistMbidMappings = append(opts.ArtistMbidMappings, ArtistMbidMap{Artist: m.Artist, Mbid: m.MbzID})
+	}
+	if s.RecordingMbzID != nil {
+		opts.RecordingMbzID = *s.RecordingMbzID
+	}
+	if s.ReleaseMbzID != nil {
+		opts.ReleaseMbzID = *s.ReleaseMbzID
+	}
+	if s.ReleaseGroupMbzID != nil {
+		opts.ReleaseGroupMbzID = *s.ReleaseGroupMbzID
+	}
+	return opts
+}
+
+// submissionFromTrack rebuilds the submission of a listen from the track it is matched to. The
+// artist names are split again with the configured separators, so artists that were wrongly
+// combined into one are separated.
+func submissionFromTrack(ctx context.Context, store submitListenStore, id int32) (*models.ListenSubmission, error) {
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: track.AlbumID})
+	if err != nil {
+		return nil, err
+	}
+	s := &models.ListenSubmission{
+		Title:          track.Title,
+		Album:          album.Title,
+		Duration:       track.Duration,
+		RecordingMbzID: track.MbzID,
+		ReleaseMbzID:   album.MbzID,
+	}
+	var credited []string
+	for _, a := range track.Artists {
+		credited = append(credited, a.Name)
+		for _, name := range ParseArtists(a.Name, "", cfg.ArtistSeparators()) {
+			if !slices.Contains(s.ArtistNames, name) {
+				s.ArtistNames = append(s.ArtistNames, name)
+			}
+		}
+	}
+	s.Artist = strings.Join(credited, " & ")
+	return s, nil
+}
@@ -0,0 +1,102 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReprocessListens(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	listenedAt := time.Unix(1700000000, 0)
+	opts := catalog.SubmitListenOpts{
+		MbzCaller:      &mbz.MbzErrorCaller{},
+		ArtistNames:    []string{"ATARASHII GAKKO!"},
+		Artist:         "ATARASHII GAKKO!",
+		TrackTitle:     "Tokyo Calling - Remastered 2011",
+		ReleaseTitle:   "AG! Calling",
+		Time:           listenedAt,
+		UserID:         1,
+		Client:         "player",
+		AdditionalInfo: []byte(`{"media_player":"player","custom":1}`),
+	}
+	require.NoError(t, catalog.SubmitListen(ctx, store, opts))
+
+	all := db.GetSubmittedListensOpts{Timeframe: db.Timeframe{Period: db.PeriodAllTime}}
+	listens, err := store.GetSubmittedListens(ctx, all)
+	require.NoError(t, err)
+	require.Len(t, listens, 1)
+	require.NotNil(t, listens[0].Submission)
+	assert.Equal(t, "Tokyo Calling - Remastered 2011", listens[0].Submission.Title)
+	assert.JSONEq(t, `{"media_player":"player","custom":1}`, string(listens[0].Submission.AdditionalInfo))
+	oldTrackID := listens[0].TrackID
+
+	// a listen saved before submissions were recorded
+	require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{
+		TrackID: oldTrackID,
+		Time:    listenedAt.Add(time.Hour),
+		UserID:  1,
+	}))
+
+	_, err = store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		Enabled: true, Action: "strip", Field: "title", Pattern: `\s*-\s*Remastered( \d{4})?$`,
+	})
+	require.NoError(t, err)
+	catalog.InvalidateRewriteRules()
+
+	reprocessOpts := catalog.ReprocessOpts{
+		Timeframe: db.Timeframe{Period: db.PeriodAllTime},
+		MbzCaller: &mbz.MbzErrorCaller{},
+		DryRun:    true,
+	}
+	res, err := catalog.ReprocessListens(ctx, store, reprocessOpts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Listens)
+	require.Len(t, res.Changes, 2)
+	assert.Equal(t, "Tokyo Calling - Remastered 2011", res.Changes[0].From.Title)
+	assert.Equal(t, "Tokyo Calling", res.Changes[0].To.Title)
+	assert.Nil(t, res.Changes[0].To.ID, "the track does not exist yet")
+
+	// a dry run changes nothing
+	listens, err = store.GetSubmittedListens(ctx, all)
+	require.NoError(t, err)
+	assert.Equal(t, oldTrackID, listens[0].TrackID)
+
+	reprocessOpts.DryRun = false
+	res, err = catalog.ReprocessListens(ctx, store, reprocessOpts)
+	require.NoError(t, err)
+	require.Len(t, res.Changes, 2)
+	require.NotNil(t, res.Changes[0].To.ID)
+
+	listens, err = store.GetSubmittedListens(ctx, all)
+	require.NoError(t, err)
+	require.Len(t, listens, 2)
+	for _, l := range listens {
+		assert.Equal(t, *res.Changes[0].To.ID, l.TrackID)
+	}
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: listens[0].TrackID})
+	require.NoError(t, err)
+	assert.Equal(t, "Tokyo Calling", track.Title)
+
+	// reprocessing again finds nothing to change
+	res, err = catalog.ReprocessListens(ctx, store, reprocessOpts)
+	require.NoError(t, err)
+	assert.Empty(t, res.Changes)
+
+	// filters narrow down the listens
+	res, err = catalog.ReprocessListens(ctx, store, catalog.ReprocessOpts{
+		Timeframe: db.Timeframe{Period: db.PeriodAllTime},
+		Client:    "player",
+		DryRun:    true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Listens)
+}
@@ -0,0 +1,277 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// the fields of a listen rewrite rules can match on and change
+var rewriteFields = []string{"artist", "title", "album", "client"}
+
+var (
+	rewriteMatchTypes = []string{"exact", "regex"}
+	rewriteActions    = []string{"replace", "strip", "set", "drop"}
+)
+
+// ListenMetadata is the metadata of a submitted listen that rewrite rules apply to.
+type ListenMetadata struct {
+	Artist      string   `json:"artist"`
+	ArtistNames []string `json:"artist_names,omitempty"`
+	Title       string   `json:"title"`
+	Album       string   `json:"album"`
+	Client      string   `json:"client"`
+}
+
+type RewriteResult struct {
+	ListenMetadata
+	Dropped bool `json:"dropped"`
+	// the ids of the rules that changed the listen, in the order they were applied
+	Applied []int32 `json:"applied_rules"`
+}
+
+// ValidateRewriteRule reports whether a rule can be applied, checking its fields, match types,
+// action and regular expressions.
+func ValidateRewriteRule(r models.RewriteRule) error {
+	for _, c := range r.Conditions {
+		if !slices.Contains(rewriteFields, c.Field) {
+			return fmt.Errorf("invalid condition field '%s'", c.Field)
+		}
+		if !slices.Contains(rewriteMatchTypes, c.Match) {
+			return fmt.Errorf("invalid match type '%s'", c.Match)
+		}
+		if c.Match == "regex" {
+			if _, err := regexp.Compile(c.Value); err != nil {
+				return fmt.Errorf("invalid condition pattern: %w", err)
+			}
+		}
+	}
+	if !slices.Contains(rewriteActions, r.Action) {
+		return fmt.Errorf("invalid action '%s'", r.Action)
+	}
+	if r.Action == "drop" {
+		return nil
+	}
+	if !slices.Contains(rewriteFields, r.Field) {
+		return fmt.Errorf("invalid field '%s'", r.Field)
+	}
+	switch r.Action {
+	case "replace", "strip":
+		if r.Pattern == "" {
+			return errors.New("pattern is required")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case "set":
+		if r.Value == "" && (r.Field == "artist" || r.Field == "title") {
+			return fmt.Errorf("%s cannot be set to an empty value", r.Field)
+		}
+	}
+	return nil
+}
+
+// rewriteRule is a valid rewrite rule with its regular expressions compiled.
+type rewriteRule struct {
+	*models.RewriteRule
+	pattern *regexp.Regexp
+	// the compiled patterns of the conditions, nil for the ones that match exactly
+	conditions []*regexp.Regexp
+}
+
+func compileRewriteRule(r *models.RewriteRule) (*rewriteRule, error) {
+	if err := ValidateRewriteRule(*r); err != nil {
+		return nil, fmt.Errorf("compileRewriteRule: rule %d: %w", r.ID, err)
+	}
+	cr := &rewriteRule{RewriteRule: r, conditions: make([]*regexp.Regexp, len(r.Conditions))}
+	for i, c := range r.Conditions {
+		if c.Match != "regex" {
+			continue
+		}
+		pattern := c.Value
+		if c.CaseInsensitive {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compileRewriteRule: rule %d: invalid condition pattern: %w", r.ID, err)
+		}
+		cr.conditions[i] = re
+	}
+	if r.Action == "replace" || r.Action == "strip" {
+		// already known to compile, as the rule is valid
+		cr.pattern = regexp.MustCompile(r.Pattern)
+	}
+	return cr, nil
+}
+
+// compileRewriteRules compiles the enabled rules in order. Rules that are not valid are skipped,
+// and returned joined in the error.
+func compileRewriteRules(rules []*models.RewriteRule) ([]*rewriteRule, error) {
+	compiled := make([]*rewriteRule, 0, len(rules))
+	var errs []error
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		cr, err := compileRewriteRule(r)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		compiled = append(compiled, cr)
+	}
+	return compiled, errors.Join(errs...)
+}
+
+// rewriteRuleCache holds the compiled rewrite rules of the store they were loaded from, so they
+// are not loaded and compiled again for every listen.
+var rewriteRuleCache struct {
+	sync.Mutex
+	store db.RewriteRuleStore
+	rules []*rewriteRule
+}
+
+// loadRewriteRules returns the compiled rewrite rules of the store, loading them when they are
+// not cached yet. Rules that are not valid are logged and skipped.
+func loadRewriteRules(ctx context.Context, store db.RewriteRuleStore) ([]*rewriteRule, error) {
+	rewriteRuleCache.Lock()
+	defer rewriteRuleCache.Unlock()
+	if rewriteRuleCache.store != nil && rewriteRuleCache.store == store {
+		return rewriteRuleCache.rules, nil
+	}
+	rules, err := store.GetRewriteRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loadRewriteRules: %w", err)
+	}
+	compiled, err := compileRewriteRules(rules)
+	if err != nil {
+		logger.FromContext(ctx).Warn().Err(err).Msg("loadRewriteRules: Skipping invalid rewrite rules")
+	}
+	rewriteRuleCache.store = store
+	rewriteRuleCache.rules = compiled
+	return compiled, nil
+}
+
+// InvalidateRewriteRules clears the cached rewrite rules. It must be called whenever rewrite
+// rules are created, changed or deleted.
+func InvalidateRewriteRules() {
+	rewriteRuleCache.Lock()
+	defer rewriteRuleCache.Unlock()
+	rewriteRuleCache.store = nil
+	rewriteRuleCache.rules = nil
+}
+
+// ApplyRewriteRules runs the enabled rules over the metadata of a listen in order, so each rule
+// sees the output of the rules before it. Rules that are not valid are skipped. Once a rule
+// drops the listen no further rules are applied.
+//
+// Actions on the artist field apply to the artist credit and to each artist name separately,
+// except for "set", which replaces all of them with the single value.
+func ApplyRewriteRules(rules []*models.RewriteRule, md ListenMetadata) RewriteResult {
+	compiled, _ := compileRewriteRules(rules)
+	return applyRewriteRules(compiled, md)
+}
+
+func applyRewriteRules(rules []*rewriteRule, md ListenMetadata) RewriteResult {
+	res := RewriteResult{ListenMetadata: md, Applied: make([]int32, 0)}
+	res.ArtistNames = slices.Clone(md.ArtistNames)
+
+	for _, r := range rules {
+		if !rewriteRuleMatches(r, res.ListenMetadata) {
+			continue
+		}
+		if r.Action == "drop" {
+			res.Applied = append(res.Applied, r.ID)
+			res.Dropped = true
+			return res
+		}
+		if applyRewriteRule(r, &res.ListenMetadata) {
+			res.Applied = append(res.Applied, r.ID)
+		}
+	}
+	return res
+}
+
+// applyRewriteRule applies the action of a rule to the metadata and reports whether it changed.
+func applyRewriteRule(r *rewriteRule, md *ListenMetadata) bool {
+	if r.Field == "artist" {
+		artist, names := md.Artist, slices.Clone(md.ArtistNames)
+		if r.Action == "set" {
+			md.Artist = r.Value
+			if len(md.ArtistNames) > 0 {
+				md.ArtistNames = []string{r.Value}
+			}
+		} else {
+			rewrite := rewriteFunc(r)
+			md.Artist = rewrite(md.Artist)
+			for i := range md.ArtistNames {
+				md.ArtistNames[i] = rewrite(md.ArtistNames[i])
+			}
+			md.ArtistNames = slices.DeleteFunc(md.ArtistNames, func(s string) bool { return s == "" })
+		}
+		return md.Artist != artist || !slices.Equal(md.ArtistNames, names)
+	}
+
+	var field *string
+	switch r.Field {
+	case "title":
+		field = &md.Title
+	case "album":
+		field = &md.Album
+	case "client":
+		field = &md.Client
+	}
+	before := *field
+	*field = rewriteFunc(r)(before)
+	return *field != before
+}
+
+func rewriteRuleMatches(r *rewriteRule, md ListenMetadata) bool {
+	for i, c := range r.Conditions {
+		var values []string
+		switch c.Field {
+		case "artist":
+			values = append([]string{md.Artist}, md.ArtistNames...)
+		case "title":
+			values = []string{md.Title}
+		case "album":
+			values = []string{md.Album}
+		case "client":
+			values = []string{md.Client}
+		}
+		if !slices.ContainsFunc(values, func(v string) bool { return rewriteConditionMatches(c, r.conditions[i], v) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func rewriteConditionMatches(c models.RewriteCondition, re *regexp.Regexp, v string) bool {
+	if re != nil {
+		return re.MatchString(v)
+	}
+	if c.CaseInsensitive {
+		return strings.EqualFold(c.Value, v)
+	}
+	return c.Value == v
+}
+
+func rewriteFunc(r *rewriteRule) func(string) string {
+	switch r.Action {
+	case "replace":
+		return func(s string) string { return r.pattern.ReplaceAllString(s, r.Value) }
+	case "strip":
+		return func(s string) string { return strings.TrimSpace(r.pattern.ReplaceAllString(s, "")) }
+	default:
+		return func(string) string { return r.Value }
+	}
+}
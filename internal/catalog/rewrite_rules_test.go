@@ -0,0 +1,162 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRewriteRules(t *testing.T) {
+	stripRemaster := &models.RewriteRule{
+		ID: 1, Enabled: true, Action: "strip", Field: "title", Pattern: `\s*-\s*Remastered( \d{4})?$`,
+	}
+	stripTopic := &models.RewriteRule{
+		ID: 2, Enabled: true, Action: "strip", Field: "artist", Pattern: ` - Topic$`,
+	}
+	fixAlbum := &models.RewriteRule{
+		ID: 3, Enabled: true, Action: "set", Field: "album", Value: "",
+		Conditions: []models.RewriteCondition{{Field: "client", Match: "exact", Value: "badplayer", CaseInsensitive: true}},
+	}
+	mapArtist := &models.RewriteRule{
+		ID: 4, Enabled: true, Action: "set", Field: "artist", Value: "ATARASHII GAKKO!",
+		Conditions: []models.RewriteCondition{{Field: "artist", Match: "regex", Value: `^atarashii gakko!?$`, CaseInsensitive: true}},
+	}
+	dropPodcasts := &models.RewriteRule{
+		ID: 5, Enabled: true, Action: "drop",
+		Conditions: []models.RewriteCondition{
+			{Field: "album", Match: "regex", Value: `(?i)podcast`},
+			{Field: "client", Match: "exact", Value: "phone"},
+		},
+	}
+	rules := []*models.RewriteRule{stripRemaster, stripTopic, fixAlbum, mapArtist, dropPodcasts}
+
+	res := catalog.ApplyRewriteRules(rules, catalog.ListenMetadata{
+		Artist:      "Some Band - Topic",
+		ArtistNames: []string{"Some Band - Topic"},
+		Title:       "Song - Remastered 2011",
+		Album:       "Album",
+		Client:      "BadPlayer",
+	})
+	assert.Equal(t, "Some Band", res.Artist)
+	assert.Equal(t, []string{"Some Band"}, res.ArtistNames)
+	assert.Equal(t, "Song", res.Title)
+	assert.Equal(t, "", res.Album)
+	assert.Equal(t, []int32{1, 2, 3}, res.Applied)
+	assert.False(t, res.Dropped)
+
+	res = catalog.ApplyRewriteRules(rules, catalog.ListenMetadata{Artist: "Atarashii Gakko", Title: "Song"})
+	assert.Equal(t, "ATARASHII GAKKO!", res.Artist)
+	assert.Empty(t, res.ArtistNames)
+
+	// every condition has to match
+	res = catalog.ApplyRewriteRules(rules, catalog.ListenMetadata{Artist: "A", Title: "B", Album: "My Podcast", Client: "desktop"})
+	assert.False(t, res.Dropped)
+	res = catalog.ApplyRewriteRules(rules, catalog.ListenMetadata{Artist: "A", Title: "B", Album: "My Podcast", Client: "phone"})
+	assert.True(t, res.Dropped)
+	assert.Equal(t, []int32{5}, res.Applied)
+
+	stripRemaster.Enabled = false
+	res = catalog.ApplyRewriteRules(rules, catalog.ListenMetadata{Artist: "A", Title: "Song - Remastered"})
+	assert.Equal(t, "Song - Remastered", res.Title)
+}
+
+func TestValidateRewriteRule(t *testing.T) {
+	assert.NoError(t, catalog.ValidateRewriteRule(models.RewriteRule{Action: "drop"}))
+	assert.NoError(t, catalog.ValidateRewriteRule(models.RewriteRule{Action: "replace", Field: "title", Pattern: "a", Value: "b"}))
+	assert.Error(t, catalog.ValidateRewriteRule(models.RewriteRule{Action: "rename", Field: "title"}))
+	assert.Error(t, catalog.ValidateRewriteRule(models.RewriteRule{Action: "strip", Field: "genre", Pattern: "a"}))
+	assert.Error(t, catalog.ValidateRewriteRule(models.RewriteRule{Action: "strip", Field: "title", Pattern: "("}))
+	assert.Error(t, catalog.ValidateRewriteRule(models.RewriteRule{Action: "set", Field: "title"}))
+	assert.Error(t, catalog.ValidateRewriteRule(models.RewriteRule{
+		Action:     "drop",
+		Conditions: []models.RewriteCondition{{Field: "title", Match: "contains", Value: "a"}},
+	}))
+}
+
+func TestSubmitListen_RewriteRules(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	_, err := store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		Enabled: true, Action: "strip", Field: "title", Pattern: `\s*-\s*Remastered( \d{4})?$`,
+	})
+	require.NoError(t, err)
+	_, err = store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		Enabled:    true,
+		Action:     "drop",
+		Conditions: []models.RewriteCondition{{Field: "client", Match: "exact", Value: "spam"}},
+	})
+	require.NoError(t, err)
+
+	opts := catalog.SubmitListenOpts{
+		MbzCaller:    &mbz.MbzErrorCaller{},
+		ArtistNames:  []string{"ATARASHII GAKKO!"},
+		Artist:       "ATARASHII GAKKO!",
+		TrackTitle:   "Tokyo Calling - Remastered 2011",
+		ReleaseTitle: "AG! Calling",
+		Time:         time.Now(),
+		UserID:       1,
+	}
+	require.NoError(t, catalog.SubmitListen(ctx, store, opts))
+
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Tokyo Calling", track.Title)
+	assert.EqualValues(t, 1, track.ListenCount)
+
+	opts.Client = "spam"
+	opts.Time = opts.Time.Add(time.Minute)
+	require.NoError(t, catalog.SubmitListen(ctx, store, opts))
+	count, err := store.CountListens(ctx, db.Timeframe{Period: db.PeriodAllTime})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count, "dropped listens are not saved")
+}
+
+func TestSubmitListen_RewriteRuleCache(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+
+	// a stored rule that no longer compiles is skipped instead of failing the listen
+	_, err := store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		Enabled: true, Action: "strip", Field: "title", Pattern: `(`,
+	})
+	require.NoError(t, err)
+
+	opts := catalog.SubmitListenOpts{
+		MbzCaller:    &mbz.MbzErrorCaller{},
+		ArtistNames:  []string{"ATARASHII GAKKO!"},
+		Artist:       "ATARASHII GAKKO!",
+		TrackTitle:   "Tokyo Calling - Remastered",
+		ReleaseTitle: "AG! Calling",
+		Time:         time.Now(),
+		UserID:       1,
+	}
+	require.NoError(t, catalog.SubmitListen(ctx, store, opts))
+
+	// rules are cached until they are invalidated
+	_, err = store.SaveRewriteRule(ctx, db.SaveRewriteRuleOpts{
+		Enabled: true, Action: "strip", Field: "title", Pattern: `\s*-\s*Remastered$`,
+	})
+	require.NoError(t, err)
+	opts.Time = opts.Time.Add(time.Minute)
+	require.NoError(t, catalog.SubmitListen(ctx, store, opts))
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Tokyo Calling - Remastered", track.Title)
+	assert.EqualValues(t, 2, track.ListenCount)
+
+	catalog.InvalidateRewriteRules()
+	opts.Time = opts.Time.Add(time.Minute)
+	require.NoError(t, catalog.SubmitListen(ctx, store, opts))
+	track, err = store.GetTrack(ctx, db.GetTrackOpts{ID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "Tokyo Calling", track.Title)
+	assert.EqualValues(t, 1, track.ListenCount)
+}
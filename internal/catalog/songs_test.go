@@ -0,0 +1,53 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssociateTrack_SameRecordingOnOtherRelease(t *testing.T) {
+	store := newTestDB()
+
+	setupTestDataWithMbzIDs(store, t)
+
+	ctx := context.Background()
+	compilation, err := store.SaveAlbum(ctx, db.SaveAlbumOpts{Title: "Best Of", ArtistIDs: []int32{1}})
+	require.NoError(t, err)
+
+	original, err := store.GetTrack(ctx, db.GetTrackOpts{ID: 1})
+	require.NoError(t, err)
+
+	opts := catalog.AssociateTrackOpts{
+		ArtistIDs:  []int32{1},
+		AlbumID:    compilation.ID,
+		TrackMbzID: uuid.MustParse("00000000-0000-0000-0000-000000001001"),
+		TrackName:  "Tokyo Calling",
+		Mbzc:       &mbz.MbzMockCaller{},
+	}
+	track, err := catalog.AssociateTrack(ctx, store, opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, original.ID, track.ID, "the listen should stay on the release it was heard on")
+	assert.Equal(t, compilation.ID, track.AlbumID)
+	assert.Equal(t, original.SongID, track.SongID)
+
+	again, err := catalog.AssociateTrack(ctx, store, opts)
+	require.NoError(t, err)
+	assert.Equal(t, track.ID, again.ID)
+
+	// the original release still resolves to the original track
+	opts.AlbumID = original.AlbumID
+	track, err = catalog.AssociateTrack(ctx, store, opts)
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, track.ID)
+
+	song, err := store.GetSong(ctx, original.SongID)
+	require.NoError(t, err)
+	assert.Len(t, song.Tracks, 2)
+}
@@ -1050,3 +1050,61 @@ func TestSubmitListen_MusicBrainzUnreachableMBIDMappings(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, exists, "expected artist to have correct musicbrainz id")
 }
+
+func TestSubmitListen_SaveTags(t *testing.T) {
+	store := newTestDB()
+
+	// tags submitted with the listen are saved to the track,
+	// and genres from musicbrainz are saved to the artist and album
+
+	ctx := context.Background()
+	artistMbzID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	releaseGroupMbzID := uuid.MustParse("00000000-0000-0000-0000-000000000011")
+	releaseMbzID := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	artist := *mbzArtistData[artistMbzID]
+	artist.Genres = []mbz.MusicBrainzGenre{{Name: "J-Pop", Count: 3}}
+	rg := *mbzReleaseGroupData[releaseGroupMbzID]
+	rg.Genres = []mbz.MusicBrainzGenre{{Name: "Pop Rock", Count: 1}}
+	release := *mbzReleaseData[releaseMbzID]
+	release.Genres = []mbz.MusicBrainzGenre{{Name: "j-pop", Count: 2}}
+	mbzc := &mbz.MbzMockCaller{
+		Artists:       map[uuid.UUID]*mbz.MusicBrainzArtist{artistMbzID: &artist},
+		ReleaseGroups: map[uuid.UUID]*mbz.MusicBrainzReleaseGroup{releaseGroupMbzID: &rg},
+		Releases:      map[uuid.UUID]*mbz.MusicBrainzRelease{releaseMbzID: &release},
+	}
+	opts := catalog.SubmitListenOpts{
+		MbzCaller:         mbzc,
+		ArtistNames:       []string{"ATARASHII GAKKO!"},
+		Artist:            "ATARASHII GAKKO!",
+		ArtistMbzIDs:      []uuid.UUID{artistMbzID},
+		TrackTitle:        "Tokyo Calling",
+		ReleaseTitle:      "AG! Calling",
+		ReleaseMbzID:      releaseMbzID,
+		ReleaseGroupMbzID: releaseGroupMbzID,
+		Tags:              []string{"Dance", ""},
+		Time:              time.Now(),
+		UserID:            1,
+	}
+
+	err := catalog.SubmitListen(ctx, store, opts)
+	require.NoError(t, err)
+
+	a, err := store.GetArtist(ctx, db.GetArtistOpts{MusicBrainzID: artistMbzID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"j-pop"}, a.Tags)
+
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{MusicBrainzID: releaseMbzID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"j-pop", "pop rock"}, album.Tags)
+
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{Title: "Tokyo Calling", ReleaseID: album.ID, ArtistIDs: []int32{a.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dance"}, track.Tags)
+
+	tags, err := store.GetTopTagsPaginated(ctx, db.GetItemsOpts{Limit: 10, Page: 1, Timeframe: db.Timeframe{Period: db.PeriodAllTime}})
+	require.NoError(t, err)
+	require.Len(t, tags.Items, 3)
+	for _, tag := range tags.Items {
+		assert.EqualValues(t, 1, tag.Item.ListenCount)
+	}
+}
@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+// tracklistFromRelease converts the media of a MusicBrainz release into a tracklist.
+func tracklistFromRelease(release *mbz.MusicBrainzRelease) []models.TracklistEntry {
+	tracklist := make([]models.TracklistEntry, 0)
+	for _, medium := range release.Media {
+		for _, track := range medium.Tracks {
+			e := models.TracklistEntry{
+				DiscNumber: int32(medium.Position),
+				Position:   int32(track.Position),
+				Number:     track.Number,
+				Title:      track.Title,
+				Duration:   int32(track.LengthMs / 1000),
+			}
+			if e.Title == "" {
+				e.Title = track.Recording.Title
+			}
+			if e.Duration == 0 {
+				e.Duration = int32(track.Recording.LengthMs / 1000)
+			}
+			if id, err := uuid.Parse(track.Recording.ID); err == nil {
+				e.RecordingMbzID = &id
+			}
+			tracklist = append(tracklist, e)
+		}
+	}
+	return tracklist
+}
+
+// BackfillTracklistsFromMusicBrainz fetches the full tracklist of every album that has a
+// MusicBrainz release ID but whose tracklist has never been looked up.
+func BackfillTracklistsFromMusicBrainz(
+	ctx context.Context,
+	store db.AlbumStore,
+	mbzCaller mbz.MusicBrainzCaller,
+) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("BackfillTracklistsFromMusicBrainz: Starting backfill of tracklists from MusicBrainz")
+
+	var from int32 = 0
+
+	for {
+		l.Debug().Int32("ID", from).Msg("Fetching albums to backfill from ID")
+		albums, err := store.GetAlbumsWithNoTracklistButHaveMbzID(ctx, from)
+		if err != nil {
+			return fmt.Errorf("BackfillTracklistsFromMusicBrainz: failed to fetch albums for tracklist backfill: %w", err)
+		}
+
+		if len(albums) == 0 {
+			if from == 0 {
+				l.Info().Msg("BackfillTracklistsFromMusicBrainz: No albums need updating. Skipping backfill...")
+			} else {
+				l.Info().Msg("BackfillTracklistsFromMusicBrainz: Backfill complete")
+			}
+			return nil
+		}
+
+		for _, album := range albums {
+			from = album.ID
+
+			if album.MbzID == nil || *album.MbzID == uuid.Nil {
+				continue
+			}
+
+			l.Debug().
+				Str("title", album.Title).
+				Str("mbz_id", album.MbzID.String()).
+				Msg("BackfillTracklistsFromMusicBrainz: Backfilling tracklist from MusicBrainz")
+
+			release, err := mbzCaller.GetRelease(ctx, *album.MbzID)
+			if err != nil {
+				l.Err(err).
+					Str("title", album.Title).
+					Msg("BackfillTracklistsFromMusicBrainz: Failed to fetch release from MusicBrainz")
+				continue
+			}
+
+			tracklist := tracklistFromRelease(release)
+			err = store.SaveAlbumTracklist(ctx, album.ID, tracklist)
+			if err != nil {
+				l.Err(err).
+					Str("title", album.Title).
+					Msg("BackfillTracklistsFromMusicBrainz: Failed to save tracklist")
+			} else {
+				l.Info().
+					Str("title", album.Title).
+					Int("tracks", len(tracklist)).
+					Msg("BackfillTracklistsFromMusicBrainz: Tracklist backfilled successfully")
+			}
+		}
+	}
+}
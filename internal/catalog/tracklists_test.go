@@ -0,0 +1,130 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillTracklists(t *testing.T) {
+	store := newTestDB()
+
+	setupTestDataWithMbzIDs(store, t)
+
+	ctx := context.Background()
+	releaseMbzID := uuid.MustParse("00000000-0000-0000-0000-000000000101")
+	release := *mbzReleaseData[releaseMbzID]
+	release.Media = []mbz.MusicBrainzMedium{
+		{
+			Position: 1,
+			Tracks: []mbz.MusicBrainzReleaseTrack{
+				{Position: 1, Number: "1", Title: "Tokyo Calling", LengthMs: 215000,
+					Recording: mbz.MusicBrainzRecording{ID: "00000000-0000-0000-0000-000000001001"}},
+				{Position: 2, Number: "2", Title: "Jiwaru DAYS", LengthMs: 242000,
+					Recording: mbz.MusicBrainzRecording{ID: "00000000-0000-0000-0000-000000001002"}},
+			},
+		},
+		{
+			Position: 2,
+			Tracks: []mbz.MusicBrainzReleaseTrack{
+				{Position: 1, Number: "1", Recording: mbz.MusicBrainzRecording{
+					ID: "00000000-0000-0000-0000-000000001003", Title: "Otona Blue", LengthMs: 223000}},
+			},
+		},
+	}
+	mbzc := &mbz.MbzMockCaller{
+		Releases: map[uuid.UUID]*mbz.MusicBrainzRelease{releaseMbzID: &release},
+	}
+
+	err := catalog.BackfillTracklistsFromMusicBrainz(ctx, store, &mbz.MbzErrorCaller{})
+	assert.NoError(t, err)
+	albums, err := store.GetAlbumsWithNoTracklistButHaveMbzID(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, albums, 1, "failed lookups should be retried")
+
+	err = catalog.BackfillTracklistsFromMusicBrainz(ctx, store, mbzc)
+	assert.NoError(t, err)
+
+	tracklist, err := store.GetAlbumTracklist(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, tracklist, 3)
+	assert.EqualValues(t, 1, tracklist[0].DiscNumber)
+	assert.Equal(t, "Tokyo Calling", tracklist[0].Title)
+	assert.EqualValues(t, 215, tracklist[0].Duration)
+	require.NotNil(t, tracklist[0].TrackID)
+	assert.EqualValues(t, 1, *tracklist[0].TrackID)
+	assert.Nil(t, tracklist[1].TrackID)
+	assert.EqualValues(t, 2, tracklist[2].DiscNumber)
+	assert.Equal(t, "Otona Blue", tracklist[2].Title, "recording title is used when the track has none")
+
+	albums, err = store.GetAlbumsWithNoTracklistButHaveMbzID(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, albums)
+}
+
+func TestAlbumCompletion(t *testing.T) {
+	store := newTestDB()
+
+	setupTestDataWithMbzIDs(store, t)
+
+	ctx := context.Background()
+	// a second track without an mbid, linked to the tracklist by title
+	require.NoError(t, store.Exec(`INSERT INTO tracks (release_id, duration) VALUES (1, 240)`))
+	require.NoError(t, store.Exec(
+		`INSERT INTO track_aliases (track_id, alias, source, is_primary) VALUES (2, 'jiwaru days', 'Testing', true)`))
+	require.NoError(t, store.Exec(`INSERT INTO artist_tracks (artist_id, track_id) VALUES (1, 2)`))
+
+	recording := uuid.MustParse("00000000-0000-0000-0000-000000001001")
+	require.NoError(t, store.SaveAlbumTracklist(ctx, 1, []models.TracklistEntry{
+		{DiscNumber: 1, Position: 1, Title: "Tokyo Calling", RecordingMbzID: &recording, Duration: 215},
+		{DiscNumber: 1, Position: 2, Title: "Jiwaru DAYS", Duration: 242},
+	}))
+
+	completion, err := store.GetAlbumCompletion(ctx, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, completion.TotalTracks)
+	assert.EqualValues(t, 0, completion.HeardTracks)
+	assert.Len(t, completion.Unheard, 2)
+
+	start := time.Now().Add(-24 * time.Hour)
+	for _, l := range []struct {
+		track  int32
+		offset time.Duration
+	}{
+		{1, 0}, {2, 216 * time.Second}, // full play
+		{2, 2 * time.Hour}, {1, 2*time.Hour + 250*time.Second}, // out of order
+		{1, 4 * time.Hour}, {2, 6 * time.Hour}, // too far apart
+		{1, 8 * time.Hour}, {1, 8*time.Hour + 216*time.Second}, {2, 8*time.Hour + 432*time.Second}, // restarted, full play
+	} {
+		require.NoError(t, store.SaveListen(ctx, db.SaveListenOpts{TrackID: l.track, Time: start.Add(l.offset), UserID: 1}))
+	}
+
+	completion, err = store.GetAlbumCompletion(ctx, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, completion.HeardTracks)
+	assert.Equal(t, 1.0, completion.Completion)
+	assert.Empty(t, completion.Unheard)
+	assert.EqualValues(t, 2, completion.FullAlbumPlays)
+
+	// a position that is not linked to any track prevents detecting full plays
+	require.NoError(t, store.SaveAlbumTracklist(ctx, 1, []models.TracklistEntry{
+		{DiscNumber: 1, Position: 1, Title: "Tokyo Calling", RecordingMbzID: &recording},
+		{DiscNumber: 1, Position: 2, Title: "Jiwaru DAYS"},
+		{DiscNumber: 1, Position: 3, Title: "Otona Blue"},
+	}))
+	completion, err = store.GetAlbumCompletion(ctx, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, completion.TotalTracks)
+	assert.InDelta(t, 2.0/3.0, completion.Completion, 0.0001)
+	require.Len(t, completion.Unheard, 1)
+	assert.Equal(t, "Otona Blue", completion.Unheard[0].Title)
+	assert.EqualValues(t, 0, completion.FullAlbumPlays)
+}
@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+)
+
+var ErrTrashExpired = errors.New("item was deleted too long ago to be restored")
+
+// trashPurgeInterval is how often RunTrashPurge purges the trash.
+const trashPurgeInterval = 24 * time.Hour
+
+// RestoreTrashItem restores an item deleted within the trash retention window.
+func RestoreTrashItem(ctx context.Context, store db.TrashStore, id int32) error {
+	item, err := store.GetTrashItem(ctx, id)
+	if err != nil {
+		return fmt.Errorf("RestoreTrashItem: %w", err)
+	}
+	if item.DeletedAt.Before(time.Now().Add(-cfg.TrashRetention())) {
+		return fmt.Errorf("RestoreTrashItem: %w", ErrTrashExpired)
+	}
+	if err := store.RestoreTrashItem(ctx, id); err != nil {
+		return fmt.Errorf("RestoreTrashItem: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrash removes the items that are past the trash retention window for good.
+func PurgeTrash(ctx context.Context, store db.TrashStore) error {
+	l := logger.FromContext(ctx)
+	n, err := store.DeleteTrashBefore(ctx, time.Now().Add(-cfg.TrashRetention()))
+	if err != nil {
+		l.Err(err).Msg("PurgeTrash: Failed to purge trash")
+		return fmt.Errorf("PurgeTrash: %w", err)
+	}
+	l.Info().Msgf("PurgeTrash: Purged %d items past the trash retention window", n)
+	return nil
+}
+
+// RunTrashPurge purges the trash once a day, until ctx is done.
+func RunTrashPurge(ctx context.Context, store db.TrashStore) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			PurgeTrash(ctx, store)
+		}
+	}
+}
@@ -13,6 +13,13 @@ const (
 	// defaultBaseUrl        = "http://127.0.0.1"
 	defaultListenPort     = 4110
 	defaultMusicBrainzUrl = "https://musicbrainz.org"
+	defaultBackupInterval = 24 * time.Hour
+	defaultBackupDaily    = 7
+	defaultBackupWeekly   = 4
+	defaultBackupMonthly  = 6
+	defaultMergeUndoDays  = 30
+	defaultTrashDays      = 30
+	defaultLibraryScan    = 24 * time.Hour
 )
 
 const (
@@ -50,8 +57,38 @@ const (
 	ARTIST_SEPARATORS_ENV          = "KOITO_ARTIST_SEPARATORS_REGEX"
 	LOGIN_GATE_ENV                 = "KOITO_LOGIN_GATE"
 	FORCE_TZ                       = "KOITO_FORCE_TZ"
+	ENABLE_AUTO_BACKUP_ENV         = "KOITO_ENABLE_AUTO_BACKUP"
+	BACKUP_INTERVAL_HOURS_ENV      = "KOITO_BACKUP_INTERVAL_HOURS"
+	BACKUP_KEEP_DAILY_ENV          = "KOITO_BACKUP_KEEP_DAILY"
+	BACKUP_KEEP_WEEKLY_ENV         = "KOITO_BACKUP_KEEP_WEEKLY"
+	BACKUP_KEEP_MONTHLY_ENV        = "KOITO_BACKUP_KEEP_MONTHLY"
+	INGEST_ALLOW_CLIENTS_ENV       = "KOITO_INGEST_ALLOW_CLIENTS"
+	INGEST_DENY_CLIENTS_ENV        = "KOITO_INGEST_DENY_CLIENTS"
+	INGEST_DENY_ARTISTS_ENV        = "KOITO_INGEST_DENY_ARTISTS_REGEX"
+	INGEST_DENY_ALBUMS_ENV         = "KOITO_INGEST_DENY_ALBUMS_REGEX"
+	INGEST_MIN_DURATION_ENV        = "KOITO_INGEST_MIN_TRACK_DURATION"
+	INGEST_MIN_PLAYED_ENV          = "KOITO_INGEST_MIN_PLAYED_DURATION"
+	MBZ_MATCH_AUTO_APPLY_ENV       = "KOITO_MBZ_MATCH_AUTO_APPLY_CONFIDENCE"
+	MERGE_UNDO_RETENTION_ENV       = "KOITO_MERGE_UNDO_RETENTION_DAYS"
+	TRASH_RETENTION_ENV            = "KOITO_TRASH_RETENTION_DAYS"
+	LIBRARY_DIR_ENV                = "KOITO_LIBRARY_DIR"
+	LIBRARY_SCAN_INTERVAL_ENV      = "KOITO_LIBRARY_SCAN_INTERVAL_HOURS"
+	ARTIST_IMAGE_PROVIDERS_ENV     = "KOITO_ARTIST_IMAGE_PROVIDERS"
+	ALBUM_IMAGE_PROVIDERS_ENV      = "KOITO_ALBUM_IMAGE_PROVIDERS"
 )
 
+// IngestFilterConfig decides which submitted listens are discarded before they are saved.
+// Durations are in seconds, and are only checked when the submission includes them.
+type IngestFilterConfig struct {
+	// when not empty, only listens from these clients are accepted
+	AllowClients      []string
+	DenyClients       []string
+	DenyArtists       []*regexp.Regexp
+	DenyAlbums        []*regexp.Regexp
+	MinTrackDuration  int32
+	MinPlayedDuration int32
+}
+
 type config struct {
 	bindAddr   string
 	listenPort int
@@ -75,6 +112,8 @@ type config struct {
 	subsonicUrl            string
 	subsonicParams         string
 	lastfmApiKey           string
+	artistImageProviders   []string
+	albumImageProviders    []string
 	subsonicEnabled        bool
 	skipImport             bool
 	fetchImageDuringImport bool
@@ -84,11 +123,23 @@ type config struct {
 	disableRateLimit       bool
 	importThrottleMs       int
 	userAgent              string
+	version                string
 	importBefore           time.Time
 	importAfter            time.Time
 	artistSeparators       []*regexp.Regexp
 	loginGate              bool
 	forceTZ                *time.Location
+	autoBackup             bool
+	backupInterval         time.Duration
+	backupKeepDaily        int
+	backupKeepWeekly       int
+	backupKeepMonthly      int
+	ingestFilter           IngestFilterConfig
+	mbzMatchAutoApply      float64
+	mergeUndoRetention     time.Duration
+	trashRetention         time.Duration
+	libraryDir             string
+	libraryScanInterval    time.Duration
 }
 
 var (
@@ -162,6 +213,10 @@ func loadConfig(getenv func(string) string, version string) (*config, error) {
 	cfg.disableDeezer = parseBool(getenv(DISABLE_DEEZER_ENV))
 	cfg.disableCAA = parseBool(getenv(DISABLE_COVER_ART_ARCHIVE_ENV))
 	cfg.disableMusicBrainz = parseBool(getenv(DISABLE_MUSICBRAINZ_ENV))
+	cfg.mbzMatchAutoApply = 0.95
+	if v, err := strconv.ParseFloat(getenv(MBZ_MATCH_AUTO_APPLY_ENV), 64); err == nil {
+		cfg.mbzMatchAutoApply = v
+	}
 	cfg.subsonicUrl = getenv(SUBSONIC_URL_ENV)
 	cfg.subsonicParams = getenv(SUBSONIC_PARAMS_ENV)
 	cfg.subsonicEnabled = cfg.subsonicUrl != "" && cfg.subsonicParams != ""
@@ -169,8 +224,12 @@ func loadConfig(getenv func(string) string, version string) (*config, error) {
 		return nil, fmt.Errorf("loadConfig: invalid configuration: both %s and %s must be set in order to use subsonic image fetching", SUBSONIC_URL_ENV, SUBSONIC_PARAMS_ENV)
 	}
 	cfg.lastfmApiKey = getenv(LASTFM_API_KEY_ENV)
+	// the providers are validated when the image sources are initialized
+	cfg.artistImageProviders = parseProviderList(getenv(ARTIST_IMAGE_PROVIDERS_ENV))
+	cfg.albumImageProviders = parseProviderList(getenv(ALBUM_IMAGE_PROVIDERS_ENV))
 	cfg.skipImport = parseBool(getenv(SKIP_IMPORT_ENV))
 
+	cfg.version = version
 	cfg.userAgent = fmt.Sprintf("Koito %s (contact@koito.io)", version)
 
 	if getenv(DEFAULT_USERNAME_ENV) == "" {
@@ -221,6 +280,67 @@ func loadConfig(getenv func(string) string, version string) (*config, error) {
 		}
 	}
 
+	cfg.ingestFilter.AllowClients = parseList(getenv(INGEST_ALLOW_CLIENTS_ENV))
+	cfg.ingestFilter.DenyClients = parseList(getenv(INGEST_DENY_CLIENTS_ENV))
+	cfg.ingestFilter.DenyArtists, err = parseRegexList(getenv(INGEST_DENY_ARTISTS_ENV))
+	if err != nil {
+		return nil, fmt.Errorf("loadConfig: %s: %w", INGEST_DENY_ARTISTS_ENV, err)
+	}
+	cfg.ingestFilter.DenyAlbums, err = parseRegexList(getenv(INGEST_DENY_ALBUMS_ENV))
+	if err != nil {
+		return nil, fmt.Errorf("loadConfig: %s: %w", INGEST_DENY_ALBUMS_ENV, err)
+	}
+	if v, err := strconv.Atoi(getenv(INGEST_MIN_DURATION_ENV)); err == nil {
+		cfg.ingestFilter.MinTrackDuration = int32(v)
+	}
+	if v, err := strconv.Atoi(getenv(INGEST_MIN_PLAYED_ENV)); err == nil {
+		cfg.ingestFilter.MinPlayedDuration = int32(v)
+	}
+	cfg.mergeUndoRetention = defaultMergeUndoDays * 24 * time.Hour
+	if days, err := strconv.Atoi(getenv(MERGE_UNDO_RETENTION_ENV)); err == nil {
+		if days < 0 {
+			return nil, fmt.Errorf("loadConfig: invalid configuration: %s must not be negative", MERGE_UNDO_RETENTION_ENV)
+		}
+		cfg.mergeUndoRetention = time.Duration(days) * 24 * time.Hour
+	}
+	cfg.trashRetention = defaultTrashDays * 24 * time.Hour
+	if days, err := strconv.Atoi(getenv(TRASH_RETENTION_ENV)); err == nil {
+		if days < 0 {
+			return nil, fmt.Errorf("loadConfig: invalid configuration: %s must not be negative", TRASH_RETENTION_ENV)
+		}
+		cfg.trashRetention = time.Duration(days) * 24 * time.Hour
+	}
+
+	cfg.libraryDir = getenv(LIBRARY_DIR_ENV)
+	cfg.libraryScanInterval = defaultLibraryScan
+	if hours, err := strconv.Atoi(getenv(LIBRARY_SCAN_INTERVAL_ENV)); err == nil {
+		if hours < 1 {
+			return nil, fmt.Errorf("loadConfig: invalid configuration: %s must be at least 1", LIBRARY_SCAN_INTERVAL_ENV)
+		}
+		cfg.libraryScanInterval = time.Duration(hours) * time.Hour
+	}
+
+	cfg.autoBackup = parseBool(getenv(ENABLE_AUTO_BACKUP_ENV))
+	cfg.backupInterval = defaultBackupInterval
+	if hours, err := strconv.Atoi(getenv(BACKUP_INTERVAL_HOURS_ENV)); err == nil {
+		if hours < 1 {
+			return nil, fmt.Errorf("loadConfig: invalid configuration: %s must be at least 1", BACKUP_INTERVAL_HOURS_ENV)
+		}
+		cfg.backupInterval = time.Duration(hours) * time.Hour
+	}
+	cfg.backupKeepDaily, err = parseBackupKeep(getenv, BACKUP_KEEP_DAILY_ENV, defaultBackupDaily)
+	if err != nil {
+		return nil, err
+	}
+	cfg.backupKeepWeekly, err = parseBackupKeep(getenv, BACKUP_KEEP_WEEKLY_ENV, defaultBackupWeekly)
+	if err != nil {
+		return nil, err
+	}
+	cfg.backupKeepMonthly, err = parseBackupKeep(getenv, BACKUP_KEEP_MONTHLY_ENV, defaultBackupMonthly)
+	if err != nil {
+		return nil, err
+	}
+
 	switch strings.ToLower(getenv(LOG_LEVEL_ENV)) {
 	case "debug":
 		cfg.logLevel = 0
@@ -243,3 +363,52 @@ func parseBool(s string) bool {
 		return false
 	}
 }
+
+// parseList splits a comma separated list, dropping empty entries.
+func parseList(s string) []string {
+	var ret []string
+	for v := range strings.SplitSeq(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// parseBackupKeep parses how many backups to keep from the env variable, which must not be
+// negative.
+func parseBackupKeep(getenv func(string) string, env string, def int) (int, error) {
+	n, err := strconv.Atoi(getenv(env))
+	if err != nil {
+		return def, nil
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("loadConfig: invalid configuration: %s must not be negative", env)
+	}
+	return n, nil
+}
+
+// parseProviderList parses a comma separated list of image provider names, ignoring case.
+func parseProviderList(s string) []string {
+	var ret []string
+	for _, v := range parseList(s) {
+		ret = append(ret, strings.ToLower(v))
+	}
+	return ret
+}
+
+// parseRegexList compiles a list of patterns separated by two semicolons (;;).
+func parseRegexList(s string) ([]*regexp.Regexp, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ret []*regexp.Regexp
+	for pattern := range strings.SplitSeq(s, ";;") {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regex pattern %s", pattern)
+		}
+		ret = append(ret, regex)
+	}
+	return ret, nil
+}
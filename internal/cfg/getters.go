@@ -12,6 +12,12 @@ func UserAgent() string {
 	return globalConfig.userAgent
 }
 
+func Version() string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.version
+}
+
 func ListenAddr() string {
 	lock.RLock()
 	defer lock.RUnlock()
@@ -193,6 +199,34 @@ func ArtistSeparators() []*regexp.Regexp {
 	return globalConfig.artistSeparators
 }
 
+// MbzMatchAutoApplyConfidence returns the confidence at or above which a MusicBrainz match is
+// applied without review.
+func MbzMatchAutoApplyConfidence() float64 {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.mbzMatchAutoApply
+}
+
+// MergeUndoRetention returns how long a merge can be undone for.
+func MergeUndoRetention() time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.mergeUndoRetention
+}
+
+// TrashRetention returns how long deleted artists, albums and tracks can be restored for.
+func TrashRetention() time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.trashRetention
+}
+
+func IngestFilter() IngestFilterConfig {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.ingestFilter
+}
+
 func LoginGate() bool {
 	lock.RLock()
 	defer lock.RUnlock()
@@ -204,3 +238,49 @@ func ForceTZ() *time.Location {
 	defer lock.RUnlock()
 	return globalConfig.forceTZ
 }
+
+func AutoBackupEnabled() bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.autoBackup
+}
+
+func BackupInterval() time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.backupInterval
+}
+
+// BackupRetention returns how many daily, weekly and monthly backups to keep.
+func BackupRetention() (daily, weekly, monthly int) {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.backupKeepDaily, globalConfig.backupKeepWeekly, globalConfig.backupKeepMonthly
+}
+
+// LibraryDir returns the directory the library scanner reads, or "" when it is disabled.
+func LibraryDir() string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.libraryDir
+}
+
+// ArtistImageProviders returns the order to look for artist images in, or nil for the default order.
+func ArtistImageProviders() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.artistImageProviders
+}
+
+// AlbumImageProviders returns the order to look for album images in, or nil for the default order.
+func AlbumImageProviders() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.albumImageProviders
+}
+
+func LibraryScanInterval() time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	return globalConfig.libraryScanInterval
+}
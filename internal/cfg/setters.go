@@ -1,7 +1,39 @@
 package cfg
 
+import "time"
+
 func SetLoginGate(val bool) {
 	lock.Lock()
 	defer lock.Unlock()
 	globalConfig.loginGate = val
 }
+
+func SetIngestFilter(val IngestFilterConfig) {
+	lock.Lock()
+	defer lock.Unlock()
+	globalConfig.ingestFilter = val
+}
+
+func SetMbzMatchAutoApplyConfidence(val float64) {
+	lock.Lock()
+	defer lock.Unlock()
+	globalConfig.mbzMatchAutoApply = val
+}
+
+func SetMergeUndoRetention(val time.Duration) {
+	lock.Lock()
+	defer lock.Unlock()
+	globalConfig.mergeUndoRetention = val
+}
+
+func SetTrashRetention(val time.Duration) {
+	lock.Lock()
+	defer lock.Unlock()
+	globalConfig.trashRetention = val
+}
+
+func SetLibraryDir(val string) {
+	lock.Lock()
+	defer lock.Unlock()
+	globalConfig.libraryDir = val
+}
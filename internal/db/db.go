@@ -3,6 +3,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/gabehf/koito/internal/models"
@@ -17,17 +18,29 @@ type ArtistStore interface {
 	GetAllArtistAliases(ctx context.Context, id int32) ([]models.Alias, error)
 	SaveArtist(ctx context.Context, opts SaveArtistOpts) (*models.Artist, error)
 	SaveArtistAliases(ctx context.Context, id int32, aliases []string, source string) error
+	SaveArtistTags(ctx context.Context, id int32, tags []string, source string) error
 	UpdateArtist(ctx context.Context, opts UpdateArtistOpts) error
 	SetPrimaryArtistAlias(ctx context.Context, id int32, alias string) error
 	SetPrimaryAlbumArtist(ctx context.Context, id int32, artistId int32, value bool) error
 	SetPrimaryTrackArtist(ctx context.Context, id int32, artistId int32, value bool) error
+	// DeleteArtist moves an artist to the trash, along with the albums no other artist is credited
+	// on and their listens.
 	DeleteArtist(ctx context.Context, id int32) error
 	DeleteArtistAlias(ctx context.Context, id int32, alias string) error
 	MergeArtists(ctx context.Context, fromId, toId int32, replaceImage bool) error
+	// SplitArtist creates an artist for each part and moves the part's tracks and albums to it.
+	// Returns ErrConflict when the original artist would be left without tracks.
+	SplitArtist(ctx context.Context, opts SplitArtistOpts) ([]*models.Artist, error)
 	SearchArtists(ctx context.Context, q string) ([]*models.Artist, error)
 	CountArtists(ctx context.Context, timeframe Timeframe) (int64, error)
 	CountNewArtists(ctx context.Context, timeframe Timeframe) (int64, error)
 	ArtistsWithoutImages(ctx context.Context, from int32) ([]*models.Artist, error)
+	GetArtistsWithNoMetadataButHaveMbzID(ctx context.Context, from int32) ([]*models.Artist, error)
+	GetListensByArtistCountry(ctx context.Context, timeframe Timeframe) ([]RankedItem[*models.ArtistGroup], error)
+	GetListensByArtistType(ctx context.Context, timeframe Timeframe) ([]RankedItem[*models.ArtistGroup], error)
+	GetArtistsWithNoRelationsButHaveMbzID(ctx context.Context, from int32) ([]*models.Artist, error)
+	SaveArtistRelations(ctx context.Context, id int32, relations []models.ArtistRelation) error
+	GetRelatedArtists(ctx context.Context, id int32) ([]*models.ArtistRelation, error)
 }
 
 type AlbumStore interface {
@@ -37,16 +50,33 @@ type AlbumStore interface {
 	GetAllAlbumAliases(ctx context.Context, id int32) ([]models.Alias, error)
 	SaveAlbum(ctx context.Context, opts SaveAlbumOpts) (*models.Album, error)
 	SaveAlbumAliases(ctx context.Context, id int32, aliases []string, source string) error
+	SaveAlbumTags(ctx context.Context, id int32, tags []string, source string) error
 	UpdateAlbum(ctx context.Context, opts UpdateAlbumOpts) error
 	SetPrimaryAlbumAlias(ctx context.Context, id int32, alias string) error
 	AddArtistsToAlbum(ctx context.Context, opts AddArtistsToAlbumOpts) error
+	// SaveAlbumCredits replaces the ordered artist credit of an album. Only the ID, credited name
+	// and join phrase of the credits are used. Every credited artist must already be an artist
+	// of the album, otherwise ErrNotFound is returned.
+	SaveAlbumCredits(ctx context.Context, id int32, credits []models.ArtistCredit) error
+	// DeleteAlbum moves an album to the trash, along with its tracks and their listens.
 	DeleteAlbum(ctx context.Context, id int32) error
 	DeleteAlbumAlias(ctx context.Context, id int32, alias string) error
 	MergeAlbums(ctx context.Context, fromId, toId int32, replaceImage bool) error
+	// SplitAlbum creates an album for each part and moves the part's tracks to it. Returns
+	// ErrConflict when the original album would be left without tracks.
+	SplitAlbum(ctx context.Context, opts SplitAlbumOpts) ([]*models.Album, error)
 	SearchAlbums(ctx context.Context, q string) ([]*models.Album, error)
 	CountAlbums(ctx context.Context, timeframe Timeframe) (int64, error)
 	CountNewAlbums(ctx context.Context, timeframe Timeframe) (int64, error)
 	AlbumsWithoutImages(ctx context.Context, from int32) ([]*models.Album, error)
+	GetAlbumsWithNoReleaseDateButHaveMbzID(ctx context.Context, from int32) ([]*models.Album, error)
+	GetAlbumsWithNoTypeButHaveMbzID(ctx context.Context, from int32) ([]*models.Album, error)
+	GetTopReleaseYears(ctx context.Context, opts GetReleaseYearsOpts) ([]RankedItem[*models.ReleaseYear], error)
+	GetReleaseAgeStats(ctx context.Context, opts GetReleaseAgeOpts) (*ReleaseAgeStats, error)
+	GetAlbumsWithNoTracklistButHaveMbzID(ctx context.Context, from int32) ([]*models.Album, error)
+	SaveAlbumTracklist(ctx context.Context, id int32, tracklist []models.TracklistEntry) error
+	GetAlbumTracklist(ctx context.Context, id int32) ([]*models.TracklistEntry, error)
+	GetAlbumCompletion(ctx context.Context, id int32) (*AlbumCompletion, error)
 }
 
 type TrackStore interface {
@@ -56,15 +86,28 @@ type TrackStore interface {
 	GetAllTrackAliases(ctx context.Context, id int32) ([]models.Alias, error)
 	SaveTrack(ctx context.Context, opts SaveTrackOpts) (*models.Track, error)
 	SaveTrackAliases(ctx context.Context, id int32, aliases []string, source string) error
+	SaveTrackTags(ctx context.Context, id int32, tags []string, source string) error
 	UpdateTrack(ctx context.Context, opts UpdateTrackOpts) error
 	SetPrimaryTrackAlias(ctx context.Context, id int32, alias string) error
+	// SaveTrackCredits replaces the ordered artist credit of a track. Only the ID, credited name
+	// and join phrase of the credits are used. Every credited artist must already be an artist
+	// of the track, otherwise ErrNotFound is returned.
+	SaveTrackCredits(ctx context.Context, id int32, credits []models.ArtistCredit) error
+	// DeleteTrack moves a track to the trash, along with its listens.
 	DeleteTrack(ctx context.Context, id int32) error
 	DeleteTrackAlias(ctx context.Context, id int32, alias string) error
 	MergeTracks(ctx context.Context, fromId, toId int32) error
+	// SplitTrack creates a track for each part, on the same album and by the same artists, and
+	// moves the part's listens to it. Returns ErrConflict when a part selects no listens, or when
+	// the original track would be left without listens.
+	SplitTrack(ctx context.Context, opts SplitTrackOpts) ([]*models.Track, error)
 	SearchTracks(ctx context.Context, q string) ([]*models.Track, error)
 	CountTracks(ctx context.Context, timeframe Timeframe) (int64, error)
 	CountNewTracks(ctx context.Context, timeframe Timeframe) (int64, error)
 	AddArtistsToAlbum(ctx context.Context, opts AddArtistsToAlbumOpts) error
+	GetSong(ctx context.Context, id int32) (*models.Song, error)
+	AddTracksToSong(ctx context.Context, songID int32, trackIDs []int32) error
+	RemoveTrackFromSong(ctx context.Context, trackID int32) error
 }
 
 type ListenStore interface {
@@ -73,6 +116,20 @@ type ListenStore interface {
 	GetInterest(ctx context.Context, opts GetInterestOpts) ([]InterestBucket, error)
 	SaveListen(ctx context.Context, opts SaveListenOpts) error
 	DeleteListen(ctx context.Context, trackId int32, listenedAt time.Time) error
+	// UpdateListen changes the track, time or client of a listen. Returns ErrConflict when the
+	// track already has a listen at the new time.
+	UpdateListen(ctx context.Context, opts UpdateListenOpts) error
+	// MoveListens moves every listen matching the filters to another track.
+	MoveListens(ctx context.Context, opts MoveListensOpts) (*MoveListensResult, error)
+	// GetSubmittedListens returns the listens matching the filters, oldest first.
+	GetSubmittedListens(ctx context.Context, opts GetSubmittedListensOpts) ([]*SubmittedListen, error)
+	// MoveListen moves a listen to another track. If the track already has a listen at the same
+	// time, the two are merged.
+	MoveListen(ctx context.Context, trackID int32, listenedAt time.Time, toTrackID int32) error
+	SaveFilteredListen(ctx context.Context, opts SaveFilteredListenOpts) error
+	// GetFilteredListens returns the most recently filtered submissions, along with the number of
+	// submissions filtered in total and for each reason.
+	GetFilteredListens(ctx context.Context, limit int) (*FilteredListenLog, error)
 	CountListens(ctx context.Context, timeframe Timeframe) (int64, error)
 	CountListensToItem(ctx context.Context, opts TimeListenedOpts) (int64, error)
 	CountTimeListened(ctx context.Context, timeframe Timeframe) (int64, error)
@@ -83,7 +140,7 @@ type ListenStore interface {
 type UserStore interface {
 	GetUserBySession(ctx context.Context, sessionId uuid.UUID) (*models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
-	GetUserByApiKey(ctx context.Context, key string) (*models.User, error)
+	GetUserByApiKey(ctx context.Context, key string) (*models.User, *models.ApiKey, error)
 	GetAdminUser(ctx context.Context) (*models.User, error)
 	GetApiKeysByUserID(ctx context.Context, id int32) ([]models.ApiKey, error)
 	SaveUser(ctx context.Context, opts SaveUserOpts) (*models.User, error)
@@ -103,10 +160,116 @@ type ImageStore interface {
 	GetUserUploadedImageIDs(ctx context.Context) ([]uuid.UUID, error)
 }
 
+type TagStore interface {
+	GetTopTagsPaginated(ctx context.Context, opts GetItemsOpts) (*PaginatedResponse[RankedItem[*models.Tag]], error)
+}
+
+type RewriteRuleStore interface {
+	// GetRewriteRules returns all rewrite rules in the order they are applied.
+	GetRewriteRules(ctx context.Context) ([]*models.RewriteRule, error)
+	GetRewriteRule(ctx context.Context, id int32) (*models.RewriteRule, error)
+	SaveRewriteRule(ctx context.Context, opts SaveRewriteRuleOpts) (*models.RewriteRule, error)
+	UpdateRewriteRule(ctx context.Context, opts UpdateRewriteRuleOpts) error
+	DeleteRewriteRule(ctx context.Context, id int32) error
+}
+
+type MatchSuggestionStore interface {
+	// GetUnmatchedArtists, GetUnmatchedAlbums and GetUnmatchedTracks return items without an MBID
+	// that have never been searched for on MusicBrainz, ordered by ID and starting after from.
+	GetUnmatchedArtists(ctx context.Context, from int32) ([]*UnmatchedItem, error)
+	GetUnmatchedAlbums(ctx context.Context, from int32) ([]*UnmatchedItem, error)
+	GetUnmatchedTracks(ctx context.Context, from int32) ([]*UnmatchedItem, error)
+	// MarkMbzSearched records that MusicBrainz was searched for a match for an item.
+	MarkMbzSearched(ctx context.Context, entityType string, id int32) error
+	SaveMatchSuggestion(ctx context.Context, opts SaveMatchSuggestionOpts) (*models.MatchSuggestion, error)
+	// GetMatchSuggestions returns the suggestions with the given status, or all suggestions when
+	// status is empty, most confident first.
+	GetMatchSuggestions(ctx context.Context, status string) ([]*models.MatchSuggestion, error)
+	GetMatchSuggestion(ctx context.Context, id int32) (*models.MatchSuggestion, error)
+	// ResolveMatchSuggestion sets the status of a suggestion. When the suggestion is accepted or
+	// applied, the other pending suggestions for the same item are rejected.
+	ResolveMatchSuggestion(ctx context.Context, id int32, status string) error
+}
+
+type MergeSuggestionStore interface {
+	// GetDuplicateCandidates returns every artist, album or track, described for the duplicate
+	// analyzer.
+	GetDuplicateCandidates(ctx context.Context, entityType string) ([]*DuplicateCandidate, error)
+	// ReplaceMergeSuggestions replaces the pending suggestions for an entity type. Pairs that
+	// were dismissed before are not suggested again.
+	ReplaceMergeSuggestions(ctx context.Context, entityType string, suggestions []SaveMergeSuggestionOpts) error
+	// GetMergeSuggestions returns the pending suggestions, for one entity type or for all of them
+	// when entityType is empty, highest score first.
+	GetMergeSuggestions(ctx context.Context, entityType string) ([]*models.MergeSuggestion, error)
+	GetMergeSuggestion(ctx context.Context, id int32) (*models.MergeSuggestion, error)
+	DismissMergeSuggestion(ctx context.Context, id int32) error
+}
+
+type MergeStore interface {
+	// PreviewMerge returns the merge that merging fromId into toId would make, without making it.
+	PreviewMerge(ctx context.Context, entityType string, fromId, toId int32) (*models.Merge, error)
+	// GetMerges returns the merges made since the given time, newest first.
+	GetMerges(ctx context.Context, since time.Time) ([]*models.Merge, error)
+	GetMerge(ctx context.Context, id int32) (*models.Merge, error)
+	// UndoMerge puts back everything a merge removed and removes everything it added. Returns
+	// ErrConflict when the data has changed in a way that keeps the merge from being undone.
+	UndoMerge(ctx context.Context, id int32) error
+	// DeleteMergesBefore forgets the merges made before t, which can then no longer be undone.
+	DeleteMergesBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+type TrashStore interface {
+	// GetTrash returns the deleted items, most recently deleted first.
+	GetTrash(ctx context.Context) ([]*models.TrashItem, error)
+	GetTrashItem(ctx context.Context, id int32) (*models.TrashItem, error)
+	// RestoreTrashItem puts back everything deleting the item removed. Returns ErrConflict when the
+	// data has changed in a way that keeps the item from being restored.
+	RestoreTrashItem(ctx context.Context, id int32) error
+	// DeleteTrashItem removes a deleted item for good.
+	DeleteTrashItem(ctx context.Context, id int32) error
+	// DeleteTrashBefore removes the items deleted before t for good.
+	DeleteTrashBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+type AuditStore interface {
+	// GetAuditSnapshot returns the metadata of an artist, album or track as it is recorded in the
+	// audit log, or nil when the item doesn't exist.
+	GetAuditSnapshot(ctx context.Context, entityType string, id int32) (json.RawMessage, error)
+	SaveAuditEntry(ctx context.Context, opts SaveAuditEntryOpts) error
+	// GetAuditEntries returns the entries matching the filters, newest first.
+	GetAuditEntries(ctx context.Context, opts GetAuditEntriesOpts) (*PaginatedResponse[*models.AuditEntry], error)
+}
+
 type ExportStore interface {
 	GetExportPage(ctx context.Context, opts GetExportPageOpts) ([]*ExportItem, error)
 }
 
+type BackupStore interface {
+	// VacuumInto writes a consistent snapshot of the database to dest without blocking writers.
+	VacuumInto(ctx context.Context, dest string) error
+	// IntegrityCheck opens the database file at path read-only and verifies that it is intact.
+	IntegrityCheck(ctx context.Context, path string) error
+}
+
+type LibraryStore interface {
+	// GetLibraryFileStates returns the modification time and size of every library file, by path.
+	GetLibraryFileStates(ctx context.Context) (map[string]LibraryFileState, error)
+	// SaveLibraryFile adds a file to the library, or replaces the file with the same path. A
+	// replaced file is no longer matched to a track.
+	SaveLibraryFile(ctx context.Context, opts SaveLibraryFileOpts) error
+	DeleteLibraryFiles(ctx context.Context, paths []string) error
+	// GetUnmatchedLibraryFiles returns files that are not matched to a track, ordered by ID and
+	// starting after from.
+	GetUnmatchedLibraryFiles(ctx context.Context, from int32) ([]*models.LibraryFile, error)
+	SetLibraryFileTrack(ctx context.Context, id int32, trackID int32) error
+	// GetUnplayedLibraryFiles returns the files whose track has never been listened to, ordered
+	// by artist, album and track number.
+	GetUnplayedLibraryFiles(ctx context.Context, opts GetLibraryFilesOpts) (*PaginatedResponse[*models.LibraryFile], error)
+	// GetTracksNotInLibrary returns the tracks listened to in the timeframe whose song has no file
+	// in the library, most listened first.
+	GetTracksNotInLibrary(ctx context.Context, opts GetItemsOpts) (*PaginatedResponse[RankedItem[*models.Track]], error)
+}
+
 type DB interface {
 	ArtistStore
 	AlbumStore
@@ -114,7 +277,16 @@ type DB interface {
 	ListenStore
 	UserStore
 	ImageStore
+	TagStore
+	RewriteRuleStore
+	MatchSuggestionStore
+	MergeSuggestionStore
+	MergeStore
+	TrashStore
+	AuditStore
 	ExportStore
+	BackupStore
+	LibraryStore
 	Ping(ctx context.Context) error
 	Close(ctx context.Context)
 	PurgeAllData(ctx context.Context) error
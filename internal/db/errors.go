@@ -4,3 +4,7 @@ import "errors"
 
 // ErrNotFound is returned by Store methods when a queried row does not exist.
 var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned by Store methods when a change can't be made because it conflicts
+// with the data as it is now.
+var ErrConflict = errors.New("conflict")
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/gabehf/koito/internal/models"
@@ -21,6 +22,8 @@ type GetArtistOpts struct {
 	MusicBrainzID uuid.UUID
 	Name          string
 	Image         uuid.UUID
+	// include listens of groups, members and their projects in the listen counts
+	RollUp bool
 }
 
 type GetTrackOpts struct {
@@ -37,12 +40,15 @@ type SaveTrackOpts struct {
 	ArtistIDs      []int32
 	RecordingMbzID uuid.UUID
 	Duration       int32
+	// the song to add the track to. A new song is created when not set.
+	SongID int32
 }
 
 type SaveAlbumOpts struct {
 	Title          string
 	MusicBrainzID  uuid.UUID
 	Type           string
+	SecondaryTypes []string
 	ArtistIDs      []int32
 	VariousArtists bool
 	Image          uuid.UUID
@@ -58,6 +64,54 @@ type SaveArtistOpts struct {
 	ImageSrc      string
 }
 
+type SplitArtistOpts struct {
+	ID    int32
+	Parts []SplitArtistPart
+}
+
+// SplitArtistPart is a new artist split off of another one. The given tracks, and the tracks
+// of the original artist on the given albums, are credited to the new artist instead of the
+// original one, or to both when Shared is set.
+type SplitArtistPart struct {
+	Name          string
+	Aliases       []string
+	MusicBrainzID uuid.UUID
+	TrackIDs      []int32
+	AlbumIDs      []int32
+	Shared        bool
+}
+
+type SplitAlbumOpts struct {
+	ID    int32
+	Parts []SplitAlbumPart
+}
+
+// SplitAlbumPart is a new album split off of another one, which the given tracks move to.
+type SplitAlbumPart struct {
+	Title         string
+	Aliases       []string
+	MusicBrainzID uuid.UUID
+	TrackIDs      []int32
+}
+
+type SplitTrackOpts struct {
+	ID    int32
+	Parts []SplitTrackPart
+}
+
+// SplitTrackPart is a new track split off of another one, which the selected listens move to.
+// Listens are selected by their exact times when ListenedAt is set, and otherwise by time range
+// and client, where a zero From or To and an empty Client match any listen.
+type SplitTrackPart struct {
+	Title         string
+	Aliases       []string
+	MusicBrainzID uuid.UUID
+	ListenedAt    []time.Time
+	From          time.Time
+	To            time.Time
+	Client        string
+}
+
 type UpdateApiKeyLabelOpts struct {
 	UserID int32
 	ID     int32
@@ -77,10 +131,38 @@ type SaveApiKeyOpts struct {
 }
 
 type SaveListenOpts struct {
-	TrackID int32
-	Time    time.Time
-	UserID  int32
-	Client  string
+	TrackID    int32
+	Time       time.Time
+	UserID     int32
+	Client     string
+	Submission *models.ListenSubmission
+}
+
+// UpdateListenOpts changes the listen of TrackID at ListenedAt. Zero values and a nil Client
+// keep the listen as it is.
+type UpdateListenOpts struct {
+	TrackID    int32
+	ListenedAt time.Time
+	NewTrackID int32
+	NewTime    time.Time
+	Client     *string
+}
+
+// MoveListensOpts selects the listens to move to ToTrackID. A zero TrackID, From or To and an
+// empty Client match any listen.
+type MoveListensOpts struct {
+	TrackID   int32
+	From      time.Time
+	To        time.Time
+	Client    string
+	ToTrackID int32
+}
+
+type GetSubmittedListensOpts struct {
+	Timeframe Timeframe
+	Client    string
+	ArtistID  int32
+	AlbumID   int32
 }
 
 type UpdateTrackOpts struct {
@@ -96,6 +178,14 @@ type UpdateArtistOpts struct {
 	MusicBrainzID uuid.UUID
 	Image         uuid.UUID
 	ImageSrc      string
+	// details set by the user are never overwritten by other sources
+	MetadataUpdate bool
+	Country        string
+	Area           string
+	Type           string
+	Gender         string
+	SortName       string
+	MetadataSource InformationSource
 }
 
 type UpdateAlbumOpts struct {
@@ -105,6 +195,14 @@ type UpdateAlbumOpts struct {
 	ImageSrc             string
 	VariousArtistsUpdate bool
 	VariousArtistsValue  bool
+	ReleaseDateUpdate    bool
+	ReleaseDate          string
+	// When TypeUpdate is set, the primary and secondary types are replaced. Types set by
+	// the user are never overwritten by types from other sources.
+	TypeUpdate     bool
+	PrimaryType    string
+	SecondaryTypes []string
+	TypeSource     InformationSource
 }
 
 type UpdateUserOpts struct {
@@ -134,6 +232,17 @@ type GetItemsOpts struct {
 
 	// Used for getting listens
 	TrackID int
+
+	// Only count listens of tracks carrying this tag
+	Tag string
+
+	// Used only for getting top albums. An album matches a type when it is either its
+	// primary type or one of its secondary types.
+	ReleaseTypes        []string
+	ExcludeReleaseTypes []string
+
+	// Used only for getting top tracks. Groups the tracks of the same song across releases.
+	BySong bool
 }
 
 type ListenActivityOpts struct {
@@ -159,6 +268,24 @@ type GetExportPageOpts struct {
 	ListenedAt time.Time
 	TrackID    int32
 	Limit      int32
+	// optional filters, zero values are ignored
+	Timeframe      Timeframe
+	FilterArtistID int32
+	FilterAlbumID  int32
+	FilterTrackID  int32
+}
+
+type GetReleaseYearsOpts struct {
+	Timeframe Timeframe
+	// Group release years into decades
+	Decades bool
+}
+
+type GetReleaseAgeOpts struct {
+	Timeframe Timeframe
+	// A listen counts as new music when the release came out less than this many
+	// years before the year of the listen.
+	NewWithinYears int
 }
 
 type GetInterestOpts struct {
@@ -167,3 +294,106 @@ type GetInterestOpts struct {
 	ArtistID int32
 	TrackID  int32
 }
+
+type SaveRewriteRuleOpts struct {
+	Name       string
+	Enabled    bool
+	Position   int32
+	Conditions []models.RewriteCondition
+	Action     string
+	Field      string
+	Pattern    string
+	Value      string
+}
+
+type UpdateRewriteRuleOpts struct {
+	ID         int32
+	Name       string
+	Enabled    bool
+	Position   int32
+	Conditions []models.RewriteCondition
+	Action     string
+	Field      string
+	Pattern    string
+	Value      string
+}
+
+type SaveFilteredListenOpts struct {
+	Time   time.Time
+	UserID int32
+	Client string
+	Artist string
+	Title  string
+	Album  string
+	Reason string
+}
+
+type SaveMatchSuggestionOpts struct {
+	EntityType string
+	EntityID   int32
+	MbzID      uuid.UUID
+	Name       string
+	Artist     string
+	Confidence float64
+	Status     string
+}
+
+type SaveMergeSuggestionOpts struct {
+	FromID  int32
+	ToID    int32
+	Score   float64
+	Reasons []string
+}
+
+// SaveAuditEntryOpts records a change. A nil UserID, ApiKeyLabel or EntityID is stored as null,
+// and Params, Before and After as null when empty.
+type SaveAuditEntryOpts struct {
+	UserID      *int32
+	Username    string
+	ApiKeyLabel *string
+	Action      string
+	EntityType  string
+	EntityID    *int32
+	Params      json.RawMessage
+	Before      json.RawMessage
+	After       json.RawMessage
+}
+
+// GetAuditEntriesOpts filters the audit log. Zero values match any entry, and Action matches the
+// start of the action.
+type GetAuditEntriesOpts struct {
+	EntityType string
+	EntityID   int32
+	UserID     int32
+	Action     string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Page       int
+}
+
+// SaveLibraryFileOpts describes an audio file found by the library scanner. ModTime and Size
+// are those of the file when its tags were read.
+type SaveLibraryFileOpts struct {
+	Path              string
+	ModTime           time.Time
+	Size              int64
+	Title             string
+	Artist            string
+	Album             string
+	AlbumArtist       string
+	TrackNumber       int32
+	DiscNumber        int32
+	Duration          int32
+	ISRC              string
+	RecordingMbzID    *uuid.UUID
+	ReleaseMbzID      *uuid.UUID
+	ReleaseGroupMbzID *uuid.UUID
+	ArtistMbzIDs      []uuid.UUID
+	HasPicture        bool
+}
+
+type GetLibraryFilesOpts struct {
+	Limit int
+	Page  int
+}
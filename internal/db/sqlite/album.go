@@ -57,12 +57,12 @@ func (s *Sqlite) GetAlbum(ctx context.Context, opts db.GetAlbumOpts) (*models.Al
 
 func (s *Sqlite) getAlbumByID(ctx context.Context, id int32) (*models.Album, error) {
 	var ret models.Album
-	var mbzID, image, imageSrc sql.NullString
+	var mbzID, image, imageSrc, releaseDate, primaryType, secondaryTypes sql.NullString
 	var variousArtists int
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, musicbrainz_id, image, image_source, various_artists, title
+		SELECT id, musicbrainz_id, image, image_source, various_artists, release_date, primary_type, secondary_types, title
 		FROM releases_with_title WHERE id = ? LIMIT 1`, id).
-		Scan(&ret.ID, &mbzID, &image, &imageSrc, &variousArtists, &ret.Title)
+		Scan(&ret.ID, &mbzID, &image, &imageSrc, &variousArtists, &releaseDate, &primaryType, &secondaryTypes, &ret.Title)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("getAlbumByID: %w", db.ErrNotFound)
 	}
@@ -72,12 +72,19 @@ func (s *Sqlite) getAlbumByID(ctx context.Context, id int32) (*models.Album, err
 	ret.MbzID = parseNullableUUID(mbzID)
 	ret.Image = catalog.BuildImageList(parseNullableUUID(image))
 	ret.VariousArtists = variousArtists == 1
+	ret.ReleaseDate = releaseDate.String
+	ret.PrimaryType = primaryType.String
+	ret.SecondaryTypes = splitReleaseTypes(secondaryTypes)
 
 	artists, err := s.artistsForRelease(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("getAlbumByID: artists: %w", err)
 	}
 	ret.Artists = artists
+	ret.Credits, err = s.creditsFor(ctx, "artist_releases", id)
+	if err != nil {
+		return nil, fmt.Errorf("getAlbumByID: credits: %w", err)
+	}
 
 	var listenCount int64
 	s.db.QueryRowContext(ctx, `
@@ -111,6 +118,11 @@ func (s *Sqlite) getAlbumByID(ctx context.Context, id int32) (*models.Album, err
 		) WHERE release_id = ?`, id).Scan(&rank)
 	ret.AllTimeRank = rank
 
+	ret.Tags, err = s.getTagsForEntity(ctx, "release_tags", "release_id", id)
+	if err != nil {
+		return nil, fmt.Errorf("getAlbumByID: tags: %w", err)
+	}
+
 	return &ret, nil
 }
 
@@ -172,11 +184,19 @@ func (s *Sqlite) SaveAlbum(ctx context.Context, opts db.SaveAlbumOpts) (*models.
 	if opts.VariousArtists {
 		variousArtistsInt = 1
 	}
+	var typeSource sql.NullString
+	if opts.Type != "" {
+		typeSource = sql.NullString{String: string(db.InformationSourceMusicBrainz), Valid: true}
+	}
 	res, err := tx.ExecContext(ctx,
-		`INSERT INTO releases (musicbrainz_id, various_artists, image, image_source) VALUES (?,?,?,?)`,
+		`INSERT INTO releases (musicbrainz_id, various_artists, image, image_source, primary_type, secondary_types, type_source)
+		VALUES (?,?,?,?,?,?,?)`,
 		nullableUUID(&opts.MusicBrainzID), variousArtistsInt,
 		nullableUUID(&opts.Image),
 		sql.NullString{String: opts.ImageSrc, Valid: opts.ImageSrc != ""},
+		sql.NullString{String: opts.Type, Valid: opts.Type != ""},
+		joinReleaseTypes(opts.SecondaryTypes),
+		typeSource,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("SaveAlbum: insert: %w", err)
@@ -214,6 +234,8 @@ func (s *Sqlite) SaveAlbum(ctx context.Context, opts db.SaveAlbumOpts) (*models.
 		ID:             id,
 		Title:          opts.Title,
 		VariousArtists: opts.VariousArtists,
+		PrimaryType:    opts.Type,
+		SecondaryTypes: splitReleaseTypes(joinReleaseTypes(opts.SecondaryTypes)),
 	}
 	if opts.MusicBrainzID != uuid.Nil {
 		u := opts.MusicBrainzID
@@ -299,12 +321,38 @@ func (s *Sqlite) UpdateAlbum(ctx context.Context, opts db.UpdateAlbumOpts) error
 			return fmt.Errorf("UpdateAlbum: various_artists: %w", err)
 		}
 	}
+	if opts.ReleaseDateUpdate {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE releases SET release_date = ? WHERE id = ?`, opts.ReleaseDate, opts.ID); err != nil {
+			return fmt.Errorf("UpdateAlbum: release_date: %w", err)
+		}
+	}
+	if opts.TypeUpdate {
+		// types set by the user take precedence over everything else
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE releases SET primary_type = ?, secondary_types = ?, type_source = ?
+			WHERE id = ? AND (? = ? OR COALESCE(type_source, '') != ?)`,
+			sql.NullString{String: opts.PrimaryType, Valid: opts.PrimaryType != ""},
+			joinReleaseTypes(opts.SecondaryTypes),
+			string(opts.TypeSource),
+			opts.ID,
+			string(opts.TypeSource), string(db.InformationSourceUserProvided), string(db.InformationSourceUserProvided),
+		); err != nil {
+			return fmt.Errorf("UpdateAlbum: types: %w", err)
+		}
+	}
 	return tx.Commit()
 }
 
 func (s *Sqlite) DeleteAlbum(ctx context.Context, id int32) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM releases WHERE id = ?`, id)
-	return err
+	err := s.trash(ctx, "album", id, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM releases WHERE id = ?`, id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteAlbum: %w", err)
+	}
+	return nil
 }
 
 func (s *Sqlite) DeleteAlbumAlias(ctx context.Context, id int32, alias string) error {
@@ -410,14 +458,17 @@ func (s *Sqlite) GetTopAlbumsPaginated(ctx context.Context, opts db.GetItemsOpts
 	var rows *sql.Rows
 	var err error
 
+	typeFilter, typeArgs := releaseTypeFilter(opts.ReleaseTypes, opts.ExcludeReleaseTypes)
+
 	if opts.ArtistID != 0 {
 		query := `
 			WITH AlbumCounts AS (
 				SELECT t.release_id, COUNT(*) AS listen_count
 				FROM listens l
 				JOIN tracks t ON l.track_id = t.id
+				JOIN releases r ON t.release_id = r.id
 				JOIN artist_releases ar ON t.release_id = ar.release_id
-				WHERE ar.artist_id = ? AND l.listened_at BETWEEN ? AND ?
+				WHERE ar.artist_id = ? AND l.listened_at BETWEEN ? AND ?` + tagFilter + typeFilter + `
 				GROUP BY t.release_id
 			),
 			RankedAlbums AS (
@@ -433,14 +484,16 @@ func (s *Sqlite) GetTopAlbumsPaginated(ctx context.Context, opts db.GetItemsOpts
 			JOIN releases_with_title rwt ON rwt.id = r.release_id
 			ORDER BY r.rank, r.release_id`
 
-		rows, err = s.db.QueryContext(ctx, query, opts.ArtistID, t1.Unix(), t2.Unix(), opts.Limit, offset)
+		args := append([]any{opts.ArtistID, t1.Unix(), t2.Unix(), opts.Tag, opts.Tag}, typeArgs...)
+		rows, err = s.db.QueryContext(ctx, query, append(args, opts.Limit, offset)...)
 	} else {
 		query := `
 			WITH AlbumCounts AS (
 				SELECT t.release_id, COUNT(*) AS listen_count
 				FROM listens l
 				JOIN tracks t ON l.track_id = t.id
-				WHERE l.listened_at BETWEEN ? AND ?
+				JOIN releases r ON t.release_id = r.id
+				WHERE l.listened_at BETWEEN ? AND ?` + tagFilter + typeFilter + `
 				GROUP BY t.release_id
 			),
 			RankedAlbums AS (
@@ -456,7 +509,8 @@ func (s *Sqlite) GetTopAlbumsPaginated(ctx context.Context, opts db.GetItemsOpts
 			JOIN releases_with_title rwt ON rwt.id = r.release_id
 			ORDER BY r.rank, r.release_id`
 
-		rows, err = s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.Limit, offset)
+		args := append([]any{t1.Unix(), t2.Unix(), opts.Tag, opts.Tag}, typeArgs...)
+		rows, err = s.db.QueryContext(ctx, query, append(args, opts.Limit, offset)...)
 	}
 
 	if err != nil {
@@ -552,17 +606,16 @@ func (s *Sqlite) AlbumsWithoutImages(ctx context.Context, from int32) ([]*models
 }
 
 func (s *Sqlite) MergeAlbums(ctx context.Context, fromId, toId int32, replaceImage bool) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+	_, err := s.merge(ctx, "album", fromId, toId, false, func(tx *sql.Tx) error {
+		return mergeAlbums(ctx, tx, fromId, toId, replaceImage)
+	})
 	if err != nil {
-		return fmt.Errorf("MergeAlbums: BeginTx: %w", err)
+		return fmt.Errorf("MergeAlbums: %w", err)
 	}
-	defer tx.Rollback()
+	return nil
+}
 
-	// fetch artists from fromId before moving tracks (for re-association)
-	fromArtists, err := s.artistsForRelease(ctx, fromId)
-	if err != nil {
-		return fmt.Errorf("MergeAlbums: fetch from artists: %w", err)
-	}
+func mergeAlbums(ctx context.Context, tx *sql.Tx, fromId, toId int32, replaceImage bool) error {
 
 	if replaceImage {
 		var image, imageSrc sql.NullString
@@ -572,28 +625,59 @@ func (s *Sqlite) MergeAlbums(ctx context.Context, fromId, toId int32, replaceIma
 			if _, err := tx.ExecContext(ctx,
 				`UPDATE releases SET image = ?, image_source = ? WHERE id = ?`,
 				image, imageSrc, toId); err != nil {
-				return fmt.Errorf("MergeAlbums: update image: %w", err)
+				return fmt.Errorf("update image: %w", err)
 			}
 		}
 	}
 
 	if _, err := tx.ExecContext(ctx,
 		`UPDATE tracks SET release_id = ? WHERE release_id = ?`, toId, fromId); err != nil {
-		return fmt.Errorf("MergeAlbums: move tracks: %w", err)
+		return fmt.Errorf("move tracks: %w", err)
 	}
 
-	for _, artist := range fromArtists {
-		if _, err := tx.ExecContext(ctx,
-			`INSERT OR IGNORE INTO artist_releases (artist_id, release_id, is_primary) VALUES (?,?,0)`,
-			artist.ID, toId); err != nil {
-			return fmt.Errorf("MergeAlbums: associate artist: %w", err)
-		}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE releases SET release_date = (SELECT release_date FROM releases WHERE id = ?)
+		WHERE id = ? AND COALESCE(release_date, '') = ''`, fromId, toId); err != nil {
+		return fmt.Errorf("release date: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE releases SET (primary_type, secondary_types, type_source) =
+			(SELECT primary_type, secondary_types, type_source FROM releases WHERE id = ?)
+		WHERE id = ? AND type_source IS NULL`, fromId, toId); err != nil {
+		return fmt.Errorf("types: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE release_tracks SET release_id = ?
+		WHERE release_id = ? AND NOT EXISTS (SELECT 1 FROM release_tracks WHERE release_id = ?)`,
+		toId, fromId, toId); err != nil {
+		return fmt.Errorf("move tracklist: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE releases SET track_count = (SELECT track_count FROM releases WHERE id = ?)
+		WHERE id = ? AND COALESCE(track_count, 0) = 0`, fromId, toId); err != nil {
+		return fmt.Errorf("track count: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO release_tags (release_id, tag_id, source)
+		SELECT ?, tag_id, source FROM release_tags WHERE release_id = ?`, toId, fromId); err != nil {
+		return fmt.Errorf("move tags: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO release_aliases (release_id, alias, source, is_primary)
+		SELECT ?, alias, source, 0 FROM release_aliases WHERE release_id = ?`, toId, fromId); err != nil {
+		return fmt.Errorf("move aliases: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO artist_releases (artist_id, release_id, is_primary)
+		SELECT artist_id, ?, 0 FROM artist_releases WHERE release_id = ?`, toId, fromId); err != nil {
+		return fmt.Errorf("associate artists: %w", err)
 	}
 
 	if err := cleanOrphanedEntries(ctx, tx); err != nil {
-		return fmt.Errorf("MergeAlbums: clean: %w", err)
+		return fmt.Errorf("clean: %w", err)
 	}
-	return tx.Commit()
+	return nil
 }
 
 func (s *Sqlite) CountAlbums(ctx context.Context, timeframe db.Timeframe) (int64, error) {
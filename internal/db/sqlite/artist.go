@@ -41,16 +41,19 @@ func (s *Sqlite) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.
 
 	var mbzID, image, imageSrc sql.NullString
 	var name, aliasesConcat sql.NullString
+	var country, area, artistType, gender, sortName sql.NullString
 	err := s.db.QueryRowContext(ctx, `
 		SELECT a.id, a.musicbrainz_id, a.image, a.image_source, awn.name,
-		       group_concat(aa.alias, '||') AS aliases
+		       group_concat(aa.alias, '||') AS aliases,
+		       a.country, a.area, a.artist_type, a.gender, a.sort_name
 		FROM artists_with_name awn
 		JOIN artists a ON a.id = awn.id
 		LEFT JOIN artist_aliases aa ON aa.artist_id = a.id
 		WHERE a.id = ?
 		GROUP BY a.id`,
 		opts.ID,
-	).Scan(&opts.ID, &mbzID, &image, &imageSrc, &name, &aliasesConcat)
+	).Scan(&opts.ID, &mbzID, &image, &imageSrc, &name, &aliasesConcat,
+		&country, &area, &artistType, &gender, &sortName)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("GetArtist: %w", db.ErrNotFound)
 	}
@@ -64,25 +67,43 @@ func (s *Sqlite) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.
 		utils.Unique(&aliases)
 	}
 
+	// the listen counts cover the artist alone, or every related artist when rolling up
+	ids := []any{opts.ID}
+	var rolledUp []models.SimpleArtist
+	if opts.RollUp {
+		rolledUp, err = s.rollUpArtists(ctx, opts.ID)
+		if err != nil {
+			return nil, fmt.Errorf("GetArtist: roll up: %w", err)
+		}
+		for _, a := range rolledUp {
+			if a.ID == opts.ID {
+				continue
+			}
+			ids = append(ids, a.ID)
+		}
+	}
+	placeholders := strings.Repeat("?,", len(ids))
+	placeholders = placeholders[:len(placeholders)-1]
+	artistTracks := `SELECT track_id FROM artist_tracks WHERE artist_id IN (` + placeholders + `)`
+
 	var listenCount int64
 	s.db.QueryRowContext(ctx,
-		`SELECT COUNT(*) FROM listens l JOIN artist_tracks at2 ON l.track_id = at2.track_id WHERE at2.artist_id = ?`,
-		opts.ID).Scan(&listenCount)
+		`SELECT COUNT(*) FROM listens l WHERE l.track_id IN (`+artistTracks+`)`,
+		ids...).Scan(&listenCount)
 
 	var timeListened int64
 	s.db.QueryRowContext(ctx, `
 		SELECT COALESCE(SUM(t.duration), 0)
 		FROM listens l JOIN tracks t ON l.track_id = t.id
-		JOIN artist_tracks at2 ON t.id = at2.track_id
-		WHERE at2.artist_id = ?`,
-		opts.ID).Scan(&timeListened)
+		WHERE t.id IN (`+artistTracks+`)`,
+		ids...).Scan(&timeListened)
 
 	var firstListenUnix int64
 	err = s.db.QueryRowContext(ctx, `
 		SELECT l.listened_at FROM listens l
-		JOIN artist_tracks at2 ON l.track_id = at2.track_id
-		WHERE at2.artist_id = ? ORDER BY l.listened_at ASC LIMIT 1`,
-		opts.ID).Scan(&firstListenUnix)
+		WHERE l.track_id IN (`+artistTracks+`)
+		ORDER BY l.listened_at ASC LIMIT 1`,
+		ids...).Scan(&firstListenUnix)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("GetArtist: first listen: %w", err)
 	}
@@ -97,6 +118,11 @@ func (s *Sqlite) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.
 		) WHERE artist_id = ?`,
 		opts.ID).Scan(&rank)
 
+	tags, err := s.getTagsForEntity(ctx, "artist_tags", "artist_id", opts.ID)
+	if err != nil {
+		return nil, fmt.Errorf("GetArtist: tags: %w", err)
+	}
+
 	return &models.Artist{
 		ID:           opts.ID,
 		MbzID:        parseNullableUUID(mbzID),
@@ -107,6 +133,13 @@ func (s *Sqlite) GetArtist(ctx context.Context, opts db.GetArtistOpts) (*models.
 		TimeListened: timeListened,
 		FirstListen:  firstListenUnix,
 		AllTimeRank:  rank,
+		Tags:         tags,
+		Country:      country.String,
+		Area:         area.String,
+		Type:         artistType.String,
+		Gender:       gender.String,
+		SortName:     sortName.String,
+		RolledUp:     rolledUp,
 	}, nil
 }
 
@@ -224,12 +257,32 @@ func (s *Sqlite) UpdateArtist(ctx context.Context, opts db.UpdateArtistOpts) err
 			return fmt.Errorf("UpdateArtist: image: %w", err)
 		}
 	}
+	if opts.MetadataUpdate {
+		// details set by the user take precedence over everything else
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE artists SET country = ?, area = ?, artist_type = ?, gender = ?, sort_name = ?, metadata_source = ?
+			WHERE id = ? AND (? = ? OR COALESCE(metadata_source, '') != ?)`,
+			nullableString(opts.Country), nullableString(opts.Area), nullableString(opts.Type),
+			nullableString(opts.Gender), nullableString(opts.SortName),
+			string(opts.MetadataSource),
+			opts.ID,
+			string(opts.MetadataSource), string(db.InformationSourceUserProvided), string(db.InformationSourceUserProvided),
+		); err != nil {
+			return fmt.Errorf("UpdateArtist: metadata: %w", err)
+		}
+	}
 	return tx.Commit()
 }
 
 func (s *Sqlite) DeleteArtist(ctx context.Context, id int32) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM artists WHERE id = ?`, id)
-	return err
+	err := s.trash(ctx, "artist", id, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM artists WHERE id = ?`, id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("DeleteArtist: %w", err)
+	}
+	return nil
 }
 
 func (s *Sqlite) DeleteArtistAlias(ctx context.Context, id int32, alias string) error {
@@ -380,7 +433,7 @@ func (s *Sqlite) GetTopArtistsPaginated(ctx context.Context, opts db.GetItemsOpt
 			SELECT at2.artist_id, COUNT(*) AS listen_count
 			FROM listens l
 			JOIN artist_tracks at2 ON l.track_id = at2.track_id
-			WHERE l.listened_at BETWEEN ? AND ?
+			WHERE l.listened_at BETWEEN ? AND ?` + tagFilter + `
 			GROUP BY at2.artist_id
 		),
 		RankedArtists AS (
@@ -398,7 +451,7 @@ func (s *Sqlite) GetTopArtistsPaginated(ctx context.Context, opts db.GetItemsOpt
 		JOIN artists_with_name awn ON awn.id = r.artist_id
 		ORDER BY r.rank, r.artist_id`
 
-	rows, err := s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.Limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.Tag, opts.Tag, opts.Limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("GetTopArtistsPaginated: %w", err)
 	}
@@ -558,12 +611,16 @@ func (s *Sqlite) CountNewArtists(ctx context.Context, timeframe db.Timeframe) (i
 }
 
 func (s *Sqlite) MergeArtists(ctx context.Context, fromId, toId int32, replaceImage bool) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+	_, err := s.merge(ctx, "artist", fromId, toId, false, func(tx *sql.Tx) error {
+		return mergeArtists(ctx, tx, fromId, toId, replaceImage)
+	})
 	if err != nil {
-		return fmt.Errorf("MergeArtists: BeginTx: %w", err)
+		return fmt.Errorf("MergeArtists: %w", err)
 	}
-	defer tx.Rollback()
+	return nil
+}
 
+func mergeArtists(ctx context.Context, tx *sql.Tx, fromId, toId int32, replaceImage bool) error {
 	if replaceImage {
 		var image, imageSrc sql.NullString
 		tx.QueryRowContext(ctx, `SELECT image, image_source FROM artists WHERE id = ?`, fromId).
@@ -572,7 +629,7 @@ func (s *Sqlite) MergeArtists(ctx context.Context, fromId, toId int32, replaceIm
 			if _, err := tx.ExecContext(ctx,
 				`UPDATE artists SET image = ?, image_source = ? WHERE id = ?`,
 				image, imageSrc, toId); err != nil {
-				return fmt.Errorf("MergeArtists: update image: %w", err)
+				return fmt.Errorf("update image: %w", err)
 			}
 		}
 	}
@@ -581,27 +638,49 @@ func (s *Sqlite) MergeArtists(ctx context.Context, fromId, toId int32, replaceIm
 		DELETE FROM artist_tracks WHERE artist_id = ? AND track_id IN (
 			SELECT track_id FROM artist_tracks WHERE artist_id = ?
 		)`, fromId, toId); err != nil {
-		return fmt.Errorf("MergeArtists: delete conflicting tracks: %w", err)
+		return fmt.Errorf("delete conflicting tracks: %w", err)
 	}
 	if _, err := tx.ExecContext(ctx, `
 		DELETE FROM artist_releases WHERE artist_id = ? AND release_id IN (
 			SELECT release_id FROM artist_releases WHERE artist_id = ?
 		)`, fromId, toId); err != nil {
-		return fmt.Errorf("MergeArtists: delete conflicting releases: %w", err)
+		return fmt.Errorf("delete conflicting releases: %w", err)
 	}
 	if _, err := tx.ExecContext(ctx,
 		`UPDATE artist_tracks SET artist_id = ? WHERE artist_id = ?`, toId, fromId); err != nil {
-		return fmt.Errorf("MergeArtists: update tracks: %w", err)
+		return fmt.Errorf("update tracks: %w", err)
 	}
 	if _, err := tx.ExecContext(ctx,
 		`UPDATE artist_releases SET artist_id = ? WHERE artist_id = ?`, toId, fromId); err != nil {
-		return fmt.Errorf("MergeArtists: update releases: %w", err)
+		return fmt.Errorf("update releases: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO artist_aliases (artist_id, alias, source, is_primary)
+		SELECT ?, alias, source, 0 FROM artist_aliases WHERE artist_id = ?`, toId, fromId); err != nil {
+		return fmt.Errorf("move aliases: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO artist_tags (artist_id, tag_id, source)
+		SELECT ?, tag_id, source FROM artist_tags WHERE artist_id = ?`, toId, fromId); err != nil {
+		return fmt.Errorf("move tags: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE artists SET (country, area, artist_type, gender, sort_name, metadata_source) =
+			(SELECT country, area, artist_type, gender, sort_name, metadata_source FROM artists WHERE id = ?)
+		WHERE id = ? AND metadata_source IS NULL`, fromId, toId); err != nil {
+		return fmt.Errorf("metadata: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO artist_relations (artist_id, related_mbid, related_name, relation_type, direction)
+		SELECT ?, related_mbid, related_name, relation_type, direction FROM artist_relations WHERE artist_id = ?`,
+		toId, fromId); err != nil {
+		return fmt.Errorf("move relations: %w", err)
 	}
 	if _, err := tx.ExecContext(ctx, `DELETE FROM artists WHERE id = ?`, fromId); err != nil {
-		return fmt.Errorf("MergeArtists: delete from: %w", err)
+		return fmt.Errorf("delete from: %w", err)
 	}
 	if err := cleanOrphanedEntries(ctx, tx); err != nil {
-		return fmt.Errorf("MergeArtists: clean: %w", err)
+		return fmt.Errorf("clean: %w", err)
 	}
-	return tx.Commit()
+	return nil
 }
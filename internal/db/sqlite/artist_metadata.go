@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+func (s *Sqlite) GetArtistsWithNoMetadataButHaveMbzID(ctx context.Context, from int32) ([]*models.Artist, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, musicbrainz_id, name
+		FROM artists_with_name
+		WHERE metadata_source IS NULL AND musicbrainz_id IS NOT NULL AND id > ?
+		ORDER BY id ASC LIMIT 20`,
+		from)
+	if err != nil {
+		return nil, fmt.Errorf("GetArtistsWithNoMetadataButHaveMbzID: %w", err)
+	}
+	defer rows.Close()
+	var artists []*models.Artist
+	for rows.Next() {
+		var a models.Artist
+		var mbzID sql.NullString
+		if err := rows.Scan(&a.ID, &mbzID, &a.Name); err != nil {
+			return nil, err
+		}
+		a.MbzID = parseNullableUUID(mbzID)
+		artists = append(artists, &a)
+	}
+	return artists, rows.Err()
+}
+
+func (s *Sqlite) GetListensByArtistCountry(ctx context.Context, timeframe db.Timeframe) ([]db.RankedItem[*models.ArtistGroup], error) {
+	groups, err := s.getListensByArtistColumn(ctx, "country", timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("GetListensByArtistCountry: %w", err)
+	}
+	return groups, nil
+}
+
+func (s *Sqlite) GetListensByArtistType(ctx context.Context, timeframe db.Timeframe) ([]db.RankedItem[*models.ArtistGroup], error) {
+	groups, err := s.getListensByArtistColumn(ctx, "artist_type", timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("GetListensByArtistType: %w", err)
+	}
+	return groups, nil
+}
+
+// getListensByArtistColumn groups the listens in the timeframe by the given column of their
+// artists. A listen counts once towards every distinct value among its artists, and artists
+// without a value are left out.
+func (s *Sqlite) getListensByArtistColumn(ctx context.Context, col string, timeframe db.Timeframe) ([]db.RankedItem[*models.ArtistGroup], error) {
+	t1, t2 := db.TimeframeToTimeRange(timeframe)
+
+	rows, err := s.db.QueryContext(ctx, `
+		WITH ListenArtists AS (
+			SELECT l.track_id, l.listened_at, t.duration, a.id AS artist_id, a.`+col+` AS name
+			FROM listens l
+			JOIN tracks t ON l.track_id = t.id
+			JOIN artist_tracks at2 ON at2.track_id = l.track_id
+			JOIN artists a ON a.id = at2.artist_id
+			WHERE l.listened_at BETWEEN ? AND ? AND COALESCE(a.`+col+`, '') != ''
+		),
+		GroupCounts AS (
+			SELECT name, COUNT(*) AS listen_count, COALESCE(SUM(duration), 0) AS time_listened
+			FROM (SELECT DISTINCT track_id, listened_at, duration, name FROM ListenArtists)
+			GROUP BY name
+		),
+		ArtistCounts AS (
+			SELECT name, COUNT(DISTINCT artist_id) AS artist_count
+			FROM ListenArtists
+			GROUP BY name
+		)
+		SELECT g.name, g.listen_count, g.time_listened, ac.artist_count,
+		       RANK() OVER (ORDER BY g.listen_count DESC) AS rank
+		FROM GroupCounts g
+		JOIN ArtistCounts ac ON ac.name = g.name
+		ORDER BY rank, g.name`,
+		t1.Unix(), t2.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]db.RankedItem[*models.ArtistGroup], 0)
+	for rows.Next() {
+		var g models.ArtistGroup
+		var item db.RankedItem[*models.ArtistGroup]
+		if err := rows.Scan(&g.Name, &g.ListenCount, &g.TimeListened, &g.ArtistCount, &item.Rank); err != nil {
+			return nil, err
+		}
+		item.Item = &g
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
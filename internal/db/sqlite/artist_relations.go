@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+// rollUpDepth is how many relationships away from an artist listens are rolled up, which
+// reaches from a group to its members and on to the members' other projects.
+const rollUpDepth = 2
+
+func (s *Sqlite) GetArtistsWithNoRelationsButHaveMbzID(ctx context.Context, from int32) ([]*models.Artist, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, musicbrainz_id, name
+		FROM artists_with_name
+		WHERE relations_fetched_at IS NULL AND musicbrainz_id IS NOT NULL AND id > ?
+		ORDER BY id ASC LIMIT 20`,
+		from)
+	if err != nil {
+		return nil, fmt.Errorf("GetArtistsWithNoRelationsButHaveMbzID: %w", err)
+	}
+	defer rows.Close()
+	var artists []*models.Artist
+	for rows.Next() {
+		var a models.Artist
+		var mbzID sql.NullString
+		if err := rows.Scan(&a.ID, &mbzID, &a.Name); err != nil {
+			return nil, err
+		}
+		a.MbzID = parseNullableUUID(mbzID)
+		artists = append(artists, &a)
+	}
+	return artists, rows.Err()
+}
+
+// SaveArtistRelations replaces the relationships of an artist and marks them as fetched.
+func (s *Sqlite) SaveArtistRelations(ctx context.Context, id int32, relations []models.ArtistRelation) error {
+	if id == 0 {
+		return errors.New("SaveArtistRelations: artist id not specified")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("SaveArtistRelations: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM artist_relations WHERE artist_id = ?`, id); err != nil {
+		return fmt.Errorf("SaveArtistRelations: delete: %w", err)
+	}
+	for _, r := range relations {
+		if r.MbzID == uuid.Nil {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO artist_relations (artist_id, related_mbid, related_name, relation_type, direction)
+			VALUES (?, ?, ?, ?, ?)`,
+			id, r.MbzID.String(), r.Name, r.Type, r.Direction); err != nil {
+			return fmt.Errorf("SaveArtistRelations: insert: %w", err)
+		}
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE artists SET relations_fetched_at = ? WHERE id = ?`, time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("SaveArtistRelations: update: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return db.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// GetRelatedArtists returns the relationships of an artist, linked to the related artist in the
+// library when there is one. Relationships fetched from the related artist's side are included
+// with their direction reversed.
+func (s *Sqlite) GetRelatedArtists(ctx context.Context, id int32) ([]*models.ArtistRelation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH Relations AS (
+			SELECT ar.related_mbid AS mbid, ar.related_name AS name, ar.relation_type, ar.direction
+			FROM artist_relations ar
+			WHERE ar.artist_id = ?
+			UNION
+			SELECT a.musicbrainz_id, a.name, ar.relation_type,
+			       CASE ar.direction WHEN 'forward' THEN 'backward' ELSE 'forward' END
+			FROM artist_relations ar
+			JOIN artists_with_name a ON a.id = ar.artist_id
+			WHERE ar.related_mbid = (SELECT musicbrainz_id FROM artists WHERE id = ?)
+		)
+		SELECT r.mbid, COALESCE(a.name, r.name), r.relation_type, r.direction, a.id,
+		       (SELECT COUNT(*) FROM listens l
+		        JOIN artist_tracks at2 ON at2.track_id = l.track_id
+		        WHERE at2.artist_id = a.id)
+		FROM Relations r
+		LEFT JOIN artists_with_name a ON a.musicbrainz_id = r.mbid
+		ORDER BY r.relation_type, COALESCE(a.name, r.name)`,
+		id, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetRelatedArtists: %w", err)
+	}
+	defer rows.Close()
+
+	relations := make([]*models.ArtistRelation, 0)
+	for rows.Next() {
+		var r models.ArtistRelation
+		var mbzID string
+		var localID sql.NullInt32
+		if err := rows.Scan(&mbzID, &r.Name, &r.Type, &r.Direction, &localID, &r.ListenCount); err != nil {
+			return nil, fmt.Errorf("GetRelatedArtists: scan: %w", err)
+		}
+		if u, err := uuid.Parse(mbzID); err == nil {
+			r.MbzID = u
+		}
+		if localID.Valid {
+			r.ID = &localID.Int32
+		}
+		relations = append(relations, &r)
+	}
+	return relations, rows.Err()
+}
+
+// rollUpArtists returns the artists in the library that are within rollUpDepth relationships
+// of the given artist, including the artist itself.
+func (s *Sqlite) rollUpArtists(ctx context.Context, id int32) ([]models.SimpleArtist, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH RECURSIVE
+		Edges AS (
+			SELECT ar.artist_id AS a, r.id AS b
+			FROM artist_relations ar
+			JOIN artists r ON r.musicbrainz_id = ar.related_mbid
+		),
+		Related(id, depth) AS (
+			SELECT ?, 0
+			UNION
+			SELECT CASE WHEN e.a = rel.id THEN e.b ELSE e.a END, rel.depth + 1
+			FROM Related rel
+			JOIN Edges e ON e.a = rel.id OR e.b = rel.id
+			WHERE rel.depth < ?
+		)
+		SELECT DISTINCT a.id, a.name
+		FROM Related rel
+		JOIN artists_with_name a ON a.id = rel.id
+		ORDER BY a.id`,
+		id, rollUpDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var artists []models.SimpleArtist
+	for rows.Next() {
+		var a models.SimpleArtist
+		if err := rows.Scan(&a.ID, &a.Name); err != nil {
+			return nil, err
+		}
+		artists = append(artists, a)
+	}
+	return artists, rows.Err()
+}
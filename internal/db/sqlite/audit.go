@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// auditTables are the rows that make up the metadata of an artist, album or track in the audit
+// log: the item's own row and the rows of its child tables.
+var auditTables = map[string]struct {
+	table    string
+	children [][2]string
+}{
+	"artist": {"artists", [][2]string{{"artist_aliases", "artist_id"}}},
+	"album":  {"releases", [][2]string{{"release_aliases", "release_id"}, {"artist_releases", "release_id"}}},
+	"track":  {"tracks", [][2]string{{"track_aliases", "track_id"}, {"artist_tracks", "track_id"}}},
+}
+
+func (s *Sqlite) GetAuditSnapshot(ctx context.Context, entityType string, id int32) (json.RawMessage, error) {
+	tables, ok := auditTables[entityType]
+	if !ok {
+		return nil, fmt.Errorf("GetAuditSnapshot: unknown entity type '%s'", entityType)
+	}
+	// read everything in one transaction, so the snapshot is consistent
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("GetAuditSnapshot: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	snap := make(mergeSnapshot)
+	if err := snap.load(ctx, tx, tables.table, "id", []int64{int64(id)}); err != nil {
+		return nil, fmt.Errorf("GetAuditSnapshot: %w", err)
+	}
+	if len(snap[tables.table]) == 0 {
+		return nil, nil
+	}
+	for _, child := range tables.children {
+		if err := snap.load(ctx, tx, child[0], child[1], []int64{int64(id)}); err != nil {
+			return nil, fmt.Errorf("GetAuditSnapshot: %w", err)
+		}
+	}
+
+	out := make(map[string]any, len(snap))
+	for table, rows := range snap {
+		if table == tables.table {
+			out[table] = rows[fmt.Sprint(id)]
+			continue
+		}
+		list := make([]mergeRow, 0, len(rows))
+		for _, key := range slices.Sorted(maps.Keys(rows)) {
+			list = append(list, rows[key])
+		}
+		out[table] = list
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("GetAuditSnapshot: marshal: %w", err)
+	}
+	return b, nil
+}
+
+func (s *Sqlite) SaveAuditEntry(ctx context.Context, opts db.SaveAuditEntryOpts) error {
+	if opts.Action == "" || opts.EntityType == "" {
+		return errors.New("SaveAuditEntry: action and entity type are required")
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_log (user_id, username, api_key_label, action, entity_type, entity_id,
+		                       params, before, after, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		opts.UserID, opts.Username, opts.ApiKeyLabel, opts.Action, opts.EntityType, opts.EntityID,
+		nullableJSON(opts.Params), nullableJSON(opts.Before), nullableJSON(opts.After), time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("SaveAuditEntry: %w", err)
+	}
+	return nil
+}
+
+// nullableJSON stores empty JSON as null.
+func nullableJSON(b json.RawMessage) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+func (s *Sqlite) GetAuditEntries(ctx context.Context, opts db.GetAuditEntriesOpts) (*db.PaginatedResponse[*models.AuditEntry], error) {
+	if opts.Limit == 0 {
+		opts.Limit = defaultItemsPerPage
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	offset := (opts.Page - 1) * opts.Limit
+
+	where := ` WHERE 1 = 1`
+	var args []any
+	if opts.EntityType != "" {
+		where += ` AND entity_type = ?`
+		args = append(args, opts.EntityType)
+	}
+	if opts.EntityID != 0 {
+		where += ` AND entity_id = ?`
+		args = append(args, opts.EntityID)
+	}
+	if opts.UserID != 0 {
+		where += ` AND user_id = ?`
+		args = append(args, opts.UserID)
+	}
+	if opts.Action != "" {
+		where += ` AND substr(action, 1, ?) = ?`
+		args = append(args, utf8.RuneCountInString(opts.Action), opts.Action)
+	}
+	if !opts.From.IsZero() {
+		where += ` AND created_at >= ?`
+		args = append(args, opts.From.Unix())
+	}
+	if !opts.To.IsZero() {
+		where += ` AND created_at <= ?`
+		args = append(args, opts.To.Unix())
+	}
+
+	var count int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&count); err != nil {
+		return nil, fmt.Errorf("GetAuditEntries: count: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, username, api_key_label, action, entity_type, entity_id,
+		       params, before, after, created_at
+		FROM audit_log`+where+` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`,
+		append(args, opts.Limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("GetAuditEntries: %w", err)
+	}
+	defer rows.Close()
+	entries := make([]*models.AuditEntry, 0)
+	for rows.Next() {
+		var e models.AuditEntry
+		var userID, entityID sql.NullInt32
+		var label, params, before, after sql.NullString
+		var createdAt int64
+		if err := rows.Scan(&e.ID, &userID, &e.Username, &label, &e.Action, &e.EntityType, &entityID,
+			&params, &before, &after, &createdAt); err != nil {
+			return nil, fmt.Errorf("GetAuditEntries: scan: %w", err)
+		}
+		if userID.Valid {
+			e.UserID = &userID.Int32
+		}
+		if label.Valid {
+			e.ApiKeyLabel = &label.String
+		}
+		if entityID.Valid {
+			e.EntityID = &entityID.Int32
+		}
+		if params.Valid {
+			e.Params = json.RawMessage(params.String)
+		}
+		if before.Valid {
+			e.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			e.After = json.RawMessage(after.String)
+		}
+		e.CreatedAt = time.Unix(createdAt, 0)
+		entries = append(entries, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetAuditEntries: %w", err)
+	}
+	return &db.PaginatedResponse[*models.AuditEntry]{
+		Items:        entries,
+		TotalCount:   count,
+		ItemsPerPage: int32(opts.Limit),
+		HasNextPage:  int64(offset+len(entries)) < count,
+		CurrentPage:  int32(opts.Page),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+)
+
+// VacuumInto writes a compacted copy of the database to dest. dest must either not exist or be
+// an empty file. Because the snapshot is taken inside a read transaction, writers in WAL mode
+// are not blocked while it runs.
+func (s *Sqlite) VacuumInto(ctx context.Context, dest string) error {
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, dest); err != nil {
+		return fmt.Errorf("VacuumInto: %w", err)
+	}
+	return nil
+}
+
+// IntegrityCheck runs PRAGMA integrity_check against a separate database file, usually one
+// created by VacuumInto, and returns an error describing the first problem found.
+func (s *Sqlite) IntegrityCheck(ctx context.Context, path string) error {
+	// the path is escaped, as characters like ? and # have a meaning in the URI
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() + "?mode=ro"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return fmt.Errorf("IntegrityCheck: open: %w", err)
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("IntegrityCheck: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("IntegrityCheck: %s", result)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// creditTables are the association tables holding the artist credits of tracks and releases,
+// with the column of the item.
+var creditTables = map[string]string{
+	"artist_tracks":   "track_id",
+	"artist_releases": "release_id",
+}
+
+func (s *Sqlite) SaveTrackCredits(ctx context.Context, id int32, credits []models.ArtistCredit) error {
+	if err := s.saveCredits(ctx, "artist_tracks", id, credits); err != nil {
+		return fmt.Errorf("SaveTrackCredits: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) SaveAlbumCredits(ctx context.Context, id int32, credits []models.ArtistCredit) error {
+	if err := s.saveCredits(ctx, "artist_releases", id, credits); err != nil {
+		return fmt.Errorf("SaveAlbumCredits: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) saveCredits(ctx context.Context, table string, id int32, credits []models.ArtistCredit) error {
+	column := creditTables[table]
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE `+table+` SET position = NULL, credited_name = NULL, join_phrase = NULL
+		WHERE `+column+` = ?`, id); err != nil {
+		return fmt.Errorf("clear: %w", err)
+	}
+	for i, c := range credits {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE `+table+` SET position = ?1, join_phrase = ?3,
+			    credited_name = NULLIF(NULLIF(?2, ''), (SELECT name FROM artists_with_name WHERE id = ?5))
+			WHERE `+column+` = ?4 AND artist_id = ?5`,
+			i, c.CreditedName, c.JoinPhrase, id, c.ID)
+		if err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("artist %d is not credited on %d: %w", c.ID, id, db.ErrNotFound)
+		}
+	}
+	return tx.Commit()
+}
+
+// creditsFor fetches the ordered artist credit of a track or release. Returns nil when the
+// credit is not known. Artists added after the credit was saved are credited last.
+func (s *Sqlite) creditsFor(ctx context.Context, table string, id int32) ([]models.ArtistCredit, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT awn.id, awn.name, COALESCE(c.credited_name, awn.name), COALESCE(c.join_phrase, ''),
+		       c.position IS NOT NULL
+		FROM `+table+` c
+		JOIN artists_with_name awn ON awn.id = c.artist_id
+		WHERE c.`+creditTables[table]+` = ?
+		ORDER BY c.position IS NULL, c.position, c.is_primary DESC, awn.name`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var credits []models.ArtistCredit
+	var known []bool
+	for rows.Next() {
+		var c models.ArtistCredit
+		var positioned bool
+		if err := rows.Scan(&c.ID, &c.Name, &c.CreditedName, &c.JoinPhrase, &positioned); err != nil {
+			return nil, err
+		}
+		credits = append(credits, c)
+		known = append(known, positioned)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(known) == 0 || !known[0] {
+		return nil, nil
+	}
+	for i := range credits {
+		switch {
+		case i == len(credits)-1:
+			credits[i].JoinPhrase = ""
+		case !known[i+1] && credits[i].JoinPhrase == "":
+			credits[i].JoinPhrase = " & "
+		}
+	}
+	return credits, nil
+}
@@ -10,6 +10,10 @@ import (
 )
 
 func (s *Sqlite) GetExportPage(ctx context.Context, opts db.GetExportPageOpts) ([]*db.ExportItem, error) {
+	var from, to int64
+	if t1, t2 := db.TimeframeToTimeRange(opts.Timeframe); !t1.IsZero() {
+		from, to = t1.Unix(), t2.Unix()
+	}
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT l.listened_at, l.user_id, l.client,
 		       t.id AS track_id, t.musicbrainz_id AS track_mbid, t.duration,
@@ -20,10 +24,19 @@ func (s *Sqlite) GetExportPage(ctx context.Context, opts db.GetExportPageOpts) (
 		JOIN releases r ON t.release_id = r.id
 		WHERE l.user_id = ?
 		  AND (l.listened_at > ? OR (l.listened_at = ? AND l.track_id > ?))
+		  AND (? = 0 OR l.listened_at BETWEEN ? AND ?)
+		  AND (? = 0 OR t.id = ?)
+		  AND (? = 0 OR t.release_id = ?)
+		  AND (? = 0 OR EXISTS (
+		      SELECT 1 FROM artist_tracks at2 WHERE at2.track_id = t.id AND at2.artist_id = ?))
 		ORDER BY l.listened_at, l.track_id
 		LIMIT ?`,
 		opts.UserID,
 		opts.ListenedAt.Unix(), opts.ListenedAt.Unix(), opts.TrackID,
+		from, from, to,
+		opts.FilterTrackID, opts.FilterTrackID,
+		opts.FilterAlbumID, opts.FilterAlbumID,
+		opts.FilterArtistID, opts.FilterArtistID,
 		opts.Limit,
 	)
 	if err != nil {
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+func (s *Sqlite) SaveFilteredListen(ctx context.Context, opts db.SaveFilteredListenOpts) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO filtered_listens (filtered_at, listened_at, user_id, client, artist, title, album, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		time.Now().Unix(), opts.Time.Unix(), opts.UserID, opts.Client, opts.Artist, opts.Title, opts.Album, opts.Reason)
+	if err != nil {
+		return fmt.Errorf("SaveFilteredListen: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetFilteredListens(ctx context.Context, limit int) (*db.FilteredListenLog, error) {
+	if limit <= 0 {
+		limit = defaultItemsPerPage
+	}
+	ret := &db.FilteredListenLog{ByReason: make(map[string]int64), Items: make([]*models.FilteredListen, 0)}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT reason, COUNT(*) FROM filtered_listens GROUP BY reason`)
+	if err != nil {
+		return nil, fmt.Errorf("GetFilteredListens: %w", err)
+	}
+	for rows.Next() {
+		var reason string
+		var count int64
+		if err := rows.Scan(&reason, &count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("GetFilteredListens: scan: %w", err)
+		}
+		ret.ByReason[reason] = count
+		ret.Total += count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetFilteredListens: %w", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT id, filtered_at, listened_at, client, artist, title, album, reason
+		FROM filtered_listens
+		ORDER BY filtered_at DESC, id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetFilteredListens: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f models.FilteredListen
+		var filteredAt, listenedAt int64
+		if err := rows.Scan(&f.ID, &filteredAt, &listenedAt, &f.Client, &f.Artist, &f.Title, &f.Album, &f.Reason); err != nil {
+			return nil, fmt.Errorf("GetFilteredListens: scan: %w", err)
+		}
+		f.FilteredAt = time.Unix(filteredAt, 0)
+		f.Time = time.Unix(listenedAt, 0)
+		ret.Items = append(ret.Items, &f)
+	}
+	return ret, rows.Err()
+}
@@ -16,7 +16,13 @@ func (s *Sqlite) ImageHasAssociation(ctx context.Context, image uuid.UUID) (bool
 			SELECT 1 FROM artists WHERE image = ?
 			UNION ALL
 			SELECT 1 FROM releases WHERE image = ?
-		)`, image.String(), image.String()).Scan(&exists)
+			UNION ALL
+			-- images of merged items are kept for as long as the merge can be undone
+			SELECT 1 FROM merges WHERE undone_at IS NULL AND instr(changes, ?) > 0
+			UNION ALL
+			-- and the images of deleted items for as long as they can be restored
+			SELECT 1 FROM trash_images WHERE image = ?
+		)`, image.String(), image.String(), image.String(), image.String()).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("ImageHasAssociation: %w", err)
 	}
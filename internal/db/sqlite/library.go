@@ -0,0 +1,278 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+func (s *Sqlite) GetLibraryFileStates(ctx context.Context) (map[string]db.LibraryFileState, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT path, mtime, size FROM library_files`)
+	if err != nil {
+		return nil, fmt.Errorf("GetLibraryFileStates: %w", err)
+	}
+	defer rows.Close()
+	states := make(map[string]db.LibraryFileState)
+	for rows.Next() {
+		var path string
+		var mtime int64
+		var state db.LibraryFileState
+		if err := rows.Scan(&path, &mtime, &state.Size); err != nil {
+			return nil, fmt.Errorf("GetLibraryFileStates: scan: %w", err)
+		}
+		state.ModTime = time.Unix(0, mtime)
+		states[path] = state
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetLibraryFileStates: %w", err)
+	}
+	return states, nil
+}
+
+func (s *Sqlite) SaveLibraryFile(ctx context.Context, opts db.SaveLibraryFileOpts) error {
+	if opts.Path == "" {
+		return fmt.Errorf("SaveLibraryFile: path is required")
+	}
+	artistIDs := make([]string, len(opts.ArtistMbzIDs))
+	for i, id := range opts.ArtistMbzIDs {
+		artistIDs[i] = id.String()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO library_files (path, mtime, size, title, artist, album, album_artist, track_number,
+		                           disc_number, duration, isrc, recording_mbid, release_mbid,
+		                           release_group_mbid, artist_mbids, has_picture, track_id, scanned_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, ?)
+		ON CONFLICT (path) DO UPDATE SET
+			mtime = excluded.mtime, size = excluded.size, title = excluded.title,
+			artist = excluded.artist, album = excluded.album, album_artist = excluded.album_artist,
+			track_number = excluded.track_number, disc_number = excluded.disc_number,
+			duration = excluded.duration, isrc = excluded.isrc,
+			recording_mbid = excluded.recording_mbid, release_mbid = excluded.release_mbid,
+			release_group_mbid = excluded.release_group_mbid, artist_mbids = excluded.artist_mbids,
+			has_picture = excluded.has_picture, track_id = NULL, scanned_at = excluded.scanned_at`,
+		opts.Path, opts.ModTime.UnixNano(), opts.Size, opts.Title, opts.Artist, opts.Album,
+		opts.AlbumArtist, opts.TrackNumber, opts.DiscNumber, opts.Duration, opts.ISRC,
+		nullableUUID(opts.RecordingMbzID), nullableUUID(opts.ReleaseMbzID),
+		nullableUUID(opts.ReleaseGroupMbzID), strings.Join(artistIDs, ","), opts.HasPicture,
+		time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("SaveLibraryFile: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) DeleteLibraryFiles(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("DeleteLibraryFiles: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+	for _, path := range paths {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM library_files WHERE path = ?`, path); err != nil {
+			return fmt.Errorf("DeleteLibraryFiles: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("DeleteLibraryFiles: commit: %w", err)
+	}
+	return nil
+}
+
+const selectLibraryFiles = `
+	SELECT lf.id, lf.path, lf.title, lf.artist, lf.album, lf.album_artist, lf.track_number,
+	       lf.disc_number, lf.duration, lf.isrc, lf.recording_mbid, lf.release_mbid,
+	       lf.release_group_mbid, lf.artist_mbids, lf.has_picture, lf.track_id, lf.scanned_at
+	FROM library_files lf`
+
+func scanLibraryFile(row interface{ Scan(...any) error }) (*models.LibraryFile, error) {
+	var f models.LibraryFile
+	var recording, release, releaseGroup sql.NullString
+	var artistIDs string
+	var trackID sql.NullInt32
+	var scannedAt int64
+	if err := row.Scan(&f.ID, &f.Path, &f.Title, &f.Artist, &f.Album, &f.AlbumArtist, &f.TrackNumber,
+		&f.DiscNumber, &f.Duration, &f.ISRC, &recording, &release, &releaseGroup, &artistIDs,
+		&f.HasPicture, &trackID, &scannedAt); err != nil {
+		return nil, err
+	}
+	f.RecordingMbzID = parseNullableUUID(recording)
+	f.ReleaseMbzID = parseNullableUUID(release)
+	f.ReleaseGroupMbzID = parseNullableUUID(releaseGroup)
+	f.ArtistMbzIDs = []uuid.UUID{}
+	for _, s := range strings.Split(artistIDs, ",") {
+		if id, err := uuid.Parse(s); err == nil {
+			f.ArtistMbzIDs = append(f.ArtistMbzIDs, id)
+		}
+	}
+	if trackID.Valid {
+		f.TrackID = &trackID.Int32
+	}
+	f.ScannedAt = time.Unix(scannedAt, 0)
+	return &f, nil
+}
+
+func (s *Sqlite) GetUnmatchedLibraryFiles(ctx context.Context, from int32) ([]*models.LibraryFile, error) {
+	rows, err := s.db.QueryContext(ctx, selectLibraryFiles+`
+		WHERE lf.track_id IS NULL AND lf.id > ?
+		ORDER BY lf.id LIMIT 100`, from)
+	if err != nil {
+		return nil, fmt.Errorf("GetUnmatchedLibraryFiles: %w", err)
+	}
+	defer rows.Close()
+	var files []*models.LibraryFile
+	for rows.Next() {
+		f, err := scanLibraryFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetUnmatchedLibraryFiles: scan: %w", err)
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+func (s *Sqlite) SetLibraryFileTrack(ctx context.Context, id int32, trackID int32) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE library_files SET track_id = ? WHERE id = ?`, trackID, id)
+	if err != nil {
+		return fmt.Errorf("SetLibraryFileTrack: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("SetLibraryFileTrack: %w", db.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Sqlite) GetUnplayedLibraryFiles(ctx context.Context, opts db.GetLibraryFilesOpts) (*db.PaginatedResponse[*models.LibraryFile], error) {
+	if opts.Limit == 0 {
+		opts.Limit = defaultItemsPerPage
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	offset := (opts.Page - 1) * opts.Limit
+
+	// files that aren't matched to a track are unplayed too, since tracks are only created by
+	// listens
+	const where = `
+		WHERE lf.track_id IS NULL
+		   OR NOT EXISTS (SELECT 1 FROM listens l WHERE l.track_id = lf.track_id)`
+	var count int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM library_files lf`+where).Scan(&count); err != nil {
+		return nil, fmt.Errorf("GetUnplayedLibraryFiles: count: %w", err)
+	}
+	rows, err := s.db.QueryContext(ctx, selectLibraryFiles+where+`
+		ORDER BY COALESCE(NULLIF(lf.album_artist, ''), lf.artist) COLLATE NOCASE, lf.album COLLATE NOCASE,
+		         lf.disc_number, lf.track_number, lf.path
+		LIMIT ? OFFSET ?`, opts.Limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("GetUnplayedLibraryFiles: %w", err)
+	}
+	defer rows.Close()
+	files := make([]*models.LibraryFile, 0)
+	for rows.Next() {
+		f, err := scanLibraryFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetUnplayedLibraryFiles: scan: %w", err)
+		}
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetUnplayedLibraryFiles: %w", err)
+	}
+	return &db.PaginatedResponse[*models.LibraryFile]{
+		Items:        files,
+		TotalCount:   count,
+		ItemsPerPage: int32(opts.Limit),
+		HasNextPage:  int64(offset+len(files)) < count,
+		CurrentPage:  int32(opts.Page),
+	}, nil
+}
+
+func (s *Sqlite) GetTracksNotInLibrary(ctx context.Context, opts db.GetItemsOpts) (*db.PaginatedResponse[db.RankedItem[*models.Track]], error) {
+	if opts.Limit == 0 {
+		opts.Limit = defaultItemsPerPage
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	offset := (opts.Page - 1) * opts.Limit
+	t1, t2 := db.TimeframeToTimeRange(opts.Timeframe)
+
+	// a track is in the library when a file is matched to any track of the same song, so that a
+	// listen to the single counts as in the library when the album version is
+	rows, err := s.db.QueryContext(ctx, `
+		WITH TrackCounts AS (
+			SELECT l.track_id, COUNT(*) AS listen_count
+			FROM listens l
+			JOIN tracks t ON l.track_id = t.id
+			WHERE l.listened_at BETWEEN ? AND ?
+			  AND NOT EXISTS (
+				SELECT 1 FROM library_files lf
+				JOIN tracks lt ON lt.id = lf.track_id
+				WHERE lt.song_id = t.song_id
+			  )
+			GROUP BY l.track_id
+		),
+		RankedTracks AS (
+			SELECT track_id, listen_count,
+				   RANK() OVER (ORDER BY listen_count DESC) AS rank,
+				   COUNT(*) OVER () AS total_count
+			FROM TrackCounts
+			ORDER BY listen_count DESC, track_id
+			LIMIT ? OFFSET ?
+		)
+		SELECT r.track_id, twt.title, twt.musicbrainz_id, twt.release_id, twt.song_id, rls.image, r.listen_count, r.rank, r.total_count
+		FROM RankedTracks r
+		JOIN tracks_with_title twt ON twt.id = r.track_id
+		JOIN releases rls ON twt.release_id = rls.id
+		ORDER BY r.rank, r.track_id`,
+		t1.Unix(), t2.Unix(), opts.Limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("GetTracksNotInLibrary: %w", err)
+	}
+	defer rows.Close()
+
+	tracks := make([]db.RankedItem[*models.Track], 0, opts.Limit)
+	var totalCount int64
+	for rows.Next() {
+		var t models.Track
+		var mbzID, image sql.NullString
+		var item db.RankedItem[*models.Track]
+		if err := rows.Scan(&t.ID, &t.Title, &mbzID, &t.AlbumID, &t.SongID, &image, &t.ListenCount, &item.Rank, &totalCount); err != nil {
+			return nil, fmt.Errorf("GetTracksNotInLibrary: scan: %w", err)
+		}
+		t.MbzID = parseNullableUUID(mbzID)
+		t.Image = catalog.BuildImageList(parseNullableUUID(image))
+		item.Item = &t
+		tracks = append(tracks, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetTracksNotInLibrary: %w", err)
+	}
+	rows.Close()
+
+	// the artists are read once the rows are closed, so that the query doesn't need a second
+	// connection
+	for _, item := range tracks {
+		if item.Item.Artists, err = s.artistsForTrack(ctx, item.Item.ID); err != nil {
+			return nil, fmt.Errorf("GetTracksNotInLibrary: %w", err)
+		}
+	}
+
+	return &db.PaginatedResponse[db.RankedItem[*models.Track]]{
+		Items:        tracks,
+		TotalCount:   totalCount,
+		ItemsPerPage: int32(opts.Limit),
+		HasNextPage:  int64(offset+len(tracks)) < totalCount,
+		CurrentPage:  int32(opts.Page),
+	}, nil
+}
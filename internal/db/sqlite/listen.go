@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -24,9 +25,17 @@ func (s *Sqlite) SaveListen(ctx context.Context, opts db.SaveListenOpts) error {
 	if opts.Client != "" {
 		client = opts.Client
 	}
+	var submission sql.NullString
+	if opts.Submission != nil {
+		b, err := json.Marshal(opts.Submission)
+		if err != nil {
+			return fmt.Errorf("SaveListen: marshal submission: %w", err)
+		}
+		submission = sql.NullString{String: string(b), Valid: true}
+	}
 	_, err := s.db.ExecContext(ctx,
-		`INSERT OR IGNORE INTO listens (track_id, listened_at, user_id, client) VALUES (?,?,?,?)`,
-		opts.TrackID, opts.Time.Unix(), opts.UserID, client,
+		`INSERT OR IGNORE INTO listens (track_id, listened_at, user_id, client, submission) VALUES (?,?,?,?,?)`,
+		opts.TrackID, opts.Time.Unix(), opts.UserID, client, submission,
 	)
 	return err
 }
@@ -42,6 +51,108 @@ func (s *Sqlite) DeleteListen(ctx context.Context, trackId int32, listenedAt tim
 	return err
 }
 
+func (s *Sqlite) UpdateListen(ctx context.Context, opts db.UpdateListenOpts) error {
+	if opts.TrackID == 0 {
+		return errors.New("UpdateListen: required parameter TrackID missing")
+	}
+	newTrackID, newTime := opts.NewTrackID, opts.NewTime
+	if newTrackID == 0 {
+		newTrackID = opts.TrackID
+	}
+	if newTime.IsZero() {
+		newTime = opts.ListenedAt
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("UpdateListen: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireSplitRow(ctx, tx, "tracks", newTrackID); err != nil {
+		return fmt.Errorf("UpdateListen: track %d: %w", newTrackID, err)
+	}
+	if newTrackID != opts.TrackID || newTime.Unix() != opts.ListenedAt.Unix() {
+		var exists int
+		err := tx.QueryRowContext(ctx,
+			`SELECT 1 FROM listens WHERE track_id = ? AND listened_at = ?`, newTrackID, newTime.Unix()).Scan(&exists)
+		if err == nil {
+			return fmt.Errorf("UpdateListen: track already has a listen at %s: %w", newTime, db.ErrConflict)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("UpdateListen: %w", err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE listens SET track_id = ?, listened_at = ?, client = COALESCE(?, client)
+		WHERE track_id = ? AND listened_at = ?`,
+		newTrackID, newTime.Unix(), opts.Client, opts.TrackID, opts.ListenedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("UpdateListen: update: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("UpdateListen: %w", db.ErrNotFound)
+	}
+	// the track the listen moved off of may be left without listens
+	if newTrackID != opts.TrackID {
+		if err := cleanOrphanedEntries(ctx, tx); err != nil {
+			return fmt.Errorf("UpdateListen: clean: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Sqlite) MoveListens(ctx context.Context, opts db.MoveListensOpts) (*db.MoveListensResult, error) {
+	if opts.ToTrackID == 0 {
+		return nil, errors.New("MoveListens: required parameter ToTrackID missing")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("MoveListens: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireSplitRow(ctx, tx, "tracks", opts.ToTrackID); err != nil {
+		return nil, fmt.Errorf("MoveListens: track %d: %w", opts.ToTrackID, err)
+	}
+	where := ` WHERE track_id != ?`
+	args := []any{opts.ToTrackID}
+	if opts.TrackID != 0 {
+		where += ` AND track_id = ?`
+		args = append(args, opts.TrackID)
+	}
+	if !opts.From.IsZero() {
+		where += ` AND listened_at >= ?`
+		args = append(args, opts.From.Unix())
+	}
+	if !opts.To.IsZero() {
+		where += ` AND listened_at <= ?`
+		args = append(args, opts.To.Unix())
+	}
+	if opts.Client != "" {
+		where += ` AND client = ?`
+		args = append(args, opts.Client)
+	}
+
+	var matched int64
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM listens`+where, args...).Scan(&matched); err != nil {
+		return nil, fmt.Errorf("MoveListens: count: %w", err)
+	}
+	// listens at a time the target track already has a listen at are left where they are
+	res, err := tx.ExecContext(ctx, `UPDATE OR IGNORE listens SET track_id = ?`+where,
+		append([]any{opts.ToTrackID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("MoveListens: update: %w", err)
+	}
+	moved, _ := res.RowsAffected()
+	if err := cleanOrphanedEntries(ctx, tx); err != nil {
+		return nil, fmt.Errorf("MoveListens: clean: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("MoveListens: commit: %w", err)
+	}
+	return &db.MoveListensResult{Moved: moved, Skipped: matched - moved}, nil
+}
+
 // listenRow is an intermediate scan target used to decouple the main rows
 // query from the per-row artistsForTrack sub-query. With MaxOpenConns(1),
 // both the count query and the artistsForTrack call would deadlock if
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+func (s *Sqlite) GetSubmittedListens(ctx context.Context, opts db.GetSubmittedListensOpts) ([]*db.SubmittedListen, error) {
+	t1, t2 := db.TimeframeToTimeRange(opts.Timeframe)
+	query := `
+		SELECT l.track_id, l.listened_at, l.user_id, l.client, l.submission
+		FROM listens l
+		JOIN tracks t ON l.track_id = t.id
+		WHERE l.listened_at BETWEEN ? AND ?`
+	args := []any{t1.Unix(), t2.Unix()}
+	if opts.Client != "" {
+		query += ` AND l.client = ?`
+		args = append(args, opts.Client)
+	}
+	if opts.ArtistID != 0 {
+		query += ` AND EXISTS (SELECT 1 FROM artist_tracks at2 WHERE at2.track_id = l.track_id AND at2.artist_id = ?)`
+		args = append(args, opts.ArtistID)
+	}
+	if opts.AlbumID != 0 {
+		query += ` AND t.release_id = ?`
+		args = append(args, opts.AlbumID)
+	}
+	query += ` ORDER BY l.listened_at, l.track_id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("GetSubmittedListens: %w", err)
+	}
+	defer rows.Close()
+	listens := make([]*db.SubmittedListen, 0)
+	for rows.Next() {
+		var l db.SubmittedListen
+		var listenedAt int64
+		var submission sql.NullString
+		if err := rows.Scan(&l.TrackID, &listenedAt, &l.UserID, &l.Client, &submission); err != nil {
+			return nil, fmt.Errorf("GetSubmittedListens: scan: %w", err)
+		}
+		l.Time = time.Unix(listenedAt, 0)
+		if submission.Valid {
+			l.Submission = new(models.ListenSubmission)
+			if err := json.Unmarshal([]byte(submission.String), l.Submission); err != nil {
+				return nil, fmt.Errorf("GetSubmittedListens: unmarshal submission: %w", err)
+			}
+		}
+		listens = append(listens, &l)
+	}
+	return listens, rows.Err()
+}
+
+func (s *Sqlite) MoveListen(ctx context.Context, trackID int32, listenedAt time.Time, toTrackID int32) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE OR REPLACE listens SET track_id = ? WHERE track_id = ? AND listened_at = ?`,
+		toTrackID, trackID, listenedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("MoveListen: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("MoveListen: %w", db.ErrNotFound)
+	}
+	return nil
+}
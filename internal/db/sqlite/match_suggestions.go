@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+// the tables holding the items suggestions can be made for, by entity type
+var matchEntityTables = map[string]string{
+	"artist": "artists",
+	"album":  "releases",
+	"track":  "tracks",
+}
+
+func (s *Sqlite) GetUnmatchedArtists(ctx context.Context, from int32) ([]*db.UnmatchedItem, error) {
+	items, err := s.getUnmatchedItems(ctx, `
+		SELECT a.id, a.name, '', '', NULL
+		FROM artists_with_name a
+		WHERE a.musicbrainz_id IS NULL AND a.mbz_searched_at IS NULL AND a.id > ?
+		ORDER BY a.id ASC LIMIT 20`, from)
+	if err != nil {
+		return nil, fmt.Errorf("GetUnmatchedArtists: %w", err)
+	}
+	return items, nil
+}
+
+func (s *Sqlite) GetUnmatchedAlbums(ctx context.Context, from int32) ([]*db.UnmatchedItem, error) {
+	items, err := s.getUnmatchedItems(ctx, `
+		SELECT r.id, r.title,
+		       COALESCE((SELECT group_concat(a.name, ' & ')
+		                 FROM artist_releases ar
+		                 JOIN artists_with_name a ON a.id = ar.artist_id
+		                 WHERE ar.release_id = r.id), ''),
+		       '', NULL
+		FROM releases_with_title r
+		WHERE r.musicbrainz_id IS NULL AND r.mbz_searched_at IS NULL AND r.id > ?
+		ORDER BY r.id ASC LIMIT 20`, from)
+	if err != nil {
+		return nil, fmt.Errorf("GetUnmatchedAlbums: %w", err)
+	}
+	return items, nil
+}
+
+func (s *Sqlite) GetUnmatchedTracks(ctx context.Context, from int32) ([]*db.UnmatchedItem, error) {
+	items, err := s.getUnmatchedItems(ctx, `
+		SELECT t.id, t.title,
+		       COALESCE((SELECT group_concat(a.name, ' & ')
+		                 FROM artist_tracks at2
+		                 JOIN artists_with_name a ON a.id = at2.artist_id
+		                 WHERE at2.track_id = t.id), ''),
+		       r.title, r.musicbrainz_id
+		FROM tracks_with_title t
+		JOIN releases_with_title r ON r.id = t.release_id
+		WHERE t.musicbrainz_id IS NULL AND t.mbz_searched_at IS NULL AND t.id > ?
+		ORDER BY t.id ASC LIMIT 20`, from)
+	if err != nil {
+		return nil, fmt.Errorf("GetUnmatchedTracks: %w", err)
+	}
+	return items, nil
+}
+
+func (s *Sqlite) getUnmatchedItems(ctx context.Context, query string, from int32) ([]*db.UnmatchedItem, error) {
+	rows, err := s.db.QueryContext(ctx, query, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*db.UnmatchedItem
+	for rows.Next() {
+		var item db.UnmatchedItem
+		var albumMbzID sql.NullString
+		if err := rows.Scan(&item.ID, &item.Name, &item.Artist, &item.Album, &albumMbzID); err != nil {
+			return nil, err
+		}
+		item.AlbumMbzID = parseNullableUUID(albumMbzID)
+		items = append(items, &item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Sqlite) MarkMbzSearched(ctx context.Context, entityType string, id int32) error {
+	table, ok := matchEntityTables[entityType]
+	if !ok {
+		return fmt.Errorf("MarkMbzSearched: unknown entity type '%s'", entityType)
+	}
+	res, err := s.db.ExecContext(ctx,
+		fmt.Sprintf(`UPDATE %s SET mbz_searched_at = ? WHERE id = ?`, table), time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("MarkMbzSearched: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("MarkMbzSearched: %w", db.ErrNotFound)
+	}
+	return nil
+}
+
+// SaveMatchSuggestion saves a suggestion. A suggestion of the same MBID for the same item that
+// is still pending has its details updated, while reviewed suggestions are left untouched.
+func (s *Sqlite) SaveMatchSuggestion(ctx context.Context, opts db.SaveMatchSuggestionOpts) (*models.MatchSuggestion, error) {
+	if _, ok := matchEntityTables[opts.EntityType]; !ok {
+		return nil, fmt.Errorf("SaveMatchSuggestion: unknown entity type '%s'", opts.EntityType)
+	}
+	if opts.MbzID == uuid.Nil {
+		return nil, errors.New("SaveMatchSuggestion: musicbrainz id not specified")
+	}
+	status := opts.Status
+	if status == "" {
+		status = models.MatchSuggestionPending
+	}
+	var reviewedAt any
+	if status != models.MatchSuggestionPending {
+		reviewedAt = time.Now().Unix()
+	}
+	var id int32
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO mbz_match_suggestions
+			(entity_type, entity_id, musicbrainz_id, name, artist, confidence, status, created_at, reviewed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (entity_type, entity_id, musicbrainz_id) DO UPDATE SET
+			name = excluded.name,
+			artist = excluded.artist,
+			confidence = excluded.confidence,
+			status = excluded.status,
+			reviewed_at = excluded.reviewed_at
+		WHERE mbz_match_suggestions.status = 'pending'
+		RETURNING id`,
+		opts.EntityType, opts.EntityID, opts.MbzID.String(), opts.Name, opts.Artist, opts.Confidence,
+		status, time.Now().Unix(), reviewedAt).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the suggestion was already reviewed
+		err = s.db.QueryRowContext(ctx, `
+			SELECT id FROM mbz_match_suggestions
+			WHERE entity_type = ? AND entity_id = ? AND musicbrainz_id = ?`,
+			opts.EntityType, opts.EntityID, opts.MbzID.String()).Scan(&id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SaveMatchSuggestion: %w", err)
+	}
+	return s.GetMatchSuggestion(ctx, id)
+}
+
+const matchSuggestionColumns = `
+	ms.id, ms.entity_type, ms.entity_id, ms.musicbrainz_id, ms.name, ms.artist, ms.confidence,
+	ms.status, ms.created_at, ms.reviewed_at,
+	COALESCE(CASE ms.entity_type
+		WHEN 'artist' THEN (SELECT name FROM artists_with_name WHERE id = ms.entity_id)
+		WHEN 'album' THEN (SELECT title FROM releases_with_title WHERE id = ms.entity_id)
+		WHEN 'track' THEN (SELECT title FROM tracks_with_title WHERE id = ms.entity_id)
+	END, '')`
+
+func scanMatchSuggestion(row interface{ Scan(...any) error }) (*models.MatchSuggestion, error) {
+	var ms models.MatchSuggestion
+	var mbzID string
+	var createdAt int64
+	var reviewedAt sql.NullInt64
+	err := row.Scan(&ms.ID, &ms.EntityType, &ms.EntityID, &mbzID, &ms.Name, &ms.Artist, &ms.Confidence,
+		&ms.Status, &createdAt, &reviewedAt, &ms.EntityName)
+	if err != nil {
+		return nil, err
+	}
+	ms.MbzID, _ = uuid.Parse(mbzID)
+	ms.CreatedAt = time.Unix(createdAt, 0)
+	if reviewedAt.Valid {
+		t := time.Unix(reviewedAt.Int64, 0)
+		ms.ReviewedAt = &t
+	}
+	return &ms, nil
+}
+
+func (s *Sqlite) GetMatchSuggestion(ctx context.Context, id int32) (*models.MatchSuggestion, error) {
+	ms, err := scanMatchSuggestion(s.db.QueryRowContext(ctx,
+		`SELECT `+matchSuggestionColumns+` FROM mbz_match_suggestions ms WHERE ms.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("GetMatchSuggestion: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetMatchSuggestion: %w", err)
+	}
+	return ms, nil
+}
+
+func (s *Sqlite) GetMatchSuggestions(ctx context.Context, status string) ([]*models.MatchSuggestion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+matchSuggestionColumns+`
+		FROM mbz_match_suggestions ms
+		WHERE ? = '' OR ms.status = ?
+		ORDER BY ms.confidence DESC, ms.id ASC`, status, status)
+	if err != nil {
+		return nil, fmt.Errorf("GetMatchSuggestions: %w", err)
+	}
+	defer rows.Close()
+	suggestions := make([]*models.MatchSuggestion, 0)
+	for rows.Next() {
+		ms, err := scanMatchSuggestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetMatchSuggestions: scan: %w", err)
+		}
+		suggestions = append(suggestions, ms)
+	}
+	return suggestions, rows.Err()
+}
+
+func (s *Sqlite) ResolveMatchSuggestion(ctx context.Context, id int32, status string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ResolveMatchSuggestion: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	res, err := tx.ExecContext(ctx,
+		`UPDATE mbz_match_suggestions SET status = ?, reviewed_at = ? WHERE id = ?`, status, now, id)
+	if err != nil {
+		return fmt.Errorf("ResolveMatchSuggestion: update: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("ResolveMatchSuggestion: %w", db.ErrNotFound)
+	}
+	if status == models.MatchSuggestionAccepted || status == models.MatchSuggestionApplied {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE mbz_match_suggestions SET status = ?, reviewed_at = ?
+			WHERE status = ? AND id != ?
+			  AND (entity_type, entity_id) = (SELECT entity_type, entity_id FROM mbz_match_suggestions WHERE id = ?)`,
+			models.MatchSuggestionRejected, now, models.MatchSuggestionPending, id, id)
+		if err != nil {
+			return fmt.Errorf("ResolveMatchSuggestion: reject others: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,197 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// separates the values of lists built with group_concat
+const listSeparator = "\x1f"
+
+var duplicateCandidateQueries = map[string]string{
+	"artist": `
+		SELECT a.id, a.name, a.musicbrainz_id,
+		       (SELECT group_concat(alias, char(31)) FROM artist_aliases WHERE artist_id = a.id),
+		       (SELECT COUNT(*) FROM listens l JOIN artist_tracks at2 ON at2.track_id = l.track_id
+		        WHERE at2.artist_id = a.id),
+		       NULL, 0,
+		       (SELECT group_concat(r.title, char(31)) FROM artist_releases ar
+		        JOIN releases_with_title r ON r.id = ar.release_id WHERE ar.artist_id = a.id),
+		       NULL
+		FROM artists_with_name a
+		ORDER BY a.id`,
+	"album": `
+		SELECT r.id, r.title, r.musicbrainz_id,
+		       (SELECT group_concat(alias, char(31)) FROM release_aliases WHERE release_id = r.id),
+		       (SELECT COUNT(*) FROM listens l JOIN tracks t ON t.id = l.track_id WHERE t.release_id = r.id),
+		       (SELECT group_concat(artist_id) FROM artist_releases WHERE release_id = r.id),
+		       0,
+		       (SELECT group_concat(t.title, char(31)) FROM tracks_with_title t WHERE t.release_id = r.id),
+		       (SELECT group_concat(mbid, char(31)) FROM (
+		            SELECT musicbrainz_id AS mbid FROM tracks WHERE release_id = r.id AND musicbrainz_id IS NOT NULL
+		            UNION
+		            SELECT recording_mbid FROM release_tracks WHERE release_id = r.id AND recording_mbid IS NOT NULL))
+		FROM releases_with_title r
+		ORDER BY r.id`,
+	"track": `
+		SELECT t.id, t.title, t.musicbrainz_id,
+		       (SELECT group_concat(alias, char(31)) FROM track_aliases WHERE track_id = t.id),
+		       (SELECT COUNT(*) FROM listens l WHERE l.track_id = t.id),
+		       (SELECT group_concat(artist_id) FROM artist_tracks WHERE track_id = t.id),
+		       t.release_id,
+		       NULL, NULL
+		FROM tracks_with_title t
+		ORDER BY t.id`,
+}
+
+func splitList(s sql.NullString) []string {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	return strings.Split(s.String, listSeparator)
+}
+
+func (s *Sqlite) GetDuplicateCandidates(ctx context.Context, entityType string) ([]*db.DuplicateCandidate, error) {
+	query, ok := duplicateCandidateQueries[entityType]
+	if !ok {
+		return nil, fmt.Errorf("GetDuplicateCandidates: unknown entity type '%s'", entityType)
+	}
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("GetDuplicateCandidates: %w", err)
+	}
+	defer rows.Close()
+	var candidates []*db.DuplicateCandidate
+	for rows.Next() {
+		var c db.DuplicateCandidate
+		var mbzID, aliases, artistIDs, titles, recordings sql.NullString
+		if err := rows.Scan(&c.ID, &c.Name, &mbzID, &aliases, &c.ListenCount, &artistIDs, &c.AlbumID, &titles, &recordings); err != nil {
+			return nil, fmt.Errorf("GetDuplicateCandidates: scan: %w", err)
+		}
+		c.MbzID = parseNullableUUID(mbzID)
+		c.Aliases = splitList(aliases)
+		c.Titles = splitList(titles)
+		c.RecordingMbzIDs = splitList(recordings)
+		if artistIDs.Valid {
+			for v := range strings.SplitSeq(artistIDs.String, ",") {
+				if id, err := strconv.Atoi(v); err == nil {
+					c.ArtistIDs = append(c.ArtistIDs, int32(id))
+				}
+			}
+		}
+		candidates = append(candidates, &c)
+	}
+	return candidates, rows.Err()
+}
+
+func (s *Sqlite) ReplaceMergeSuggestions(ctx context.Context, entityType string, suggestions []db.SaveMergeSuggestionOpts) error {
+	if _, ok := duplicateCandidateQueries[entityType]; !ok {
+		return fmt.Errorf("ReplaceMergeSuggestions: unknown entity type '%s'", entityType)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ReplaceMergeSuggestions: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM merge_suggestions WHERE entity_type = ? AND status = ?`,
+		entityType, models.MergeSuggestionPending); err != nil {
+		return fmt.Errorf("ReplaceMergeSuggestions: delete: %w", err)
+	}
+	now := time.Now().Unix()
+	for _, ms := range suggestions {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO merge_suggestions (entity_type, from_id, to_id, score, reasons, status, created_at)
+			SELECT ?1, ?2, ?3, ?4, ?5, ?6, ?7
+			WHERE NOT EXISTS (
+				SELECT 1 FROM merge_suggestions
+				WHERE entity_type = ?1
+				  AND ((from_id = ?2 AND to_id = ?3) OR (from_id = ?3 AND to_id = ?2))
+			)`,
+			entityType, ms.FromID, ms.ToID, ms.Score, strings.Join(ms.Reasons, ","),
+			models.MergeSuggestionPending, now); err != nil {
+			return fmt.Errorf("ReplaceMergeSuggestions: insert: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+const mergeSuggestionQuery = `
+	SELECT ms.id, ms.entity_type, ms.from_id, ms.to_id, ms.score, ms.reasons, ms.status, ms.created_at,
+	       COALESCE(CASE ms.entity_type
+	           WHEN 'artist' THEN (SELECT name FROM artists_with_name WHERE id = ms.from_id)
+	           WHEN 'album' THEN (SELECT title FROM releases_with_title WHERE id = ms.from_id)
+	           WHEN 'track' THEN (SELECT title FROM tracks_with_title WHERE id = ms.from_id)
+	       END, ''),
+	       COALESCE(CASE ms.entity_type
+	           WHEN 'artist' THEN (SELECT name FROM artists_with_name WHERE id = ms.to_id)
+	           WHEN 'album' THEN (SELECT title FROM releases_with_title WHERE id = ms.to_id)
+	           WHEN 'track' THEN (SELECT title FROM tracks_with_title WHERE id = ms.to_id)
+	       END, '')
+	FROM merge_suggestions ms`
+
+func scanMergeSuggestion(row interface{ Scan(...any) error }) (*models.MergeSuggestion, error) {
+	var ms models.MergeSuggestion
+	var reasons string
+	var createdAt int64
+	err := row.Scan(&ms.ID, &ms.EntityType, &ms.FromID, &ms.ToID, &ms.Score, &reasons, &ms.Status, &createdAt,
+		&ms.FromName, &ms.ToName)
+	if err != nil {
+		return nil, err
+	}
+	ms.Reasons = strings.Split(reasons, ",")
+	ms.CreatedAt = time.Unix(createdAt, 0)
+	return &ms, nil
+}
+
+func (s *Sqlite) GetMergeSuggestions(ctx context.Context, entityType string) ([]*models.MergeSuggestion, error) {
+	rows, err := s.db.QueryContext(ctx, mergeSuggestionQuery+`
+		WHERE ms.status = ? AND (? = '' OR ms.entity_type = ?)
+		ORDER BY ms.score DESC, ms.id ASC`,
+		models.MergeSuggestionPending, entityType, entityType)
+	if err != nil {
+		return nil, fmt.Errorf("GetMergeSuggestions: %w", err)
+	}
+	defer rows.Close()
+	suggestions := make([]*models.MergeSuggestion, 0)
+	for rows.Next() {
+		ms, err := scanMergeSuggestion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetMergeSuggestions: scan: %w", err)
+		}
+		suggestions = append(suggestions, ms)
+	}
+	return suggestions, rows.Err()
+}
+
+func (s *Sqlite) GetMergeSuggestion(ctx context.Context, id int32) (*models.MergeSuggestion, error) {
+	ms, err := scanMergeSuggestion(s.db.QueryRowContext(ctx, mergeSuggestionQuery+` WHERE ms.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("GetMergeSuggestion: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetMergeSuggestion: %w", err)
+	}
+	return ms, nil
+}
+
+func (s *Sqlite) DismissMergeSuggestion(ctx context.Context, id int32) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE merge_suggestions SET status = ? WHERE id = ?`, models.MergeSuggestionDismissed, id)
+	if err != nil {
+		return fmt.Errorf("DismissMergeSuggestion: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("DismissMergeSuggestion: %w", db.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,535 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// mergeParentTables are the tables a merge can change rows of by id, in the order their rows
+// have to be put back in.
+var mergeParentTables = []string{"songs", "artists", "releases", "tracks"}
+
+// mergeChildTables are the tables holding the rows that belong to an artist, release or track,
+// with the column that points to it.
+var mergeChildTables = map[string][][2]string{
+	"artists": {
+		{"artist_aliases", "artist_id"},
+		{"artist_releases", "artist_id"},
+		{"artist_tracks", "artist_id"},
+		{"artist_tags", "artist_id"},
+		{"artist_relations", "artist_id"},
+	},
+	"releases": {
+		{"release_aliases", "release_id"},
+		{"artist_releases", "release_id"},
+		{"release_tags", "release_id"},
+		{"release_tracks", "release_id"},
+	},
+	"tracks": {
+		{"track_aliases", "track_id"},
+		{"artist_tracks", "track_id"},
+		{"track_tags", "track_id"},
+		{"listens", "track_id"},
+	},
+}
+
+type mergeQueries struct {
+	// selects the name of an item
+	name string
+	// selects the ids of the rows a merge of from (?1) into to (?2) can change, as a table name
+	// and an id
+	scope string
+	// counts the listens, tracks, releases and aliases moving from ?1 onto ?2
+	counts string
+}
+
+var mergeQueriesByType = map[string]mergeQueries{
+	"artist": {
+		name: `SELECT name FROM artists_with_name WHERE id = ?`,
+		scope: `
+			SELECT 'artists', id FROM artists WHERE id IN (?1, ?2)`,
+		counts: `
+			SELECT
+				(SELECT COUNT(*) FROM listens l JOIN artist_tracks at2 ON at2.track_id = l.track_id
+				 WHERE at2.artist_id = ?1),
+				(SELECT COUNT(*) FROM artist_tracks WHERE artist_id = ?1),
+				(SELECT COUNT(*) FROM artist_releases WHERE artist_id = ?1),
+				(SELECT COUNT(*) FROM artist_aliases WHERE artist_id = ?1
+				 AND alias NOT IN (SELECT alias FROM artist_aliases WHERE artist_id = ?2))`,
+	},
+	"album": {
+		name: `SELECT title FROM releases_with_title WHERE id = ?`,
+		scope: `
+			SELECT 'releases', id FROM releases WHERE id IN (?1, ?2)
+			UNION ALL
+			SELECT 'tracks', id FROM tracks WHERE release_id IN (?1, ?2)`,
+		counts: `
+			SELECT
+				(SELECT COUNT(*) FROM listens l JOIN tracks t ON t.id = l.track_id WHERE t.release_id = ?1),
+				(SELECT COUNT(*) FROM tracks WHERE release_id = ?1),
+				1,
+				(SELECT COUNT(*) FROM release_aliases WHERE release_id = ?1
+				 AND alias NOT IN (SELECT alias FROM release_aliases WHERE release_id = ?2))`,
+	},
+	"track": {
+		name: `SELECT title FROM tracks_with_title WHERE id = ?`,
+		scope: `
+			SELECT 'tracks', id FROM tracks WHERE id IN (?1, ?2)
+			UNION ALL
+			SELECT 'songs', song_id FROM tracks WHERE id = ?1
+			UNION ALL
+			SELECT 'releases', release_id FROM tracks WHERE id IN (?1, ?2)
+			UNION ALL
+			SELECT 'artists', artist_id FROM artist_tracks WHERE track_id = ?1`,
+		counts: `
+			SELECT
+				(SELECT COUNT(*) FROM listens WHERE track_id = ?1),
+				1,
+				(SELECT COUNT(DISTINCT release_id) FROM tracks WHERE id IN (?1, ?2)) - 1,
+				(SELECT COUNT(*) FROM track_aliases WHERE track_id = ?1
+				 AND alias NOT IN (SELECT alias FROM track_aliases WHERE track_id = ?2))`,
+	},
+}
+
+// mergeRow is a row of any of the merge tables, by column name.
+type mergeRow map[string]any
+
+// key identifies a row. Parent rows are identified by their id, and child rows by all of their
+// values.
+func (r mergeRow) key(parent bool) string {
+	if parent {
+		return fmt.Sprint(r["id"])
+	}
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// mergeSnapshot holds the rows of the merge tables by table and key.
+type mergeSnapshot map[string]map[string]mergeRow
+
+// mergeChanges are the rows a merge changed. Removed rows are put back and added rows are
+// deleted when the merge is undone, and updated rows hold the values of parent rows from
+// before the merge.
+type mergeChanges struct {
+	Removed map[string][]mergeRow `json:"removed"`
+	Added   map[string][]mergeRow `json:"added"`
+	Updated map[string][]mergeRow `json:"updated"`
+}
+
+// images returns the images of the artists and albums in the changes.
+func (c mergeChanges) images() []string {
+	var images []string
+	for _, rows := range []map[string][]mergeRow{c.Removed, c.Added, c.Updated} {
+		for _, table := range []string{"artists", "releases"} {
+			for _, row := range rows[table] {
+				if image, ok := row["image"].(string); ok && image != "" {
+					images = append(images, image)
+				}
+			}
+		}
+	}
+	return images
+}
+
+// merge runs a merge of fromId into toId in a transaction and records the rows it changed, so
+// that the merge can be undone. When dryRun is set, the merge is rolled back and not recorded.
+func (s *Sqlite) merge(ctx context.Context, entityType string, fromId, toId int32, dryRun bool, fn func(tx *sql.Tx) error) (*models.Merge, error) {
+	queries, ok := mergeQueriesByType[entityType]
+	if !ok {
+		return nil, fmt.Errorf("unknown entity type '%s'", entityType)
+	}
+	if fromId == toId {
+		return nil, errors.New("cannot merge an item into itself")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	m := &models.Merge{EntityType: entityType, FromID: fromId, ToID: toId}
+	for _, item := range []struct {
+		id   int32
+		name *string
+	}{{fromId, &m.FromName}, {toId, &m.ToName}} {
+		err := tx.QueryRowContext(ctx, queries.name, item.id).Scan(item.name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s %d: %w", entityType, item.id, db.ErrNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("name: %w", err)
+		}
+	}
+	if err := tx.QueryRowContext(ctx, queries.counts, fromId, toId).
+		Scan(&m.Moved.Listens, &m.Moved.Tracks, &m.Moved.Releases, &m.Moved.Aliases); err != nil {
+		return nil, fmt.Errorf("counts: %w", err)
+	}
+	if dryRun {
+		return m, nil
+	}
+
+	scope, err := mergeScope(ctx, tx, queries.scope, fromId, toId)
+	if err != nil {
+		return nil, fmt.Errorf("scope: %w", err)
+	}
+	before, err := snapshotMergeRows(ctx, tx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		return nil, err
+	}
+	after, err := snapshotMergeRows(ctx, tx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	changes, err := json.Marshal(diffMergeRows(before, after))
+	if err != nil {
+		return nil, fmt.Errorf("marshal changes: %w", err)
+	}
+
+	m.CreatedAt = time.Now()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO merges (entity_type, from_id, from_name, to_id, to_name, changes,
+		                    listen_count, track_count, release_count, alias_count, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entityType, fromId, m.FromName, toId, m.ToName, string(changes),
+		m.Moved.Listens, m.Moved.Tracks, m.Moved.Releases, m.Moved.Aliases, m.CreatedAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("insert: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	m.ID = int32(id)
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return m, nil
+}
+
+func mergeScope(ctx context.Context, tx *sql.Tx, query string, args ...any) (map[string][]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	scope := make(map[string][]int64)
+	for rows.Next() {
+		var table string
+		var id sql.NullInt64
+		if err := rows.Scan(&table, &id); err != nil {
+			return nil, err
+		}
+		if id.Valid && !slices.Contains(scope[table], id.Int64) {
+			scope[table] = append(scope[table], id.Int64)
+		}
+	}
+	return scope, rows.Err()
+}
+
+// snapshotMergeRows reads the parent rows in scope and all of their child rows.
+func snapshotMergeRows(ctx context.Context, tx *sql.Tx, scope map[string][]int64) (mergeSnapshot, error) {
+	snap := make(mergeSnapshot)
+	for _, parent := range mergeParentTables {
+		ids := scope[parent]
+		if len(ids) == 0 {
+			continue
+		}
+		if err := snap.load(ctx, tx, parent, "id", ids); err != nil {
+			return nil, err
+		}
+		for _, child := range mergeChildTables[parent] {
+			if err := snap.load(ctx, tx, child[0], child[1], ids); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return snap, nil
+}
+
+func (snap mergeSnapshot) load(ctx context.Context, tx *sql.Tx, table, column string, ids []int64) error {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	rows, err := tx.QueryContext(ctx,
+		fmt.Sprintf(`SELECT * FROM %s WHERE %s IN (%s)`, table, column, placeholders), args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", table, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("%s: %w", table, err)
+	}
+	if snap[table] == nil {
+		snap[table] = make(map[string]mergeRow)
+	}
+	parent := slices.Contains(mergeParentTables, table)
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("%s: scan: %w", table, err)
+		}
+		row := make(mergeRow, len(columns))
+		for i, c := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[c] = values[i]
+		}
+		snap[table][row.key(parent)] = row
+	}
+	return rows.Err()
+}
+
+func diffMergeRows(before, after mergeSnapshot) mergeChanges {
+	changes := mergeChanges{
+		Removed: make(map[string][]mergeRow),
+		Added:   make(map[string][]mergeRow),
+		Updated: make(map[string][]mergeRow),
+	}
+	for _, table := range slices.Sorted(maps.Keys(before)) {
+		parent := slices.Contains(mergeParentTables, table)
+		for key, row := range before[table] {
+			now, ok := after[table][key]
+			switch {
+			case !ok:
+				changes.Removed[table] = append(changes.Removed[table], row)
+			case parent && row.key(false) != now.key(false):
+				changes.Updated[table] = append(changes.Updated[table], row)
+			}
+		}
+		for key, row := range after[table] {
+			if _, ok := before[table][key]; !ok {
+				changes.Added[table] = append(changes.Added[table], row)
+			}
+		}
+	}
+	return changes
+}
+
+const selectMerges = `
+	SELECT id, entity_type, from_id, from_name, to_id, to_name,
+	       listen_count, track_count, release_count, alias_count, created_at, undone_at
+	FROM merges`
+
+func scanMerge(row interface{ Scan(...any) error }) (*models.Merge, error) {
+	var m models.Merge
+	var createdAt int64
+	var undoneAt sql.NullInt64
+	if err := row.Scan(&m.ID, &m.EntityType, &m.FromID, &m.FromName, &m.ToID, &m.ToName,
+		&m.Moved.Listens, &m.Moved.Tracks, &m.Moved.Releases, &m.Moved.Aliases, &createdAt, &undoneAt); err != nil {
+		return nil, err
+	}
+	m.CreatedAt = time.Unix(createdAt, 0)
+	if undoneAt.Valid {
+		t := time.Unix(undoneAt.Int64, 0)
+		m.UndoneAt = &t
+	}
+	return &m, nil
+}
+
+func (s *Sqlite) PreviewMerge(ctx context.Context, entityType string, fromId, toId int32) (*models.Merge, error) {
+	m, err := s.merge(ctx, entityType, fromId, toId, true, nil)
+	if err != nil {
+		return nil, fmt.Errorf("PreviewMerge: %w", err)
+	}
+	return m, nil
+}
+
+func (s *Sqlite) GetMerges(ctx context.Context, since time.Time) ([]*models.Merge, error) {
+	rows, err := s.db.QueryContext(ctx, selectMerges+` WHERE created_at >= ? ORDER BY created_at DESC, id DESC`,
+		since.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("GetMerges: %w", err)
+	}
+	defer rows.Close()
+	merges := make([]*models.Merge, 0)
+	for rows.Next() {
+		m, err := scanMerge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetMerges: scan: %w", err)
+		}
+		merges = append(merges, m)
+	}
+	return merges, rows.Err()
+}
+
+func (s *Sqlite) GetMerge(ctx context.Context, id int32) (*models.Merge, error) {
+	m, err := scanMerge(s.db.QueryRowContext(ctx, selectMerges+` WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("GetMerge: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetMerge: %w", err)
+	}
+	return m, nil
+}
+
+func (s *Sqlite) UndoMerge(ctx context.Context, id int32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("UndoMerge: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var raw string
+	var undoneAt sql.NullInt64
+	err = tx.QueryRowContext(ctx, `SELECT changes, undone_at FROM merges WHERE id = ?`, id).Scan(&raw, &undoneAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("UndoMerge: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("UndoMerge: %w", err)
+	}
+	if undoneAt.Valid {
+		return fmt.Errorf("UndoMerge: merge was already undone: %w", db.ErrConflict)
+	}
+	changes, err := decodeMergeChanges(raw)
+	if err != nil {
+		return fmt.Errorf("UndoMerge: %w", err)
+	}
+	if err := revertMergeChanges(ctx, tx, changes); err != nil {
+		return fmt.Errorf("UndoMerge: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE merges SET undone_at = ? WHERE id = ?`, time.Now().Unix(), id); err != nil {
+		return fmt.Errorf("UndoMerge: update: %w", err)
+	}
+	return tx.Commit()
+}
+
+// decodeMergeChanges decodes recorded changes. Numbers are kept as json.Number so that ids don't
+// become floats.
+func decodeMergeChanges(raw string) (mergeChanges, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.UseNumber()
+	var changes mergeChanges
+	if err := dec.Decode(&changes); err != nil {
+		return changes, fmt.Errorf("decode changes: %w", err)
+	}
+	return changes, nil
+}
+
+// revertMergeChanges puts back the rows that were removed and updated, and deletes the rows that
+// were added.
+func revertMergeChanges(ctx context.Context, tx *sql.Tx, changes mergeChanges) error {
+	// parents go back first, so that the child rows pointing to them can be put back. Child
+	// rows are put back before the added ones are deleted, as deleting the last artist of a
+	// release deletes the release.
+	for _, table := range mergeParentTables {
+		for _, row := range changes.Removed[table] {
+			var exists int
+			err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT 1 FROM %s WHERE id = ?`, table), row["id"]).Scan(&exists)
+			if err == nil {
+				return fmt.Errorf("%s %v was recreated: %w", table, row["id"], db.ErrConflict)
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if err := insertMergeRow(ctx, tx, "INSERT", table, row); err != nil {
+				return fmt.Errorf("restore %s %v: %w: %w", table, row["id"], db.ErrConflict, err)
+			}
+		}
+	}
+	for _, table := range mergeParentTables {
+		for _, row := range changes.Updated[table] {
+			columns := sortedColumns(row)
+			sets := make([]string, len(columns))
+			args := make([]any, 0, len(columns)+1)
+			for i, c := range columns {
+				sets[i] = c + " = ?"
+				args = append(args, mergeValue(row[c]))
+			}
+			args = append(args, mergeValue(row["id"]))
+			res, err := tx.ExecContext(ctx,
+				fmt.Sprintf(`UPDATE %s SET %s WHERE id = ?`, table, strings.Join(sets, ", ")), args...)
+			if err != nil {
+				return fmt.Errorf("update %s %v: %w: %w", table, row["id"], db.ErrConflict, err)
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				return fmt.Errorf("%s %v no longer exists: %w", table, row["id"], db.ErrConflict)
+			}
+		}
+	}
+	for _, table := range slices.Sorted(maps.Keys(changes.Removed)) {
+		if slices.Contains(mergeParentTables, table) {
+			continue
+		}
+		for _, row := range changes.Removed[table] {
+			if err := insertMergeRow(ctx, tx, "INSERT OR IGNORE", table, row); err != nil {
+				return fmt.Errorf("restore %s: %w", table, err)
+			}
+		}
+	}
+	for _, table := range slices.Sorted(maps.Keys(changes.Added)) {
+		for _, row := range changes.Added[table] {
+			columns := sortedColumns(row)
+			conds := make([]string, len(columns))
+			args := make([]any, len(columns))
+			for i, c := range columns {
+				conds[i] = c + " IS ?"
+				args[i] = mergeValue(row[c])
+			}
+			if _, err := tx.ExecContext(ctx,
+				fmt.Sprintf(`DELETE FROM %s WHERE %s`, table, strings.Join(conds, " AND ")), args...); err != nil {
+				return fmt.Errorf("delete %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Sqlite) DeleteMergesBefore(ctx context.Context, t time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM merges WHERE created_at < ?`, t.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("DeleteMergesBefore: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func insertMergeRow(ctx context.Context, tx *sql.Tx, verb, table string, row mergeRow) error {
+	columns := sortedColumns(row)
+	args := make([]any, len(columns))
+	for i, c := range columns {
+		args[i] = mergeValue(row[c])
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`%s INTO %s (%s) VALUES (%s)`,
+		verb, table, strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")), args...)
+	return err
+}
+
+func sortedColumns(row mergeRow) []string {
+	return slices.Sorted(maps.Keys(row))
+}
+
+// mergeValue turns a value decoded from the recorded changes back into the value it was read as.
+func mergeValue(v any) any {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
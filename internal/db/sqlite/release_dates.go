@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// releaseYear extracts the year from releases.release_date, which is NULL or empty when unknown.
+const releaseYear = `CAST(substr(r.release_date, 1, 4) AS INTEGER)`
+
+func (s *Sqlite) GetAlbumsWithNoReleaseDateButHaveMbzID(ctx context.Context, from int32) ([]*models.Album, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, musicbrainz_id, title
+		FROM releases_with_title
+		WHERE release_date IS NULL AND musicbrainz_id IS NOT NULL AND id > ?
+		ORDER BY id ASC LIMIT 20`,
+		from)
+	if err != nil {
+		return nil, fmt.Errorf("GetAlbumsWithNoReleaseDateButHaveMbzID: %w", err)
+	}
+	defer rows.Close()
+	var albums []*models.Album
+	for rows.Next() {
+		var a models.Album
+		var mbzID sql.NullString
+		if err := rows.Scan(&a.ID, &mbzID, &a.Title); err != nil {
+			return nil, err
+		}
+		a.MbzID = parseNullableUUID(mbzID)
+		albums = append(albums, &a)
+	}
+	return albums, rows.Err()
+}
+
+func (s *Sqlite) GetTopReleaseYears(ctx context.Context, opts db.GetReleaseYearsOpts) ([]db.RankedItem[*models.ReleaseYear], error) {
+	t1, t2 := db.TimeframeToTimeRange(opts.Timeframe)
+	span := 1
+	if opts.Decades {
+		span = 10
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		WITH YearCounts AS (
+			SELECT (`+releaseYear+` / ?) * ? AS year,
+			       COUNT(*) AS listen_count,
+			       COALESCE(SUM(t.duration), 0) AS time_listened,
+			       COUNT(DISTINCT r.id) AS album_count
+			FROM listens l
+			JOIN tracks t ON l.track_id = t.id
+			JOIN releases r ON t.release_id = r.id
+			WHERE l.listened_at BETWEEN ? AND ? AND length(r.release_date) >= 4
+			GROUP BY year
+		)
+		SELECT year, listen_count, time_listened, album_count,
+		       RANK() OVER (ORDER BY listen_count DESC) AS rank
+		FROM YearCounts
+		ORDER BY rank, year DESC`,
+		span, span, t1.Unix(), t2.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("GetTopReleaseYears: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]db.RankedItem[*models.ReleaseYear], 0)
+	for rows.Next() {
+		var y models.ReleaseYear
+		var item db.RankedItem[*models.ReleaseYear]
+		if err := rows.Scan(&y.Year, &y.ListenCount, &y.TimeListened, &y.AlbumCount, &item.Rank); err != nil {
+			return nil, err
+		}
+		item.Item = &y
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (s *Sqlite) GetReleaseAgeStats(ctx context.Context, opts db.GetReleaseAgeOpts) (*db.ReleaseAgeStats, error) {
+	t1, t2 := db.TimeframeToTimeRange(opts.Timeframe)
+	if opts.NewWithinYears <= 0 {
+		opts.NewWithinYears = 1
+	}
+
+	// the age of a listen's release in years, relative to the year the listen happened
+	age := `CAST(strftime('%Y', l.listened_at, 'unixepoch') AS INTEGER) - ` + releaseYear
+
+	var ret db.ReleaseAgeStats
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(CASE WHEN length(r.release_date) >= 4 AND `+age+` < ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN length(r.release_date) >= 4 AND `+age+` >= ? THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN COALESCE(length(r.release_date), 0) < 4 THEN 1 ELSE 0 END), 0)
+		FROM listens l
+		JOIN tracks t ON l.track_id = t.id
+		JOIN releases r ON t.release_id = r.id
+		WHERE l.listened_at BETWEEN ? AND ?`,
+		opts.NewWithinYears, opts.NewWithinYears, t1.Unix(), t2.Unix()).
+		Scan(&ret.NewListens, &ret.OldListens, &ret.UnknownListens)
+	if err != nil {
+		return nil, fmt.Errorf("GetReleaseAgeStats: %w", err)
+	}
+	if known := ret.NewListens + ret.OldListens; known > 0 {
+		ret.NewRatio = float64(ret.NewListens) / float64(known)
+	}
+	return &ret, nil
+}
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/gabehf/koito/internal/models"
+)
+
+// secondary types are stored as a comma separated list
+func joinReleaseTypes(types []string) sql.NullString {
+	var cleaned []string
+	for _, t := range types {
+		if t = strings.TrimSpace(t); t != "" {
+			cleaned = append(cleaned, t)
+		}
+	}
+	return sql.NullString{String: strings.Join(cleaned, ","), Valid: len(cleaned) > 0}
+}
+
+func splitReleaseTypes(s sql.NullString) []string {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	return strings.Split(s.String, ",")
+}
+
+// releaseTypeFilter builds a condition on the releases aliased as r, matching releases that
+// have any of the included types and none of the excluded types. Types are compared case
+// insensitively against both the primary and the secondary types. The returned clause starts
+// with AND, or is empty when there is nothing to filter on.
+func releaseTypeFilter(include, exclude []string) (string, []any) {
+	// instr rather than LIKE, so that % and _ in the type are not taken as wildcards
+	hasType := func(t string) (string, []any) {
+		return `(lower(COALESCE(r.primary_type, '')) = ? OR instr(',' || lower(COALESCE(r.secondary_types, '')) || ',', ',' || ? || ',') > 0)`,
+			[]any{strings.ToLower(t), strings.ToLower(t)}
+	}
+
+	var clause strings.Builder
+	var args []any
+	if len(include) > 0 {
+		conds := make([]string, 0, len(include))
+		for _, t := range include {
+			c, a := hasType(t)
+			conds = append(conds, c)
+			args = append(args, a...)
+		}
+		clause.WriteString(" AND (" + strings.Join(conds, " OR ") + ")")
+	}
+	for _, t := range exclude {
+		c, a := hasType(t)
+		clause.WriteString(" AND NOT " + c)
+		args = append(args, a...)
+	}
+	return clause.String(), args
+}
+
+// GetAlbumsWithNoTypeButHaveMbzID returns albums whose types have never been looked up. Once
+// looked up, type_source is set even if MusicBrainz has no type for the release.
+func (s *Sqlite) GetAlbumsWithNoTypeButHaveMbzID(ctx context.Context, from int32) ([]*models.Album, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, musicbrainz_id, title
+		FROM releases_with_title
+		WHERE type_source IS NULL AND musicbrainz_id IS NOT NULL AND id > ?
+		ORDER BY id ASC LIMIT 20`,
+		from)
+	if err != nil {
+		return nil, fmt.Errorf("GetAlbumsWithNoTypeButHaveMbzID: %w", err)
+	}
+	defer rows.Close()
+	var albums []*models.Album
+	for rows.Next() {
+		var a models.Album
+		var mbzID sql.NullString
+		if err := rows.Scan(&a.ID, &mbzID, &a.Title); err != nil {
+			return nil, err
+		}
+		a.MbzID = parseNullableUUID(mbzID)
+		albums = append(albums, &a)
+	}
+	return albums, rows.Err()
+}
@@ -0,0 +1,171 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+func (s *Sqlite) GetRewriteRules(ctx context.Context) ([]*models.RewriteRule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, enabled, position, action, field, pattern, value
+		FROM rewrite_rules
+		ORDER BY position, id`)
+	if err != nil {
+		return nil, fmt.Errorf("GetRewriteRules: %w", err)
+	}
+	rules := make([]*models.RewriteRule, 0)
+	byID := make(map[int32]*models.RewriteRule)
+	for rows.Next() {
+		r := &models.RewriteRule{Conditions: make([]models.RewriteCondition, 0)}
+		if err := rows.Scan(&r.ID, &r.Name, &r.Enabled, &r.Position, &r.Action, &r.Field, &r.Pattern, &r.Value); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("GetRewriteRules: scan: %w", err)
+		}
+		rules = append(rules, r)
+		byID[r.ID] = r
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetRewriteRules: %w", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT rule_id, field, match_type, value, case_insensitive
+		FROM rewrite_rule_conditions
+		ORDER BY rule_id, position`)
+	if err != nil {
+		return nil, fmt.Errorf("GetRewriteRules: conditions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ruleID int32
+		var c models.RewriteCondition
+		if err := rows.Scan(&ruleID, &c.Field, &c.Match, &c.Value, &c.CaseInsensitive); err != nil {
+			return nil, fmt.Errorf("GetRewriteRules: scan condition: %w", err)
+		}
+		if r, ok := byID[ruleID]; ok {
+			r.Conditions = append(r.Conditions, c)
+		}
+	}
+	return rules, rows.Err()
+}
+
+func (s *Sqlite) GetRewriteRule(ctx context.Context, id int32) (*models.RewriteRule, error) {
+	r := &models.RewriteRule{Conditions: make([]models.RewriteCondition, 0)}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, name, enabled, position, action, field, pattern, value
+		FROM rewrite_rules WHERE id = ?`, id).
+		Scan(&r.ID, &r.Name, &r.Enabled, &r.Position, &r.Action, &r.Field, &r.Pattern, &r.Value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("GetRewriteRule: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetRewriteRule: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT field, match_type, value, case_insensitive
+		FROM rewrite_rule_conditions
+		WHERE rule_id = ?
+		ORDER BY position`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetRewriteRule: conditions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.RewriteCondition
+		if err := rows.Scan(&c.Field, &c.Match, &c.Value, &c.CaseInsensitive); err != nil {
+			return nil, fmt.Errorf("GetRewriteRule: scan condition: %w", err)
+		}
+		r.Conditions = append(r.Conditions, c)
+	}
+	return r, rows.Err()
+}
+
+func (s *Sqlite) SaveRewriteRule(ctx context.Context, opts db.SaveRewriteRuleOpts) (*models.RewriteRule, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SaveRewriteRule: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO rewrite_rules (name, enabled, position, action, field, pattern, value, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		opts.Name, opts.Enabled, opts.Position, opts.Action, opts.Field, opts.Pattern, opts.Value, time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("SaveRewriteRule: insert: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("SaveRewriteRule: %w", err)
+	}
+	if err := saveRewriteConditions(ctx, tx, int32(id), opts.Conditions); err != nil {
+		return nil, fmt.Errorf("SaveRewriteRule: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("SaveRewriteRule: commit: %w", err)
+	}
+
+	return s.GetRewriteRule(ctx, int32(id))
+}
+
+// UpdateRewriteRule replaces every field and condition of a rule.
+func (s *Sqlite) UpdateRewriteRule(ctx context.Context, opts db.UpdateRewriteRuleOpts) error {
+	if opts.ID == 0 {
+		return errors.New("UpdateRewriteRule: rule id not specified")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("UpdateRewriteRule: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE rewrite_rules
+		SET name = ?, enabled = ?, position = ?, action = ?, field = ?, pattern = ?, value = ?
+		WHERE id = ?`,
+		opts.Name, opts.Enabled, opts.Position, opts.Action, opts.Field, opts.Pattern, opts.Value, opts.ID)
+	if err != nil {
+		return fmt.Errorf("UpdateRewriteRule: update: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("UpdateRewriteRule: %w", db.ErrNotFound)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM rewrite_rule_conditions WHERE rule_id = ?`, opts.ID); err != nil {
+		return fmt.Errorf("UpdateRewriteRule: delete conditions: %w", err)
+	}
+	if err := saveRewriteConditions(ctx, tx, opts.ID, opts.Conditions); err != nil {
+		return fmt.Errorf("UpdateRewriteRule: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *Sqlite) DeleteRewriteRule(ctx context.Context, id int32) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM rewrite_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteRewriteRule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("DeleteRewriteRule: %w", db.ErrNotFound)
+	}
+	return nil
+}
+
+func saveRewriteConditions(ctx context.Context, tx *sql.Tx, ruleID int32, conditions []models.RewriteCondition) error {
+	for i, c := range conditions {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO rewrite_rule_conditions (rule_id, position, field, match_type, value, case_insensitive)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			ruleID, i, c.Field, c.Match, c.Value, c.CaseInsensitive); err != nil {
+			return fmt.Errorf("insert condition: %w", err)
+		}
+	}
+	return nil
+}
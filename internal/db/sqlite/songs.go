@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+func (s *Sqlite) GetSong(ctx context.Context, id int32) (*models.Song, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM tracks WHERE song_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetSong: %w", err)
+	}
+	var trackIDs []int32
+	for rows.Next() {
+		var trackID int32
+		if err := rows.Scan(&trackID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("GetSong: scan: %w", err)
+		}
+		trackIDs = append(trackIDs, trackID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetSong: %w", err)
+	}
+	if len(trackIDs) == 0 {
+		return nil, fmt.Errorf("GetSong: %w", db.ErrNotFound)
+	}
+
+	song := &models.Song{ID: id, Tracks: make([]*models.Track, 0, len(trackIDs))}
+	for _, trackID := range trackIDs {
+		track, err := s.getTrackByID(ctx, trackID)
+		if err != nil {
+			return nil, fmt.Errorf("GetSong: %w", err)
+		}
+		song.ListenCount += track.ListenCount
+		song.TimeListened += track.TimeListened
+		song.Tracks = append(song.Tracks, track)
+	}
+	sort.SliceStable(song.Tracks, func(i, j int) bool {
+		return song.Tracks[i].ListenCount > song.Tracks[j].ListenCount
+	})
+	song.Title = song.Tracks[0].Title
+	song.Artists = song.Tracks[0].Artists
+	song.Image = song.Tracks[0].Image
+	return song, nil
+}
+
+// AddTracksToSong moves the given tracks into a song. Songs that are left without tracks are
+// removed.
+func (s *Sqlite) AddTracksToSong(ctx context.Context, songID int32, trackIDs []int32) error {
+	if len(trackIDs) == 0 {
+		return errors.New("AddTracksToSong: no track ids provided")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("AddTracksToSong: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, `SELECT 1 FROM songs WHERE id = ?`, songID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("AddTracksToSong: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("AddTracksToSong: %w", err)
+	}
+
+	for _, trackID := range trackIDs {
+		res, err := tx.ExecContext(ctx, `UPDATE tracks SET song_id = ? WHERE id = ?`, songID, trackID)
+		if err != nil {
+			return fmt.Errorf("AddTracksToSong: update: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("AddTracksToSong: track %d: %w", trackID, db.ErrNotFound)
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveTrackFromSong moves a track out of its song and into a new song of its own.
+func (s *Sqlite) RemoveTrackFromSong(ctx context.Context, trackID int32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("RemoveTrackFromSong: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var songID int32
+	err = tx.QueryRowContext(ctx, `SELECT song_id FROM tracks WHERE id = ?`, trackID).Scan(&songID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("RemoveTrackFromSong: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("RemoveTrackFromSong: %w", err)
+	}
+	var others int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM tracks WHERE song_id = ? AND id != ?`, songID, trackID).Scan(&others)
+	if err != nil {
+		return fmt.Errorf("RemoveTrackFromSong: %w", err)
+	}
+	if others == 0 {
+		// the track already is the only track of its song
+		return nil
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO songs DEFAULT VALUES`)
+	if err != nil {
+		return fmt.Errorf("RemoveTrackFromSong: insert song: %w", err)
+	}
+	newSongID, _ := res.LastInsertId()
+	if _, err := tx.ExecContext(ctx, `UPDATE tracks SET song_id = ? WHERE id = ?`, newSongID, trackID); err != nil {
+		return fmt.Errorf("RemoveTrackFromSong: update: %w", err)
+	}
+	return tx.Commit()
+}
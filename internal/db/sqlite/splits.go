@@ -0,0 +1,371 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+func (s *Sqlite) SplitArtist(ctx context.Context, opts db.SplitArtistOpts) ([]*models.Artist, error) {
+	if len(opts.Parts) == 0 {
+		return nil, errors.New("SplitArtist: no parts given")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SplitArtist: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireSplitRow(ctx, tx, "artists", opts.ID); err != nil {
+		return nil, fmt.Errorf("SplitArtist: %w", err)
+	}
+	ids := make([]int32, 0, len(opts.Parts))
+	for i, part := range opts.Parts {
+		id, err := insertSplitRow(ctx, tx, "artist", part.MusicBrainzID,
+			`INSERT INTO artists (musicbrainz_id) VALUES (?)`, nullableUUID(&part.MusicBrainzID))
+		if err != nil {
+			return nil, fmt.Errorf("SplitArtist: part %d: %w", i, err)
+		}
+		if err := insertSplitAliases(ctx, tx, "artist", id, part.Name, part.Aliases); err != nil {
+			return nil, fmt.Errorf("SplitArtist: part %d: %w", i, err)
+		}
+		trackIDs, err := splitArtistTracks(ctx, tx, opts.ID, part)
+		if err != nil {
+			return nil, fmt.Errorf("SplitArtist: part %d: %w", i, err)
+		}
+		for _, trackID := range trackIDs {
+			query := `UPDATE artist_tracks SET artist_id = ?1 WHERE artist_id = ?2 AND track_id = ?3`
+			if part.Shared {
+				query = `
+					INSERT OR IGNORE INTO artist_tracks (artist_id, track_id, is_primary)
+					SELECT ?1, track_id, is_primary FROM artist_tracks WHERE artist_id = ?2 AND track_id = ?3`
+			}
+			if _, err := tx.ExecContext(ctx, query, id, opts.ID, trackID); err != nil {
+				return nil, fmt.Errorf("SplitArtist: part %d: move track: %w", i, err)
+			}
+		}
+		// the new artist is credited on the albums of its tracks the way the original artist was
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO artist_releases (artist_id, release_id, is_primary)
+			SELECT DISTINCT ?1, t.release_id, COALESCE(ar.is_primary, 0)
+			FROM artist_tracks at2
+			JOIN tracks t ON t.id = at2.track_id
+			LEFT JOIN artist_releases ar ON ar.artist_id = ?2 AND ar.release_id = t.release_id
+			WHERE at2.artist_id = ?1`, id, opts.ID); err != nil {
+			return nil, fmt.Errorf("SplitArtist: part %d: associate albums: %w", i, err)
+		}
+		ids = append(ids, id)
+	}
+
+	var left int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM artist_tracks WHERE artist_id = ?`, opts.ID).Scan(&left); err != nil {
+		return nil, fmt.Errorf("SplitArtist: %w", err)
+	}
+	if left == 0 {
+		return nil, fmt.Errorf("SplitArtist: the original artist would be left without tracks: %w", db.ErrConflict)
+	}
+	if err := cleanOrphanedEntries(ctx, tx); err != nil {
+		return nil, fmt.Errorf("SplitArtist: clean: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("SplitArtist: commit: %w", err)
+	}
+
+	artists := make([]*models.Artist, 0, len(ids))
+	for _, id := range ids {
+		a, err := s.GetArtist(ctx, db.GetArtistOpts{ID: id})
+		if err != nil {
+			return nil, fmt.Errorf("SplitArtist: %w", err)
+		}
+		artists = append(artists, a)
+	}
+	return artists, nil
+}
+
+// splitArtistTracks returns the tracks of an artist that go to a part: the part's tracks, and
+// the artist's tracks on the part's albums.
+func splitArtistTracks(ctx context.Context, tx *sql.Tx, artistID int32, part db.SplitArtistPart) ([]int32, error) {
+	var trackIDs []int32
+	for _, trackID := range part.TrackIDs {
+		var exists int
+		err := tx.QueryRowContext(ctx,
+			`SELECT 1 FROM artist_tracks WHERE artist_id = ? AND track_id = ?`, artistID, trackID).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("track %d of the artist: %w", trackID, db.ErrNotFound)
+		}
+		if err != nil {
+			return nil, err
+		}
+		trackIDs = append(trackIDs, trackID)
+	}
+	for _, albumID := range part.AlbumIDs {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT at2.track_id FROM artist_tracks at2
+			JOIN tracks t ON t.id = at2.track_id
+			WHERE at2.artist_id = ? AND t.release_id = ?`, artistID, albumID)
+		if err != nil {
+			return nil, err
+		}
+		n := len(trackIDs)
+		for rows.Next() {
+			var trackID int32
+			if err := rows.Scan(&trackID); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			trackIDs = append(trackIDs, trackID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if len(trackIDs) == n {
+			return nil, fmt.Errorf("album %d of the artist: %w", albumID, db.ErrNotFound)
+		}
+	}
+	if len(trackIDs) == 0 {
+		return nil, errors.New("no tracks or albums given")
+	}
+	return trackIDs, nil
+}
+
+func (s *Sqlite) SplitAlbum(ctx context.Context, opts db.SplitAlbumOpts) ([]*models.Album, error) {
+	if len(opts.Parts) == 0 {
+		return nil, errors.New("SplitAlbum: no parts given")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SplitAlbum: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireSplitRow(ctx, tx, "releases", opts.ID); err != nil {
+		return nil, fmt.Errorf("SplitAlbum: %w", err)
+	}
+	ids := make([]int32, 0, len(opts.Parts))
+	for i, part := range opts.Parts {
+		if len(part.TrackIDs) == 0 {
+			return nil, fmt.Errorf("SplitAlbum: part %d: no tracks given", i)
+		}
+		id, err := insertSplitRow(ctx, tx, "release", part.MusicBrainzID, `
+			INSERT INTO releases (musicbrainz_id, various_artists, release_date, primary_type, secondary_types, type_source)
+			SELECT ?, various_artists, release_date, primary_type, secondary_types, type_source
+			FROM releases WHERE id = ?`,
+			nullableUUID(&part.MusicBrainzID), opts.ID)
+		if err != nil {
+			return nil, fmt.Errorf("SplitAlbum: part %d: %w", i, err)
+		}
+		if err := insertSplitAliases(ctx, tx, "release", id, part.Title, part.Aliases); err != nil {
+			return nil, fmt.Errorf("SplitAlbum: part %d: %w", i, err)
+		}
+		for _, trackID := range part.TrackIDs {
+			res, err := tx.ExecContext(ctx,
+				`UPDATE tracks SET release_id = ? WHERE id = ? AND release_id = ?`, id, trackID, opts.ID)
+			if err != nil {
+				return nil, fmt.Errorf("SplitAlbum: part %d: move track: %w", i, err)
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				return nil, fmt.Errorf("SplitAlbum: part %d: track %d of the album: %w", i, trackID, db.ErrNotFound)
+			}
+		}
+		// the artists of the moved tracks are credited on the new album the way they were on
+		// the original one
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO artist_releases (artist_id, release_id, is_primary)
+			SELECT DISTINCT at2.artist_id, ?1, COALESCE(ar.is_primary, 0)
+			FROM artist_tracks at2
+			JOIN tracks t ON t.id = at2.track_id
+			LEFT JOIN artist_releases ar ON ar.artist_id = at2.artist_id AND ar.release_id = ?2
+			WHERE t.release_id = ?1`, id, opts.ID); err != nil {
+			return nil, fmt.Errorf("SplitAlbum: part %d: associate artists: %w", i, err)
+		}
+		ids = append(ids, id)
+	}
+
+	var left int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM tracks WHERE release_id = ?`, opts.ID).Scan(&left); err != nil {
+		return nil, fmt.Errorf("SplitAlbum: %w", err)
+	}
+	if left == 0 {
+		return nil, fmt.Errorf("SplitAlbum: the original album would be left without tracks: %w", db.ErrConflict)
+	}
+	if err := cleanOrphanedEntries(ctx, tx); err != nil {
+		return nil, fmt.Errorf("SplitAlbum: clean: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("SplitAlbum: commit: %w", err)
+	}
+
+	albums := make([]*models.Album, 0, len(ids))
+	for _, id := range ids {
+		a, err := s.GetAlbum(ctx, db.GetAlbumOpts{ID: id})
+		if err != nil {
+			return nil, fmt.Errorf("SplitAlbum: %w", err)
+		}
+		albums = append(albums, a)
+	}
+	return albums, nil
+}
+
+func (s *Sqlite) SplitTrack(ctx context.Context, opts db.SplitTrackOpts) ([]*models.Track, error) {
+	if len(opts.Parts) == 0 {
+		return nil, errors.New("SplitTrack: no parts given")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("SplitTrack: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireSplitRow(ctx, tx, "tracks", opts.ID); err != nil {
+		return nil, fmt.Errorf("SplitTrack: %w", err)
+	}
+	ids := make([]int32, 0, len(opts.Parts))
+	for i, part := range opts.Parts {
+		// the new track starts out as a song of its own
+		id, err := insertSplitRow(ctx, tx, "track", part.MusicBrainzID, `
+			INSERT INTO tracks (musicbrainz_id, release_id, duration)
+			SELECT ?, release_id, duration FROM tracks WHERE id = ?`,
+			nullableUUID(&part.MusicBrainzID), opts.ID)
+		if err != nil {
+			return nil, fmt.Errorf("SplitTrack: part %d: %w", i, err)
+		}
+		if err := insertSplitAliases(ctx, tx, "track", id, part.Title, part.Aliases); err != nil {
+			return nil, fmt.Errorf("SplitTrack: part %d: %w", i, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO artist_tracks (artist_id, track_id, is_primary, position, credited_name, join_phrase)
+			SELECT artist_id, ?, is_primary, position, credited_name, join_phrase
+			FROM artist_tracks WHERE track_id = ?`, id, opts.ID); err != nil {
+			return nil, fmt.Errorf("SplitTrack: part %d: associate artists: %w", i, err)
+		}
+		moved, err := moveSplitListens(ctx, tx, opts.ID, id, part)
+		if err != nil {
+			return nil, fmt.Errorf("SplitTrack: part %d: move listens: %w", i, err)
+		}
+		if moved == 0 {
+			return nil, fmt.Errorf("SplitTrack: part %d selects no listens: %w", i, db.ErrConflict)
+		}
+		ids = append(ids, id)
+	}
+
+	var left int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM listens WHERE track_id = ?`, opts.ID).Scan(&left); err != nil {
+		return nil, fmt.Errorf("SplitTrack: %w", err)
+	}
+	if left == 0 {
+		return nil, fmt.Errorf("SplitTrack: the original track would be left without listens: %w", db.ErrConflict)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("SplitTrack: commit: %w", err)
+	}
+
+	tracks := make([]*models.Track, 0, len(ids))
+	for _, id := range ids {
+		t, err := s.getTrackByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("SplitTrack: %w", err)
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, nil
+}
+
+func moveSplitListens(ctx context.Context, tx *sql.Tx, fromID, toID int32, part db.SplitTrackPart) (int64, error) {
+	if len(part.ListenedAt) > 0 {
+		var moved int64
+		for _, t := range part.ListenedAt {
+			res, err := tx.ExecContext(ctx,
+				`UPDATE listens SET track_id = ? WHERE track_id = ? AND listened_at = ?`, toID, fromID, t.Unix())
+			if err != nil {
+				return 0, err
+			}
+			n, _ := res.RowsAffected()
+			moved += n
+		}
+		return moved, nil
+	}
+	query := `UPDATE listens SET track_id = ? WHERE track_id = ?`
+	args := []any{toID, fromID}
+	if !part.From.IsZero() {
+		query += ` AND listened_at >= ?`
+		args = append(args, part.From.Unix())
+	}
+	if !part.To.IsZero() {
+		query += ` AND listened_at <= ?`
+		args = append(args, part.To.Unix())
+	}
+	if part.Client != "" {
+		query += ` AND client = ?`
+		args = append(args, part.Client)
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func requireSplitRow(ctx context.Context, tx *sql.Tx, table string, id int32) error {
+	var exists int
+	err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT 1 FROM %s WHERE id = ?`, table), id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.ErrNotFound
+	}
+	return err
+}
+
+// insertSplitRow inserts the row of a new artist, release or track, after making sure that its
+// MusicBrainz ID is not taken.
+func insertSplitRow(ctx context.Context, tx *sql.Tx, entity string, mbzID uuid.UUID, query string, args ...any) (int32, error) {
+	if mbzID != uuid.Nil {
+		var exists int
+		err := tx.QueryRowContext(ctx,
+			fmt.Sprintf(`SELECT 1 FROM %ss WHERE musicbrainz_id = ?`, entity), mbzID.String()).Scan(&exists)
+		if err == nil {
+			return 0, fmt.Errorf("musicbrainz id %s is already used by another %s: %w", mbzID, entity, db.ErrConflict)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("insert: %w", err)
+	}
+	id, _ := res.LastInsertId()
+	return int32(id), nil
+}
+
+// insertSplitAliases gives a new artist, release or track its name as the primary alias, along
+// with any other aliases.
+func insertSplitAliases(ctx context.Context, tx *sql.Tx, entity string, id int32, name string, aliases []string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("name must not be blank")
+	}
+	query := fmt.Sprintf(
+		`INSERT OR IGNORE INTO %s_aliases (%s_id, alias, source, is_primary) VALUES (?, ?, ?, ?)`, entity, entity)
+	if _, err := tx.ExecContext(ctx, query, id, name, "Canonical", 1); err != nil {
+		return fmt.Errorf("canonical alias: %w", err)
+	}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, query, id, alias, "Manual", 0); err != nil {
+			return fmt.Errorf("alias: %w", err)
+		}
+	}
+	return nil
+}
@@ -239,6 +239,11 @@ func nullableUUID(u *uuid.UUID) sql.NullString {
 	return sql.NullString{String: u.String(), Valid: true}
 }
 
+// nullableString stores empty strings as NULL.
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
 // parseNullableUUID converts a sql.NullString from storage back to *uuid.UUID.
 func parseNullableUUID(s sql.NullString) *uuid.UUID {
 	if !s.Valid || s.String == "" {
@@ -293,6 +298,10 @@ func (s *Sqlite) PurgeAllData(ctx context.Context) error {
 		`DELETE FROM tracks`,
 		`DELETE FROM releases`,
 		`DELETE FROM artists`,
+		`DELETE FROM tags`,
+		`DELETE FROM trash`,
+		// merges can't be undone once the items they were made from are gone
+		`DELETE FROM merges`,
 	} {
 		if _, err := tx.ExecContext(ctx, stmt); err != nil {
 			return fmt.Errorf("PurgeAllData: %w", err)
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// tagFilter restricts the listens aliased as l to tracks carrying the given tag, either
+// directly or through their release or artists. It takes the tag name twice as arguments.
+const tagFilter = `
+	AND (? = '' OR l.track_id IN (
+		SELECT tet.track_id FROM track_effective_tags tet
+		JOIN tags tg ON tg.id = tet.tag_id
+		WHERE tg.name = ?))`
+
+// normalizeTag lowercases and trims a tag name and collapses inner whitespace.
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+func (s *Sqlite) SaveArtistTags(ctx context.Context, id int32, tags []string, source string) error {
+	if id == 0 {
+		return errors.New("SaveArtistTags: artist id not specified")
+	}
+	if err := s.saveTags(ctx, "artist_tags", "artist_id", id, tags, source); err != nil {
+		return fmt.Errorf("SaveArtistTags: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) SaveAlbumTags(ctx context.Context, id int32, tags []string, source string) error {
+	if id == 0 {
+		return errors.New("SaveAlbumTags: album id not specified")
+	}
+	if err := s.saveTags(ctx, "release_tags", "release_id", id, tags, source); err != nil {
+		return fmt.Errorf("SaveAlbumTags: %w", err)
+	}
+	return nil
+}
+
+func (s *Sqlite) SaveTrackTags(ctx context.Context, id int32, tags []string, source string) error {
+	if id == 0 {
+		return errors.New("SaveTrackTags: track id not specified")
+	}
+	if err := s.saveTags(ctx, "track_tags", "track_id", id, tags, source); err != nil {
+		return fmt.Errorf("SaveTrackTags: %w", err)
+	}
+	return nil
+}
+
+// saveTags links the given tags to an entity through the given link table, creating any
+// tags that do not exist yet. Tags that are already linked keep their original source.
+func (s *Sqlite) saveTags(ctx context.Context, table, idCol string, id int32, tags []string, source string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags (name) VALUES (?)`, tag); err != nil {
+			return fmt.Errorf("insert tag: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO `+table+` (`+idCol+`, tag_id, source)
+			SELECT ?, id, ? FROM tags WHERE name = ?`,
+			id, source, tag); err != nil {
+			return fmt.Errorf("link tag: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// getTagsForEntity returns the names of the tags linked to an entity, sorted by name.
+func (s *Sqlite) getTagsForEntity(ctx context.Context, table, idCol string, id int32) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tg.name FROM `+table+` x
+		JOIN tags tg ON tg.id = x.tag_id
+		WHERE x.`+idCol+` = ?
+		ORDER BY tg.name`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+func (s *Sqlite) GetTopTagsPaginated(ctx context.Context, opts db.GetItemsOpts) (*db.PaginatedResponse[db.RankedItem[*models.Tag]], error) {
+	if opts.Limit == 0 {
+		opts.Limit = defaultItemsPerPage
+	}
+	offset := (opts.Page - 1) * opts.Limit
+	t1, t2 := db.TimeframeToTimeRange(opts.Timeframe)
+
+	// a listen counts once towards every tag of its track, release and artists
+	query := `
+		WITH TagCounts AS (
+			SELECT tet.tag_id, COUNT(*) AS listen_count, COALESCE(SUM(t.duration), 0) AS time_listened
+			FROM listens l
+			JOIN tracks t ON l.track_id = t.id
+			JOIN track_effective_tags tet ON tet.track_id = l.track_id
+			WHERE l.listened_at BETWEEN ? AND ?
+			GROUP BY tet.tag_id
+		),
+		RankedTags AS (
+			SELECT tag_id, listen_count, time_listened,
+			       RANK() OVER (ORDER BY listen_count DESC) AS rank,
+			       COUNT(*) OVER () AS total_count
+			FROM TagCounts
+			ORDER BY listen_count DESC, tag_id
+			LIMIT ? OFFSET ?
+		)
+		SELECT r.tag_id, tg.name, r.listen_count, r.time_listened, r.rank, r.total_count
+		FROM RankedTags r
+		JOIN tags tg ON tg.id = r.tag_id
+		ORDER BY r.rank, r.tag_id`
+
+	rows, err := s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.Limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("GetTopTagsPaginated: %w", err)
+	}
+	defer rows.Close()
+
+	tags := make([]db.RankedItem[*models.Tag], 0, opts.Limit)
+	var totalCount int64
+
+	for rows.Next() {
+		var t models.Tag
+		var item db.RankedItem[*models.Tag]
+		if err := rows.Scan(&t.ID, &t.Name, &t.ListenCount, &t.TimeListened, &item.Rank, &totalCount); err != nil {
+			return nil, err
+		}
+		item.Item = &t
+		tags = append(tags, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &db.PaginatedResponse[db.RankedItem[*models.Tag]]{
+		Items:        tags,
+		TotalCount:   totalCount,
+		ItemsPerPage: int32(opts.Limit),
+		HasNextPage:  int64(offset+len(tags)) < totalCount,
+		CurrentPage:  int32(opts.Page),
+	}, nil
+}
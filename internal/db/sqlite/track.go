@@ -45,11 +45,11 @@ func (s *Sqlite) getTrackByID(ctx context.Context, id int32) (*models.Track, err
 	var track models.Track
 	var mbzID, image sql.NullString
 	err := s.db.QueryRowContext(ctx, `
-		SELECT t.id, t.musicbrainz_id, t.duration, t.release_id, t.title, r.image
+		SELECT t.id, t.musicbrainz_id, t.duration, t.release_id, t.song_id, t.title, r.image
 		FROM tracks_with_title t
 		JOIN releases r ON t.release_id = r.id
 		WHERE t.id = ? LIMIT 1`, id).
-		Scan(&track.ID, &mbzID, &track.Duration, &track.AlbumID, &track.Title, &image)
+		Scan(&track.ID, &mbzID, &track.Duration, &track.AlbumID, &track.SongID, &track.Title, &image)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("getTrackByID: %w", db.ErrNotFound)
 	}
@@ -64,6 +64,10 @@ func (s *Sqlite) getTrackByID(ctx context.Context, id int32) (*models.Track, err
 		return nil, fmt.Errorf("getTrackByID: artists: %w", err)
 	}
 	track.Artists = artists
+	track.Credits, err = s.creditsFor(ctx, "artist_tracks", id)
+	if err != nil {
+		return nil, fmt.Errorf("getTrackByID: credits: %w", err)
+	}
 
 	var listenCount int64
 	s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM listens WHERE track_id = ?`, id).Scan(&listenCount)
@@ -92,6 +96,11 @@ func (s *Sqlite) getTrackByID(ctx context.Context, id int32) (*models.Track, err
 		) WHERE track_id = ?`, id).Scan(&rank)
 	track.AllTimeRank = rank
 
+	track.Tags, err = s.getTagsForEntity(ctx, "track_tags", "track_id", id)
+	if err != nil {
+		return nil, fmt.Errorf("getTrackByID: tags: %w", err)
+	}
+
 	return &track, nil
 }
 
@@ -139,8 +148,9 @@ func (s *Sqlite) SaveTrack(ctx context.Context, opts db.SaveTrackOpts) (*models.
 	defer tx.Rollback()
 
 	res, err := tx.ExecContext(ctx,
-		`INSERT INTO tracks (musicbrainz_id, release_id, duration) VALUES (?,?,?)`,
+		`INSERT INTO tracks (musicbrainz_id, release_id, duration, song_id) VALUES (?,?,?,?)`,
 		nullableUUID(&opts.RecordingMbzID), opts.AlbumID, opts.Duration,
+		sql.NullInt32{Int32: opts.SongID, Valid: opts.SongID != 0},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("SaveTrack: insert: %w", err)
@@ -171,6 +181,11 @@ func (s *Sqlite) SaveTrack(ctx context.Context, opts db.SaveTrackOpts) (*models.
 		return nil, fmt.Errorf("SaveTrack: canonical alias: %w", err)
 	}
 
+	var songID int32
+	if err := tx.QueryRowContext(ctx, `SELECT song_id FROM tracks WHERE id = ?`, id).Scan(&songID); err != nil {
+		return nil, fmt.Errorf("SaveTrack: song: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("SaveTrack: commit: %w", err)
 	}
@@ -180,6 +195,7 @@ func (s *Sqlite) SaveTrack(ctx context.Context, opts db.SaveTrackOpts) (*models.
 		Title:    opts.Title,
 		Duration: opts.Duration,
 		AlbumID:  opts.AlbumID,
+		SongID:   songID,
 	}
 	if opts.RecordingMbzID != uuid.Nil {
 		u := opts.RecordingMbzID
@@ -305,19 +321,19 @@ func (s *Sqlite) UpdateTrack(ctx context.Context, opts db.UpdateTrackOpts) error
 }
 
 func (s *Sqlite) DeleteTrack(ctx context.Context, id int32) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+	err := s.trash(ctx, "track", id, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM tracks WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+		if err := cleanOrphanedEntries(ctx, tx); err != nil {
+			return fmt.Errorf("clean: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("DeleteTrack: BeginTx: %w", err)
+		return fmt.Errorf("DeleteTrack: %w", err)
 	}
-	defer tx.Rollback()
-
-	if _, err := tx.ExecContext(ctx, `DELETE FROM tracks WHERE id = ?`, id); err != nil {
-		return fmt.Errorf("DeleteTrack: delete: %w", err)
-	}
-	if err := cleanOrphanedEntries(ctx, tx); err != nil {
-		return fmt.Errorf("DeleteTrack: clean: %w", err)
-	}
-	return tx.Commit()
+	return nil
 }
 
 func (s *Sqlite) DeleteTrackAlias(ctx context.Context, id int32, alias string) error {
@@ -432,13 +448,56 @@ func (s *Sqlite) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts
 	var err error
 
 	switch {
+	case opts.BySong:
+		// each song is shown as its most listened track in the timeframe, with the listens of all
+		// its tracks
+		query := `
+			WITH TrackCounts AS (
+				SELECT l.track_id, t.song_id, COUNT(*) AS listen_count
+				FROM listens l
+				JOIN tracks t ON l.track_id = t.id
+				WHERE l.listened_at BETWEEN ? AND ?
+				  AND (? = 0 OR l.track_id IN (SELECT track_id FROM artist_tracks WHERE artist_id = ?))
+				  AND (? = 0 OR t.release_id = ?)` + tagFilter + `
+				GROUP BY l.track_id
+			),
+			SongCounts AS (
+				SELECT song_id, SUM(listen_count) AS listen_count
+				FROM TrackCounts
+				GROUP BY song_id
+			),
+			RankedSongs AS (
+				SELECT song_id, listen_count,
+					   RANK() OVER (ORDER BY listen_count DESC) AS rank,
+					   COUNT(*) OVER () AS total_count
+				FROM SongCounts
+				ORDER BY listen_count DESC, song_id
+				LIMIT ? OFFSET ?
+			),
+			SongTracks AS (
+				SELECT r.*, (
+					SELECT tc.track_id FROM TrackCounts tc
+					WHERE tc.song_id = r.song_id
+					ORDER BY tc.listen_count DESC, tc.track_id
+					LIMIT 1
+				) AS track_id
+				FROM RankedSongs r
+			)
+			SELECT r.track_id, twt.title, twt.musicbrainz_id, twt.release_id, twt.song_id, rls.image, r.listen_count, r.rank, r.total_count
+			FROM SongTracks r
+			JOIN tracks_with_title twt ON twt.id = r.track_id
+			JOIN releases rls ON twt.release_id = rls.id
+			ORDER BY r.rank, r.song_id`
+
+		rows, err = s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.ArtistID, opts.ArtistID, opts.AlbumID, opts.AlbumID, opts.Tag, opts.Tag, opts.Limit, offset)
+
 	case opts.AlbumID > 0:
 		query := `
 			WITH TrackCounts AS (
 				SELECT l.track_id, COUNT(*) AS listen_count
 				FROM listens l
 				JOIN tracks t ON l.track_id = t.id
-				WHERE l.listened_at BETWEEN ? AND ? AND t.release_id = ?
+				WHERE l.listened_at BETWEEN ? AND ? AND t.release_id = ?` + tagFilter + `
 				GROUP BY l.track_id
 			),
 			RankedTracks AS (
@@ -449,13 +508,13 @@ func (s *Sqlite) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts
 				ORDER BY listen_count DESC, track_id
 				LIMIT ? OFFSET ?
 			)
-			SELECT r.track_id, twt.title, twt.musicbrainz_id, twt.release_id, rls.image, r.listen_count, r.rank, r.total_count
+			SELECT r.track_id, twt.title, twt.musicbrainz_id, twt.release_id, twt.song_id, rls.image, r.listen_count, r.rank, r.total_count
 			FROM RankedTracks r
 			JOIN tracks_with_title twt ON twt.id = r.track_id
 			JOIN releases rls ON twt.release_id = rls.id
 			ORDER BY r.rank, r.track_id`
 
-		rows, err = s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.AlbumID, opts.Limit, offset)
+		rows, err = s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.AlbumID, opts.Tag, opts.Tag, opts.Limit, offset)
 
 	case opts.ArtistID > 0:
 		query := `
@@ -463,7 +522,7 @@ func (s *Sqlite) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts
 				SELECT l.track_id, COUNT(*) AS listen_count
 				FROM listens l
 				JOIN artist_tracks at2 ON l.track_id = at2.track_id
-				WHERE l.listened_at BETWEEN ? AND ? AND at2.artist_id = ?
+				WHERE l.listened_at BETWEEN ? AND ? AND at2.artist_id = ?` + tagFilter + `
 				GROUP BY l.track_id
 			),
 			RankedTracks AS (
@@ -474,21 +533,21 @@ func (s *Sqlite) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts
 				ORDER BY listen_count DESC, track_id
 				LIMIT ? OFFSET ?
 			)
-			SELECT r.track_id, twt.title, twt.musicbrainz_id, twt.release_id, rls.image, r.listen_count, r.rank, r.total_count
+			SELECT r.track_id, twt.title, twt.musicbrainz_id, twt.release_id, twt.song_id, rls.image, r.listen_count, r.rank, r.total_count
 			FROM RankedTracks r
 			JOIN tracks_with_title twt ON twt.id = r.track_id
 			JOIN releases rls ON twt.release_id = rls.id
 			ORDER BY r.rank, r.track_id`
 
-		rows, err = s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.ArtistID, opts.Limit, offset)
+		rows, err = s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.ArtistID, opts.Tag, opts.Tag, opts.Limit, offset)
 
 	default:
 		query := `
 			WITH TrackCounts AS (
-				SELECT track_id, COUNT(*) AS listen_count
-				FROM listens
-				WHERE listened_at BETWEEN ? AND ?
-				GROUP BY track_id
+				SELECT l.track_id, COUNT(*) AS listen_count
+				FROM listens l
+				WHERE l.listened_at BETWEEN ? AND ?` + tagFilter + `
+				GROUP BY l.track_id
 			),
 			RankedTracks AS (
 				SELECT track_id, listen_count,
@@ -498,13 +557,13 @@ func (s *Sqlite) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts
 				ORDER BY listen_count DESC, track_id
 				LIMIT ? OFFSET ?
 			)
-			SELECT r.track_id, twt.title, twt.musicbrainz_id, twt.release_id, rls.image, r.listen_count, r.rank, r.total_count
+			SELECT r.track_id, twt.title, twt.musicbrainz_id, twt.release_id, twt.song_id, rls.image, r.listen_count, r.rank, r.total_count
 			FROM RankedTracks r
 			JOIN tracks_with_title twt ON twt.id = r.track_id
 			JOIN releases rls ON twt.release_id = rls.id
 			ORDER BY r.rank, r.track_id`
 
-		rows, err = s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.Limit, offset)
+		rows, err = s.db.QueryContext(ctx, query, t1.Unix(), t2.Unix(), opts.Tag, opts.Tag, opts.Limit, offset)
 	}
 
 	if err != nil {
@@ -523,7 +582,7 @@ func (s *Sqlite) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts
 		var item db.RankedItem[*models.Track]
 
 		// Scan totalCount directly alongside the row data
-		if err := rows.Scan(&t.ID, &t.Title, &mbzID, &t.AlbumID, &image, &t.ListenCount, &item.Rank, &totalCount); err != nil {
+		if err := rows.Scan(&t.ID, &t.Title, &mbzID, &t.AlbumID, &t.SongID, &image, &t.ListenCount, &item.Rank, &totalCount); err != nil {
 			return nil, err
 		}
 
@@ -554,12 +613,16 @@ func (s *Sqlite) GetTopTracksPaginated(ctx context.Context, opts db.GetItemsOpts
 }
 
 func (s *Sqlite) MergeTracks(ctx context.Context, fromId, toId int32) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+	_, err := s.merge(ctx, "track", fromId, toId, false, func(tx *sql.Tx) error {
+		return mergeTracks(ctx, tx, fromId, toId)
+	})
 	if err != nil {
-		return fmt.Errorf("MergeTracks: BeginTx: %w", err)
+		return fmt.Errorf("MergeTracks: %w", err)
 	}
-	defer tx.Rollback()
+	return nil
+}
 
+func mergeTracks(ctx context.Context, tx *sql.Tx, fromId, toId int32) error {
 	// check if tracks are in different releases
 	var fromRelease, toRelease int32
 	tx.QueryRowContext(ctx, `SELECT release_id FROM tracks WHERE id = ?`, fromId).Scan(&fromRelease)
@@ -568,7 +631,18 @@ func (s *Sqlite) MergeTracks(ctx context.Context, fromId, toId int32) error {
 	// redirect all listens (ignore conflicts — same timestamp already exists for toId)
 	if _, err := tx.ExecContext(ctx,
 		`UPDATE OR IGNORE listens SET track_id = ? WHERE track_id = ?`, toId, fromId); err != nil {
-		return fmt.Errorf("MergeTracks: redirect listens: %w", err)
+		return fmt.Errorf("redirect listens: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO track_aliases (track_id, alias, source, is_primary)
+		SELECT ?, alias, source, 0 FROM track_aliases WHERE track_id = ?`, toId, fromId); err != nil {
+		return fmt.Errorf("move aliases: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO track_tags (track_id, tag_id, source)
+		SELECT ?, tag_id, source FROM track_tags WHERE track_id = ?`, toId, fromId); err != nil {
+		return fmt.Errorf("move tags: %w", err)
 	}
 
 	if fromRelease != toRelease {
@@ -576,7 +650,7 @@ func (s *Sqlite) MergeTracks(ctx context.Context, fromId, toId int32) error {
 		rows, err := tx.QueryContext(ctx,
 			`SELECT artist_id FROM artist_tracks WHERE track_id = ?`, fromId)
 		if err != nil {
-			return fmt.Errorf("MergeTracks: fetch artists: %w", err)
+			return fmt.Errorf("fetch artists: %w", err)
 		}
 		var artistIDs []int32
 		for rows.Next() {
@@ -589,15 +663,15 @@ func (s *Sqlite) MergeTracks(ctx context.Context, fromId, toId int32) error {
 			if _, err := tx.ExecContext(ctx,
 				`INSERT OR IGNORE INTO artist_releases (artist_id, release_id, is_primary) VALUES (?,?,0)`,
 				aid, toRelease); err != nil {
-				return fmt.Errorf("MergeTracks: associate artist to release: %w", err)
+				return fmt.Errorf("associate artist to release: %w", err)
 			}
 		}
 	}
 
 	if err := cleanOrphanedEntries(ctx, tx); err != nil {
-		return fmt.Errorf("MergeTracks: clean: %w", err)
+		return fmt.Errorf("clean: %w", err)
 	}
-	return tx.Commit()
+	return nil
 }
 
 func (s *Sqlite) CountTracks(ctx context.Context, timeframe db.Timeframe) (int64, error) {
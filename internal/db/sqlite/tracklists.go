@@ -0,0 +1,183 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// fullAlbumPlayMaxGap is the longest pause, in seconds, allowed between the end of one track
+// and the start of the next for both listens to count towards the same full album play.
+const fullAlbumPlayMaxGap = 15 * 60
+
+func (s *Sqlite) GetAlbumsWithNoTracklistButHaveMbzID(ctx context.Context, from int32) ([]*models.Album, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, musicbrainz_id, title
+		FROM releases_with_title
+		WHERE track_count IS NULL AND musicbrainz_id IS NOT NULL AND id > ?
+		ORDER BY id ASC LIMIT 20`,
+		from)
+	if err != nil {
+		return nil, fmt.Errorf("GetAlbumsWithNoTracklistButHaveMbzID: %w", err)
+	}
+	defer rows.Close()
+	var albums []*models.Album
+	for rows.Next() {
+		var a models.Album
+		var mbzID sql.NullString
+		if err := rows.Scan(&a.ID, &mbzID, &a.Title); err != nil {
+			return nil, err
+		}
+		a.MbzID = parseNullableUUID(mbzID)
+		albums = append(albums, &a)
+	}
+	return albums, rows.Err()
+}
+
+// SaveAlbumTracklist replaces the tracklist of an album. Saving an empty tracklist marks the
+// tracklist of the album as looked up.
+func (s *Sqlite) SaveAlbumTracklist(ctx context.Context, id int32, tracklist []models.TracklistEntry) error {
+	if id == 0 {
+		return errors.New("SaveAlbumTracklist: album id not specified")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("SaveAlbumTracklist: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM release_tracks WHERE release_id = ?`, id); err != nil {
+		return fmt.Errorf("SaveAlbumTracklist: delete: %w", err)
+	}
+	for _, e := range tracklist {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO release_tracks
+				(release_id, disc_number, position, number, title, recording_mbid, duration)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, e.DiscNumber, e.Position, e.Number, e.Title, nullableUUID(e.RecordingMbzID), e.Duration); err != nil {
+			return fmt.Errorf("SaveAlbumTracklist: insert: %w", err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE releases SET track_count = ? WHERE id = ?`, len(tracklist), id)
+	if err != nil {
+		return fmt.Errorf("SaveAlbumTracklist: update track count: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return db.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// GetAlbumTracklist returns the tracklist of an album ordered by disc and position, along with
+// the listen count of the track linked to each position.
+func (s *Sqlite) GetAlbumTracklist(ctx context.Context, id int32) ([]*models.TracklistEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT rt.disc_number, rt.position, rt.number, rt.title, rt.recording_mbid, rt.duration, rt.track_id,
+		       (SELECT COUNT(*) FROM listens l WHERE l.track_id = rt.track_id)
+		FROM release_tracks_linked rt
+		WHERE rt.release_id = ?
+		ORDER BY rt.disc_number, rt.position`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetAlbumTracklist: %w", err)
+	}
+	defer rows.Close()
+	tracklist := make([]*models.TracklistEntry, 0)
+	for rows.Next() {
+		var e models.TracklistEntry
+		var mbzID sql.NullString
+		var trackID sql.NullInt32
+		if err := rows.Scan(&e.DiscNumber, &e.Position, &e.Number, &e.Title, &mbzID, &e.Duration, &trackID, &e.ListenCount); err != nil {
+			return nil, fmt.Errorf("GetAlbumTracklist: scan: %w", err)
+		}
+		e.RecordingMbzID = parseNullableUUID(mbzID)
+		if trackID.Valid {
+			e.TrackID = &trackID.Int32
+		}
+		tracklist = append(tracklist, &e)
+	}
+	return tracklist, rows.Err()
+}
+
+// GetAlbumCompletion reports how much of an album's tracklist has been heard, and how many
+// times the album was played in full. A full album play is a run of listens that covers the
+// whole tracklist in order, with no more than fullAlbumPlayMaxGap between consecutive tracks.
+func (s *Sqlite) GetAlbumCompletion(ctx context.Context, id int32) (*db.AlbumCompletion, error) {
+	tracklist, err := s.GetAlbumTracklist(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetAlbumCompletion: %w", err)
+	}
+
+	ret := &db.AlbumCompletion{
+		TotalTracks: int64(len(tracklist)),
+		Unheard:     make([]*models.TracklistEntry, 0),
+	}
+	// the tracklist indexes each track is linked to; a track may appear more than once
+	indexes := make(map[int32][]int)
+	complete := len(tracklist) > 0
+	for i, e := range tracklist {
+		if e.ListenCount > 0 {
+			ret.HeardTracks++
+		} else {
+			ret.Unheard = append(ret.Unheard, e)
+		}
+		if e.TrackID == nil {
+			complete = false
+			continue
+		}
+		indexes[*e.TrackID] = append(indexes[*e.TrackID], i)
+	}
+	if ret.TotalTracks > 0 {
+		ret.Completion = float64(ret.HeardTracks) / float64(ret.TotalTracks)
+	}
+	// a full play can't be detected while part of the tracklist is not linked to a track
+	if !complete {
+		return ret, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT l.track_id, l.listened_at
+		FROM listens l
+		JOIN tracks t ON l.track_id = t.id
+		WHERE t.release_id = ?
+		ORDER BY l.listened_at`, id)
+	if err != nil {
+		return nil, fmt.Errorf("GetAlbumCompletion: listens: %w", err)
+	}
+	defer rows.Close()
+
+	next := 0
+	var last int64
+	for rows.Next() {
+		var trackID int32
+		var listenedAt int64
+		if err := rows.Scan(&trackID, &listenedAt); err != nil {
+			return nil, fmt.Errorf("GetAlbumCompletion: scan: %w", err)
+		}
+		idx := indexes[trackID]
+		if next > 0 && listenedAt-last > int64(tracklist[next-1].Duration)+fullAlbumPlayMaxGap {
+			next = 0
+		}
+		switch {
+		case next > 0 && slices.Contains(idx, next):
+			next++
+		case slices.Contains(idx, 0):
+			next = 1
+		default:
+			next = 0
+		}
+		last = listenedAt
+		if next == len(tracklist) {
+			ret.FullAlbumPlays++
+			next = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetAlbumCompletion: %w", err)
+	}
+	return ret, nil
+}
@@ -0,0 +1,208 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/models"
+)
+
+type trashQueries struct {
+	// selects the name of an item
+	name string
+	// selects the ids of the rows deleting the item (?1) can remove, as a table name and an id
+	scope string
+}
+
+var trashQueriesByType = map[string]trashQueries{
+	"artist": {
+		name: `SELECT name FROM artists_with_name WHERE id = ?`,
+		scope: `
+			SELECT 'artists', ?1
+			UNION ALL
+			SELECT 'releases', release_id FROM artist_releases WHERE artist_id = ?1
+			UNION ALL
+			SELECT 'tracks', t.id FROM tracks t
+			JOIN artist_releases ar ON ar.release_id = t.release_id WHERE ar.artist_id = ?1
+			UNION ALL
+			SELECT 'songs', t.song_id FROM tracks t
+			JOIN artist_releases ar ON ar.release_id = t.release_id WHERE ar.artist_id = ?1`,
+	},
+	"album": {
+		name: `SELECT title FROM releases_with_title WHERE id = ?`,
+		scope: `
+			SELECT 'releases', ?1
+			UNION ALL
+			SELECT 'tracks', id FROM tracks WHERE release_id = ?1
+			UNION ALL
+			SELECT 'songs', song_id FROM tracks WHERE release_id = ?1`,
+	},
+	"track": {
+		name: `SELECT title FROM tracks_with_title WHERE id = ?`,
+		scope: `
+			SELECT 'tracks', ?1
+			UNION ALL
+			SELECT 'songs', song_id FROM tracks WHERE id = ?1
+			UNION ALL
+			SELECT 'releases', release_id FROM tracks WHERE id = ?1
+			UNION ALL
+			SELECT 'artists', artist_id FROM artist_tracks WHERE track_id = ?1`,
+	},
+}
+
+// trash runs a delete of an item in a transaction and records the rows it removed in the trash,
+// so that the item can be restored. Deleting an item that doesn't exist does nothing.
+func (s *Sqlite) trash(ctx context.Context, entityType string, id int32, fn func(tx *sql.Tx) error) error {
+	queries, ok := trashQueriesByType[entityType]
+	if !ok {
+		return fmt.Errorf("unknown entity type '%s'", entityType)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var name string
+	err = tx.QueryRowContext(ctx, queries.name, id).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("name: %w", err)
+	}
+
+	scope, err := mergeScope(ctx, tx, queries.scope, id)
+	if err != nil {
+		return fmt.Errorf("scope: %w", err)
+	}
+	before, err := snapshotMergeRows(ctx, tx, scope)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	after, err := snapshotMergeRows(ctx, tx, scope)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	changes := diffMergeRows(before, after)
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("marshal changes: %w", err)
+	}
+
+	var trashID int64
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO trash (entity_type, entity_id, name, changes, listen_count, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id`,
+		entityType, id, name, string(raw), len(changes.Removed["listens"]), time.Now().Unix()).Scan(&trashID); err != nil {
+		return fmt.Errorf("insert: %w", err)
+	}
+	for _, image := range changes.images() {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO trash_images (trash_id, image) VALUES (?, ?)`, trashID, image); err != nil {
+			return fmt.Errorf("insert image: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+const selectTrash = `
+	SELECT id, entity_type, entity_id, name, listen_count, deleted_at
+	FROM trash`
+
+func scanTrashItem(row interface{ Scan(...any) error }) (*models.TrashItem, error) {
+	var t models.TrashItem
+	var deletedAt int64
+	if err := row.Scan(&t.ID, &t.EntityType, &t.EntityID, &t.Name, &t.ListenCount, &deletedAt); err != nil {
+		return nil, err
+	}
+	t.DeletedAt = time.Unix(deletedAt, 0)
+	return &t, nil
+}
+
+func (s *Sqlite) GetTrash(ctx context.Context) ([]*models.TrashItem, error) {
+	rows, err := s.db.QueryContext(ctx, selectTrash+` ORDER BY deleted_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("GetTrash: %w", err)
+	}
+	defer rows.Close()
+	items := make([]*models.TrashItem, 0)
+	for rows.Next() {
+		t, err := scanTrashItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetTrash: scan: %w", err)
+		}
+		items = append(items, t)
+	}
+	return items, rows.Err()
+}
+
+func (s *Sqlite) GetTrashItem(ctx context.Context, id int32) (*models.TrashItem, error) {
+	t, err := scanTrashItem(s.db.QueryRowContext(ctx, selectTrash+` WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("GetTrashItem: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetTrashItem: %w", err)
+	}
+	return t, nil
+}
+
+func (s *Sqlite) RestoreTrashItem(ctx context.Context, id int32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("RestoreTrashItem: BeginTx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var raw string
+	err = tx.QueryRowContext(ctx, `SELECT changes FROM trash WHERE id = ?`, id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("RestoreTrashItem: %w", db.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("RestoreTrashItem: %w", err)
+	}
+	changes, err := decodeMergeChanges(raw)
+	if err != nil {
+		return fmt.Errorf("RestoreTrashItem: %w", err)
+	}
+	if err := revertMergeChanges(ctx, tx, changes); err != nil {
+		return fmt.Errorf("RestoreTrashItem: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM trash WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("RestoreTrashItem: delete: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (s *Sqlite) DeleteTrashItem(ctx context.Context, id int32) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM trash WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteTrashItem: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("DeleteTrashItem: %w", db.ErrNotFound)
+	}
+	return nil
+}
+
+func (s *Sqlite) DeleteTrashBefore(ctx context.Context, t time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM trash WHERE deleted_at < ?`, t.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("DeleteTrashBefore: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -77,20 +77,26 @@ func (s *Sqlite) GetAdminUser(ctx context.Context) (*models.User, error) {
 	return &u, nil
 }
 
-func (s *Sqlite) GetUserByApiKey(ctx context.Context, key string) (*models.User, error) {
+// GetUserByApiKey returns the user an api key belongs to, along with the key itself. Both are
+// nil when the key does not exist.
+func (s *Sqlite) GetUserByApiKey(ctx context.Context, key string) (*models.User, *models.ApiKey, error) {
 	var u models.User
+	var k models.ApiKey
 	var role string
+	var createdAt int64
 	err := s.db.QueryRowContext(ctx, `
-		SELECT u.id, u.username, u.role, u.password
+		SELECT u.id, u.username, u.role, u.password, ak.id, ak.key, ak.label, ak.created_at
 		FROM users u JOIN api_keys ak ON u.id = ak.user_id
-		WHERE ak.key = ? LIMIT 1`, key).Scan(&u.ID, &u.Username, &role, &u.Password)
+		WHERE ak.key = ? LIMIT 1`, key).Scan(&u.ID, &u.Username, &role, &u.Password, &k.ID, &k.Key, &k.Label, &createdAt)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
+		return nil, nil, nil
 	} else if err != nil {
-		return nil, fmt.Errorf("GetUserByApiKey: %w", err)
+		return nil, nil, fmt.Errorf("GetUserByApiKey: %w", err)
 	}
 	u.Role = models.UserRole(role)
-	return &u, nil
+	k.UserID = u.ID
+	k.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return &u, &k, nil
 }
 
 func (s *Sqlite) SaveUser(ctx context.Context, opts db.SaveUserOpts) (*models.User, error) {
@@ -28,6 +28,56 @@ type PaginatedResponse[T any] struct {
 	CurrentPage  int32 `json:"current_page"`
 }
 
+// SubmittedListen is a listen along with the metadata it was submitted with. Submission is nil
+// when the listen was saved before submissions were recorded.
+type SubmittedListen struct {
+	TrackID    int32
+	Time       time.Time
+	UserID     int32
+	Client     string
+	Submission *models.ListenSubmission
+}
+
+// UnmatchedItem is an artist, album or track without an MBID, described for a MusicBrainz
+// search. Artist is empty for artists, and Album is only set for tracks.
+type UnmatchedItem struct {
+	ID         int32
+	Name       string
+	Artist     string
+	Album      string
+	AlbumMbzID *uuid.UUID
+}
+
+// DuplicateCandidate describes an artist, album or track for the duplicate analyzer. Titles
+// holds the titles of the albums of an artist, or of the tracks of an album. ArtistIDs is set
+// for albums and tracks, and AlbumID only for tracks. RecordingMbzIDs holds the recording MBIDs
+// of the tracks and tracklist of an album.
+type DuplicateCandidate struct {
+	ID          int32
+	Name        string
+	Aliases     []string
+	MbzID       *uuid.UUID
+	ListenCount int64
+	ArtistIDs   []int32
+	AlbumID     int32
+	Titles      []string
+
+	RecordingMbzIDs []string
+}
+
+type FilteredListenLog struct {
+	Total    int64                    `json:"total"`
+	ByReason map[string]int64         `json:"by_reason"`
+	Items    []*models.FilteredListen `json:"items"`
+}
+
+// MoveListensResult counts the listens a bulk move moved, and those it skipped because the
+// target track already had a listen at the same time.
+type MoveListensResult struct {
+	Moved   int64 `json:"moved"`
+	Skipped int64 `json:"skipped"`
+}
+
 type RankedItem[T any] struct {
 	Item T     `json:"item"`
 	Rank int64 `json:"rank"`
@@ -50,8 +100,29 @@ type ExportItem struct {
 	Artists            []models.ArtistWithFullAliases
 }
 
+type ReleaseAgeStats struct {
+	NewListens     int64   `json:"new_listens"`
+	OldListens     int64   `json:"old_listens"`
+	UnknownListens int64   `json:"unknown_listens"`
+	NewRatio       float64 `json:"new_ratio"`
+}
+
+type AlbumCompletion struct {
+	TotalTracks    int64                    `json:"total_tracks"`
+	HeardTracks    int64                    `json:"heard_tracks"`
+	Completion     float64                  `json:"completion"`
+	FullAlbumPlays int64                    `json:"full_album_plays"`
+	Unheard        []*models.TracklistEntry `json:"unheard"`
+}
+
 type InterestBucket struct {
 	BucketStart time.Time `json:"bucket_start"`
 	BucketEnd   time.Time `json:"bucket_end"`
 	ListenCount int64     `json:"listen_count"`
 }
+
+// LibraryFileState is what a library rescan compares to decide whether a file has changed.
+type LibraryFileState struct {
+	ModTime time.Time
+	Size    int64
+}
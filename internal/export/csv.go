@@ -0,0 +1,95 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+)
+
+var csvHeader = []string{
+	"listened_at",
+	"listened_at_unix",
+	"cursor",
+	"client",
+	"track",
+	"track_mbid",
+	"duration",
+	"album",
+	"album_mbid",
+	"artists",
+	"artist_mbids",
+}
+
+// ExportCSV writes the user's listens matching opts to out as CSV with a header row. Multiple
+// artists are joined with "; ", and artists without an MBID leave an empty slot in artist_mbids
+// so the two columns line up.
+func ExportCSV(ctx context.Context, user *models.User, store db.ExportStore, out io.Writer, opts ExportOpts) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("ExportCSV: Generating CSV export file...")
+
+	w := csv.NewWriter(out)
+	if err := w.Write(csvHeader); err != nil {
+		return fmt.Errorf("ExportCSV: %w", err)
+	}
+
+	count := 0
+	err := forEachExportItem(ctx, user.ID, store, opts, 1000, func(r *db.ExportItem) error {
+		count++
+		return w.Write(convertToCSVRecord(r))
+	})
+	if err != nil {
+		return fmt.Errorf("ExportCSV: %w", err)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("ExportCSV: %w", err)
+	}
+
+	l.Info().Msgf("ExportCSV: Exported %d listens", count)
+	return nil
+}
+
+func convertToCSVRecord(item *db.ExportItem) []string {
+	var client, trackMbid, albumMbid string
+	if item.Client != nil {
+		client = *item.Client
+	}
+	if item.TrackMbid != nil {
+		trackMbid = item.TrackMbid.String()
+	}
+	if item.ReleaseMbid != nil {
+		albumMbid = item.ReleaseMbid.String()
+	}
+
+	names := make([]string, len(item.Artists))
+	mbids := make([]string, len(item.Artists))
+	for i, a := range item.Artists {
+		names[i] = a.Name
+		if a.MbzID != nil {
+			mbids[i] = a.MbzID.String()
+		}
+	}
+
+	return []string{
+		item.ListenedAt.UTC().Format(time.RFC3339),
+		strconv.FormatInt(item.ListenedAt.Unix(), 10),
+		cursorOf(item).String(),
+		client,
+		primaryAlias(item.TrackAliases),
+		trackMbid,
+		strconv.Itoa(int(item.TrackDuration)),
+		primaryAlias(item.ReleaseAliases),
+		albumMbid,
+		strings.Join(names, "; "),
+		strings.Join(mbids, "; "),
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gabehf/koito/internal/db"
@@ -44,9 +47,51 @@ type KoitoArtist struct {
 	Aliases   []models.Alias `json:"aliases"`
 }
 
-func ExportData(ctx context.Context, user *models.User, store db.ExportStore, out io.Writer) error {
-	lastTime := time.Unix(0, 0)
-	lastTrackId := int32(0)
+// ExportOpts narrows down which listens are included in an export. The zero value exports
+// the user's entire listening history.
+type ExportOpts struct {
+	Timeframe db.Timeframe
+	ArtistID  int32
+	AlbumID   int32
+	TrackID   int32
+	// Only listens after this cursor are exported
+	Since Cursor
+}
+
+// Cursor marks a position in the export ordering, which is by listen time and then by track ID.
+// It is serialized as "<unix>:<track_id>", and a bare unix timestamp is accepted as meaning
+// every listen after that second.
+type Cursor struct {
+	ListenedAt time.Time
+	TrackID    int32
+}
+
+func ParseCursor(s string) (Cursor, error) {
+	unixStr, trackStr, hasTrack := strings.Cut(s, ":")
+	unix, err := strconv.ParseInt(unixStr, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("ParseCursor: invalid timestamp: %w", err)
+	}
+	c := Cursor{ListenedAt: time.Unix(unix, 0), TrackID: math.MaxInt32}
+	if hasTrack {
+		id, err := strconv.ParseInt(trackStr, 10, 32)
+		if err != nil {
+			return Cursor{}, fmt.Errorf("ParseCursor: invalid track id: %w", err)
+		}
+		c.TrackID = int32(id)
+	}
+	return c, nil
+}
+
+func (c Cursor) String() string {
+	return fmt.Sprintf("%d:%d", c.ListenedAt.Unix(), c.TrackID)
+}
+
+func cursorOf(item *db.ExportItem) Cursor {
+	return Cursor{ListenedAt: item.ListenedAt, TrackID: item.TrackID}
+}
+
+func ExportData(ctx context.Context, user *models.User, store db.ExportStore, out io.Writer, opts ExportOpts) error {
 	pageSize := int32(1000)
 
 	l := logger.FromContext(ctx)
@@ -67,55 +112,106 @@ func ExportData(ctx context.Context, user *models.User, store db.ExportStore, ou
 	}
 
 	first := true
+	err = forEachExportItem(ctx, user.ID, store, opts, pageSize, func(r *db.ExportItem) error {
+		// Adds a comma after each listen item
+		if !first {
+			_, _ = out.Write([]byte(",\n"))
+		}
+		first = false
+
+		exported := convertToExportFormat(r)
+
+		raw, err := json.MarshalIndent(exported, "    ", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal: %w", err)
+		}
+
+		// needed to make the listen item start at the right indent level
+		out.Write([]byte("    "))
+		_, _ = out.Write(raw)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("ExportData: %w", err)
+	}
+
+	// Write closing of the JSON array and object
+	_, err = out.Write([]byte("\n  ]\n}\n"))
+	if err != nil {
+		return fmt.Errorf("ExportData: f.Write: %w", err)
+	}
+
+	l.Info().Msgf("Export successfully created")
+	return nil
+}
+
+// forEachExportItem walks every listen belonging to the user that matches opts in
+// (listened_at, track_id) order, fetching pages of the given size from the store and
+// calling fn on each item.
+func forEachExportItem(ctx context.Context, userID int32, store db.ExportStore, opts ExportOpts, pageSize int32, fn func(*db.ExportItem) error) error {
+	lastTime := time.Unix(0, 0)
+	lastTrackId := int32(0)
+	if !opts.Since.ListenedAt.IsZero() {
+		lastTime = opts.Since.ListenedAt
+		lastTrackId = opts.Since.TrackID
+	}
 	for {
 		rows, err := store.GetExportPage(ctx, db.GetExportPageOpts{
-			UserID:     user.ID,
-			ListenedAt: lastTime,
-			TrackID:    lastTrackId,
-			Limit:      pageSize,
+			UserID:         userID,
+			ListenedAt:     lastTime,
+			TrackID:        lastTrackId,
+			Limit:          pageSize,
+			Timeframe:      opts.Timeframe,
+			FilterArtistID: opts.ArtistID,
+			FilterAlbumID:  opts.AlbumID,
+			FilterTrackID:  opts.TrackID,
 		})
 		if err != nil {
-			return fmt.Errorf("ExportData: %w", err)
+			return err
 		}
 		if len(rows) == 0 {
-			break
+			return nil
 		}
 
 		for _, r := range rows {
-			// Adds a comma after each listen item
-			if !first {
-				_, _ = out.Write([]byte(",\n"))
-			}
-			first = false
-
-			exported := convertToExportFormat(r)
-
-			raw, err := json.MarshalIndent(exported, "    ", "  ")
-
-			// needed to make the listen item start at the right indent level
-			out.Write([]byte("    "))
-
-			if err != nil {
-				return fmt.Errorf("ExportData: marshal: %w", err)
-			}
-			_, _ = out.Write(raw)
-
-			if r.TrackID > lastTrackId {
-				lastTrackId = r.TrackID
-			}
-			if r.ListenedAt.After(lastTime) {
-				lastTime = r.ListenedAt
+			if err := fn(r); err != nil {
+				return err
 			}
 		}
+
+		last := rows[len(rows)-1]
+		lastTime = last.ListenedAt
+		lastTrackId = last.TrackID
 	}
+}
 
-	// Write closing of the JSON array and object
-	_, err = out.Write([]byte("\n  ]\n}\n"))
+// ndjsonListen is a KoitoListen with the cursor needed to resume the export after it.
+type ndjsonListen struct {
+	Cursor string `json:"cursor"`
+	*KoitoListen
+}
+
+// ExportNDJSON writes the user's listens in the Koito listen format, one listen per line.
+// Unlike ExportData, each line also carries the cursor that can be passed back as
+// ExportOpts.Since to continue from that listen.
+func ExportNDJSON(ctx context.Context, user *models.User, store db.ExportStore, out io.Writer, opts ExportOpts) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("ExportNDJSON: Generating NDJSON export file...")
+
+	enc := json.NewEncoder(out)
+	count := 0
+	err := forEachExportItem(ctx, user.ID, store, opts, 1000, func(r *db.ExportItem) error {
+		count++
+		return enc.Encode(ndjsonListen{
+			Cursor:      cursorOf(r).String(),
+			KoitoListen: convertToExportFormat(r),
+		})
+	})
 	if err != nil {
-		return fmt.Errorf("ExportData: f.Write: %w", err)
+		return fmt.Errorf("ExportNDJSON: %w", err)
 	}
 
-	l.Info().Msgf("Export successfully created")
+	l.Info().Msgf("ExportNDJSON: Exported %d listens", count)
 	return nil
 }
 
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+)
+
+// LbzListen mirrors a single line of a ListenBrainz listens export, which is also the
+// format accepted by the ListenBrainz importer.
+type LbzListen struct {
+	ListenedAt    int64            `json:"listened_at"`
+	TrackMetadata LbzTrackMetadata `json:"track_metadata"`
+}
+type LbzTrackMetadata struct {
+	ArtistName     string            `json:"artist_name"`
+	TrackName      string            `json:"track_name"`
+	ReleaseName    string            `json:"release_name,omitempty"`
+	MBIDMapping    LbzMBIDMapping    `json:"mbid_mapping"`
+	AdditionalInfo LbzAdditionalInfo `json:"additional_info"`
+}
+type LbzMBIDMapping struct {
+	RecordingMBID string      `json:"recording_mbid,omitempty"`
+	ReleaseMBID   string      `json:"release_mbid,omitempty"`
+	ArtistMBIDs   []string    `json:"artist_mbids,omitempty"`
+	Artists       []LbzArtist `json:"artists,omitempty"`
+}
+type LbzArtist struct {
+	ArtistMBID string `json:"artist_mbid"`
+	ArtistName string `json:"artist_credit_name"`
+}
+type LbzAdditionalInfo struct {
+	RecordingMBID    string   `json:"recording_mbid,omitempty"`
+	ReleaseMBID      string   `json:"release_mbid,omitempty"`
+	ArtistMBIDs      []string `json:"artist_mbids,omitempty"`
+	ArtistNames      []string `json:"artist_names,omitempty"`
+	DurationMs       int64    `json:"duration_ms,omitempty"`
+	SubmissionClient string   `json:"submission_client,omitempty"`
+}
+
+// ExportListenBrainz writes the user's listens matching opts to out as ListenBrainz-compatible JSONL,
+// one listen per line.
+func ExportListenBrainz(ctx context.Context, user *models.User, store db.ExportStore, out io.Writer, opts ExportOpts) error {
+	l := logger.FromContext(ctx)
+	l.Info().Msg("ExportListenBrainz: Generating ListenBrainz export file...")
+
+	enc := json.NewEncoder(out)
+	count := 0
+	err := forEachExportItem(ctx, user.ID, store, opts, 1000, func(r *db.ExportItem) error {
+		count++
+		return enc.Encode(convertToListenBrainzFormat(r))
+	})
+	if err != nil {
+		return fmt.Errorf("ExportListenBrainz: %w", err)
+	}
+
+	l.Info().Msgf("ExportListenBrainz: Exported %d listens", count)
+	return nil
+}
+
+func convertToListenBrainzFormat(item *db.ExportItem) *LbzListen {
+	ret := &LbzListen{
+		ListenedAt: item.ListenedAt.Unix(),
+		TrackMetadata: LbzTrackMetadata{
+			TrackName:   primaryAlias(item.TrackAliases),
+			ReleaseName: primaryAlias(item.ReleaseAliases),
+		},
+	}
+	meta := &ret.TrackMetadata
+
+	if item.TrackMbid != nil {
+		meta.MBIDMapping.RecordingMBID = item.TrackMbid.String()
+		meta.AdditionalInfo.RecordingMBID = item.TrackMbid.String()
+	}
+	if item.ReleaseMbid != nil {
+		meta.MBIDMapping.ReleaseMBID = item.ReleaseMbid.String()
+		meta.AdditionalInfo.ReleaseMBID = item.ReleaseMbid.String()
+	}
+	if item.TrackDuration > 0 {
+		meta.AdditionalInfo.DurationMs = int64(item.TrackDuration) * 1000
+	}
+	if item.Client != nil {
+		meta.AdditionalInfo.SubmissionClient = *item.Client
+	}
+
+	for _, a := range item.Artists {
+		meta.AdditionalInfo.ArtistNames = append(meta.AdditionalInfo.ArtistNames, a.Name)
+		if a.MbzID != nil {
+			meta.MBIDMapping.ArtistMBIDs = append(meta.MBIDMapping.ArtistMBIDs, a.MbzID.String())
+			meta.MBIDMapping.Artists = append(meta.MBIDMapping.Artists, LbzArtist{
+				ArtistMBID: a.MbzID.String(),
+				ArtistName: a.Name,
+			})
+		}
+	}
+	meta.AdditionalInfo.ArtistMBIDs = meta.MBIDMapping.ArtistMBIDs
+	meta.ArtistName = strings.Join(meta.AdditionalInfo.ArtistNames, ", ")
+
+	return ret
+}
+
+// primaryAlias returns the primary alias from the list, falling back to the first alias.
+func primaryAlias(aliases []models.Alias) string {
+	for _, a := range aliases {
+		if a.Primary {
+			return a.Alias
+		}
+	}
+	if len(aliases) > 0 {
+		return aliases[0].Alias
+	}
+	return ""
+}
@@ -0,0 +1,211 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gabehf/koito/internal/audiotag"
+)
+
+// FilesystemClient finds images in a library directory that is structured as
+// <artist>/<album>/<tracks>, either as image files next to the tracks or embedded in them.
+type FilesystemClient struct {
+	root string
+}
+
+// names of the image files that are looked for, in order of preference, with any of the
+// imageExtensions
+var (
+	albumImageNames  = []string{"cover", "folder", "front", "album"}
+	artistImageNames = []string{"artist", "folder"}
+	imageExtensions  = []string{".jpg", ".jpeg", ".png", ".webp"}
+)
+
+const localImageScheme = "file"
+
+func NewFilesystemClient(root string) *FilesystemClient {
+	return &FilesystemClient{root: filepath.Clean(root)}
+}
+
+// GetArtistImage returns the source of the image file in the directory of the first artist with
+// one, or "" when there is none.
+func (c *FilesystemClient) GetArtistImage(aliases []string) (string, error) {
+	for _, alias := range aliases {
+		dir, err := findDir(c.root, alias)
+		if err != nil {
+			return "", fmt.Errorf("GetArtistImage: %w", err)
+		}
+		if dir == "" {
+			continue
+		}
+		img, err := findImageFile(dir, artistImageNames)
+		if err != nil {
+			return "", fmt.Errorf("GetArtistImage: %w", err)
+		}
+		if img != "" {
+			return localImageSource(img), nil
+		}
+	}
+	return "", nil
+}
+
+// GetAlbumImage returns the source of the image file in the album's directory under one of its
+// artists, falling back to the first track in that directory with embedded artwork. Returns ""
+// when there is neither.
+func (c *FilesystemClient) GetAlbumImage(artists []string, album string) (string, error) {
+	for _, artist := range artists {
+		artistDir, err := findDir(c.root, artist)
+		if err != nil {
+			return "", fmt.Errorf("GetAlbumImage: %w", err)
+		}
+		if artistDir == "" {
+			continue
+		}
+		dir, err := findDir(artistDir, album)
+		if err != nil {
+			return "", fmt.Errorf("GetAlbumImage: %w", err)
+		}
+		if dir == "" {
+			continue
+		}
+		img, err := findImageFile(dir, albumImageNames)
+		if err != nil {
+			return "", fmt.Errorf("GetAlbumImage: %w", err)
+		}
+		if img == "" {
+			img, err = findEmbeddedImage(dir)
+			if err != nil {
+				return "", fmt.Errorf("GetAlbumImage: %w", err)
+			}
+		}
+		if img != "" {
+			return localImageSource(img), nil
+		}
+	}
+	return "", nil
+}
+
+// ReadImage reads the image of a source returned by the client. The source must be inside the
+// library directory. When it is an audio file, its embedded artwork is returned.
+func (c *FilesystemClient) ReadImage(src string) ([]byte, error) {
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme != localImageScheme {
+		return nil, fmt.Errorf("ReadImage: not a local image: %s", src)
+	}
+	path := filepath.Clean(filepath.FromSlash(u.Path))
+	rel, err := filepath.Rel(c.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("ReadImage: %s is outside of the library directory", path)
+	}
+	if audiotag.HasSupportedExtension(path) {
+		pic, err := audiotag.ReadPicture(path)
+		if err != nil {
+			return nil, fmt.Errorf("ReadImage: %w", err)
+		}
+		if pic == nil {
+			return nil, fmt.Errorf("ReadImage: %s has no embedded artwork", path)
+		}
+		return pic.Data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ReadImage: %w", err)
+	}
+	return data, nil
+}
+
+// IsLocalImage returns whether the image source points to a file in the library directory
+// rather than to a URL.
+func IsLocalImage(src string) bool {
+	return strings.HasPrefix(src, localImageScheme+"://")
+}
+
+// ReadLocalImage reads the image of a local image source. It fails when the filesystem image
+// provider is disabled.
+func ReadLocalImage(src string) ([]byte, error) {
+	if !imgsrc.filesystemEnabled {
+		return nil, errors.New("ReadLocalImage: local images are disabled")
+	}
+	return imgsrc.filesystemC.ReadImage(src)
+}
+
+func localImageSource(path string) string {
+	return (&url.URL{Scheme: localImageScheme, Path: filepath.ToSlash(path)}).String()
+}
+
+// findDir returns the path of the directory in parent that is named name, ignoring case and the
+// characters that can't be used in file names. Returns "" when there is none.
+func findDir(parent, name string) (string, error) {
+	entries, err := os.ReadDir(parent)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	want := normalizeFileName(name)
+	for _, e := range entries {
+		if e.IsDir() && normalizeFileName(e.Name()) == want {
+			return filepath.Join(parent, e.Name()), nil
+		}
+	}
+	return "", nil
+}
+
+// findImageFile returns the path of the image file in dir with the first of names, ignoring case.
+// Returns "" when there is none.
+func findImageFile(dir string, names []string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	best, bestRank := "", len(names)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if !slices.Contains(imageExtensions, ext) {
+			continue
+		}
+		rank := slices.Index(names, strings.ToLower(strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))))
+		if rank >= 0 && rank < bestRank {
+			best, bestRank = filepath.Join(dir, e.Name()), rank
+		}
+	}
+	return best, nil
+}
+
+// findEmbeddedImage returns the path of the first audio file in dir with embedded artwork, or ""
+// when there is none.
+func findEmbeddedImage(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !audiotag.HasSupportedExtension(e.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		// files that can't be read are skipped, as the next one may have the same artwork
+		if pic, err := audiotag.ReadPicture(path); err == nil && pic != nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+func normalizeFileName(name string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name)))
+}
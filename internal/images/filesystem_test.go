@@ -0,0 +1,94 @@
+package images
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+// flacWithPicture returns a FLAC file with no audio and the image as its front cover.
+func flacWithPicture(mime string, image []byte) []byte {
+	pic := binary.BigEndian.AppendUint32(nil, 3)
+	pic = binary.BigEndian.AppendUint32(pic, uint32(len(mime)))
+	pic = append(pic, mime...)
+	pic = binary.BigEndian.AppendUint32(pic, 0)
+	pic = append(pic, make([]byte, 16)...)
+	pic = binary.BigEndian.AppendUint32(pic, uint32(len(image)))
+	pic = append(pic, image...)
+
+	data := []byte("fLaC")
+	data = append(data, 0, 0, 0, 34)
+	data = append(data, make([]byte, 34)...)
+	data = append(data, 0x86, byte(len(pic)>>16), byte(len(pic)>>8), byte(len(pic)))
+	return append(data, pic...)
+}
+
+func TestFilesystemClient(t *testing.T) {
+	root := t.TempDir()
+	c := NewFilesystemClient(root)
+
+	writeFile(t, filepath.Join(root, "Artist", "artist.JPG"), []byte("artist image"))
+	writeFile(t, filepath.Join(root, "Artist", "Album", "folder.png"), []byte("folder image"))
+	writeFile(t, filepath.Join(root, "Artist", "Album", "Cover.jpg"), []byte("cover image"))
+	writeFile(t, filepath.Join(root, "Artist", "Album", "back.jpg"), []byte("back image"))
+	writeFile(t, filepath.Join(root, "Artist", "Embedded", "01.flac"), flacWithPicture("image/png", []byte("embedded image")))
+	writeFile(t, filepath.Join(root, "AC_DC", "Back in Black", "01.flac"), flacWithPicture("image/jpeg", []byte("acdc image")))
+	writeFile(t, filepath.Join(root, "Other", "Bare", "01.flac"), []byte("not music"))
+
+	read := func(t *testing.T, src string) string {
+		t.Helper()
+		require.True(t, IsLocalImage(src), src)
+		data, err := c.ReadImage(src)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("Artist", func(t *testing.T) {
+		src, err := c.GetArtistImage([]string{"Unknown", "artist"})
+		require.NoError(t, err)
+		assert.Equal(t, "artist image", read(t, src))
+
+		src, err = c.GetArtistImage([]string{"Other"})
+		require.NoError(t, err)
+		assert.Empty(t, src)
+	})
+
+	t.Run("Album", func(t *testing.T) {
+		src, err := c.GetAlbumImage([]string{"Artist"}, "ALBUM")
+		require.NoError(t, err)
+		assert.Equal(t, "cover image", read(t, src))
+
+		src, err = c.GetAlbumImage([]string{"Nobody", "Artist"}, "Embedded")
+		require.NoError(t, err)
+		assert.Equal(t, "embedded image", read(t, src))
+
+		src, err = c.GetAlbumImage([]string{"AC/DC"}, "Back in Black")
+		require.NoError(t, err)
+		assert.Equal(t, "acdc image", read(t, src))
+
+		src, err = c.GetAlbumImage([]string{"Other"}, "Bare")
+		require.NoError(t, err)
+		assert.Empty(t, src)
+	})
+
+	t.Run("Outside Library", func(t *testing.T) {
+		outside := filepath.Join(t.TempDir(), "cover.jpg")
+		writeFile(t, outside, []byte("secret"))
+		_, err := c.ReadImage(localImageSource(outside))
+		assert.Error(t, err)
+		_, err = c.ReadImage(localImageSource(filepath.Join(root, "..", filepath.Base(filepath.Dir(outside)), "cover.jpg")))
+		assert.Error(t, err)
+		_, err = c.ReadImage("https://example.com/cover.jpg")
+		assert.Error(t, err)
+	})
+}
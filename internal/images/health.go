@@ -0,0 +1,97 @@
+package images
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// ProviderHealth describes how an image provider has been doing since Koito started.
+type ProviderHealth struct {
+	Provider Provider `json:"provider"`
+	Enabled  bool     `json:"enabled"`
+	// the position of the provider in the order artist and album images are looked up in,
+	// starting at 1, or 0 when it isn't used for them
+	ArtistPriority int `json:"artist_priority"`
+	AlbumPriority  int `json:"album_priority"`
+	Requests       int `json:"requests"`
+	Found          int `json:"found"`
+	Errors         int `json:"errors"`
+	// the share of requests that found an image
+	SuccessRate      float64    `json:"success_rate"`
+	AverageLatencyMs int64      `json:"average_latency_ms"`
+	LastLatencyMs    int64      `json:"last_latency_ms"`
+	LastFoundAt      *time.Time `json:"last_found_at,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	LastErrorAt      *time.Time `json:"last_error_at,omitempty"`
+}
+
+type providerStats struct {
+	requests     int
+	found        int
+	errors       int
+	totalLatency time.Duration
+	lastLatency  time.Duration
+	lastFoundAt  time.Time
+	lastError    string
+	lastErrorAt  time.Time
+}
+
+var (
+	statsLock sync.Mutex
+	stats     = make(map[Provider]*providerStats)
+)
+
+func record(p Provider, latency time.Duration, img string, err error) {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+	s, ok := stats[p]
+	if !ok {
+		s = new(providerStats)
+		stats[p] = s
+	}
+	s.requests++
+	s.totalLatency += latency
+	s.lastLatency = latency
+	if err != nil {
+		s.errors++
+		s.lastError = err.Error()
+		s.lastErrorAt = time.Now()
+	} else if img != "" {
+		s.found++
+		s.lastFoundAt = time.Now()
+	}
+}
+
+// Health returns the health of every image provider.
+func Health() []ProviderHealth {
+	statsLock.Lock()
+	defer statsLock.Unlock()
+
+	var ret []ProviderHealth
+	for _, p := range defaultAlbumProviders {
+		h := ProviderHealth{
+			Provider:       p,
+			Enabled:        imgsrc.enabled(p),
+			ArtistPriority: slices.Index(imgsrc.artistProviders, p) + 1,
+			AlbumPriority:  slices.Index(imgsrc.albumProviders, p) + 1,
+		}
+		if s, ok := stats[p]; ok && s.requests > 0 {
+			h.Requests = s.requests
+			h.Found = s.found
+			h.Errors = s.errors
+			h.SuccessRate = float64(s.found) / float64(s.requests)
+			h.AverageLatencyMs = (s.totalLatency / time.Duration(s.requests)).Milliseconds()
+			h.LastLatencyMs = s.lastLatency.Milliseconds()
+			h.LastError = s.lastError
+			if t := s.lastFoundAt; !t.IsZero() {
+				h.LastFoundAt = &t
+			}
+			if t := s.lastErrorAt; !t.IsZero() {
+				h.LastErrorAt = &t
+			}
+		}
+		ret = append(ret, h)
+	}
+	return ret
+}
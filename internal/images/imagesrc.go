@@ -3,23 +3,30 @@ package images
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gabehf/koito/internal/logger"
 	"github.com/google/uuid"
 )
 
 type ImageSource struct {
-	deezerEnabled   bool
-	deezerC         *DeezerClient
-	subsonicEnabled bool
-	subsonicC       *SubsonicClient
-	lastfmEnabled   bool
-	lastfmC         *LastFMClient
-	caaEnabled      bool
+	filesystemEnabled bool
+	filesystemC       *FilesystemClient
+	deezerEnabled     bool
+	deezerC           *DeezerClient
+	subsonicEnabled   bool
+	subsonicC         *SubsonicClient
+	lastfmEnabled     bool
+	lastfmC           *LastFMClient
+	caaEnabled        bool
+	artistProviders   []Provider
+	albumProviders    []Provider
 }
 type ImageSourceOpts struct {
 	UserAgent      string
@@ -27,6 +34,12 @@ type ImageSourceOpts struct {
 	EnableDeezer   bool
 	EnableSubsonic bool
 	EnableLastFM   bool
+	// the library directory to look for local images in, if any
+	LibraryDir string
+	// the order to try the providers in, defaulting to ArtistProviders and AlbumProviders.
+	// Providers that are left out are never tried, and each may only be listed once.
+	ArtistProviders []Provider
+	AlbumProviders  []Provider
 }
 
 var once sync.Once
@@ -44,11 +57,65 @@ type AlbumImageOpts struct {
 	ReleaseGroupMbzID *uuid.UUID
 }
 
+type Provider string
+
+const (
+	ProviderLibrary  Provider = "library"
+	ProviderSubsonic Provider = "subsonic"
+	ProviderCAA      Provider = "caa"
+	ProviderLastFM   Provider = "lastfm"
+	ProviderDeezer   Provider = "deezer"
+)
+
+// the providers that can find images of artists and albums, in the order they are tried in by
+// default
+var (
+	defaultArtistProviders = []Provider{ProviderLibrary, ProviderSubsonic, ProviderDeezer, ProviderLastFM}
+	defaultAlbumProviders  = []Provider{ProviderLibrary, ProviderSubsonic, ProviderCAA, ProviderLastFM, ProviderDeezer}
+)
+
+// ArtistProviders returns the providers that can find artist images, in the order they are tried
+// in by default.
+func ArtistProviders() []Provider {
+	return slices.Clone(defaultArtistProviders)
+}
+
+// AlbumProviders returns the providers that can find album images, in the order they are tried
+// in by default.
+func AlbumProviders() []Provider {
+	return slices.Clone(defaultAlbumProviders)
+}
+
+var (
+	ErrUnknownProvider  = errors.New("unknown image provider")
+	ErrProviderDisabled = errors.New("image provider is disabled")
+)
+
 const caaBaseUrl = "https://coverartarchive.org"
 
 // all functions are no-op if no providers are enabled
-func Initialize(opts ImageSourceOpts) {
+// Initialize sets up the image providers. It fails when the order of the providers lists one
+// that can't find that kind of image, or lists one more than once.
+func Initialize(opts ImageSourceOpts) error {
+	if err := checkProviderOrder(opts.ArtistProviders, defaultArtistProviders); err != nil {
+		return fmt.Errorf("Initialize: artist image providers: %w", err)
+	}
+	if err := checkProviderOrder(opts.AlbumProviders, defaultAlbumProviders); err != nil {
+		return fmt.Errorf("Initialize: album image providers: %w", err)
+	}
 	once.Do(func() {
+		imgsrc.artistProviders = slices.Clone(defaultArtistProviders)
+		if len(opts.ArtistProviders) > 0 {
+			imgsrc.artistProviders = slices.Clone(opts.ArtistProviders)
+		}
+		imgsrc.albumProviders = slices.Clone(defaultAlbumProviders)
+		if len(opts.AlbumProviders) > 0 {
+			imgsrc.albumProviders = slices.Clone(opts.AlbumProviders)
+		}
+		if opts.LibraryDir != "" {
+			imgsrc.filesystemEnabled = true
+			imgsrc.filesystemC = NewFilesystemClient(opts.LibraryDir)
+		}
 		if opts.EnableCAA {
 			imgsrc.caaEnabled = true
 		}
@@ -65,6 +132,23 @@ func Initialize(opts ImageSourceOpts) {
 			imgsrc.lastfmC = NewLastFMClient()
 		}
 	})
+	return nil
+}
+
+func checkProviderOrder(order, known []Provider) error {
+	for i, p := range order {
+		if !slices.Contains(known, p) {
+			names := make([]string, len(known))
+			for j, k := range known {
+				names[j] = string(k)
+			}
+			return fmt.Errorf("%w %q, must be one of %s", ErrUnknownProvider, p, strings.Join(names, ", "))
+		}
+		if slices.Contains(order[:i], p) {
+			return fmt.Errorf("image provider %q is listed more than once", p)
+		}
+	}
+	return nil
 }
 
 func Shutdown() {
@@ -73,99 +157,152 @@ func Shutdown() {
 
 func GetArtistImage(ctx context.Context, opts ArtistImageOpts) (string, error) {
 	l := logger.FromContext(ctx)
-	if imgsrc.subsonicEnabled {
-		img, err := imgsrc.subsonicC.GetArtistImage(ctx, opts.MBID, opts.Aliases[0])
-		if err != nil {
-			l.Debug().Err(err).Msg("GetArtistImage: Could not find artist image from Subsonic")
-		} else if img != "" {
-			return img, nil
+	tried := false
+	for _, p := range imgsrc.artistProviders {
+		if !imgsrc.enabled(p) {
+			l.Debug().Msgf("GetArtistImage: %s image fetching is disabled", p)
+			continue
 		}
-	} else {
-		l.Debug().Msg("GetArtistImage: Subsonic image fetching is disabled")
-	}
-	if imgsrc.deezerEnabled {
-		img, err := imgsrc.deezerC.GetArtistImages(ctx, opts.Aliases)
+		tried = true
+		img, err := GetArtistImageFrom(ctx, p, opts)
 		if err != nil {
-			l.Debug().Err(err).Msg("GetArtistImage: Could not find artist image from Deezer")
+			l.Debug().Err(err).Msgf("GetArtistImage: Could not find artist image from %s", p)
 		} else if img != "" {
 			return img, nil
 		}
-	} else {
-		l.Debug().Msg("GetArtistImage: Deezer image fetching is disabled")
 	}
-	if imgsrc.lastfmEnabled {
-		img, err := imgsrc.lastfmC.GetArtistImage(ctx, opts.MBID, opts.Aliases[0])
-		if err != nil {
-			l.Debug().Err(err).Msg("GetArtistImage: Could not find artist image from LastFM")
-		} else if img != "" {
-			return img, nil
-		}
-	} else {
-		l.Debug().Msg("GetArtistImage: LastFM image fetching is disabled")
+	if !tried {
+		l.Warn().Msg("GetArtistImage: No image providers are enabled")
 	}
-	l.Warn().Msg("GetArtistImage: No image providers are enabled")
 	return "", nil
 }
 
 func GetAlbumImage(ctx context.Context, opts AlbumImageOpts) (string, error) {
 	l := logger.FromContext(ctx)
-	if imgsrc.subsonicEnabled {
-		img, err := imgsrc.subsonicC.GetAlbumImage(ctx, opts.ReleaseMbzID, opts.Artists[0], opts.Album)
-		if err != nil {
-			l.Debug().Err(err).Msg("GetAlbumImage: Could not find artist image from Subsonic")
-		}
-		if img != "" {
-			return img, nil
+	tried := false
+	for _, p := range imgsrc.albumProviders {
+		if !imgsrc.enabled(p) {
+			l.Debug().Msgf("GetAlbumImage: %s image fetching is disabled", p)
+			continue
 		}
-		l.Debug().Msg("Could not find album cover from Subsonic")
-	}
-	if imgsrc.caaEnabled {
-		l.Debug().Msg("Attempting to find album image from CoverArtArchive")
-		if opts.ReleaseMbzID != nil && *opts.ReleaseMbzID != uuid.Nil {
-			url := fmt.Sprintf(caaBaseUrl+"/release/%s/front", opts.ReleaseMbzID.String())
-			resp, err := http.DefaultClient.Head(url)
-			if err != nil {
-				l.Debug().Err(err).Msg("GetAlbumImage: Could not find artist image from CoverArtArchive with Release MBID")
-			} else {
-				if resp.StatusCode == 200 {
-					return url, nil
-				} else {
-					l.Debug().Int("status", resp.StatusCode).Msg("GetAlbumImage: Got non-OK response from CoverArtArchive")
-				}
-			}
-		}
-		if opts.ReleaseGroupMbzID != nil && *opts.ReleaseGroupMbzID != uuid.Nil {
-			url := fmt.Sprintf(caaBaseUrl+"/release-group/%s/front", opts.ReleaseGroupMbzID.String())
-			resp, err := http.DefaultClient.Head(url)
-			if err != nil {
-				l.Debug().Err(err).Msg("GetAlbumImage: Could not find artist image from CoverArtArchive with Release Group MBID")
-			}
-			if resp.StatusCode == 200 {
-				return url, nil
-			}
-		}
-	}
-	if imgsrc.lastfmEnabled {
-		img, err := imgsrc.lastfmC.GetAlbumImage(ctx, opts.ReleaseMbzID, opts.Artists[0], opts.Album)
+		tried = true
+		img, err := GetAlbumImageFrom(ctx, p, opts)
 		if err != nil {
-			l.Debug().Err(err).Msg("GetAlbumImage: Could not find artist image from Subsonic")
-		}
-		if img != "" {
+			l.Debug().Err(err).Msgf("GetAlbumImage: Could not find album image from %s", p)
+		} else if img != "" {
 			return img, nil
 		}
-		l.Debug().Msg("Could not find album cover from Subsonic")
 	}
-	if imgsrc.deezerEnabled {
-		l.Debug().Msg("Attempting to find album image from Deezer")
-		img, err := imgsrc.deezerC.GetAlbumImages(ctx, opts.Artists, opts.Album)
+	if !tried {
+		l.Warn().Msg("GetAlbumImage: No image providers are enabled")
+	}
+	return "", nil
+}
+
+// GetArtistImageFrom finds an artist image from a single provider, regardless of the configured
+// order. Returns "" when the provider has no image for the artist.
+func GetArtistImageFrom(ctx context.Context, p Provider, opts ArtistImageOpts) (string, error) {
+	if !slices.Contains(defaultArtistProviders, p) {
+		return "", fmt.Errorf("GetArtistImageFrom: %w: %s", ErrUnknownProvider, p)
+	}
+	if !imgsrc.enabled(p) {
+		return "", fmt.Errorf("GetArtistImageFrom: %w: %s", ErrProviderDisabled, p)
+	}
+	if len(opts.Aliases) == 0 {
+		return "", errors.New("GetArtistImageFrom: no artist name given")
+	}
+
+	start := time.Now()
+	var img string
+	var err error
+	switch p {
+	case ProviderLibrary:
+		img, err = imgsrc.filesystemC.GetArtistImage(opts.Aliases)
+	case ProviderSubsonic:
+		img, err = imgsrc.subsonicC.GetArtistImage(ctx, opts.MBID, opts.Aliases[0])
+	case ProviderDeezer:
+		img, err = imgsrc.deezerC.GetArtistImages(ctx, opts.Aliases)
+	case ProviderLastFM:
+		img, err = imgsrc.lastfmC.GetArtistImage(ctx, opts.MBID, opts.Aliases[0])
+	}
+	record(p, time.Since(start), img, err)
+	return img, err
+}
+
+// GetAlbumImageFrom finds an album image from a single provider, regardless of the configured
+// order. Returns "" when the provider has no image for the album.
+func GetAlbumImageFrom(ctx context.Context, p Provider, opts AlbumImageOpts) (string, error) {
+	if !slices.Contains(defaultAlbumProviders, p) {
+		return "", fmt.Errorf("GetAlbumImageFrom: %w: %s", ErrUnknownProvider, p)
+	}
+	if !imgsrc.enabled(p) {
+		return "", fmt.Errorf("GetAlbumImageFrom: %w: %s", ErrProviderDisabled, p)
+	}
+	if len(opts.Artists) == 0 {
+		return "", errors.New("GetAlbumImageFrom: no artist name given")
+	}
+
+	start := time.Now()
+	var img string
+	var err error
+	switch p {
+	case ProviderLibrary:
+		img, err = imgsrc.filesystemC.GetAlbumImage(opts.Artists, opts.Album)
+	case ProviderSubsonic:
+		img, err = imgsrc.subsonicC.GetAlbumImage(ctx, opts.ReleaseMbzID, opts.Artists[0], opts.Album)
+	case ProviderCAA:
+		img, err = getCAAImage(ctx, opts.ReleaseMbzID, opts.ReleaseGroupMbzID)
+	case ProviderLastFM:
+		img, err = imgsrc.lastfmC.GetAlbumImage(ctx, opts.ReleaseMbzID, opts.Artists[0], opts.Album)
+	case ProviderDeezer:
+		img, err = imgsrc.deezerC.GetAlbumImages(ctx, opts.Artists, opts.Album)
+	}
+	record(p, time.Since(start), img, err)
+	return img, err
+}
+
+func (s *ImageSource) enabled(p Provider) bool {
+	switch p {
+	case ProviderLibrary:
+		return s.filesystemEnabled
+	case ProviderSubsonic:
+		return s.subsonicEnabled
+	case ProviderCAA:
+		return s.caaEnabled
+	case ProviderLastFM:
+		return s.lastfmEnabled
+	case ProviderDeezer:
+		return s.deezerEnabled
+	default:
+		return false
+	}
+}
+
+// getCAAImage returns the front cover of the release from the Cover Art Archive, falling back to
+// the one of the release group.
+func getCAAImage(ctx context.Context, releaseMbzID, releaseGroupMbzID *uuid.UUID) (string, error) {
+	l := logger.FromContext(ctx)
+	var urls []string
+	if releaseMbzID != nil && *releaseMbzID != uuid.Nil {
+		urls = append(urls, fmt.Sprintf(caaBaseUrl+"/release/%s/front", releaseMbzID.String()))
+	}
+	if releaseGroupMbzID != nil && *releaseGroupMbzID != uuid.Nil {
+		urls = append(urls, fmt.Sprintf(caaBaseUrl+"/release-group/%s/front", releaseGroupMbzID.String()))
+	}
+	var lastErr error
+	for _, url := range urls {
+		resp, err := http.DefaultClient.Head(url)
 		if err != nil {
-			l.Debug().Err(err).Msg("GetAlbumImage: Could not find artist image from Deezer")
-			return "", err
+			lastErr = fmt.Errorf("getCAAImage: %w", err)
+			continue
 		}
-		return img, nil
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return url, nil
+		}
+		l.Debug().Int("status", resp.StatusCode).Msg("getCAAImage: Got non-OK response from CoverArtArchive")
 	}
-	l.Warn().Msg("GetAlbumImage: No image providers are enabled")
-	return "", nil
+	return "", lastErr
 }
 
 // ValidateImageURL checks if the URL points to a valid image by performing a HEAD request.
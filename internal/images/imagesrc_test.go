@@ -0,0 +1,70 @@
+package images
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAlbumImage_ProviderOrder(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "Artist", "Album", "cover.jpg"), []byte("cover image"))
+
+	defer func(old ImageSource) { imgsrc = old }(imgsrc)
+	imgsrc = ImageSource{
+		filesystemEnabled: true,
+		filesystemC:       NewFilesystemClient(root),
+		// disabled providers are skipped
+		albumProviders: []Provider{ProviderDeezer, ProviderLibrary},
+	}
+	ctx := context.Background()
+
+	src, err := GetAlbumImage(ctx, AlbumImageOpts{Artists: []string{"Artist"}, Album: "Album"})
+	require.NoError(t, err)
+	assert.Equal(t, localImageSource(filepath.Join(root, "Artist", "Album", "cover.jpg")), src)
+
+	src, err = GetAlbumImage(ctx, AlbumImageOpts{Artists: []string{"Artist"}, Album: "Missing"})
+	require.NoError(t, err)
+	assert.Empty(t, src)
+
+	_, err = GetAlbumImageFrom(ctx, ProviderDeezer, AlbumImageOpts{Artists: []string{"Artist"}, Album: "Album"})
+	assert.ErrorIs(t, err, ErrProviderDisabled)
+	_, err = GetAlbumImageFrom(ctx, "nope", AlbumImageOpts{Artists: []string{"Artist"}, Album: "Album"})
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+
+	// providers that are left out of the order are not tried
+	imgsrc.albumProviders = []Provider{ProviderDeezer}
+	src, err = GetAlbumImage(ctx, AlbumImageOpts{Artists: []string{"Artist"}, Album: "Album"})
+	require.NoError(t, err)
+	assert.Empty(t, src)
+
+	for _, h := range Health() {
+		switch h.Provider {
+		case ProviderLibrary:
+			assert.True(t, h.Enabled)
+			assert.Zero(t, h.AlbumPriority)
+			assert.Equal(t, 2, h.Requests)
+			assert.Equal(t, 1, h.Found)
+			assert.Equal(t, 0.5, h.SuccessRate)
+			assert.NotNil(t, h.LastFoundAt)
+		case ProviderDeezer:
+			assert.False(t, h.Enabled)
+			assert.Equal(t, 1, h.AlbumPriority)
+			assert.Zero(t, h.Requests)
+		}
+	}
+}
+
+func TestInitialize_ProviderOrder(t *testing.T) {
+	err := Initialize(ImageSourceOpts{ArtistProviders: []Provider{ProviderLibrary, ProviderCAA}})
+	assert.ErrorIs(t, err, ErrUnknownProvider, "the Cover Art Archive has no artist images")
+	err = Initialize(ImageSourceOpts{AlbumProviders: []Provider{ProviderCAA, ProviderDeezer, ProviderCAA}})
+	assert.Error(t, err)
+
+	providers := AlbumProviders()
+	providers[0] = "nope"
+	assert.Equal(t, ProviderLibrary, AlbumProviders()[0], "the default order can't be changed")
+}
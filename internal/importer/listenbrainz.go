@@ -146,8 +146,10 @@ func ImportListenBrainzFile(ctx context.Context, store importStore, mbzc mbz.Mus
 			TrackTitle:         payload.TrackMeta.TrackName,
 			RecordingMbzID:     recordingMbzID,
 			ReleaseTitle:       payload.TrackMeta.ReleaseName,
+			AlbumArtist:        payload.TrackMeta.AdditionalInfo.AlbumArtist,
 			ReleaseMbzID:       releaseMbzID,
 			ReleaseGroupMbzID:  rgMbzID,
+			Tags:               payload.TrackMeta.AdditionalInfo.Tags,
 			ArtistMbidMappings: artistMbidMap,
 			Duration:           duration,
 			Time:               ts,
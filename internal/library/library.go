@@ -0,0 +1,264 @@
+// Package library scans a directory of audio files, reads their tags and matches them to the
+// tracks in the catalog, filling in what the catalog is missing.
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gabehf/koito/internal/audiotag"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrScanRunning   = errors.New("a library scan is already running")
+	ErrNotConfigured = errors.New("no library directory is configured")
+)
+
+type Store interface {
+	db.ArtistStore
+	db.AlbumStore
+	db.TrackStore
+	db.LibraryStore
+}
+
+// ScanResult counts what a scan did. Unchanged files are skipped without being read.
+type ScanResult struct {
+	Files     int `json:"files"`
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+	// files that could not be read, like files in a format that isn't supported
+	Failed int `json:"failed"`
+	// files matched to a track by this scan, and the tracks, albums and artists that were
+	// filled in from them
+	Matched  int `json:"matched"`
+	Enriched int `json:"enriched"`
+}
+
+type Status struct {
+	Enabled    bool        `json:"enabled"`
+	Dir        string      `json:"dir,omitempty"`
+	Running    bool        `json:"running"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+	Result     *ScanResult `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+var (
+	statusLock sync.Mutex
+	status     Status
+)
+
+// GetStatus returns the state of the running or last finished scan.
+func GetStatus() Status {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	ret := status
+	ret.Dir = cfg.LibraryDir()
+	ret.Enabled = ret.Dir != ""
+	return ret
+}
+
+func begin() error {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	if status.Running {
+		return ErrScanRunning
+	}
+	now := time.Now()
+	status = Status{Running: true, StartedAt: &now}
+	return nil
+}
+
+func finish(res *ScanResult, err error) {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	now := time.Now()
+	status.Running = false
+	status.FinishedAt = &now
+	status.Result = res
+	if err != nil {
+		status.Error = err.Error()
+	}
+}
+
+// StartScan scans the configured library directory in the background. It returns
+// ErrScanRunning when a scan is already running.
+func StartScan(ctx context.Context, store Store) error {
+	dir := cfg.LibraryDir()
+	if dir == "" {
+		return ErrNotConfigured
+	}
+	if err := begin(); err != nil {
+		return err
+	}
+	go func() {
+		finish(scan(ctx, store, dir))
+	}()
+	return nil
+}
+
+// RunScanner scans the configured library directory straight away, and then every
+// cfg.LibraryScanInterval() until ctx is cancelled.
+func RunScanner(ctx context.Context, store Store) {
+	l := logger.FromContext(ctx)
+
+	ticker := time.NewTicker(cfg.LibraryScanInterval())
+	defer ticker.Stop()
+	for {
+		if err := Scan(ctx, store, cfg.LibraryDir()); err != nil {
+			if errors.Is(err, ErrScanRunning) {
+				l.Info().Msg("RunScanner: Skipping scheduled scan, as a scan is already running")
+			} else {
+				l.Err(err).Msg("RunScanner: Library scan failed")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan reads the tags of the audio files in dir that were added or changed since the last
+// scan, forgets the files that are gone, and then tries to match every file that isn't matched
+// to a track yet. Files are matched again on every scan, as the tracks they belong to are only
+// created once they are listened to.
+func Scan(ctx context.Context, store Store, dir string) error {
+	if err := begin(); err != nil {
+		return err
+	}
+	res, err := scan(ctx, store, dir)
+	finish(res, err)
+	return err
+}
+
+func scan(ctx context.Context, store Store, dir string) (*ScanResult, error) {
+	l := logger.FromContext(ctx)
+	l.Info().Msgf("Scan: Scanning library directory %s", dir)
+
+	res := &ScanResult{}
+	known, err := store.GetLibraryFileStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Scan: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// an unreadable directory shouldn't stop the scan, but the root must be readable
+			if path == dir {
+				return err
+			}
+			l.Warn().Err(err).Msgf("Scan: Skipping %s", path)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !audiotag.HasSupportedExtension(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			l.Warn().Err(err).Msgf("Scan: Skipping %s", path)
+			return nil
+		}
+		seen[path] = true
+		res.Files++
+
+		state, ok := known[path]
+		if ok && state.ModTime.Equal(info.ModTime()) && state.Size == info.Size() {
+			res.Unchanged++
+			return nil
+		}
+		if err := saveFile(ctx, store, path, info); err != nil {
+			l.Debug().Err(err).Msgf("Scan: Failed to read %s", path)
+			res.Failed++
+			return nil
+		}
+		if ok {
+			res.Updated++
+		} else {
+			res.Added++
+		}
+		return nil
+	})
+	if err != nil {
+		return res, fmt.Errorf("Scan: %w", err)
+	}
+
+	var removed []string
+	for path := range known {
+		if !seen[path] {
+			removed = append(removed, path)
+		}
+	}
+	if err := store.DeleteLibraryFiles(ctx, removed); err != nil {
+		return res, fmt.Errorf("Scan: %w", err)
+	}
+	res.Removed = len(removed)
+
+	if err := matchFiles(ctx, store, res); err != nil {
+		return res, fmt.Errorf("Scan: %w", err)
+	}
+
+	l.Info().
+		Int("files", res.Files).
+		Int("added", res.Added).
+		Int("updated", res.Updated).
+		Int("removed", res.Removed).
+		Int("failed", res.Failed).
+		Int("matched", res.Matched).
+		Int("enriched", res.Enriched).
+		Msg("Scan: Library scan finished")
+	return res, nil
+}
+
+func saveFile(ctx context.Context, store Store, path string, info os.FileInfo) error {
+	tags, err := audiotag.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return store.SaveLibraryFile(ctx, db.SaveLibraryFileOpts{
+		Path:              path,
+		ModTime:           info.ModTime(),
+		Size:              info.Size(),
+		Title:             tags.Title,
+		Artist:            tags.Artist,
+		Album:             tags.Album,
+		AlbumArtist:       tags.AlbumArtist,
+		TrackNumber:       int32(tags.TrackNumber),
+		DiscNumber:        int32(tags.DiscNumber),
+		Duration:          tags.Duration,
+		ISRC:              tags.ISRC,
+		RecordingMbzID:    nonNil(tags.RecordingMbzID),
+		ReleaseMbzID:      nonNil(tags.ReleaseMbzID),
+		ReleaseGroupMbzID: nonNil(tags.ReleaseGroupMbzID),
+		ArtistMbzIDs:      tags.ArtistMbzIDs,
+		HasPicture:        tags.HasPicture,
+	})
+}
+
+func nonNil(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,180 @@
+package library_test
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/db/sqlite"
+	"github.com/gabehf/koito/internal/library"
+	"github.com/gabehf/koito/internal/mbz"
+	"github.com/gabehf/koito/internal/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := utils.GenerateRandomString(8)
+	if err != nil {
+		panic(err)
+	}
+	err = cfg.Load(func(env string) string {
+		switch env {
+		case cfg.SQLITE_ENABLED:
+			return "true"
+		case cfg.CONFIG_DIR_ENV:
+			return filepath.Join(os.TempDir(), "koito_library_test_"+dir)
+		case cfg.DISABLE_DEEZER_ENV, cfg.DISABLE_COVER_ART_ARCHIVE_ENV, cfg.DISABLE_MUSICBRAINZ_ENV:
+			return "true"
+		default:
+			return ""
+		}
+	}, "test")
+	if err != nil {
+		log.Fatalf("Could not load cfg: %s", err)
+	}
+	code := m.Run()
+	os.RemoveAll(cfg.ConfigDir())
+	os.Exit(code)
+}
+
+func newTestDB() *sqlite.Sqlite {
+	s, err := sqlite.NewInMemory()
+	if err != nil {
+		panic(err)
+	}
+	// insert a user into the db with id 1 to use for tests
+	if err := s.Exec(`INSERT INTO users (username, password) VALUES ('test', 0x123)`); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// writeFLAC writes a FLAC file with the given Vorbis comments and a duration of seconds.
+func writeFLAC(t *testing.T, path string, seconds int, comments ...string) {
+	t.Helper()
+	const sampleRate = 44100
+	info := make([]byte, 34)
+	info[10] = byte(sampleRate >> 12)
+	info[11] = byte(sampleRate >> 4 & 0xff)
+	info[12] = byte(sampleRate&0x0f)<<4 | 1<<1
+	binary.BigEndian.PutUint32(info[14:], uint32(seconds*sampleRate))
+
+	comment := binary.LittleEndian.AppendUint32(nil, 0)
+	comment = binary.LittleEndian.AppendUint32(comment, uint32(len(comments)))
+	for _, c := range comments {
+		comment = binary.LittleEndian.AppendUint32(comment, uint32(len(c)))
+		comment = append(comment, c...)
+	}
+
+	data := []byte("fLaC")
+	data = append(data, 0, 0, 0, byte(len(info)))
+	data = append(data, info...)
+	data = append(data, 0x84, byte(len(comment)>>16), byte(len(comment)>>8), byte(len(comment)))
+	data = append(data, comment...)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func submit(t *testing.T, store *sqlite.Sqlite, artist, title, album string) {
+	t.Helper()
+	require.NoError(t, catalog.SubmitListen(context.Background(), store, catalog.SubmitListenOpts{
+		MbzCaller:    &mbz.MbzErrorCaller{},
+		Artist:       artist,
+		TrackTitle:   title,
+		ReleaseTitle: album,
+		Time:         time.Now(),
+		UserID:       1,
+	}))
+}
+
+func TestScan(t *testing.T) {
+	store := newTestDB()
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	recordingID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	releaseID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	artistID := uuid.MustParse("00000000-0000-0000-0000-000000000003")
+
+	// the listened track has no MBIDs or duration, which the file fills in
+	submit(t, store, "Artist", "Song", "Album")
+	writeFLAC(t, filepath.Join(dir, "Artist", "Album", "01 Song.flac"), 200,
+		"TITLE=Song", "ARTIST=Artist", "ALBUM=Album", "TRACKNUMBER=1",
+		"MUSICBRAINZ_TRACKID="+recordingID.String(),
+		"MUSICBRAINZ_ALBUMID="+releaseID.String(),
+		"MUSICBRAINZ_ARTISTID="+artistID.String(),
+	)
+	writeFLAC(t, filepath.Join(dir, "Artist", "Album", "02 Other.flac"), 180,
+		"TITLE=Other", "ARTIST=Artist", "ALBUM=Album", "TRACKNUMBER=2")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not music"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.mp3"), []byte("not music either"), 0644))
+
+	require.NoError(t, library.Scan(ctx, store, dir))
+	status := library.GetStatus()
+	assert.False(t, status.Running)
+	require.NotNil(t, status.Result)
+	assert.Equal(t, library.ScanResult{Files: 3, Added: 2, Failed: 1, Matched: 1, Enriched: 1}, *status.Result)
+
+	track, err := store.GetTrack(ctx, db.GetTrackOpts{Title: "Song", ReleaseID: 1, ArtistIDs: []int32{1}})
+	require.NoError(t, err)
+	require.NotNil(t, track.MbzID)
+	assert.Equal(t, recordingID, *track.MbzID)
+	assert.EqualValues(t, 200, track.Duration)
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: track.AlbumID})
+	require.NoError(t, err)
+	require.NotNil(t, album.MbzID)
+	assert.Equal(t, releaseID, *album.MbzID)
+	artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: track.Artists[0].ID})
+	require.NoError(t, err)
+	require.NotNil(t, artist.MbzID)
+	assert.Equal(t, artistID, *artist.MbzID)
+
+	unplayed, err := store.GetUnplayedLibraryFiles(ctx, db.GetLibraryFilesOpts{})
+	require.NoError(t, err)
+	require.Len(t, unplayed.Items, 1)
+	assert.Equal(t, "Other", unplayed.Items[0].Title)
+	assert.Equal(t, filepath.Join(dir, "Artist", "Album", "02 Other.flac"), unplayed.Items[0].Path)
+	assert.Nil(t, unplayed.Items[0].TrackID)
+
+	allTime := db.GetItemsOpts{Timeframe: db.Timeframe{Period: db.PeriodAllTime}}
+	submit(t, store, "Artist", "Elsewhere", "Album")
+	missing, err := store.GetTracksNotInLibrary(ctx, allTime)
+	require.NoError(t, err)
+	require.Len(t, missing.Items, 1)
+	assert.Equal(t, "Elsewhere", missing.Items[0].Item.Title)
+	assert.EqualValues(t, 1, missing.Items[0].Item.ListenCount)
+
+	// a rescan only reads changed files, but matches the files that are still unmatched
+	submit(t, store, "Artist", "Other", "Album")
+	require.NoError(t, library.Scan(ctx, store, dir))
+	assert.Equal(t, library.ScanResult{Files: 3, Unchanged: 2, Failed: 1, Matched: 1, Enriched: 1}, *library.GetStatus().Result)
+	unplayed, err = store.GetUnplayedLibraryFiles(ctx, db.GetLibraryFilesOpts{})
+	require.NoError(t, err)
+	assert.Empty(t, unplayed.Items)
+
+	// changed and removed files are picked up
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "Artist", "Album", "02 Other.flac"), later, later))
+	require.NoError(t, os.Remove(filepath.Join(dir, "Artist", "Album", "01 Song.flac")))
+	require.NoError(t, library.Scan(ctx, store, dir))
+	assert.Equal(t, library.ScanResult{Files: 2, Updated: 1, Removed: 1, Failed: 1, Matched: 1}, *library.GetStatus().Result)
+	missing, err = store.GetTracksNotInLibrary(ctx, allTime)
+	require.NoError(t, err)
+	require.Len(t, missing.Items, 2)
+	assert.ElementsMatch(t, []string{"Song", "Elsewhere"}, []string{missing.Items[0].Item.Title, missing.Items[1].Item.Title})
+}
+
+func TestStartScan_NotConfigured(t *testing.T) {
+	cfg.SetLibraryDir("")
+	assert.ErrorIs(t, library.StartScan(context.Background(), newTestDB()), library.ErrNotConfigured)
+	assert.False(t, library.GetStatus().Enabled)
+}
@@ -0,0 +1,233 @@
+package library
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/gabehf/koito/imagecache"
+	"github.com/gabehf/koito/internal/audiotag"
+	"github.com/gabehf/koito/internal/catalog"
+	"github.com/gabehf/koito/internal/cfg"
+	"github.com/gabehf/koito/internal/db"
+	"github.com/gabehf/koito/internal/logger"
+	"github.com/gabehf/koito/internal/models"
+	"github.com/google/uuid"
+)
+
+// ImageSource is the image source of album art taken from the files in the library.
+const ImageSource = "Local Library"
+
+// matchFiles matches every file that isn't matched to a track yet, and fills in the MBIDs,
+// duration and album art of the tracks from the files' tags.
+func matchFiles(ctx context.Context, store Store, res *ScanResult) error {
+	l := logger.FromContext(ctx)
+
+	var from int32
+	for {
+		files, err := store.GetUnmatchedLibraryFiles(ctx, from)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return nil
+		}
+		for _, f := range files {
+			from = f.ID
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			track, err := matchTrack(ctx, store, f)
+			if errors.Is(err, db.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				l.Err(err).Msgf("matchFiles: Failed to match %s", f.Path)
+				continue
+			}
+			if err := store.SetLibraryFileTrack(ctx, f.ID, track.ID); err != nil {
+				return err
+			}
+			res.Matched++
+			l.Debug().Msgf("matchFiles: Matched %s to track %d", f.Path, track.ID)
+
+			if enrich(ctx, store, f, track) {
+				res.Enriched++
+			}
+		}
+	}
+}
+
+// matchTrack finds the track of a file, by its recording MBID, or else by its title, album and
+// artists. Returns db.ErrNotFound when there is no such track.
+func matchTrack(ctx context.Context, store Store, f *models.LibraryFile) (*models.Track, error) {
+	if f.RecordingMbzID != nil {
+		track, err := store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: *f.RecordingMbzID})
+		if err == nil || !errors.Is(err, db.ErrNotFound) {
+			return track, err
+		}
+	}
+	if f.Title == "" || f.Artist == "" {
+		return nil, db.ErrNotFound
+	}
+
+	artistIDs, err := findArtists(ctx, store, f.ArtistMbzIDs, catalog.ParseArtists(f.Artist, f.Title, cfg.ArtistSeparators()))
+	if err != nil {
+		return nil, err
+	}
+	if len(artistIDs) == 0 {
+		return nil, db.ErrNotFound
+	}
+
+	album, err := findAlbum(ctx, store, f, artistIDs)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetTrack(ctx, db.GetTrackOpts{
+		Title:     f.Title,
+		ReleaseID: album.ID,
+		ArtistIDs: artistIDs,
+	})
+}
+
+// findArtists returns the IDs of the artists of the catalog with one of the MBIDs or names.
+func findArtists(ctx context.Context, store Store, mbzIDs []uuid.UUID, names []string) ([]int32, error) {
+	var ids []int32
+	add := func(opts db.GetArtistOpts) error {
+		artist, err := store.GetArtist(ctx, opts)
+		if errors.Is(err, db.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(ids, artist.ID) {
+			ids = append(ids, artist.ID)
+		}
+		return nil
+	}
+	for _, id := range mbzIDs {
+		if err := add(db.GetArtistOpts{MusicBrainzID: id}); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if err := add(db.GetArtistOpts{Name: name}); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// findAlbum finds the album of a file by its release MBID, or else by its title and the album
+// artist, falling back to the track's artists.
+func findAlbum(ctx context.Context, store Store, f *models.LibraryFile, artistIDs []int32) (*models.Album, error) {
+	if f.ReleaseMbzID != nil {
+		album, err := store.GetAlbum(ctx, db.GetAlbumOpts{MusicBrainzID: *f.ReleaseMbzID})
+		if err == nil || !errors.Is(err, db.ErrNotFound) {
+			return album, err
+		}
+	}
+	if f.Album == "" {
+		return nil, db.ErrNotFound
+	}
+	candidates := artistIDs
+	if f.AlbumArtist != "" {
+		albumArtists, err := findArtists(ctx, store, nil, []string{f.AlbumArtist})
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(albumArtists, artistIDs...)
+	}
+	for _, id := range candidates {
+		album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ArtistID: id, Title: f.Album})
+		if err == nil || !errors.Is(err, db.ErrNotFound) {
+			return album, err
+		}
+	}
+	return nil, db.ErrNotFound
+}
+
+// enrich fills in what the catalog is missing from the tags of a file that was matched to the
+// track: the MBID and duration of the track, the MBID and art of its album, and the MBID of its
+// artist. MBIDs that are already used by another item are left alone. Returns whether anything
+// was filled in.
+func enrich(ctx context.Context, store Store, f *models.LibraryFile, track *models.Track) bool {
+	l := logger.FromContext(ctx)
+	changed := false
+
+	trackOpts := db.UpdateTrackOpts{ID: track.ID}
+	if track.MbzID == nil && f.RecordingMbzID != nil {
+		if _, err := store.GetTrack(ctx, db.GetTrackOpts{MusicBrainzID: *f.RecordingMbzID}); errors.Is(err, db.ErrNotFound) {
+			trackOpts.MusicBrainzID = *f.RecordingMbzID
+		}
+	}
+	if track.Duration == 0 && f.Duration > 0 {
+		trackOpts.Duration = f.Duration
+	}
+	if trackOpts.MusicBrainzID != uuid.Nil || trackOpts.Duration != 0 {
+		if err := store.UpdateTrack(ctx, trackOpts); err != nil {
+			l.Err(err).Msgf("enrich: Failed to update track %d", track.ID)
+		} else {
+			changed = true
+		}
+	}
+
+	album, err := store.GetAlbum(ctx, db.GetAlbumOpts{ID: track.AlbumID})
+	if err != nil {
+		l.Err(err).Msgf("enrich: Failed to get album %d", track.AlbumID)
+		return changed
+	}
+	albumOpts := db.UpdateAlbumOpts{ID: album.ID}
+	if album.MbzID == nil && f.ReleaseMbzID != nil {
+		if _, err := store.GetAlbum(ctx, db.GetAlbumOpts{MusicBrainzID: *f.ReleaseMbzID}); errors.Is(err, db.ErrNotFound) {
+			albumOpts.MusicBrainzID = *f.ReleaseMbzID
+		}
+	}
+	if album.Image.Small == "" && f.HasPicture {
+		if id, err := saveArtwork(f.Path); err != nil {
+			l.Err(err).Msgf("enrich: Failed to save the artwork of %s", f.Path)
+		} else if id != uuid.Nil {
+			albumOpts.Image = id
+			albumOpts.ImageSrc = ImageSource
+		}
+	}
+	if albumOpts.MusicBrainzID != uuid.Nil || albumOpts.Image != uuid.Nil {
+		if err := store.UpdateAlbum(ctx, albumOpts); err != nil {
+			l.Err(err).Msgf("enrich: Failed to update album %d", album.ID)
+		} else {
+			changed = true
+		}
+	}
+
+	// the MBID of an artist is only known for sure when the track has a single artist
+	if len(track.Artists) == 1 && len(f.ArtistMbzIDs) == 1 {
+		artist, err := store.GetArtist(ctx, db.GetArtistOpts{ID: track.Artists[0].ID})
+		if err == nil && artist.MbzID == nil {
+			if _, err := store.GetArtist(ctx, db.GetArtistOpts{MusicBrainzID: f.ArtistMbzIDs[0]}); errors.Is(err, db.ErrNotFound) {
+				if err := store.UpdateArtist(ctx, db.UpdateArtistOpts{ID: artist.ID, MusicBrainzID: f.ArtistMbzIDs[0]}); err != nil {
+					l.Err(err).Msgf("enrich: Failed to update artist %d", artist.ID)
+				} else {
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// saveArtwork saves the embedded artwork of a file to the image cache. Returns uuid.Nil when the
+// file has no artwork.
+func saveArtwork(path string) (uuid.UUID, error) {
+	pic, err := audiotag.ReadPicture(path)
+	if err != nil || pic == nil {
+		return uuid.Nil, err
+	}
+	id := uuid.New()
+	if err := imagecache.SaveImage(id, bytes.NewReader(pic.Data)); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
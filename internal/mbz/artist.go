@@ -11,11 +11,22 @@ import (
 )
 
 type MusicBrainzArtist struct {
-	Name     string                   `json:"name"`
-	SortName string                   `json:"sort_name"`
-	Gender   string                   `json:"gender"`
-	Area     MusicBrainzArea          `json:"area"`
-	Aliases  []MusicBrainzArtistAlias `json:"aliases"`
+	ID        string                   `json:"id"`
+	Name      string                   `json:"name"`
+	SortName  string                   `json:"sort-name"`
+	Type      string                   `json:"type"`
+	Gender    string                   `json:"gender"`
+	Country   string                   `json:"country"`
+	Area      MusicBrainzArea          `json:"area"`
+	Aliases   []MusicBrainzArtistAlias `json:"aliases"`
+	Genres    []MusicBrainzGenre       `json:"genres"`
+	Relations []MusicBrainzRelation    `json:"relations"`
+}
+type MusicBrainzRelation struct {
+	Type       string             `json:"type"`
+	Direction  string             `json:"direction"`
+	TargetType string             `json:"target-type"`
+	Artist     *MusicBrainzArtist `json:"artist"`
 }
 type MusicBrainzArtistAlias struct {
 	Name    string `json:"name"`
@@ -23,36 +34,38 @@ type MusicBrainzArtistAlias struct {
 	Primary bool   `json:"primary"`
 }
 
-const artistAliasFmtStr = "%s/ws/2/artist/%s?inc=aliases"
+const artistFmtStr = "%s/ws/2/artist/%s?inc=aliases+genres+artist-rels"
 
-func (c *MusicBrainzClient) getArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error) {
+func (c *MusicBrainzClient) GetArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error) {
 	mbzArtist := new(MusicBrainzArtist)
-	err := c.getEntity(ctx, artistAliasFmtStr, id, mbzArtist)
+	err := c.getEntity(ctx, artistFmtStr, id, mbzArtist)
 	if err != nil {
-		return nil, fmt.Errorf("getArtist: %w", err)
+		return nil, fmt.Errorf("GetArtist: %w", err)
 	}
 	return mbzArtist, nil
 }
 
 // Returns the artist name at index 0, and all primary aliases after.
 func (c *MusicBrainzClient) GetArtistPrimaryAliases(ctx context.Context, id uuid.UUID) ([]string, error) {
-	l := logger.FromContext(ctx)
-	artist, err := c.getArtist(ctx, id)
+	artist, err := c.GetArtist(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("GetArtistPrimaryAliases: %w", err)
 	}
 	if artist == nil {
 		return nil, errors.New("GetArtistPrimaryAliases: artist could not be found by musicbrainz")
 	}
-	used := make(map[string]bool)
-	ret := make([]string, 1)
-	ret[0] = artist.Name
-	used[artist.Name] = true
+	return PrimaryAliases(ctx, artist), nil
+}
+
+// PrimaryAliases returns the artist name at index 0, and all primary aliases after.
+func PrimaryAliases(ctx context.Context, artist *MusicBrainzArtist) []string {
+	l := logger.FromContext(ctx)
+	ret := []string{artist.Name}
 	for _, alias := range artist.Aliases {
 		if alias.Primary && !slices.Contains(ret, alias.Name) {
 			l.Debug().Msgf("Found primary alias '%s' for artist '%s'", alias.Name, artist.Name)
 			ret = append(ret, alias.Name)
 		}
 	}
-	return ret, nil
+	return ret
 }
@@ -19,6 +19,20 @@ type MusicBrainzArea struct {
 	Iso3166_1Codes []string `json:"iso-3166-1-codes"`
 }
 
+type MusicBrainzGenre struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// GenreNames returns the names of the given genres.
+func GenreNames(genres []MusicBrainzGenre) []string {
+	names := make([]string, 0, len(genres))
+	for _, g := range genres {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
 type MusicBrainzClient struct {
 	url          string
 	userAgent    string
@@ -26,11 +40,15 @@ type MusicBrainzClient struct {
 }
 
 type MusicBrainzCaller interface {
+	GetArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error)
 	GetArtistPrimaryAliases(ctx context.Context, id uuid.UUID) ([]string, error)
 	GetReleaseTitles(ctx context.Context, RGID uuid.UUID) ([]string, error)
 	GetTrack(ctx context.Context, id uuid.UUID) (*MusicBrainzTrack, error)
 	GetReleaseGroup(ctx context.Context, id uuid.UUID) (*MusicBrainzReleaseGroup, error)
 	GetRelease(ctx context.Context, id uuid.UUID) (*MusicBrainzRelease, error)
+	SearchArtist(ctx context.Context, name string) ([]MusicBrainzArtistSearchResult, error)
+	SearchRelease(ctx context.Context, title, artist string) ([]MusicBrainzReleaseSearchResult, error)
+	SearchRecording(ctx context.Context, title, artist, release string) ([]MusicBrainzRecordingSearchResult, error)
 	Shutdown()
 }
 
@@ -15,6 +15,18 @@ type MbzMockCaller struct {
 	ReleaseGroups map[uuid.UUID]*MusicBrainzReleaseGroup
 	Releases      map[uuid.UUID]*MusicBrainzRelease
 	Tracks        map[uuid.UUID]*MusicBrainzTrack
+	// search results, keyed by the artist name, or release or recording title searched for
+	ArtistSearches    map[string][]MusicBrainzArtistSearchResult
+	ReleaseSearches   map[string][]MusicBrainzReleaseSearchResult
+	RecordingSearches map[string][]MusicBrainzRecordingSearchResult
+}
+
+func (m *MbzMockCaller) GetArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error) {
+	artist, exists := m.Artists[id]
+	if !exists {
+		return nil, fmt.Errorf("artist with ID %s not found", id)
+	}
+	return artist, nil
 }
 
 func (m *MbzMockCaller) GetReleaseGroup(ctx context.Context, id uuid.UUID) (*MusicBrainzReleaseGroup, error) {
@@ -70,10 +82,26 @@ func (m *MbzMockCaller) GetArtistPrimaryAliases(ctx context.Context, id uuid.UUI
 	return ss, nil
 }
 
+func (m *MbzMockCaller) SearchArtist(ctx context.Context, name string) ([]MusicBrainzArtistSearchResult, error) {
+	return m.ArtistSearches[name], nil
+}
+
+func (m *MbzMockCaller) SearchRelease(ctx context.Context, title, artist string) ([]MusicBrainzReleaseSearchResult, error) {
+	return m.ReleaseSearches[title], nil
+}
+
+func (m *MbzMockCaller) SearchRecording(ctx context.Context, title, artist, release string) ([]MusicBrainzRecordingSearchResult, error) {
+	return m.RecordingSearches[title], nil
+}
+
 func (m *MbzMockCaller) Shutdown() {}
 
 type MbzErrorCaller struct{}
 
+func (m *MbzErrorCaller) GetArtist(ctx context.Context, id uuid.UUID) (*MusicBrainzArtist, error) {
+	return nil, fmt.Errorf("error: GetArtist not implemented")
+}
+
 func (m *MbzErrorCaller) GetReleaseGroup(ctx context.Context, id uuid.UUID) (*MusicBrainzReleaseGroup, error) {
 	return nil, fmt.Errorf("error: GetReleaseGroup not implemented")
 }
@@ -94,4 +122,16 @@ func (m *MbzErrorCaller) GetArtistPrimaryAliases(ctx context.Context, id uuid.UU
 	return nil, fmt.Errorf("error: GetArtistPrimaryAliases not implemented")
 }
 
+func (m *MbzErrorCaller) SearchArtist(ctx context.Context, name string) ([]MusicBrainzArtistSearchResult, error) {
+	return nil, fmt.Errorf("error: SearchArtist not implemented")
+}
+
+func (m *MbzErrorCaller) SearchRelease(ctx context.Context, title, artist string) ([]MusicBrainzReleaseSearchResult, error) {
+	return nil, fmt.Errorf("error: SearchRelease not implemented")
+}
+
+func (m *MbzErrorCaller) SearchRecording(ctx context.Context, title, artist, release string) ([]MusicBrainzRecordingSearchResult, error) {
+	return nil, fmt.Errorf("error: SearchRecording not implemented")
+}
+
 func (m *MbzErrorCaller) Shutdown() {}
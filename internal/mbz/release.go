@@ -9,10 +9,13 @@ import (
 )
 
 type MusicBrainzReleaseGroup struct {
-	Title        string                    `json:"title"`
-	Type         string                    `json:"primary_type"`
-	ArtistCredit []MusicBrainzArtistCredit `json:"artist-credit"`
-	Releases     []MusicBrainzRelease      `json:"releases"`
+	Title            string                    `json:"title"`
+	Type             string                    `json:"primary-type"`
+	SecondaryTypes   []string                  `json:"secondary-types"`
+	FirstReleaseDate string                    `json:"first-release-date"`
+	ArtistCredit     []MusicBrainzArtistCredit `json:"artist-credit"`
+	Releases         []MusicBrainzRelease      `json:"releases"`
+	Genres           []MusicBrainzGenre        `json:"genres"`
 }
 type MusicBrainzRelease struct {
 	Title              string                    `json:"title"`
@@ -20,18 +23,41 @@ type MusicBrainzRelease struct {
 	ArtistCredit       []MusicBrainzArtistCredit `json:"artist-credit"`
 	Status             string                    `json:"status"`
 	TextRepresentation TextRepresentation        `json:"text-representation"`
+	Genres             []MusicBrainzGenre        `json:"genres"`
+	Date               string                    `json:"date"`
+	ReleaseGroup       *MusicBrainzReleaseGroup  `json:"release-group"`
+	Media              []MusicBrainzMedium       `json:"media"`
+}
+type MusicBrainzMedium struct {
+	Position int                       `json:"position"`
+	Format   string                    `json:"format"`
+	Tracks   []MusicBrainzReleaseTrack `json:"tracks"`
+}
+type MusicBrainzReleaseTrack struct {
+	ID        string               `json:"id"`
+	Position  int                  `json:"position"`
+	Number    string               `json:"number"`
+	Title     string               `json:"title"`
+	LengthMs  int                  `json:"length"`
+	Recording MusicBrainzRecording `json:"recording"`
+}
+type MusicBrainzRecording struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	LengthMs int    `json:"length"`
 }
 type MusicBrainzArtistCredit struct {
-	Artist MusicBrainzArtist `json:"artist"`
-	Name   string            `json:"name"`
+	Artist     MusicBrainzArtist `json:"artist"`
+	Name       string            `json:"name"`
+	JoinPhrase string            `json:"joinphrase"`
 }
 type TextRepresentation struct {
 	Language string `json:"language"`
 	Script   string `json:"script"`
 }
 
-const releaseGroupFmtStr = "%s/ws/2/release-group/%s?inc=releases+artists"
-const releaseFmtStr = "%s/ws/2/release/%s?inc=artists"
+const releaseGroupFmtStr = "%s/ws/2/release-group/%s?inc=releases+artists+genres"
+const releaseFmtStr = "%s/ws/2/release/%s?inc=artists+genres+release-groups+recordings"
 
 func (c *MusicBrainzClient) GetReleaseGroup(ctx context.Context, id uuid.UUID) (*MusicBrainzReleaseGroup, error) {
 	mbzRG := new(MusicBrainzReleaseGroup)
@@ -67,6 +93,22 @@ func (c *MusicBrainzClient) GetReleaseTitles(ctx context.Context, RGID uuid.UUID
 	return titles, nil
 }
 
+// ReleaseDate returns the first release date of a release. The release group's first release
+// date is preferred, falling back to the date of the release itself. rg may be nil, in which
+// case the release group included in the release lookup is used.
+func ReleaseDate(release *MusicBrainzRelease, rg *MusicBrainzReleaseGroup) string {
+	if rg == nil && release != nil {
+		rg = release.ReleaseGroup
+	}
+	if rg != nil && rg.FirstReleaseDate != "" {
+		return rg.FirstReleaseDate
+	}
+	if release != nil {
+		return release.Date
+	}
+	return ""
+}
+
 func ReleaseGroupToTitles(rg *MusicBrainzReleaseGroup) []string {
 	var titles []string
 	for _, release := range rg.Releases {
@@ -0,0 +1,135 @@
+package mbz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gabehf/koito/internal/logger"
+)
+
+// the number of results requested from each search
+const searchLimit = 5
+
+type MusicBrainzArtistSearchResult struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	SortName       string `json:"sort-name"`
+	Disambiguation string `json:"disambiguation"`
+	// how well the result matches the query, from 0 to 100
+	Score int `json:"score"`
+}
+
+type MusicBrainzReleaseSearchResult struct {
+	ID           string                    `json:"id"`
+	Title        string                    `json:"title"`
+	Date         string                    `json:"date"`
+	ArtistCredit []MusicBrainzArtistCredit `json:"artist-credit"`
+	Score        int                       `json:"score"`
+}
+
+type MusicBrainzRecordingSearchResult struct {
+	ID           string                    `json:"id"`
+	Title        string                    `json:"title"`
+	LengthMs     int                       `json:"length"`
+	ArtistCredit []MusicBrainzArtistCredit `json:"artist-credit"`
+	Releases     []MusicBrainzRelease      `json:"releases"`
+	Score        int                       `json:"score"`
+}
+
+// ArtistCreditName renders an artist credit with the credited names and the join phrases
+// between them. Credits without any join phrases are joined with " & ".
+func ArtistCreditName(credit []MusicBrainzArtistCredit) string {
+	names := make([]string, 0, len(credit))
+	var b strings.Builder
+	joined := false
+	for _, c := range credit {
+		name := c.Name
+		if name == "" {
+			name = c.Artist.Name
+		}
+		names = append(names, name)
+		b.WriteString(name + c.JoinPhrase)
+		joined = joined || c.JoinPhrase != ""
+	}
+	if !joined {
+		return strings.Join(names, " & ")
+	}
+	return b.String()
+}
+
+// SearchArtist searches MusicBrainz for artists by name, best matches first.
+func (c *MusicBrainzClient) SearchArtist(ctx context.Context, name string) ([]MusicBrainzArtistSearchResult, error) {
+	var result struct {
+		Artists []MusicBrainzArtistSearchResult `json:"artists"`
+	}
+	err := c.search(ctx, "artist", searchQuery("artist", name), &result)
+	if err != nil {
+		return nil, fmt.Errorf("SearchArtist: %w", err)
+	}
+	return result.Artists, nil
+}
+
+// SearchRelease searches MusicBrainz for releases by title and artist, best matches first.
+func (c *MusicBrainzClient) SearchRelease(ctx context.Context, title, artist string) ([]MusicBrainzReleaseSearchResult, error) {
+	var result struct {
+		Releases []MusicBrainzReleaseSearchResult `json:"releases"`
+	}
+	err := c.search(ctx, "release", searchQuery("release", title, "artist", artist), &result)
+	if err != nil {
+		return nil, fmt.Errorf("SearchRelease: %w", err)
+	}
+	return result.Releases, nil
+}
+
+// SearchRecording searches MusicBrainz for recordings by title, artist and, when given, the
+// title of a release the recording appears on. Best matches come first.
+func (c *MusicBrainzClient) SearchRecording(ctx context.Context, title, artist, release string) ([]MusicBrainzRecordingSearchResult, error) {
+	var result struct {
+		Recordings []MusicBrainzRecordingSearchResult `json:"recordings"`
+	}
+	err := c.search(ctx, "recording", searchQuery("recording", title, "artist", artist, "release", release), &result)
+	if err != nil {
+		return nil, fmt.Errorf("SearchRecording: %w", err)
+	}
+	return result.Recordings, nil
+}
+
+func (c *MusicBrainzClient) search(ctx context.Context, entity, query string, result any) error {
+	l := logger.FromContext(ctx)
+	u := fmt.Sprintf("%s/ws/2/%s?query=%s&limit=%d", c.url, entity, url.QueryEscape(query), searchLimit)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		l.Err(err).Msg("Failed to build MusicBrainz request")
+		return fmt.Errorf("search: %w", err)
+	}
+	l.Debug().Msg("Adding MusicBrainz search request to queue")
+	body, err := c.queue(ctx, req)
+	if err != nil {
+		l.Err(err).Msg("MusicBrainz request failed")
+		return fmt.Errorf("search: %w", err)
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		l.Err(err).Str("body", string(body)).Msg("Failed to unmarshal MusicBrainz response body")
+		return fmt.Errorf("search: %w", err)
+	}
+	return nil
+}
+
+// searchQuery builds a Lucene query from pairs of field names and values. Fields with an empty
+// value are left out.
+func searchQuery(pairs ...string) string {
+	var terms []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		terms = append(terms, fmt.Sprintf(`%s:"%s"`, pairs[i], luceneEscaper.Replace(pairs[i+1])))
+	}
+	return strings.Join(terms, " AND ")
+}
+
+var luceneEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
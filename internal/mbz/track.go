@@ -8,11 +8,13 @@ import (
 )
 
 type MusicBrainzTrack struct {
-	Title    string `json:"title"`
-	LengthMs int    `json:"length"`
+	Title        string                    `json:"title"`
+	LengthMs     int                       `json:"length"`
+	Genres       []MusicBrainzGenre        `json:"genres"`
+	ArtistCredit []MusicBrainzArtistCredit `json:"artist-credit"`
 }
 
-const recordingFmtStr = "%s/ws/2/recording/%s"
+const recordingFmtStr = "%s/ws/2/recording/%s?inc=genres+artist-credits"
 
 // Returns the artist name at index 0, and all primary aliases after.
 func (c *MusicBrainzClient) GetTrack(ctx context.Context, id uuid.UUID) (*MusicBrainzTrack, error) {
@@ -8,9 +8,14 @@ type Album struct {
 	Title          string         `json:"title"`
 	Image          ImageList      `json:"image"`
 	Artists        []SimpleArtist `json:"artists"`
+	Credits        []ArtistCredit `json:"credits,omitempty"`
 	VariousArtists bool           `json:"is_various_artists"`
+	ReleaseDate    string         `json:"release_date,omitempty"`
+	PrimaryType    string         `json:"primary_type,omitempty"`
+	SecondaryTypes []string       `json:"secondary_types,omitempty"`
 	ListenCount    int64          `json:"listen_count"`
 	TimeListened   int64          `json:"time_listened"`
 	FirstListen    int64          `json:"first_listen"`
 	AllTimeRank    int64          `json:"all_time_rank"`
+	Tags           []string       `json:"tags,omitempty"`
 }
@@ -13,6 +13,14 @@ type Artist struct {
 	FirstListen  int64      `json:"first_listen"`
 	IsPrimary    bool       `json:"is_primary,omitempty"`
 	AllTimeRank  int64      `json:"all_time_rank"`
+	Tags         []string   `json:"tags,omitempty"`
+	Country      string     `json:"country,omitempty"`
+	Area         string     `json:"area,omitempty"`
+	Type         string     `json:"type,omitempty"`
+	Gender       string     `json:"gender,omitempty"`
+	SortName     string     `json:"sort_name,omitempty"`
+	// artists whose listens are included in the counts when rolling up related artists
+	RolledUp []SimpleArtist `json:"rolled_up,omitempty"`
 }
 
 type ImageList struct {
@@ -28,6 +36,17 @@ type SimpleArtist struct {
 	Name string `json:"name"`
 }
 
+// ArtistCredit is an artist in the ordered artist credit of a track or album, as in
+// "A feat. B" or "A, B & C".
+type ArtistCredit struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	// the name the artist is credited as, which is the artist's name unless credited otherwise
+	CreditedName string `json:"credited_name"`
+	// the text between this artist and the next, empty for the last artist
+	JoinPhrase string `json:"join_phrase"`
+}
+
 type ArtistWithFullAliases struct {
 	ID           int32      `json:"id"`
 	MbzID        *uuid.UUID `json:"musicbrainz_id"`
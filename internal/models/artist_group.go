@@ -0,0 +1,9 @@
+package models
+
+// ArtistGroup holds the listening statistics of all artists that share a country or type.
+type ArtistGroup struct {
+	Name         string `json:"name"`
+	ListenCount  int64  `json:"listen_count"`
+	TimeListened int64  `json:"time_listened"`
+	ArtistCount  int64  `json:"artist_count"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "github.com/google/uuid"
+
+// ArtistRelation is a MusicBrainz relationship from an artist to another artist, such as
+// "member of band". Direction is "forward" when the artist is the subject of the relationship,
+// e.g. the artist is a member of the related band, and "backward" otherwise. ID is only set
+// when the related artist is in the library.
+type ArtistRelation struct {
+	ID          *int32    `json:"id"`
+	MbzID       uuid.UUID `json:"musicbrainz_id"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Direction   string    `json:"direction"`
+	ListenCount int64     `json:"listen_count"`
+}
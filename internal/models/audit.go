@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry is a change made through the API. Before and After hold the metadata of the
+// artist, album or track that was changed, and are null when it didn't exist.
+type AuditEntry struct {
+	ID          int64           `json:"id"`
+	UserID      *int32          `json:"user_id"`
+	Username    string          `json:"username"`
+	ApiKeyLabel *string         `json:"api_key_label,omitempty"`
+	Action      string          `json:"action"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    *int32          `json:"entity_id,omitempty"`
+	Params      json.RawMessage `json:"params,omitempty"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
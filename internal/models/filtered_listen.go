@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// FilteredListen is a submitted listen that was discarded by the ingest filters.
+type FilteredListen struct {
+	ID         int32     `json:"id"`
+	FilteredAt time.Time `json:"filtered_at"`
+	Time       time.Time `json:"time"`
+	Client     string    `json:"client"`
+	Artist     string    `json:"artist"`
+	Title      string    `json:"title"`
+	Album      string    `json:"album"`
+	Reason     string    `json:"reason"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LibraryFile is an audio file found by the library scanner, along with the tags read from it.
+type LibraryFile struct {
+	ID                int32       `json:"id"`
+	Path              string      `json:"path"`
+	Title             string      `json:"title"`
+	Artist            string      `json:"artist"`
+	Album             string      `json:"album"`
+	AlbumArtist       string      `json:"album_artist"`
+	TrackNumber       int32       `json:"track_number"`
+	DiscNumber        int32       `json:"disc_number"`
+	Duration          int32       `json:"duration"`
+	ISRC              string      `json:"isrc"`
+	RecordingMbzID    *uuid.UUID  `json:"recording_musicbrainz_id"`
+	ReleaseMbzID      *uuid.UUID  `json:"release_musicbrainz_id"`
+	ReleaseGroupMbzID *uuid.UUID  `json:"release_group_musicbrainz_id"`
+	ArtistMbzIDs      []uuid.UUID `json:"artist_musicbrainz_ids"`
+	HasPicture        bool        `json:"has_picture"`
+	// the track the file was matched to
+	TrackID   *int32    `json:"track_id"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
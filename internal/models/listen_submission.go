@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// ListenSubmission is the metadata of a listen exactly as it was submitted, before rewrite rules
+// were applied and it was matched to the catalog.
+type ListenSubmission struct {
+	Artist            string               `json:"artist"`
+	ArtistNames       []string             `json:"artist_names,omitempty"`
+	ArtistMbzIDs      []uuid.UUID          `json:"artist_mbids,omitempty"`
+	ArtistMbzIDMap    []ArtistMbzIDMapping `json:"artist_mbid_mapping,omitempty"`
+	Title             string               `json:"title"`
+	RecordingMbzID    *uuid.UUID           `json:"recording_mbid,omitempty"`
+	Album             string               `json:"album,omitempty"`
+	ReleaseMbzID      *uuid.UUID           `json:"release_mbid,omitempty"`
+	ReleaseGroupMbzID *uuid.UUID           `json:"release_group_mbid,omitempty"`
+	Duration          int32                `json:"duration,omitempty"`
+	PlayedDuration    int32                `json:"played_duration,omitempty"`
+	Tags              []string             `json:"tags,omitempty"`
+	Client            string               `json:"client,omitempty"`
+	AdditionalInfo    json.RawMessage      `json:"additional_info,omitempty"`
+}
+
+type ArtistMbzIDMapping struct {
+	Artist string    `json:"artist"`
+	MbzID  uuid.UUID `json:"mbid"`
+}
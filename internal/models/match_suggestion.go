@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MatchSuggestion is a MusicBrainz entity proposed as the match for an artist, album or track
+// that has no MBID.
+type MatchSuggestion struct {
+	ID         int32  `json:"id"`
+	EntityType string `json:"entity_type"`
+	EntityID   int32  `json:"entity_id"`
+	// the name or title of the artist, album or track in the library
+	EntityName string    `json:"entity_name"`
+	MbzID      uuid.UUID `json:"musicbrainz_id"`
+	// the name or title, and the credited artist, of the MusicBrainz entity
+	Name       string     `json:"name"`
+	Artist     string     `json:"artist"`
+	Confidence float64    `json:"confidence"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+}
+
+// The statuses of a match suggestion.
+const (
+	MatchSuggestionPending  = "pending"
+	MatchSuggestionAccepted = "accepted"
+	MatchSuggestionRejected = "rejected"
+	// accepted automatically because of its high confidence
+	MatchSuggestionApplied = "applied"
+)
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Merge is a merge of an artist, album or track into another one. Merges are kept for a while
+// so that they can be undone.
+type Merge struct {
+	ID         int32  `json:"id,omitzero"`
+	EntityType string `json:"entity_type"`
+	FromID     int32  `json:"from_id"`
+	FromName   string `json:"from_name"`
+	ToID       int32  `json:"to_id"`
+	ToName     string `json:"to_name"`
+	// what was moved from the merged item onto the item it was merged into
+	Moved     MergeCounts `json:"moved"`
+	CreatedAt time.Time   `json:"created_at,omitzero"`
+	UndoneAt  *time.Time  `json:"undone_at,omitempty"`
+}
+
+type MergeCounts struct {
+	Listens  int64 `json:"listens"`
+	Tracks   int64 `json:"tracks"`
+	Releases int64 `json:"releases"`
+	Aliases  int64 `json:"aliases"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// MergeSuggestion proposes merging an artist, album or track into a likely duplicate of it.
+type MergeSuggestion struct {
+	ID         int32   `json:"id"`
+	EntityType string  `json:"entity_type"`
+	FromID     int32   `json:"from_id"`
+	FromName   string  `json:"from_name"`
+	ToID       int32   `json:"to_id"`
+	ToName     string  `json:"to_name"`
+	Score      float64 `json:"score"`
+	// the signals that made the items look like duplicates, e.g. name or shared_tracks
+	Reasons   []string  `json:"reasons"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// The statuses of a merge suggestion.
+const (
+	MergeSuggestionPending   = "pending"
+	MergeSuggestionDismissed = "dismissed"
+)
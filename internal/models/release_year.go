@@ -0,0 +1,9 @@
+package models
+
+type ReleaseYear struct {
+	// The first year of the decade when grouped by decade
+	Year         int   `json:"year"`
+	ListenCount  int64 `json:"listen_count"`
+	TimeListened int64 `json:"time_listened"`
+	AlbumCount   int64 `json:"album_count"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// RewriteRule rewrites the metadata of a submitted listen before it is matched to the catalog.
+// When all of its conditions match, the action is applied to Field:
+//
+//   - "replace" replaces every match of the regular expression Pattern with Value
+//   - "strip" removes every match of Pattern and trims the surrounding whitespace
+//   - "set" sets the field to Value
+//   - "drop" discards the listen, and ignores Field
+type RewriteRule struct {
+	ID         int32              `json:"id"`
+	Name       string             `json:"name"`
+	Enabled    bool               `json:"enabled"`
+	Position   int32              `json:"position"`
+	Conditions []RewriteCondition `json:"conditions"`
+	Action     string             `json:"action"`
+	Field      string             `json:"field,omitempty"`
+	Pattern    string             `json:"pattern,omitempty"`
+	Value      string             `json:"value,omitempty"`
+}
+
+// RewriteCondition matches a field of a listen ("artist", "title", "album" or "client")
+// against Value, either exactly or as a regular expression.
+type RewriteCondition struct {
+	Field           string `json:"field"`
+	Match           string `json:"match"`
+	Value           string `json:"value"`
+	CaseInsensitive bool   `json:"case_insensitive"`
+}
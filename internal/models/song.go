@@ -0,0 +1,13 @@
+package models
+
+// Song groups the tracks of the same recording across releases. The title and artists are
+// those of the song's most listened track, and the listen counts cover all of its tracks.
+type Song struct {
+	ID           int32          `json:"id"`
+	Title        string         `json:"title"`
+	Artists      []SimpleArtist `json:"artists"`
+	Image        ImageList      `json:"image"`
+	ListenCount  int64          `json:"listen_count"`
+	TimeListened int64          `json:"time_listened"`
+	Tracks       []*Track       `json:"tracks"`
+}
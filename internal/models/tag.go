@@ -0,0 +1,8 @@
+package models
+
+type Tag struct {
+	ID           int32  `json:"id"`
+	Name         string `json:"name"`
+	ListenCount  int64  `json:"listen_count"`
+	TimeListened int64  `json:"time_listened"`
+}
@@ -6,14 +6,17 @@ type Track struct {
 	ID           int32          `json:"id"`
 	Title        string         `json:"title"`
 	Artists      []SimpleArtist `json:"artists"`
+	Credits      []ArtistCredit `json:"credits,omitempty"`
 	MbzID        *uuid.UUID     `json:"musicbrainz_id"`
 	ListenCount  int64          `json:"listen_count"`
 	Duration     int32          `json:"duration"`
 	Image        ImageList      `json:"image"`
 	AlbumID      int32          `json:"album_id"`
+	SongID       int32          `json:"song_id"`
 	TimeListened int64          `json:"time_listened"`
 	FirstListen  int64          `json:"first_listen"`
 	AllTimeRank  int64          `json:"all_time_rank"`
+	Tags         []string       `json:"tags,omitempty"`
 }
 
 type SimpleTrack struct {
@@ -0,0 +1,16 @@
+package models
+
+import "github.com/google/uuid"
+
+// TracklistEntry is a single position on the MusicBrainz tracklist of an album. TrackID is
+// set when a listened track has been linked to the position.
+type TracklistEntry struct {
+	DiscNumber     int32      `json:"disc_number"`
+	Position       int32      `json:"position"`
+	Number         string     `json:"number"`
+	Title          string     `json:"title"`
+	RecordingMbzID *uuid.UUID `json:"recording_musicbrainz_id"`
+	Duration       int32      `json:"duration"`
+	TrackID        *int32     `json:"track_id"`
+	ListenCount    int64      `json:"listen_count"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TrashItem is a deleted artist, album or track. Deleted items are kept for a while, along with
+// their listens, so that they can be restored.
+type TrashItem struct {
+	ID          int32     `json:"id"`
+	EntityType  string    `json:"entity_type"`
+	EntityID    int32     `json:"entity_id"`
+	Name        string    `json:"name"`
+	ListenCount int64     `json:"listen_count"`
+	DeletedAt   time.Time `json:"deleted_at"`
+}